@@ -0,0 +1,123 @@
+package vortex
+
+import (
+	"context"
+
+	"github.com/larayavrs/vortex/internal/backend"
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/larayavrs/vortex/internal/disk"
+)
+
+// The type Client is the entry point of programs embedding vortex. It holds the settings shared by
+// the requests it sends, so they do not have to be threaded through the data, disk and backend
+// packages by hand. The zero Client executes requests with the default configuration.
+type Client struct {
+	// Config is the configuration passed to every request, providing the timeouts, the cache and
+	// the other global settings.
+	Config data.Config
+
+	// Logger, if set, receives the verbose messages of the requests instead of the Logger of the
+	// Config.
+	Logger data.Logger
+
+	// Editor is the editor opening the templates whose filename carries the edit suffix.
+	Editor disk.EditorConfig
+
+	// Verbose, if true, makes the requests sent by DoTemplate verbose, and reports the editor
+	// opening their templates.
+	Verbose bool
+
+	// Backend, if set, is the backend executing every request, overriding the VORTEX_BACKEND
+	// variable and the [Backend] of the templates.
+	Backend string
+
+	// Overrides are the `key=value` overrides applied by DoTemplate to the parsed templates, as
+	// described by ApplyOverrides.
+	Overrides []string
+}
+
+// config returns the configuration of the requests sent by the Client.
+func (c *Client) config() data.Config {
+	cfg := c.Config
+	if c.Logger != nil {
+		cfg.Logger = c.Logger
+	}
+	if c.Backend != "" {
+		cfg.Backend = c.Backend
+	}
+	return cfg
+}
+
+// editor returns the editor opening the templates of the Client, verbose when the Client is.
+func (c *Client) editor() disk.EditorConfig {
+	editor := c.Editor
+	if c.Verbose {
+		editor.Verbose = true
+	}
+	if editor.Logger == nil {
+		editor.Logger = c.config().Logger
+	}
+	return editor
+}
+
+// The method Do sends the request with backend.Execute, which selects the backend, interpolates
+// the headers and body, builds the backend arguments and runs them, then checks the expectations
+// of the RequestConfig against the response with CheckExpectations.
+//
+// Parameters:
+//   - ctx: The context governing the request. Cancelling it aborts the request.
+//   - rc: The request configuration to send.
+//
+// Returns:
+//   - The RequestResult of the request, also returned along with a failed expectation.
+//   - An error if the request cannot be executed or the response does not meet its expectations.
+func (c *Client) Do(ctx context.Context, rc *data.RequestConfig) (data.RequestResult, error) {
+	cfg := c.config()
+	result, err := backend.Execute(ctx, rc, cfg)
+	if err != nil {
+		return result, err
+	}
+	return result, rc.CheckExpectations(result)
+}
+
+// The method DoTemplate reads the template file, opening it with the Editor of the Client when its
+// filename carries the edit suffix, parses it with ParseTemplate, applies the Overrides of the
+// Client and sends the request with Do. When the Client is Verbose, the editor and the request
+// report what they do through the Logger of the Client.
+//
+// Parameters:
+//   - ctx: The context governing the request. Cancelling it aborts the request.
+//   - path: The path of the template file.
+//
+// Returns:
+//   - The RequestResult of the request.
+//   - An error if the template cannot be read or parsed, or as described by Do.
+func (c *Client) DoTemplate(ctx context.Context, path string) (data.RequestResult, error) {
+	rc, err := c.loadTemplate(path)
+	if err != nil {
+		return data.RequestResult{}, err
+	}
+	defer rc.Close()
+	return c.Do(ctx, rc)
+}
+
+// loadTemplate reads and parses the template file, and applies the Overrides of the Client. The
+// caller closes the returned RequestConfig.
+func (c *Client) loadTemplate(path string) (*data.RequestConfig, error) {
+	tmpl, err := disk.ReadRawTemplateStringWith(c.editor(), path)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := data.ParseTemplate(path, tmpl, c.config())
+	if err != nil {
+		return nil, err
+	}
+	if c.Verbose {
+		rc.Verbose = true
+	}
+	if err := rc.ApplyOverrides(c.Overrides, c.config()); err != nil {
+		_ = rc.Close()
+		return nil, err
+	}
+	return rc, nil
+}
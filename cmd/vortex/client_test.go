@@ -0,0 +1,113 @@
+package vortex
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/larayavrs/vortex/internal/disk"
+)
+
+// recordingLogger records the messages it receives.
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Log(msg string, _ ...any) {
+	l.messages = append(l.messages, msg)
+}
+
+func TestClientDoTemplate(t *testing.T) {
+	var method, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		method, body = r.Method, string(raw)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	}))
+	defer server.Close()
+	filename := filepath.Join(t.TempDir(), "post.ini")
+	tmpl := "@no-default-headers\n[Host]\n" + server.URL + "\n[Method]\nPOST\n[Body]\n{\"name\":\"a\"}\n"
+	if err := os.WriteFile(filename, []byte(tmpl), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client := &Client{Config: data.Config{NoHistory: true}, Backend: data.NativeBackend}
+	result, err := client.DoTemplate(context.Background(), filename)
+	if err != nil {
+		t.Fatalf("DoTemplate() error = %v", err)
+	}
+	if method != http.MethodPost || body != `{"name":"a"}` {
+		t.Errorf("server received %s %q, want the POST body of the template", method, body)
+	}
+	if result.StatusCode != http.StatusCreated || result.Stdout != "created" {
+		t.Errorf("DoTemplate() = %d %q, want 201 created", result.StatusCode, result.Stdout)
+	}
+}
+
+func TestClientDoReportsFailedExpectation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	host, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc := &data.RequestConfig{Host: host, Backend: data.NativeBackend, NoDefaultHeaders: true, ExpectStatus: http.StatusCreated}
+	client := &Client{Config: data.Config{NoHistory: true}}
+	result, err := client.Do(context.Background(), rc)
+	var expectation *data.ExpectationError
+	if !errors.As(err, &expectation) {
+		t.Fatalf("Do() error = %v, want an ExpectationError", err)
+	}
+	if result.StatusCode != http.StatusOK || result.Stdout != "ok" {
+		t.Errorf("Do() = %d %q, want the result returned along with the failed expectation", result.StatusCode, result.Stdout)
+	}
+}
+
+func TestClientDoTemplateVerboseEditor(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	interactive := disk.Interactive
+	t.Cleanup(func() { disk.Interactive = interactive })
+	disk.Interactive = true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "get.ini")
+	tmpl := "@no-default-headers\n[Host]\n" + server.URL + "\n[Backend]\nnative\n"
+	if err := os.WriteFile(filename, []byte(tmpl), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	logger := &recordingLogger{}
+	client := &Client{
+		Config:  data.Config{NoHistory: true},
+		Logger:  logger,
+		Editor:  disk.EditorConfig{Command: "true"},
+		Verbose: true,
+	}
+	result, err := client.DoTemplate(context.Background(), filename+"!")
+	if err != nil {
+		t.Fatalf("DoTemplate() error = %v", err)
+	}
+	if result.Stdout != "ok" {
+		t.Errorf("Stdout = %q, want ok", result.Stdout)
+	}
+	logged := false
+	for _, msg := range logger.messages {
+		if msg == "Using editor" {
+			logged = true
+		}
+	}
+	if !logged {
+		t.Errorf("logged %q, want the editor to be reported", logger.messages)
+	}
+}
@@ -1,7 +1,202 @@
 package vortex
 
-import "fmt"
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
 
+	"github.com/larayavrs/vortex/internal/backend"
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/larayavrs/vortex/internal/disk"
+	"github.com/pkg/errors"
+)
+
+// The function Main is the entry point of the vortex command. It runs the command line with Run,
+// cancelling the requests in flight on SIGINT and SIGTERM, and exits with the code Run returns.
 func Main() {
-	fmt.Println("Hello, World!")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := Run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// runOptions holds the flags of the command line that apply to each template.
+type runOptions struct {
+	count       int
+	concurrency int
+	fail        bool
+}
+
+// The function Run parses the command line arguments with its own flag set, then sends the request
+// of each template named by the remaining arguments, or piped through stdin, as described by
+// GetTemplateFilenamesFrom. The outcome of each request is written to stdout with WriteResult,
+// and the failures are reported on stderr, pausing for the -step-delay between two templates. A
+// template is sent -count times with RunRepeat when the flag is set, its statistics being written
+// instead. The -backends and -edit-response flags only list the backends and open the last kept
+// response.
+//
+// Parameters:
+//   - ctx: The context governing the requests. Cancelling it aborts them.
+//   - args: The command line arguments, without the program name.
+//   - stdin: The file the template filenames may be piped through.
+//   - stdout: The writer receiving the responses.
+//   - stderr: The writer receiving the usage and the errors.
+//
+// Returns:
+//   - The exit code of the process: data.ExitOK when every request succeeded, otherwise the code
+//     data.ExitCode maps the first failure to, such as data.ExitParseError for a template that
+//     cannot be parsed or data.ExitHTTPError for an error status with -fail. Invalid flags and a
+//     missing template, reported with the usage, exit with data.ExitFailure.
+func Run(ctx context.Context, args []string, stdin *os.File, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("vortex", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var overrides disk.OverrideList
+	env := fs.String("env", "", "the environment whose template variants are sent, over VORTEX_ENV")
+	fs.Var(&overrides, "set", "override a setting of the templates, as `key=value`, repeatable")
+	backendName := fs.String("backend", "", "the backend sending every request")
+	listBackends := fs.Bool("backends", false, "list the backends by priority and whether they are installed")
+	count := fs.Int("count", 1, "the number of times each template is sent")
+	concurrency := fs.Int("concurrency", 1, "the number of requests sent at once with -count")
+	stepDelay := fs.Duration("step-delay", 0, "the pause between two requests sent one after the other")
+	editResponse := fs.Bool("edit-response", false, "open the response kept by -keep-last-response in the editor")
+	keepLastResponse := fs.Bool("keep-last-response", false, "keep the last response for -edit-response")
+	noHistory := fs.Bool("no-history", false, "do not record the requests in the history")
+	output := fs.String("output", data.OutputFormatRaw, "the output format, raw or json")
+	fail := fs.Bool("fail", false, "fail on a 4xx or 5xx status code, like curl -f")
+	verbose := fs.Bool("v", false, "report what the requests do on stderr")
+	yes := fs.Bool("yes", false, "send the requests to confirm without prompting")
+	remoteEnv := fs.Bool("remote-env", false, "expand the variables of remote templates from the environment")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return data.ExitOK
+		}
+		return data.ExitFailure
+	}
+	if *listBackends {
+		writeBackendStatus(stdout, disk.BackendStatus())
+		return data.ExitOK
+	}
+	client := &Client{
+		Config: data.Config{
+			NoHistory:         *noHistory,
+			KeepLastResponse:  *keepLastResponse,
+			OutputFormat:      *output,
+			AssumeYes:         *yes,
+			RemoteEnvironment: *remoteEnv,
+			StepDelay:         *stepDelay,
+		},
+		Backend:   *backendName,
+		Overrides: overrides,
+		Verbose:   *verbose,
+	}
+	if *editResponse {
+		return report(stderr, disk.EditLastResponse(client.editor()))
+	}
+
+	discovery := disk.DefaultDiscoveryOptions()
+	discovery.Environment = *env
+	filenames, err := disk.GetTemplateFilenamesFrom(fs.Args(), stdin, discovery)
+	if errors.Is(err, disk.ErrNoTemplates) {
+		fmt.Fprintf(stderr, "vortex: %v\n\nUsage: vortex [flags] template...\n", err)
+		fs.PrintDefaults()
+		return data.ExitFailure
+	}
+	if err != nil {
+		return report(stderr, err)
+	}
+	opts := runOptions{count: *count, concurrency: *concurrency, fail: *fail}
+	code := data.ExitOK
+	for i, filename := range filenames {
+		if i > 0 {
+			if err := client.config().PauseStep(ctx); err != nil {
+				return report(stderr, err)
+			}
+		}
+		err := runTemplate(ctx, client, filename, opts, stdout)
+		if failure := report(stderr, err); code == data.ExitOK {
+			code = failure
+		}
+	}
+	return code
+}
+
+// runTemplate sends the request of the template once with Do, writing its outcome with
+// WriteResult, or the number of times of the options with RunRepeat, writing its statistics.
+func runTemplate(ctx context.Context, client *Client, filename string, opts runOptions, stdout io.Writer) error {
+	rc, err := client.loadTemplate(filename)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if opts.fail {
+		rc.FailOnHTTPError = true
+	}
+	if opts.count != 1 {
+		stats, err := backend.RunRepeat(ctx, rc, opts.count, opts.concurrency, client.config())
+		if err != nil {
+			return err
+		}
+		writeRepeatStats(stdout, filename, stats)
+		if stats.Failed > 0 {
+			return errors.Errorf("%d of the %d requests of %s failed", stats.Failed, stats.Count, filename)
+		}
+		return nil
+	}
+	result, err := client.Do(ctx, rc)
+	if writeErr := backend.WriteResult(stdout, rc, result, err, client.config()); writeErr != nil && err == nil {
+		err = writeErr
+	}
+	return err
+}
+
+// report writes the error on stderr, and returns the exit code data.ExitCode maps it to.
+func report(stderr io.Writer, err error) int {
+	if err != nil {
+		fmt.Fprintf(stderr, "vortex: %v\n", err)
+	}
+	return data.ExitCode(err)
+}
+
+// writeBackendStatus writes a line per backend, by priority, telling whether it is installed.
+func writeBackendStatus(w io.Writer, infos []disk.BackendInfo) {
+	for _, info := range infos {
+		status := "not found"
+		if info.Available {
+			status = "available"
+		}
+		fmt.Fprintf(w, "%d. %-8s %-10s %s\n", info.Priority, info.Name, status, info.Path)
+	}
+}
+
+// writeRepeatStats writes the statistics of the requests sent by RunRepeat for the template, with
+// the number of responses of each status code, sorted by code, the requests that got no response
+// being counted as `none`.
+func writeRepeatStats(w io.Writer, filename string, stats backend.RepeatStats) {
+	fmt.Fprintf(w, "%s: %d requests, %d succeeded, %d failed (%.1f%%) in %v\n",
+		filename, stats.Count, stats.Succeeded, stats.Failed, stats.SuccessRate()*100, stats.Elapsed)
+	fmt.Fprintf(w, "  min %v, mean %v, max %v, p50 %v, p95 %v, p99 %v\n",
+		stats.Min, stats.Mean, stats.Max, stats.P50, stats.P95, stats.P99)
+	codes := make([]int, 0, len(stats.StatusCounts))
+	for code := range stats.StatusCounts {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	counts := make([]string, len(codes))
+	for i, code := range codes {
+		status := strconv.Itoa(code)
+		if code == 0 {
+			status = "none"
+		}
+		counts[i] = fmt.Sprintf("%s: %d", status, stats.StatusCounts[code])
+	}
+	if len(counts) > 0 {
+		fmt.Fprintf(w, "  status %s\n", strings.Join(counts, ", "))
+	}
 }
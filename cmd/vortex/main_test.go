@@ -0,0 +1,66 @@
+package vortex
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/larayavrs/vortex/internal/backend"
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+func TestRunExitCode(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("boom"))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "get.ini")
+	tmpl := "@no-default-headers\n[Host]\n" + server.URL + "\n[Backend]\nnative\n"
+	if err := os.WriteFile(filename, []byte(tmpl), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stdinName := filepath.Join(dir, "stdin")
+	if err := os.WriteFile(stdinName, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"error status", []string{filename}, data.ExitOK},
+		{"error status with -fail", []string{"-fail", filename}, data.ExitHTTPError},
+		{"missing template", []string{filepath.Join(dir, "missing.ini")}, data.ExitFailure},
+		{"invalid flag", []string{"-unknown", filename}, data.ExitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdin, err := os.Open(stdinName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stdin.Close()
+			var stdout, stderr bytes.Buffer
+			if got := Run(context.Background(), tt.args, stdin, &stdout, &stderr); got != tt.want {
+				t.Errorf("Run() = %d, want %d (stderr %q)", got, tt.want, stderr.String())
+			}
+		})
+	}
+}
+
+func TestWriteRepeatStatsStatusCounts(t *testing.T) {
+	var out bytes.Buffer
+	writeRepeatStats(&out, "get.ini", backend.RepeatStats{Count: 6, StatusCounts: map[int]int{500: 2, 0: 1, 200: 3}})
+	if want := "  status none: 1, 200: 3, 500: 2\n"; !strings.HasSuffix(out.String(), want) {
+		t.Errorf("writeRepeatStats() = %q, want it to end with %q", out.String(), want)
+	}
+}
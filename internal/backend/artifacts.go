@@ -0,0 +1,70 @@
+package backend
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/pkg/errors"
+)
+
+// createOutputDirs creates the directories of the output files of the request that do not exist
+// yet.
+func createOutputDirs(rc *data.RequestConfig) error {
+	for _, path := range []string{rc.OutputFile, rc.HeaderFile, rc.TraceFile} {
+		if path == "" {
+			continue
+		}
+		dir := filepath.Dir(path)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return errors.Wrapf(err, "Failed to create the output directory %s", dir)
+		}
+	}
+	return nil
+}
+
+// writeHeaderFile writes the status line and the headers of the response to the file, in the
+// format of the curl `--dump-header` option.
+func writeHeaderFile(path string, resp *http.Response) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to create the header file: %s", path)
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	fmt.Fprintf(w, "%s %s\r\n", resp.Proto, resp.Status)
+	if err := resp.Header.Write(w); err != nil {
+		return errors.Wrapf(err, "Failed to write the header file: %s", path)
+	}
+	w.WriteString("\r\n")
+	if err := w.Flush(); err != nil {
+		return errors.Wrapf(err, "Failed to write the header file: %s", path)
+	}
+	return errors.Wrapf(file.Close(), "Failed to write the header file: %s", path)
+}
+
+// writeTraceFile writes the trace of a completed request to the TraceFile of the RequestConfig,
+// one `key: value` line per detail, the durations being formatted like time.Duration.
+func writeTraceFile(rc *data.RequestConfig, result data.RequestResult) error {
+	file, err := os.Create(rc.TraceFile)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to create the trace file: %s", rc.TraceFile)
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	fmt.Fprintf(w, "status: %d\n", result.StatusCode)
+	fmt.Fprintf(w, "url: %s\n", result.FinalURL)
+	fmt.Fprintf(w, "remote_ip: %s\n", result.RemoteIP)
+	fmt.Fprintf(w, "dns: %s\n", result.Timings.DNS)
+	fmt.Fprintf(w, "connect: %s\n", result.Timings.Connect)
+	fmt.Fprintf(w, "tls: %s\n", result.Timings.TLS)
+	fmt.Fprintf(w, "ttfb: %s\n", result.Timings.TTFB)
+	fmt.Fprintf(w, "total: %s\n", result.Timings.Total)
+	if err := w.Flush(); err != nil {
+		return errors.Wrapf(err, "Failed to write the trace file: %s", rc.TraceFile)
+	}
+	return errors.Wrapf(file.Close(), "Failed to write the trace file: %s", rc.TraceFile)
+}
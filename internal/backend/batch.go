@@ -0,0 +1,232 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/pkg/errors"
+)
+
+// BatchOptions controls how ExecuteBatch runs a set of requests.
+type BatchOptions struct {
+	// Concurrency is the maximum number of requests executed at the same time. Zero or less runs
+	// the requests one after the other.
+	Concurrency int
+
+	// RateLimit is the maximum number of requests started per second, whatever the concurrency.
+	// Zero or less means unlimited.
+	RateLimit float64
+
+	// StopOnError, if true, stops the batch at the first failed request: the running requests are
+	// cancelled and the pending ones are never started. By default every request runs and the
+	// failures are reported together.
+	StopOnError bool
+
+	// BatchTimeout is the maximum duration of the whole batch. Once it is exceeded, the running
+	// requests are cancelled, the pending ones are never started and ExecuteBatch returns
+	// ErrBatchTimeout. Zero or less means no limit besides the deadline of the context.
+	BatchTimeout time.Duration
+}
+
+// ErrBatchTimeout is returned, wrapped, by ExecuteBatch when the batch did not complete before the
+// BatchTimeout of its options or the deadline of its context.
+var ErrBatchTimeout = errors.New("Batch timed out")
+
+// BatchResult is the outcome of one request of a batch.
+type BatchResult struct {
+	// Config is the executed request configuration.
+	Config *data.RequestConfig
+
+	// Result is the result returned by Execute.
+	Result data.RequestResult
+
+	// Err is the error returned by Execute, or the context error for a request that was never
+	// started because the batch was cancelled.
+	Err error
+}
+
+// The method Failure returns why the request of the BatchResult failed: the error returned by
+// Execute, a backend exiting with a non-zero code, or an HTTP error status code, 400 or above.
+//
+// Returns:
+//   - The failure of the request, or nil when it succeeded.
+func (br BatchResult) Failure() error {
+	switch {
+	case br.Err != nil:
+		return br.Err
+	case br.Result.ExitCode != 0:
+		return errors.Errorf("Backend exited with code %d", br.Result.ExitCode)
+	case br.Result.StatusCode >= 400:
+		return errors.Errorf("HTTP status %d", br.Result.StatusCode)
+	}
+	return nil
+}
+
+// describe names the request at the index of a batch in the errors of ExecuteBatch.
+func (br BatchResult) describe(index int) string {
+	name := fmt.Sprintf("request %d", index+1)
+	if br.Config != nil && br.Config.Host != nil {
+		name += " (" + br.Config.Method + " " + br.Config.Host.Redacted() + ")"
+	}
+	return name
+}
+
+// The function ExecuteBatch executes the requests with Execute, running up to the configured
+// Concurrency of them at the same time and starting no more than RateLimit requests per second, so
+// a fragile service is paced even when the concurrency would allow more requests. Cancelling the
+// context stops the pending requests and cancels the running ones, killing their backend process
+// and removing their body tempfile. The BatchTimeout of the options bounds the duration of the whole
+// batch the same way, as does a deadline of the context. The StepDelay of the configuration is
+// paused, with PauseStep, between a request and the next one run by the same worker, so sequential
+// batches pause between each step.
+//
+// A request fails as described by BatchResult.Failure. When the options set StopOnError, the first
+// failure cancels the rest of the batch, whose requests report the context error. Otherwise every
+// request runs and the failures are aggregated into the returned error.
+//
+// Parameters:
+//   - ctx: The context governing the batch.
+//   - configs: The request configurations to execute.
+//   - cfg: The configuration passed to Execute for each request.
+//   - opts: The concurrency, rate limit and failure handling of the batch.
+//
+// Returns:
+//   - The BatchResult of every request, in the order of the configurations.
+//   - An error naming the failed request that stopped the batch, wrapping ErrBatchTimeout when the
+//     batch timed out, or listing every failed request, or nil when all of them succeeded. The
+//     results are returned in every case, those of the cancelled requests holding the context
+//     error.
+func ExecuteBatch(ctx context.Context, configs []*data.RequestConfig, cfg data.Config, opts BatchOptions) ([]BatchResult, error) {
+	if opts.BatchTimeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, opts.BatchTimeout)
+		defer cancelTimeout()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make([]BatchResult, len(configs))
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(configs) {
+		workers = len(configs)
+	}
+	limiter := newRateLimiter(opts.RateLimit)
+	indexes := make(chan int)
+	var stopMu sync.Mutex
+	stoppedAt := -1
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			first := true
+			for i := range indexes {
+				results[i].Config = configs[i]
+				if !first {
+					if err := cfg.PauseStep(ctx); err != nil {
+						results[i].Err = err
+						continue
+					}
+				}
+				first = false
+				if err := limiter.wait(ctx); err != nil {
+					results[i].Err = err
+					continue
+				}
+				results[i].Result, results[i].Err = Execute(ctx, configs[i], cfg)
+				if opts.StopOnError && results[i].Failure() != nil {
+					stopMu.Lock()
+					if stoppedAt < 0 && ctx.Err() == nil {
+						stoppedAt = i
+						cancel()
+					}
+					stopMu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range configs {
+		if ctx.Err() != nil {
+			// The batch was stopped, the requests left are never started
+			results[i] = BatchResult{Config: configs[i], Err: ctx.Err()}
+			continue
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	if stoppedAt >= 0 {
+		return results, errors.Wrapf(results[stoppedAt].Failure(), "Batch stopped, %s failed", results[stoppedAt].describe(stoppedAt))
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		completed := 0
+		for _, result := range results {
+			if !errors.Is(result.Err, context.DeadlineExceeded) {
+				completed++
+			}
+		}
+		// A deadline passing once the last request completed does not stop the batch
+		if completed < len(results) {
+			return results, errors.Wrapf(ErrBatchTimeout, "Batch stopped, %d of %d requests completed", completed, len(results))
+		}
+	}
+	var failures []string
+	for i, result := range results {
+		if err := result.Failure(); err != nil {
+			failures = append(failures, result.describe(i)+": "+err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return results, errors.Errorf("%d of %d requests failed:\n  - %s", len(failures), len(results), strings.Join(failures, "\n  - "))
+	}
+	return results, nil
+}
+
+// rateLimiter spaces the start of requests by a fixed interval.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter returns a rateLimiter allowing the given number of requests per second, or nil
+// when the rate is unlimited.
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the next request is allowed to start, or the context is done. The first
+// request starts immediately.
+func (rl *rateLimiter) wait(ctx context.Context) error {
+	if rl == nil {
+		return ctx.Err()
+	}
+	rl.mu.Lock()
+	now := time.Now()
+	if rl.next.Before(now) {
+		rl.next = now
+	}
+	delay := rl.next.Sub(now)
+	rl.next = rl.next.Add(rl.interval)
+	rl.mu.Unlock()
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
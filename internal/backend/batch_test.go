@@ -0,0 +1,244 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+// stepConfigs returns count requests sent with the backend.
+func stepConfigs(backend string, count int) []*data.RequestConfig {
+	configs := make([]*data.RequestConfig, count)
+	for i := range configs {
+		configs[i] = &data.RequestConfig{
+			Host:             &url.URL{Scheme: "https", Host: "example.com"},
+			Method:           "GET",
+			Backend:          backend,
+			NoDefaultHeaders: true,
+		}
+	}
+	return configs
+}
+
+func TestExecuteBatchStepDelay(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	registerTestBackend(t, "test-step",
+		func(*data.RequestConfig, data.Config) ([]string, error) { return nil, nil },
+		func(context.Context, []string, *data.RequestConfig) (data.RequestResult, error) {
+			return data.RequestResult{StatusCode: 200}, nil
+		})
+	cfg := data.Config{StepDelay: 20 * time.Millisecond}
+	start := time.Now()
+	if _, err := ExecuteBatch(context.Background(), stepConfigs("test-step", 4), cfg, BatchOptions{}); err != nil {
+		t.Fatalf("ExecuteBatch() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 3*cfg.StepDelay {
+		t.Errorf("4 sequential steps took %v, want at least %v", elapsed, 3*cfg.StepDelay)
+	}
+}
+
+func TestExecuteBatchStepDelayCancel(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	registerTestBackend(t, "test-step-cancel",
+		func(*data.RequestConfig, data.Config) ([]string, error) { return nil, nil },
+		func(context.Context, []string, *data.RequestConfig) (data.RequestResult, error) {
+			// The batch is cancelled during the pause following the first step
+			time.AfterFunc(10*time.Millisecond, cancel)
+			return data.RequestResult{StatusCode: 200}, nil
+		})
+	start := time.Now()
+	results, _ := ExecuteBatch(ctx, stepConfigs("test-step-cancel", 2), data.Config{StepDelay: time.Hour}, BatchOptions{})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the cancelled batch took %v", elapsed)
+	}
+	if results[0].Err != nil {
+		t.Errorf("the first step error = %v, want it to succeed", results[0].Err)
+	}
+	if !errors.Is(results[1].Err, context.Canceled) {
+		t.Errorf("the second step error = %v, want context.Canceled", results[1].Err)
+	}
+}
+
+func TestExecuteBatchRateLimit(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	registerTestBackend(t, "test-rate",
+		func(*data.RequestConfig, data.Config) ([]string, error) { return nil, nil },
+		func(context.Context, []string, *data.RequestConfig) (data.RequestResult, error) {
+			return data.RequestResult{StatusCode: 200}, nil
+		})
+	tests := map[string]struct {
+		opts BatchOptions
+		min  time.Duration
+	}{
+		"unlimited":  {BatchOptions{Concurrency: 5}, 0},
+		"sequential": {BatchOptions{RateLimit: 50}, 4 * 20 * time.Millisecond},
+		"concurrent": {BatchOptions{Concurrency: 5, RateLimit: 50}, 4 * 20 * time.Millisecond},
+	}
+	for name, tt := range tests {
+		start := time.Now()
+		if _, err := ExecuteBatch(context.Background(), stepConfigs("test-rate", 5), data.Config{}, tt.opts); err != nil {
+			t.Errorf("%s: ExecuteBatch() error = %v", name, err)
+			continue
+		}
+		elapsed := time.Since(start)
+		if elapsed < tt.min {
+			t.Errorf("%s: 5 requests took %v, want at least %v", name, elapsed, tt.min)
+		}
+		if tt.min == 0 && elapsed > time.Second {
+			t.Errorf("%s: 5 unlimited requests took %v", name, elapsed)
+		}
+	}
+}
+
+func TestExecuteBatchRateLimitCancel(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	registerTestBackend(t, "test-rate-cancel",
+		func(*data.RequestConfig, data.Config) ([]string, error) { return nil, nil },
+		func(context.Context, []string, *data.RequestConfig) (data.RequestResult, error) {
+			// The batch is cancelled while the second request waits for its turn
+			time.AfterFunc(10*time.Millisecond, cancel)
+			return data.RequestResult{StatusCode: 200}, nil
+		})
+	start := time.Now()
+	results, _ := ExecuteBatch(ctx, stepConfigs("test-rate-cancel", 2), data.Config{}, BatchOptions{RateLimit: 0.001})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the cancelled batch took %v", elapsed)
+	}
+	if results[0].Err != nil {
+		t.Errorf("the first request error = %v, want it to succeed", results[0].Err)
+	}
+	if !errors.Is(results[1].Err, context.Canceled) {
+		t.Errorf("the second request error = %v, want context.Canceled", results[1].Err)
+	}
+}
+
+func TestExecuteBatchStopOnError(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	registerTestBackend(t, "test-stop",
+		func(*data.RequestConfig, data.Config) ([]string, error) { return nil, nil },
+		func(_ context.Context, _ []string, rc *data.RequestConfig) (data.RequestResult, error) {
+			if rc.Host.Path == "/fail" {
+				return data.RequestResult{StatusCode: 500}, nil
+			}
+			return data.RequestResult{StatusCode: 200}, nil
+		})
+	newBatch := func() []*data.RequestConfig {
+		configs := stepConfigs("test-stop", 5)
+		configs[2].Host = &url.URL{Scheme: "https", Host: "example.com", Path: "/fail"}
+		return configs
+	}
+
+	results, err := ExecuteBatch(context.Background(), newBatch(), data.Config{}, BatchOptions{StopOnError: true})
+	if err == nil || !strings.Contains(err.Error(), "Batch stopped, request 3 (GET https://example.com/fail) failed: HTTP status 500") {
+		t.Errorf("ExecuteBatch() error = %v, want the failed request 3", err)
+	}
+	for i, result := range results {
+		switch {
+		case i < 2 && result.Failure() != nil:
+			t.Errorf("stop on error: request %d failure = %v, want it to succeed", i+1, result.Failure())
+		case i == 2 && result.Result.StatusCode != 500:
+			t.Errorf("stop on error: request 3 status = %d, want 500", result.Result.StatusCode)
+		case i > 2 && !errors.Is(result.Err, context.Canceled):
+			t.Errorf("stop on error: request %d error = %v, want context.Canceled", i+1, result.Err)
+		}
+	}
+
+	results, err = ExecuteBatch(context.Background(), newBatch(), data.Config{}, BatchOptions{})
+	if err == nil || !strings.HasPrefix(err.Error(), "1 of 5 requests failed:\n  - request 3 (GET https://example.com/fail): HTTP status 500") {
+		t.Errorf("ExecuteBatch() error = %v, want the aggregated failure of request 3", err)
+	}
+	for i, result := range results {
+		if want := i == 2; (result.Failure() != nil) != want {
+			t.Errorf("run all: request %d failure = %v, want a failure: %v", i+1, result.Failure(), want)
+		}
+	}
+}
+
+func TestExecuteBatchTimeout(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	bodies := filepath.Join(t.TempDir(), "bodies")
+	t.Setenv("VORTEX_TEST_BODIES", bodies)
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep is not available")
+	}
+	// The fake curl records its body file, then answers at once unless the URL is slow
+	fakeBackend(t, "curl", `for arg in "$@"; do
+  case "$arg" in @*) echo "${arg#@}" >> "$VORTEX_TEST_BODIES" ;; esac
+done
+case "$*" in *slow*) exec '`+sleep+`' 10 ;; esac
+printf 'ok\n--vortex-write-out--\nhttp_code=200\n'
+`)
+	configs := stepConfigs("curl", 5)
+	for i, rc := range configs {
+		rc.Method = "POST"
+		rc.Body = []string{`{"n": 1}`}
+		if i >= 2 {
+			rc.Host = &url.URL{Scheme: "https", Host: "example.com", Path: "/slow"}
+		}
+	}
+	start := time.Now()
+	results, err := ExecuteBatch(context.Background(), configs, data.Config{}, BatchOptions{Concurrency: 2, BatchTimeout: 200 * time.Millisecond})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("the timed out batch took %v, want the slow backends killed", elapsed)
+	}
+	if !errors.Is(err, ErrBatchTimeout) || !strings.Contains(err.Error(), "2 of 5 requests completed") {
+		t.Errorf("ExecuteBatch() error = %v, want ErrBatchTimeout after 2 requests", err)
+	}
+	for i, result := range results {
+		if result.Config != configs[i] {
+			t.Errorf("request %d config = %p, want %p", i+1, result.Config, configs[i])
+		}
+		if i < 2 && (result.Err != nil || result.Result.Stdout != "ok") {
+			t.Errorf("request %d = %q, %v, want it completed", i+1, result.Result.Stdout, result.Err)
+		}
+		if i >= 2 && !errors.Is(result.Err, context.DeadlineExceeded) {
+			t.Errorf("request %d error = %v, want context.DeadlineExceeded", i+1, result.Err)
+		}
+	}
+	recorded, err := os.ReadFile(bodies)
+	if err != nil {
+		t.Fatalf("reading the recorded body files: %v", err)
+	}
+	files := strings.Fields(string(recorded))
+	if len(files) < 3 {
+		t.Errorf("the backend received the body files %q, want a slow request started", files)
+	}
+	for _, file := range files {
+		if _, err := os.Stat(file); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("the body tempfile %s is left behind: %v", file, err)
+		}
+	}
+}
+
+func TestExecuteBatchCompletedAtDeadline(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	registerTestBackend(t, "test-deadline",
+		func(*data.RequestConfig, data.Config) ([]string, error) { return nil, nil },
+		func(ctx context.Context, _ []string, _ *data.RequestConfig) (data.RequestResult, error) {
+			// The request completes as the deadline of the batch passes
+			<-ctx.Done()
+			return data.RequestResult{StatusCode: 200}, nil
+		})
+	results, err := ExecuteBatch(context.Background(), stepConfigs("test-deadline", 2), data.Config{}, BatchOptions{Concurrency: 2, BatchTimeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("ExecuteBatch() error = %v, want the completed batch to succeed", err)
+	}
+	for i, result := range results {
+		if result.Err != nil || result.Result.StatusCode != 200 {
+			t.Errorf("request %d = %d, %v, want it completed", i+1, result.Result.StatusCode, result.Err)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package backend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+// defaultCacheMethods lists the HTTP methods whose responses are cached when the configuration
+// does not list any.
+var defaultCacheMethods = []string{http.MethodGet, http.MethodHead}
+
+// cacheEntry is the JSON document stored for a cached response.
+type cacheEntry struct {
+	Stored time.Time          `json:"stored"`
+	Result data.RequestResult `json:"result"`
+}
+
+// responseCache stores the results of a request on disk. A nil responseCache caches nothing.
+type responseCache struct {
+	path string
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// newResponseCache returns the cache of the request, or nil when the configuration disables the
+// cache or the request cannot be cached: its method is not cacheable or its body or headers are
+// written to an OutputFile or a HeaderFile, or streamed, which are not part of the result.
+func newResponseCache(rc *data.RequestConfig, cfg data.Config) *responseCache {
+	if cfg.CacheTTL <= 0 || rc.Host == nil || rc.OutputFile != "" || rc.HeaderFile != "" || rc.Stream {
+		return nil
+	}
+	method := strings.ToUpper(rc.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	methods := cfg.CacheMethods
+	if methods == nil {
+		methods = defaultCacheMethods
+	}
+	cacheable := false
+	for _, candidate := range methods {
+		if strings.EqualFold(candidate, method) {
+			cacheable = true
+			break
+		}
+	}
+	if !cacheable {
+		return nil
+	}
+	dir := cfg.CacheDir
+	if dir == "" {
+		userDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil
+		}
+		dir = filepath.Join(userDir, "vortex")
+	}
+	return &responseCache{
+		path: filepath.Join(dir, cacheKey(rc, method)+".json"),
+		ttl:  cfg.CacheTTL,
+		now:  cfg.Now,
+	}
+}
+
+// cacheKey hashes what identifies the response of a request: its method, URL, backend, backend
+// options, body and headers, the latter sorted by name so their order does not matter.
+func cacheKey(rc *data.RequestConfig, method string) string {
+	headers := make([]string, 0, len(rc.Headers))
+	for _, header := range rc.Headers {
+		name, value, _ := data.SplitHeader(header)
+		headers = append(headers, strings.ToLower(name)+":"+value)
+	}
+	sort.Strings(headers)
+	hash := sha256.New()
+	parts := []string{method, rc.Host.String(), rc.Backend, strings.Join(rc.Body, "\n"), rc.BodyFile}
+	for _, options := range rc.BackendOptions {
+		parts = append(parts, strings.Join(options, "\x01"))
+	}
+	for _, part := range append(parts, headers...) {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// lookup returns the cached result of the request when it is younger than the TTL.
+func (c *responseCache) lookup() (data.RequestResult, bool) {
+	if c == nil {
+		return data.RequestResult{}, false
+	}
+	contents, err := os.ReadFile(c.path)
+	if err != nil {
+		return data.RequestResult{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(contents, &entry); err != nil || c.now().Sub(entry.Stored) >= c.ttl {
+		return data.RequestResult{}, false
+	}
+	entry.Result.FromCache = true
+	return entry.Result, true
+}
+
+// stale returns the cached result of the request, however old, when its response carries an ETag
+// or a Last-Modified header validating it with a conditional request.
+func (c *responseCache) stale() (data.RequestResult, bool) {
+	if c == nil {
+		return data.RequestResult{}, false
+	}
+	contents, err := os.ReadFile(c.path)
+	if err != nil {
+		return data.RequestResult{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(contents, &entry); err != nil {
+		return data.RequestResult{}, false
+	}
+	if entry.Result.Headers.Get("ETag") == "" && entry.Result.Headers.Get("Last-Modified") == "" {
+		return data.RequestResult{}, false
+	}
+	return entry.Result, true
+}
+
+// conditionalHeaders returns the If-None-Match and If-Modified-Since headers revalidating the stale
+// result, leaving out those the request already sets.
+func conditionalHeaders(rc *data.RequestConfig, stale data.RequestResult) []string {
+	validators := [][2]string{
+		{"If-None-Match", stale.Headers.Get("ETag")},
+		{"If-Modified-Since", stale.Headers.Get("Last-Modified")},
+	}
+	var headers []string
+	for _, validator := range validators {
+		if validator[1] == "" {
+			continue
+		}
+		set := false
+		for _, header := range rc.Headers {
+			if name, _, _ := data.SplitHeader(header); strings.EqualFold(name, validator[0]) {
+				set = true
+				break
+			}
+		}
+		if !set {
+			headers = append(headers, validator[0]+": "+validator[1])
+		}
+	}
+	return headers
+}
+
+// revalidate sends the request with executeWithRetry, conditionally when the cache holds a stale
+// result with validators. A 304 Not Modified response is answered with the stale result, flagged
+// with FromCache, which is stored again so it is fresh for another TTL, while another response
+// replaces it in the cache. The conditional headers are not kept in the RequestConfig.
+func (c *responseCache) revalidate(ctx context.Context, rc *data.RequestConfig, cfg data.Config) (data.RequestResult, error) {
+	stale, ok := c.stale()
+	var conditional []string
+	if ok {
+		conditional = conditionalHeaders(rc, stale)
+	}
+	if len(conditional) == 0 {
+		result, err := executeWithRetry(ctx, rc, cfg)
+		c.store(result, err)
+		return result, err
+	}
+	headers := rc.Headers
+	rc.Headers = append(append([]string(nil), headers...), conditional...)
+	result, err := executeWithRetry(ctx, rc, cfg)
+	rc.Headers = headers
+	if err != nil || result.StatusCode != http.StatusNotModified {
+		c.store(result, err)
+		return result, err
+	}
+	if rc.Verbose {
+		cfg.Log().Log("Reusing the cached response, not modified", "method", rc.Method, "url", rc.Host.Redacted())
+	}
+	c.store(stale, nil)
+	stale.FromCache = true
+	stale.Attempts = result.Attempts
+	return stale, nil
+}
+
+// store caches the result of a successful request, whose response has a 2xx status code. Failing
+// to write the cache is not an error, the response is simply not reused.
+func (c *responseCache) store(result data.RequestResult, err error) {
+	if c == nil || err != nil || result.ExitCode != 0 || result.Truncated {
+		return
+	}
+	if result.StatusCode < 200 || result.StatusCode > 299 {
+		return
+	}
+	contents, err := json.Marshal(cacheEntry{Stored: c.now(), Result: result})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return
+	}
+	_ = os.WriteFile(c.path, contents, 0o600)
+}
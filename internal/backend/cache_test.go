@@ -0,0 +1,158 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+func TestExecuteRevalidatesCachedResponse(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte("original body"))
+	}))
+	defer server.Close()
+	host, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := data.Config{
+		NoHistory: true,
+		CacheTTL:  time.Minute,
+		CacheDir:  t.TempDir(),
+		Clock:     func() time.Time { return now },
+	}
+	newRequest := func() *data.RequestConfig {
+		return &data.RequestConfig{Host: host, Method: http.MethodGet, Backend: "native", NoDefaultHeaders: true}
+	}
+
+	first, err := Execute(context.Background(), newRequest(), cfg)
+	if err != nil {
+		t.Fatalf("first Execute() error = %v", err)
+	}
+	if first.FromCache || first.Stdout != "original body" {
+		t.Fatalf("first Execute() = %q, FromCache %v, want the body of the server", first.Stdout, first.FromCache)
+	}
+
+	// Once the cached response expired, it is revalidated and reused on a 304
+	now = now.Add(2 * time.Minute)
+	second, err := Execute(context.Background(), newRequest(), cfg)
+	if err != nil {
+		t.Fatalf("second Execute() error = %v", err)
+	}
+	if !second.FromCache || second.Stdout != "original body" {
+		t.Errorf("second Execute() = %q, FromCache %v, want the cached body", second.Stdout, second.FromCache)
+	}
+	if requests.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("server received %d requests, %d conditional, want 2 and 1", requests.Load(), notModified.Load())
+	}
+
+	// The revalidated response is fresh again, and reused without a request
+	third, err := Execute(context.Background(), newRequest(), cfg)
+	if err != nil || !third.FromCache || requests.Load() != 2 {
+		t.Errorf("third Execute() = FromCache %v, %v after %d requests, want the fresh cached body", third.FromCache, err, requests.Load())
+	}
+}
+
+func TestExecuteCachesSuccessfulResponses(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := data.Config{
+		NoHistory: true,
+		CacheTTL:  time.Minute,
+		CacheDir:  t.TempDir(),
+		Clock:     func() time.Time { return now },
+	}
+	execute := func(path string, options ...[]string) data.RequestResult {
+		t.Helper()
+		host, err := url.Parse(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc := &data.RequestConfig{Host: host, Backend: "native", BackendOptions: options, NoDefaultHeaders: true}
+		result, err := Execute(context.Background(), rc, cfg)
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		return result
+	}
+	expectRequests := func(step string, want int32) {
+		t.Helper()
+		if got := requests.Load(); got != want {
+			t.Errorf("%s: the server received %d requests, want %d", step, got, want)
+		}
+	}
+
+	if execute("/ok").FromCache {
+		t.Error("the first request is served from the cache")
+	}
+	if !execute("/ok").FromCache {
+		t.Error("the second request within the TTL is not served from the cache")
+	}
+	expectRequests("hit", 1)
+
+	// Other backend options identify another response
+	execute("/ok", []string{"--compressed"})
+	expectRequests("options", 2)
+
+	// Error responses are not cached
+	execute("/fail")
+	if execute("/fail").FromCache {
+		t.Error("a 503 response is served from the cache")
+	}
+	expectRequests("error", 4)
+
+	// Once the TTL elapsed, the response is requested again
+	now = now.Add(2 * time.Minute)
+	if execute("/ok").FromCache {
+		t.Error("an expired response is served from the cache")
+	}
+	expectRequests("expiry", 5)
+}
+
+func TestCacheKey(t *testing.T) {
+	host, err := url.Parse("https://example.com/users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := data.RequestConfig{Host: host, Headers: []string{"A: 1", "B: 2"}, Body: []string{"{}"}}
+	reordered := base
+	reordered.Headers = []string{"B: 2", "A: 1"}
+	if cacheKey(&base, "GET") != cacheKey(&reordered, "GET") {
+		t.Error("the order of the headers changes the cache key")
+	}
+	otherBody := base
+	otherBody.Body = []string{`{"id":1}`}
+	withOptions := base
+	withOptions.BackendOptions = [][]string{{"--compressed"}}
+	for name, rc := range map[string]*data.RequestConfig{"body": &otherBody, "options": &withOptions} {
+		if cacheKey(&base, "GET") == cacheKey(rc, "GET") {
+			t.Errorf("another %s gives the same cache key", name)
+		}
+	}
+}
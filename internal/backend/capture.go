@@ -0,0 +1,94 @@
+package backend
+
+import (
+	"bytes"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+// captureTailSize is the number of trailing bytes always kept by a cappedBuffer, so the metadata
+// printed by a backend after the body can still be parsed when the body itself was truncated.
+const captureTailSize = 4096
+
+// cappedBuffer captures the output of a backend, keeping at most limit bytes of it plus the last
+// captureTailSize bytes. Writes never fail once the limit is reached, so the backend can finish
+// normally while the excess output is discarded. A limit of zero or less means no limit.
+type cappedBuffer struct {
+	limit    int64
+	head     bytes.Buffer
+	tail     []byte
+	overflow bool
+}
+
+// Write stores the bytes that fit under the limit and keeps the trailing bytes.
+func (cb *cappedBuffer) Write(p []byte) (int, error) {
+	if cb.limit <= 0 {
+		return cb.head.Write(p)
+	}
+	if room := cb.limit - int64(cb.head.Len()); room > 0 {
+		if int64(len(p)) <= room {
+			cb.head.Write(p)
+		} else {
+			cb.head.Write(p[:room])
+			cb.overflow = true
+		}
+	} else if len(p) > 0 {
+		cb.overflow = true
+	}
+	cb.tail = append(cb.tail, p...)
+	if len(cb.tail) > captureTailSize {
+		cb.tail = cb.tail[len(cb.tail)-captureTailSize:]
+	}
+	return len(p), nil
+}
+
+// Truncated reports whether some of the output was discarded because of the limit.
+func (cb *cappedBuffer) Truncated() bool {
+	return cb.overflow
+}
+
+// String returns the captured output, up to the limit.
+func (cb *cappedBuffer) String() string {
+	return cb.head.String()
+}
+
+// Tail returns the last bytes written, regardless of the limit.
+func (cb *cappedBuffer) Tail() string {
+	return string(cb.tail)
+}
+
+// logWriter passes the diagnostics a backend writes on stderr to the configured Logger, one
+// message per line, with the values of the sensitive headers redacted by RedactOutputLine. The
+// last line, when not terminated, is only logged by Flush.
+type logWriter struct {
+	backend string
+	cfg     data.Config
+	partial []byte
+}
+
+// Write logs the complete lines and keeps the partial one.
+func (lw *logWriter) Write(p []byte) (int, error) {
+	lw.partial = append(lw.partial, p...)
+	for {
+		end := bytes.IndexByte(lw.partial, '\n')
+		if end < 0 {
+			break
+		}
+		lw.log(string(bytes.TrimSuffix(lw.partial[:end], []byte("\r"))))
+		lw.partial = lw.partial[end+1:]
+	}
+	return len(p), nil
+}
+
+// Flush logs the last line when it was not terminated.
+func (lw *logWriter) Flush() {
+	if len(lw.partial) > 0 {
+		lw.log(string(lw.partial))
+		lw.partial = nil
+	}
+}
+
+// log logs a line of the backend.
+func (lw *logWriter) log(line string) {
+	lw.cfg.Log().Log("Backend stderr", "backend", lw.backend, "line", data.RedactOutputLine(line, lw.cfg.SensitiveHeaderNames()))
+}
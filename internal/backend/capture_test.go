@@ -0,0 +1,61 @@
+package backend
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+func TestLogWriter(t *testing.T) {
+	var logged bytes.Buffer
+	lw := &logWriter{backend: "curl", cfg: data.Config{Logger: data.NewTextLogger(&logged)}}
+	for _, chunk := range []string{"* Connected\r\n> Authorization: Bear", "er secret\n< HTTP/1.1 200 OK\n", "* done"} {
+		if _, err := io.WriteString(lw, chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "Backend stderr backend=curl line=\"* Connected\"\n" +
+		"Backend stderr backend=curl line=\"> Authorization: Bearer ***\"\n" +
+		"Backend stderr backend=curl line=\"< HTTP/1.1 200 OK\"\n"
+	if logged.String() != want {
+		t.Errorf("logged %q before Flush, want %q", logged.String(), want)
+	}
+	lw.Flush()
+	want += "Backend stderr backend=curl line=\"* done\"\n"
+	if logged.String() != want {
+		t.Errorf("logged %q, want %q", logged.String(), want)
+	}
+}
+
+func TestCappedBuffer(t *testing.T) {
+	tests := map[string]struct {
+		limit     int64
+		chunks    []string
+		want      string
+		truncated bool
+	}{
+		"unlimited":    {0, []string{"abc", "def"}, "abcdef", false},
+		"under":        {8, []string{"abc", "def"}, "abcdef", false},
+		"at the limit": {6, []string{"abc", "def"}, "abcdef", false},
+		"over":         {4, []string{"abc", "def", "ghi"}, "abcd", true},
+	}
+	for name, tt := range tests {
+		cb := &cappedBuffer{limit: tt.limit}
+		for _, chunk := range tt.chunks {
+			if n, err := io.WriteString(cb, chunk); n != len(chunk) || err != nil {
+				t.Fatalf("%s: Write() = %d, %v, want every byte accepted", name, n, err)
+			}
+		}
+		if cb.String() != tt.want || cb.Truncated() != tt.truncated {
+			t.Errorf("%s: captured %q, truncated %v, want %q, %v", name, cb.String(), cb.Truncated(), tt.want, tt.truncated)
+		}
+	}
+	cb := &cappedBuffer{limit: 1}
+	_, _ = io.WriteString(cb, strings.Repeat("x", captureTailSize)+"write-out")
+	if tail := cb.Tail(); len(tail) != captureTailSize || !strings.HasSuffix(tail, "write-out") {
+		t.Errorf("Tail() = %d bytes, want the last %d bytes kept", len(tail), captureTailSize)
+	}
+}
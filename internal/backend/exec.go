@@ -0,0 +1,343 @@
+// Package backend executes the requests described by a RequestConfig, either by running one of
+// the supported external HTTP tools or with the native Go HTTP client, and collects the outcome
+// into a RequestResult.
+package backend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/larayavrs/vortex/internal/disk"
+	"github.com/pkg/errors"
+)
+
+// argBuilders maps each supported external backend to the function building its arguments.
+var argBuilders = map[string]func(*data.RequestConfig, data.Config) ([]string, error){
+	"curl":   data.BuildCurlArgs,
+	"httpie": data.BuildHTTPieArgs,
+	"wget":   data.BuildWgetArgs,
+}
+
+// HTTPStatusError is the error returned by Execute for a response with a 4xx or 5xx status code
+// when the RequestConfig sets FailOnHTTPError.
+type HTTPStatusError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// URL is the URL of the response.
+	URL string
+}
+
+// The method Error describes the failed response.
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("The server returned HTTP status %d for %s", e.StatusCode, e.URL)
+}
+
+// The method ExitCode returns ExitHTTPError.
+func (e *HTTPStatusError) ExitCode() int {
+	return data.ExitHTTPError
+}
+
+// RequestError is the error returned by Execute when the request cannot be sent or its response
+// cannot be received, such as a network failure or a backend that cannot run. It wraps the
+// underlying error, which keeps its message.
+type RequestError struct {
+	// Err is the underlying error.
+	Err error
+}
+
+// The method Error returns the message of the underlying error.
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+// The method Unwrap returns the underlying error, so errors.Is and errors.As can inspect it.
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// The method ExitCode returns ExitRequestError, unless the underlying error carries its own code.
+func (e *RequestError) ExitCode() int {
+	var coder data.ExitCoder
+	if errors.As(e.Err, &coder) {
+		return coder.ExitCode()
+	}
+	return data.ExitRequestError
+}
+
+// The function Execute performs the request described by the RequestConfig and returns the
+// captured result. The default headers and the stdin body are merged into the request, its
+// variable references are expanded with Interpolate, its backend is chosen as described by
+// selectBackend, and a destructive request is only sent once confirmed with ConfirmRequest. The
+// content headers and authentication are added next, then the request is sent by execute, through
+// the response cache when the configuration sets a CacheTTL, and its response is post-processed.
+// Unless the configuration sets NoHistory, the request is recorded in the history read by
+// LoadHistory. Signals are left to the caller, which can cancel the context.
+//
+// Parameters:
+//   - ctx: The context governing the execution. Cancelling it kills the backend process.
+//   - rc: The request configuration to execute.
+//   - cfg: The configuration providing the timeout and other global settings. The Timeout of the
+//     RequestConfig, when set, takes precedence over the one of the configuration.
+//
+// Returns:
+//   - The RequestResult with the response body, status code, process exit code and duration.
+//   - An error if the backend is not supported, cannot be started or the headers and body cannot
+//     be prepared, if the request was not confirmed, or if the context was cancelled.
+//     The failures of the request itself are wrapped in a RequestError, so data.ExitCode maps them
+//     to ExitRequestError. A backend exiting with a non-zero code is not an error; it is reported in
+//     ExitCode. When the RequestConfig sets FailOnHTTPError, a 4xx or 5xx status is reported as an
+//     HTTPStatusError along with the captured RequestResult.
+func Execute(ctx context.Context, rc *data.RequestConfig, cfg data.Config) (data.RequestResult, error) {
+	var result data.RequestResult
+	if rc.Timeout > 0 {
+		cfg.Timeout = rc.Timeout
+	}
+	if !rc.NoDefaultHeaders {
+		defaults, err := disk.LoadDefaultHeaders()
+		if err != nil {
+			return result, err
+		}
+		rc.ApplyDefaultHeaders(defaults)
+	}
+	if err := disk.ReadStdinBody(rc, disk.Stdin); err != nil {
+		return result, err
+	}
+	if err := rc.Interpolate(cfg); err != nil {
+		return result, err
+	}
+	if err := selectBackend(rc, cfg); err != nil {
+		return result, err
+	}
+	if err := disk.ConfirmRequest(rc, cfg, disk.Stdin, disk.Prompt); err != nil {
+		return result, err
+	}
+	rc.InferContentType()
+	rc.AddContentEncoding()
+	if err := applyOAuth2Token(ctx, rc, cfg); err != nil {
+		return result, err
+	}
+	if err := rc.ApplyAuth(cfg); err != nil {
+		return result, err
+	}
+	if rc.Verbose && rc.IgnoresBody() && (len(rc.Body) > 0 || rc.BodyFile != "") {
+		cfg.Log().Log("Ignoring the request body", "method", rc.Method)
+	}
+	if rc.Verbose && len(rc.Resolve) > 0 {
+		logResolveOverride(rc, cfg)
+	}
+	start := time.Now()
+	cache := newResponseCache(rc, cfg)
+	result, cached := cache.lookup()
+	var err error
+	if cached {
+		if rc.Verbose {
+			cfg.Log().Log("Using the cached response", "method", rc.Method, "url", rc.Host.Redacted())
+		}
+	} else {
+		result, err = cache.revalidate(ctx, rc, cfg)
+		if err != nil {
+			err = &RequestError{Err: err}
+		}
+	}
+	result.Duration = time.Since(start)
+	if err == nil && rc.TraceFile != "" {
+		err = writeTraceFile(rc, result)
+	}
+	if err == nil && rc.PostProcess != "" {
+		err = postProcess(ctx, rc.PostProcess, &result)
+	}
+	if err == nil && rc.FailOnHTTPError && result.StatusCode >= 400 {
+		err = &HTTPStatusError{StatusCode: result.StatusCode, URL: result.FinalURL}
+	}
+	if !cfg.NoHistory {
+		entry := data.NewHistoryEntry(rc, result, cfg.Now().Add(-result.Duration), result.Duration, err, cfg.SensitiveHeaderNames())
+		if historyErr := data.AppendHistory(entry); historyErr != nil && rc.Verbose {
+			cfg.Log().Log("Failed to record the request history", "error", historyErr)
+		}
+	}
+	if cfg.KeepLastResponse && result.StatusCode != 0 {
+		if saveErr := data.SaveLastResponse(result, cfg.SensitiveHeaderNames()); saveErr != nil && rc.Verbose {
+			cfg.Log().Log("Failed to record the last response", "error", saveErr)
+		}
+	}
+	return result, err
+}
+
+// logResolveOverride reports the [Resolve] override the host of the request connects to, on the
+// port given by EffectivePort, or that none of the overrides matches it, which usually means the
+// entry names another port.
+func logResolveOverride(rc *data.RequestConfig, cfg data.Config) {
+	host, port := rc.Host.Hostname(), rc.EffectivePort()
+	address, err := rc.ResolveAddress(host, port)
+	switch {
+	case err != nil:
+		cfg.Log().Log("Invalid host override", "error", err)
+	case address == "":
+		cfg.Log().Log("No host override matches the request", "host", host, "port", port)
+	default:
+		cfg.Log().Log("Overriding the address of the host", "host", host, "port", port, "address", address)
+	}
+}
+
+// selectBackend sets the backend the request is executed with. The Backend of the configuration,
+// usually chosen on the command line, takes precedence over the VORTEX_BACKEND environment
+// variable, which takes precedence over the expanded backend of the RequestConfig. The backend is
+// then resolved with ResolveBackend, which detects an installed one, or falls back to the native
+// backend, when none is requested.
+func selectBackend(rc *data.RequestConfig, cfg data.Config) error {
+	requested := cfg.Backend
+	if requested == "" {
+		override, err := disk.BackendOverride()
+		if err != nil {
+			return err
+		}
+		requested = override
+	}
+	if requested == "" {
+		requested = rc.Backend
+	}
+	backend, err := disk.ResolveBackend(requested)
+	if err != nil {
+		return err
+	}
+	rc.Backend = backend
+	return nil
+}
+
+// execute runs the prepared request with its backend. The native backend sends it itself, a custom
+// backend runs with executeCustom when it registered an executor, and the external tools run with
+// the arguments built for them. The body is written to a temporary file removed afterwards, even
+// when the context is cancelled, unless the RequestConfig sets Tempfile to keep it. The standard
+// output and error of the backend are captured apart, and a verbose request reports the command,
+// its redacted arguments, its standard error and the time it took through the Logger. HEAD
+// requests capture the response headers instead of the body.
+func execute(ctx context.Context, rc *data.RequestConfig, cfg data.Config) (data.RequestResult, error) {
+	var result data.RequestResult
+	if err := createOutputDirs(rc); err != nil {
+		return result, err
+	}
+	if rc.Backend == data.NativeBackend {
+		return executeNative(ctx, rc, cfg)
+	}
+	if rc.Stream {
+		return result, errors.Errorf("Streaming is only supported by the native backend, not %s", rc.Backend)
+	}
+	buildArgs, ok := argBuilders[rc.Backend]
+	var executor disk.BackendExecutor
+	if !ok {
+		var custom disk.BackendArgsBuilder
+		if custom, executor, ok = disk.CustomBackend(rc.Backend); !ok {
+			return result, errors.Errorf("Unsupported backend: %q", rc.Backend)
+		}
+		buildArgs = custom
+	}
+	if err := rc.CreateBodyTempfile(); err != nil {
+		return result, err
+	}
+	defer func() {
+		// An interrupted request removes its body file too, unless it asked to keep it
+		_ = rc.RemoveBodyTempfile(false)
+	}()
+	args, err := buildArgs(rc, cfg)
+	if err != nil {
+		return result, err
+	}
+	if executor != nil {
+		return executeCustom(ctx, executor, args, rc)
+	}
+	var stderr bytes.Buffer
+	stdout := &cappedBuffer{}
+	if rc.MaxResponseBytes > 0 {
+		// Leave room for what backends print around the body, it is trimmed once parsed
+		stdout.limit = rc.MaxResponseBytes + captureTailSize
+	}
+	cmd := exec.CommandContext(ctx, disk.BackendExecutable(rc.Backend), args...)
+	// The streams are captured apart, so the diagnostics of the backend never mix with the body
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	var logged *logWriter
+	if rc.Verbose {
+		// The diagnostics of the backend go through the logger, redacted, as they are written
+		logged = &logWriter{backend: rc.Backend, cfg: cfg}
+		cmd.Stderr = io.MultiWriter(logged, &stderr)
+	}
+	if rc.Verbose {
+		printable := data.RedactArgs(rc.Backend, args, cfg.SensitiveHeaderNames())
+		cfg.Log().Log("Running backend", "backend", rc.Backend, "path", cmd.Path)
+		cfg.Log().Log("Command", "argv", strings.Join(printable, " "))
+	}
+	start := time.Now()
+	err = cmd.Run()
+	if rc.Verbose {
+		logged.Flush()
+		cfg.Log().Log("Backend finished", "backend", rc.Backend, "duration", time.Since(start))
+	}
+	if ctx.Err() != nil {
+		return result, errors.Wrapf(ctx.Err(), "Backend %s was interrupted", rc.Backend)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+	} else if err != nil {
+		return result, errors.Wrapf(err, "Failed to run backend %s", rc.Backend)
+	}
+	result.Stderr = stderr.String()
+	switch rc.Backend {
+	case "curl":
+		body, meta := data.ParseCurlWriteOut(stdout.String())
+		if stdout.Truncated() {
+			// The write-out metadata comes after the body, so it was cut off with the excess
+			_, meta = data.ParseCurlWriteOut(stdout.Tail())
+		}
+		result.Stdout = body
+		result.ApplyCurlWriteOut(meta)
+	case "httpie":
+		result.Stdout, result.StatusCode = data.ParseHTTPieOutput(stdout.String())
+		result.FinalURL = data.ParseHTTPieFinalURL(stdout.String(), rc.Host)
+	case "wget":
+		result.Stdout = stdout.String()
+		result.StatusCode = data.ParseWgetStatus(result.Stderr)
+		result.FinalURL = data.ParseWgetFinalURL(result.Stderr)
+	default:
+		result.Stdout = stdout.String()
+	}
+	if result.FinalURL == "" {
+		result.FinalURL = rc.Host.String()
+	}
+	if strings.EqualFold(rc.Method, http.MethodHead) {
+		if rc.Backend == "wget" {
+			result.Headers = data.ParseResponseHeaders(result.Stderr)
+		} else {
+			result.Headers = data.ParseResponseHeaders(result.Stdout)
+			result.Stdout = ""
+		}
+	}
+	if rc.MaxResponseBytes > 0 && int64(len(result.Stdout)) > rc.MaxResponseBytes {
+		result.Stdout = result.Stdout[:rc.MaxResponseBytes]
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+// executeCustom runs the request of a custom backend with the executor registered by
+// RegisterBackendExecutor, instead of running it like the external tools.
+func executeCustom(
+	ctx context.Context, executor disk.BackendExecutor, args []string, rc *data.RequestConfig,
+) (data.RequestResult, error) {
+	result, err := executor(ctx, args, rc)
+	if err != nil {
+		return result, errors.Wrapf(err, "Failed to run backend %s", rc.Backend)
+	}
+	if result.FinalURL == "" {
+		result.FinalURL = rc.Host.String()
+	}
+	return result, nil
+}
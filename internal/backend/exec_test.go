@@ -0,0 +1,334 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/larayavrs/vortex/internal/disk"
+)
+
+// registerTestBackend registers a custom backend run by the executor, once per name, since the
+// registry has no way to remove a backend.
+func registerTestBackend(t *testing.T, name string, builder disk.BackendArgsBuilder, executor disk.BackendExecutor) {
+	t.Helper()
+	if _, _, ok := disk.CustomBackend(name); !ok {
+		if err := disk.RegisterBackend(name, builder); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := disk.RegisterBackendExecutor(name, executor); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExecuteRemovesTempfileOnCancel(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	var tempfile string
+	var cancel context.CancelFunc
+	registerTestBackend(t, "test-cancel",
+		func(rc *data.RequestConfig, _ data.Config) ([]string, error) {
+			return []string{rc.TempfileName}, nil
+		},
+		func(ctx context.Context, args []string, _ *data.RequestConfig) (data.RequestResult, error) {
+			tempfile = args[0]
+			cancel()
+			return data.RequestResult{}, ctx.Err()
+		})
+	for _, keep := range []bool{false, true} {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		tempfile = ""
+		rc := &data.RequestConfig{
+			Host:             &url.URL{Scheme: "https", Host: "example.com"},
+			Method:           "POST",
+			Backend:          "test-cancel",
+			Body:             []string{"payload"},
+			Tempfile:         keep,
+			NoDefaultHeaders: true,
+		}
+		if _, err := Execute(ctx, rc, data.Config{}); err == nil {
+			t.Fatal("Execute() of a cancelled request succeeded, want an error")
+		}
+		cancel()
+		if tempfile == "" {
+			t.Fatal("the backend received no body file")
+		}
+		_, err := os.Stat(tempfile)
+		switch {
+		case !keep && !os.IsNotExist(err):
+			t.Errorf("the body file %s of the cancelled request is left behind", tempfile)
+		case keep && err != nil:
+			t.Errorf("the body file %s of the cancelled request setting Tempfile is removed: %v", tempfile, err)
+		}
+		if keep {
+			if err := rc.RemoveBodyTempfile(true); err != nil {
+				t.Errorf("RemoveBodyTempfile(true) error = %v", err)
+			}
+			if _, err := os.Stat(tempfile); !os.IsNotExist(err) {
+				t.Errorf("the body file %s is left behind once forced", tempfile)
+			}
+		}
+	}
+}
+
+// recordingLogger records the messages it receives.
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Log(msg string, _ ...any) {
+	l.messages = append(l.messages, msg)
+}
+
+func TestLogResolveOverride(t *testing.T) {
+	tests := map[string]struct {
+		host, entry, want string
+	}{
+		"default port": {"https://example.com/x", "example.com:443:127.0.0.1", "Overriding the address of the host"},
+		"other port":   {"https://example.com:8443/x", "example.com:443:127.0.0.1", "No host override matches the request"},
+	}
+	for name, tt := range tests {
+		host, err := url.Parse(tt.host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger := &recordingLogger{}
+		logResolveOverride(&data.RequestConfig{Host: host, Resolve: []string{tt.entry}}, data.Config{Logger: logger})
+		if len(logger.messages) != 1 || logger.messages[0] != tt.want {
+			t.Errorf("%s: logged %q, want %q", name, logger.messages, tt.want)
+		}
+	}
+}
+
+func TestExecuteCustomBackend(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	var got []string
+	registerTestBackend(t, "test-custom",
+		func(rc *data.RequestConfig, _ data.Config) ([]string, error) {
+			return []string{"--method", rc.Method, rc.Host.String()}, nil
+		},
+		func(_ context.Context, args []string, _ *data.RequestConfig) (data.RequestResult, error) {
+			got = args
+			return data.RequestResult{StatusCode: 200, Stdout: "ok"}, nil
+		})
+	rc := &data.RequestConfig{
+		Host:             &url.URL{Scheme: "https", Host: "example.com", Path: "/users"},
+		Method:           "DELETE",
+		Backend:          "test-custom",
+		NoDefaultHeaders: true,
+	}
+	result, err := Execute(context.Background(), rc, data.Config{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if want := []string{"--method", "DELETE", "https://example.com/users"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("executor args = %q, want %q", got, want)
+	}
+	if result.Stdout != "ok" || result.FinalURL != "https://example.com/users" {
+		t.Errorf("Execute() = %+v, want the result of the executor and the request URL", result)
+	}
+}
+
+func TestExecuteRecordsHistoryUnlessDisabled(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	registerTestBackend(t, "test-history",
+		func(*data.RequestConfig, data.Config) ([]string, error) { return nil, nil },
+		func(context.Context, []string, *data.RequestConfig) (data.RequestResult, error) {
+			return data.RequestResult{StatusCode: 200}, nil
+		})
+	newRequest := func() *data.RequestConfig {
+		return &data.RequestConfig{
+			Host:             &url.URL{Scheme: "https", Host: "example.com", RawQuery: "token=secret"},
+			Backend:          "test-history",
+			NoDefaultHeaders: true,
+		}
+	}
+	if _, err := Execute(context.Background(), newRequest(), data.Config{NoHistory: true}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if entries, err := data.LoadHistory(0); err != nil || len(entries) != 0 {
+		t.Fatalf("LoadHistory() = %+v, %v, want no history with NoHistory", entries, err)
+	}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := Execute(context.Background(), newRequest(), data.Config{Clock: func() time.Time { return now }}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	entries, err := data.LoadHistory(0)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("LoadHistory() = %+v, %v, want the request recorded by default", entries, err)
+	}
+	if want := now.Add(-entries[0].Duration); !entries[0].Time.Equal(want) {
+		t.Errorf("Time = %v, want the start of the request on the clock, %v", entries[0].Time, want)
+	}
+	if want := "https://example.com?token=***"; entries[0].URL != want {
+		t.Errorf("URL = %q, want %q", entries[0].URL, want)
+	}
+}
+
+// fakeBackend makes an executable running the shell script under the name of the backend the only
+// program of the PATH.
+func fakeBackend(t *testing.T, name, script string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, disk.BackendExecutable(name)), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestExecuteCapturesRemoteIP(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	tests := map[string]struct {
+		writeOut, want string
+	}{
+		"reported": {`printf '\n--vortex-write-out--\nhttp_code=200\nremote_ip=203.0.113.7\n'`, "203.0.113.7"},
+		"empty":    {`printf '\n--vortex-write-out--\nhttp_code=200\nremote_ip=\n'`, ""},
+		"absent":   {"", ""},
+	}
+	for name, tt := range tests {
+		fakeBackend(t, "curl", "printf 'ok'\n"+tt.writeOut+"\n")
+		rc := &data.RequestConfig{
+			Host:             &url.URL{Scheme: "https", Host: "example.com"},
+			Method:           "GET",
+			Backend:          "curl",
+			NoDefaultHeaders: true,
+		}
+		result, err := Execute(context.Background(), rc, data.Config{})
+		if err != nil {
+			t.Errorf("%s: Execute() error = %v", name, err)
+			continue
+		}
+		if result.RemoteIP != tt.want || result.Stdout != "ok" {
+			t.Errorf("%s: Execute() RemoteIP = %q, Stdout = %q, want %q and the body", name, result.RemoteIP, result.Stdout, tt.want)
+		}
+	}
+}
+
+func TestExecuteHeadCapturesHeaders(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	fakeBackend(t, "curl", `printf 'HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nContent-Length: 512\r\n\r\n'
+printf '\n--vortex-write-out--\nhttp_code=200\n'
+`)
+	rc := &data.RequestConfig{
+		Host:             &url.URL{Scheme: "https", Host: "example.com"},
+		Method:           "HEAD",
+		Backend:          "curl",
+		Body:             []string{"ignored"},
+		NoDefaultHeaders: true,
+	}
+	result, err := Execute(context.Background(), rc, data.Config{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if rc.TempfileName != "" {
+		t.Errorf("Execute() of a HEAD request created the body file %s", rc.TempfileName)
+	}
+	if got := result.Headers.Get("Content-Length"); got != "512" || result.Stdout != "" {
+		t.Errorf("Execute() Content-Length = %q, Stdout = %q, want the headers only", got, result.Stdout)
+	}
+}
+
+func TestExecuteCapturesStreamsApart(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	tests := map[string]struct {
+		script   string
+		exitCode int
+	}{
+		"success": {"printf 'body'\nprintf 'progress' >&2\n", 0},
+		"failure": {"printf 'body'\nprintf 'progress' >&2\nexit 7\n", 7},
+	}
+	for name, tt := range tests {
+		fakeBackend(t, "httpie", tt.script)
+		rc := &data.RequestConfig{
+			Host:             &url.URL{Scheme: "https", Host: "example.com"},
+			Method:           "GET",
+			Backend:          "httpie",
+			NoDefaultHeaders: true,
+		}
+		result, err := Execute(context.Background(), rc, data.Config{})
+		if err != nil {
+			t.Errorf("%s: Execute() error = %v", name, err)
+			continue
+		}
+		if result.Stdout != "body" || result.Stderr != "progress" || result.ExitCode != tt.exitCode {
+			t.Errorf("%s: Execute() Stdout = %q, Stderr = %q, ExitCode = %d, want %q, %q, %d",
+				name, result.Stdout, result.Stderr, result.ExitCode, "body", "progress", tt.exitCode)
+		}
+	}
+}
+
+func TestExecuteFailOnHTTPError(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	for _, backend := range []string{data.NativeBackend, "curl"} {
+		// The fake curl exits successfully whatever the status, as curl without --fail does
+		fakeBackend(t, "curl", "printf 'not found'\nprintf '\\n--vortex-write-out--\\nhttp_code=404\\n'\n")
+		for _, fail := range []bool{false, true} {
+			rc := &data.RequestConfig{
+				Host:             &url.URL{Scheme: "http", Host: server.Listener.Addr().String(), Path: "/missing"},
+				Method:           http.MethodGet,
+				Backend:          backend,
+				FailOnHTTPError:  fail,
+				NoDefaultHeaders: true,
+			}
+			result, err := Execute(context.Background(), rc, data.Config{})
+			if result.StatusCode != http.StatusNotFound {
+				t.Errorf("%s, fail %v: Execute() status = %d, want 404", backend, fail, result.StatusCode)
+			}
+			var statusErr *HTTPStatusError
+			switch {
+			case !fail && err != nil:
+				t.Errorf("%s: Execute() without FailOnHTTPError error = %v, want nil", backend, err)
+			case fail && (!errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound):
+				t.Errorf("%s: Execute() with FailOnHTTPError error = %v, want an HTTPStatusError", backend, err)
+			case fail && data.ExitCode(err) != data.ExitHTTPError:
+				t.Errorf("%s: ExitCode() = %d, want %d", backend, data.ExitCode(err), data.ExitHTTPError)
+			}
+		}
+	}
+}
+
+func TestSelectBackendOverride(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"curl", "http"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+	tests := map[string]struct {
+		env, flag, template, want string
+	}{
+		"template":           {"", "", "curl", "curl"},
+		"env over template":  {"httpie", "", "curl", "httpie"},
+		"env over detection": {"native", "", "", data.NativeBackend},
+		"flag over env":      {"httpie", "curl", "wget", "curl"},
+	}
+	for name, tt := range tests {
+		t.Setenv(disk.BackendVariable, tt.env)
+		rc := &data.RequestConfig{Backend: tt.template}
+		if err := selectBackend(rc, data.Config{Backend: tt.flag}); err != nil {
+			t.Errorf("%s: selectBackend() error = %v", name, err)
+			continue
+		}
+		if rc.Backend != tt.want {
+			t.Errorf("%s: selectBackend() = %q, want %q", name, rc.Backend, tt.want)
+		}
+	}
+	t.Setenv(disk.BackendVariable, "postman")
+	if err := selectBackend(&data.RequestConfig{Backend: "curl"}, data.Config{}); err == nil {
+		t.Errorf("selectBackend() with %s=postman succeeded, want an error", disk.BackendVariable)
+	}
+}
@@ -0,0 +1,461 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/pkg/errors"
+)
+
+// configureHTTPVersion forces the transport to the HTTPVersion of the request: HTTP/1.1 disables the
+// negotiation of HTTP/2, while HTTP/2 is attempted over TLS, the only way the Go HTTP client speaks
+// it, and the response is checked to have used it.
+func configureHTTPVersion(transport *http.Transport, rc *data.RequestConfig) error {
+	if err := rc.CheckHTTPVersion("native", data.HTTPVersion11, data.HTTPVersion2); err != nil {
+		return err
+	}
+	switch rc.HTTPVersion {
+	case data.HTTPVersion11:
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	case data.HTTPVersion2:
+		if !strings.EqualFold(rc.Host.Scheme, "https") {
+			return errors.New("The native backend only supports HTTP/2 over https, use curl for cleartext HTTP/2")
+		}
+		transport.ForceAttemptHTTP2 = true
+	}
+	return nil
+}
+
+// executeNative performs the request with the Go HTTP client. The response body is decompressed
+// according to its Content-Encoding and captured into the result, or written to the RequestConfig
+// OutputFile when one is set, or passed to the OnStreamLine callback of a streamed request. The
+// progress of a download to the OutputFile is reported to the Progress of the configuration, or
+// drawn as a bar when the request is verbose and stderr is a terminal. A download larger than the
+// MaxDownloadBytes of the RequestConfig is aborted, before the transfer when the Content-Length
+// announces it. The Timings of the result are recorded with an httptrace.ClientTrace.
+func executeNative(ctx context.Context, rc *data.RequestConfig, cfg data.Config) (data.RequestResult, error) {
+	var result data.RequestResult
+	req, err := newNativeRequest(ctx, rc, cfg)
+	if err != nil {
+		return result, err
+	}
+	tlsConfig, err := newTLSConfig(rc)
+	if err != nil {
+		return result, err
+	}
+	dialer := &net.Dialer{Timeout: cfg.EffectiveConnectTimeout()}
+	transport := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		DialContext:     dialer.DialContext,
+		TLSClientConfig: tlsConfig,
+	}
+	if len(rc.Resolve) > 0 {
+		transport.DialContext = resolvingDialer(rc, dialer)
+	}
+	if err := configureHTTPVersion(transport, rc); err != nil {
+		return result, err
+	}
+	if socket, _, isSocket, _ := rc.UnixSocket(); isSocket {
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+	}
+	client := &http.Client{Transport: transport}
+	if !rc.FollowRedirects {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	if cfg.Timeout != data.UnsetTimeout && cfg.Timeout > 0 {
+		client.Timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	trace := newTimingTrace()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+	resp, err := client.Do(req)
+	if err != nil {
+		return result, errors.Wrapf(err, "Failed to perform the request to %s", rc.Host.Redacted())
+	}
+	defer resp.Body.Close()
+	if rc.HTTPVersion == data.HTTPVersion2 && resp.ProtoMajor != 2 {
+		return result, errors.Errorf("The server of %s did not negotiate HTTP/2 but %s", rc.Host.Redacted(), resp.Proto)
+	}
+	result.StatusCode = resp.StatusCode
+	result.Headers = resp.Header
+	result.FinalURL = resp.Request.URL.String()
+	if rc.HeaderFile != "" {
+		if err := writeHeaderFile(rc.HeaderFile, resp); err != nil {
+			return result, err
+		}
+	}
+	if rc.MaxDownloadBytes > 0 && resp.ContentLength > rc.MaxDownloadBytes {
+		return result, errors.Errorf("The response of %d bytes exceeds the maximum download size of %d bytes", resp.ContentLength, rc.MaxDownloadBytes)
+	}
+	respBody, err := decodeBody(resp)
+	if err != nil {
+		return result, err
+	}
+	defer respBody.Close()
+	if rc.MaxDownloadBytes > 0 {
+		respBody = &downloadLimiter{ReadCloser: respBody, limit: rc.MaxDownloadBytes, remaining: rc.MaxDownloadBytes}
+	}
+	if rc.Stream {
+		result.StreamedEvents, err = streamBody(respBody, resp.Header.Get("Content-Type"), rc.OnStreamLine)
+		result.Timings = trace.timings(time.Now())
+		return result, err
+	}
+	if rc.OutputFile == "" {
+		var reader io.Reader = respBody
+		if rc.MaxResponseBytes > 0 {
+			// Read one byte past the limit to tell a response of exactly the limit from a larger one
+			reader = io.LimitReader(respBody, rc.MaxResponseBytes+1)
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			return result, errors.Wrap(err, "Failed to read the response body")
+		}
+		if rc.MaxResponseBytes > 0 && int64(len(body)) > rc.MaxResponseBytes {
+			body = body[:rc.MaxResponseBytes]
+			result.Truncated = true
+		}
+		result.Stdout = string(body)
+		result.Timings = trace.timings(time.Now())
+		return result, nil
+	}
+	file, err := os.Create(rc.OutputFile)
+	if err != nil {
+		return result, errors.Wrapf(err, "Failed to create the output file: %s", rc.OutputFile)
+	}
+	defer file.Close()
+	progress := cfg.Progress
+	if progress == nil && rc.Verbose && stderrIsTerminal() {
+		progress = newProgressBar(os.Stderr)
+	}
+	var dst io.Writer = file
+	if progress != nil {
+		dst = &countingWriter{w: file, total: resp.ContentLength, progress: progress}
+	}
+	if _, err := io.Copy(dst, respBody); err != nil {
+		return result, errors.Wrapf(err, "Failed to write the response to %s", rc.OutputFile)
+	}
+	result.Timings = trace.timings(time.Now())
+	return result, nil
+}
+
+// downloadLimiter fails the reads of a response body once more than the maximum download size was
+// read from it.
+type downloadLimiter struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+// Read reads from the body, failing past the maximum download size.
+func (dl *downloadLimiter) Read(p []byte) (int, error) {
+	// Read one byte past the limit to tell a body of exactly the limit from a larger one
+	n, err := io.LimitReader(dl.ReadCloser, dl.remaining+1).Read(p)
+	dl.remaining -= int64(n)
+	if dl.remaining < 0 {
+		return n, errors.Errorf("The response exceeds the maximum download size of %d bytes", dl.limit)
+	}
+	return n, err
+}
+
+// streamBody passes the lines of the body to the callback as they are received, or its events for
+// a `text/event-stream` body, until the body ends, and returns how many were passed. The trailing
+// line ending of each line is removed.
+func streamBody(body io.Reader, contentType string, onLine func(string) error) (int, error) {
+	if onLine == nil {
+		return 0, errors.New("A streamed request needs an OnStreamLine callback")
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	events := mediaType == "text/event-stream"
+	reader := bufio.NewReader(body)
+	count := 0
+	var event []string
+	emit := func(text string) error {
+		count++
+		if err := onLine(text); err != nil {
+			return errors.Wrap(err, "Streaming stopped")
+		}
+		return nil
+	}
+	for {
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return count, errors.Wrap(readErr, "Failed to read the response stream")
+		}
+		ended := readErr == io.EOF
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		switch {
+		case !events:
+			if line != "" || !ended {
+				if err := emit(line); err != nil {
+					return count, err
+				}
+			}
+		case line != "":
+			event = append(event, line)
+		case len(event) > 0:
+			// A blank line dispatches the event
+			if err := emit(strings.Join(event, "\n")); err != nil {
+				return count, err
+			}
+			event = nil
+		}
+		if ended {
+			return count, nil
+		}
+	}
+}
+
+// resolvingDialer returns a DialContext connecting to the address of the Resolve override matching
+// the dialed host and port, and to the dialed address itself otherwise. The TLS server name is
+// still taken from the URL, so certificates are verified against the overridden host.
+func resolvingDialer(rc *data.RequestConfig, dialer *net.Dialer) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, portStr, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return nil, err
+		}
+		override, err := rc.ResolveAddress(host, port)
+		if err != nil {
+			return nil, err
+		}
+		if override != "" {
+			addr = override
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
+
+// newTLSConfig builds the TLS configuration of the native backend, loading the custom CA bundle and
+// the client certificate when they are configured.
+func newTLSConfig(rc *data.RequestConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: rc.InsecureSkipVerify}
+	if rc.CACert != "" {
+		pem, err := os.ReadFile(rc.CACert)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read the CA certificate bundle: %s", rc.CACert)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("No valid PEM certificate found in the CA bundle: %s", rc.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if rc.ClientCert == "" && rc.ClientKey == "" {
+		return tlsConfig, nil
+	}
+	if rc.ClientCert == "" || rc.ClientKey == "" {
+		return nil, errors.New("Both a client certificate and a client key are required for mutual TLS")
+	}
+	for _, path := range []string{rc.ClientCert, rc.ClientKey} {
+		if _, err := os.Stat(path); err != nil {
+			return nil, errors.Wrapf(err, "Cannot access the client certificate file: %s", path)
+		}
+	}
+	cert, err := tls.LoadX509KeyPair(rc.ClientCert, rc.ClientKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid client certificate and key pair: %s, %s", rc.ClientCert, rc.ClientKey)
+	}
+	tlsConfig.Certificates = []tls.Certificate{cert}
+	return tlsConfig, nil
+}
+
+// newNativeRequest builds the http.Request described by the RequestConfig. A body read from a file
+// is streamed, with its length announced unless it exceeds the stream threshold of the
+// configuration, in which case it is sent with the chunked transfer encoding.
+func newNativeRequest(ctx context.Context, rc *data.RequestConfig, cfg data.Config) (*http.Request, error) {
+	if rc.Host == nil {
+		return nil, errors.New("Cannot perform a request without a host")
+	}
+	method := rc.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	var contentLength int64
+	bodyPath := rc.BodyPath()
+	switch {
+	case rc.IgnoresBody():
+		// HEAD and OPTIONS requests are sent without their body
+	case rc.CompressBody:
+		compressed, err := rc.CompressedBody()
+		if err != nil {
+			return nil, err
+		}
+		if compressed != nil {
+			body = bytes.NewReader(compressed)
+		} else if rc.ForceBody {
+			body = http.NoBody
+		}
+	case bodyPath != "":
+		file, err := os.Open(bodyPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to open the body file: %s", bodyPath)
+		}
+		fi, err := file.Stat()
+		if err != nil {
+			_ = file.Close()
+			return nil, errors.Wrapf(err, "Failed to get the size of the body file: %s", bodyPath)
+		}
+		body, contentLength = file, fi.Size()
+		if threshold := cfg.StreamThreshold(); threshold > 0 && contentLength > threshold {
+			// An unknown length makes the transport send the body in chunks
+			contentLength = -1
+		}
+	case len(rc.Body) > 0:
+		body = strings.NewReader(strings.Join(rc.Body, "\n"))
+	case rc.ForceBody && rc.BodyFile == "":
+		body = http.NoBody
+	}
+	target := rc.Host
+	_, socketTarget, isSocket, err := rc.UnixSocket()
+	if err != nil {
+		return nil, err
+	}
+	if isSocket {
+		target = socketTarget
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		if closer, ok := body.(io.Closer); ok {
+			_ = closer.Close()
+		}
+		return nil, errors.Wrap(err, "Failed to build the request")
+	}
+	if body != nil && bodyPath != "" && !rc.CompressBody {
+		req.ContentLength = contentLength
+	}
+	if rc.ForceBody && req.ContentLength == 0 && !rc.IgnoresBody() {
+		// The transport only announces a zero length for POST, PUT and PATCH, unless the body is
+		// declared as sent as is
+		req.TransferEncoding = []string{"identity"}
+	}
+	for _, header := range rc.Headers {
+		name, value, found := data.SplitHeader(header)
+		if !found {
+			return nil, errors.Errorf("Malformed header: %q", header)
+		}
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Add(name, value)
+	}
+	if rc.AcceptCompression && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", acceptedEncodings)
+	}
+	return req, nil
+}
+
+// acceptedEncodings is the Accept-Encoding value sent when compression is requested.
+const acceptedEncodings = "gzip, deflate"
+
+// decodeBody wraps the response body in the decompressor matching its Content-Encoding. Bodies
+// with no or an unknown encoding are returned as they are.
+func decodeBody(resp *http.Response) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to decompress the gzip response")
+		}
+		return reader, nil
+	case "deflate":
+		// HTTP deflate is a zlib stream, but some servers send a raw deflate stream instead
+		buffered := bufio.NewReader(resp.Body)
+		if header, err := buffered.Peek(2); err == nil && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			reader, err := zlib.NewReader(buffered)
+			if err != nil {
+				return nil, errors.Wrap(err, "Failed to decompress the deflate response")
+			}
+			return reader, nil
+		}
+		return flate.NewReader(buffered), nil
+	}
+	return io.NopCloser(resp.Body), nil
+}
+
+// stderrIsTerminal reports whether the process standard error is a terminal.
+func stderrIsTerminal() bool {
+	fi, err := os.Stderr.Stat()
+	return err == nil && (fi.Mode()&os.ModeCharDevice) != 0
+}
+
+// countingWriter forwards writes to w and reports the number of bytes written so far.
+type countingWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	progress data.ProgressFunc
+}
+
+// Write forwards the bytes and reports the progress.
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.written += int64(n)
+	cw.progress(cw.written, cw.total)
+	return n, err
+}
+
+// progressBarWidth is the number of cells of the rendered progress bar.
+const progressBarWidth = 30
+
+// newProgressBar returns a ProgressFunc drawing a percentage bar on w when the total size is
+// known, or the number of bytes transferred otherwise. The line is redrawn in place.
+func newProgressBar(w io.Writer) data.ProgressFunc {
+	lastPercent := -1
+	return func(written, total int64) {
+		if total <= 0 {
+			fmt.Fprintf(w, "\r%s downloaded", formatBytes(written))
+			return
+		}
+		percent := int(written * 100 / total)
+		if percent == lastPercent {
+			return
+		}
+		lastPercent = percent
+		filled := progressBarWidth * percent / 100
+		fmt.Fprintf(w, "\r[%s%s] %3d%% %s/%s", strings.Repeat("#", filled), strings.Repeat("-", progressBarWidth-filled), percent, formatBytes(written), formatBytes(total))
+		if written >= total {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// formatBytes renders a byte count with a binary unit suffix.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for value := n / unit; value >= unit; value /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
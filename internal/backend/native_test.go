@@ -0,0 +1,707 @@
+package backend
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+func TestExecuteNativeReportsProgress(t *testing.T) {
+	body := strings.Repeat("x", 256<<10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+	host, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "download.bin")
+	var written []int64
+	cfg := data.Config{Progress: func(n, total int64) {
+		if total != int64(len(body)) {
+			t.Errorf("progress total = %d, want %d", total, len(body))
+		}
+		written = append(written, n)
+	}}
+	rc := &data.RequestConfig{Host: host, Method: http.MethodGet, Backend: data.NativeBackend, OutputFile: output}
+	if _, err := executeNative(context.Background(), rc, cfg); err != nil {
+		t.Fatalf("executeNative() error = %v", err)
+	}
+	if len(written) == 0 || written[len(written)-1] != int64(len(body)) {
+		t.Fatalf("progress = %v, want calls ending at %d", written, len(body))
+	}
+	for i := 1; i < len(written); i++ {
+		if written[i] <= written[i-1] {
+			t.Errorf("progress = %v, want increasing values", written)
+			break
+		}
+	}
+	if fi, err := os.Stat(output); err != nil || fi.Size() != int64(len(body)) {
+		t.Errorf("the output file holds %v bytes, want %d", fi, len(body))
+	}
+}
+
+// serverHost parses the URL of a test server.
+func serverHost(t *testing.T, rawURL string) *url.URL {
+	t.Helper()
+	host, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return host
+}
+
+func TestExecuteNativeAcceptCompression(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip, deflate" {
+			_, _ = w.Write([]byte("uncompressed"))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		writer := gzip.NewWriter(w)
+		_, _ = writer.Write([]byte(`{"compressed": true}`))
+		_ = writer.Close()
+	}))
+	defer server.Close()
+	for _, accept := range []bool{true, false} {
+		rc := &data.RequestConfig{Host: serverHost(t, server.URL), Method: http.MethodGet, AcceptCompression: accept, NoDefaultHeaders: true}
+		result, err := executeNative(context.Background(), rc, data.Config{})
+		if err != nil {
+			t.Fatalf("executeNative() error = %v", err)
+		}
+		want := "uncompressed"
+		if accept {
+			want = `{"compressed": true}`
+		}
+		if result.Stdout != want {
+			t.Errorf("executeNative() with AcceptCompression %v = %q, want %q", accept, result.Stdout, want)
+		}
+	}
+}
+
+func TestExecuteNativeUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "app.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("Unix sockets are not supported: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Host + " " + r.URL.Path))
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+	rc := &data.RequestConfig{Host: serverHost(t, "http+unix://"+socket+":/health"), Method: http.MethodGet, NoDefaultHeaders: true}
+	result, err := executeNative(context.Background(), rc, data.Config{})
+	if err != nil {
+		t.Fatalf("executeNative() error = %v", err)
+	}
+	if want := "localhost /health"; result.StatusCode != http.StatusOK || result.Stdout != want {
+		t.Errorf("executeNative() = %d %q, want 200 %q", result.StatusCode, result.Stdout, want)
+	}
+}
+
+// writeClientCertificate writes a self-signed client certificate and its key, in PEM, to a new
+// directory and returns their paths.
+func writeClientCertificate(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "vortex"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+func TestExecuteNativeClientCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+	certPath, keyPath := writeClientCertificate(t)
+	rc := &data.RequestConfig{
+		Host:               serverHost(t, server.URL),
+		Method:             http.MethodGet,
+		InsecureSkipVerify: true,
+		ClientCert:         certPath,
+		ClientKey:          keyPath,
+		NoDefaultHeaders:   true,
+	}
+	result, err := executeNative(context.Background(), rc, data.Config{})
+	if err != nil {
+		t.Fatalf("executeNative() error = %v", err)
+	}
+	if result.Stdout != "vortex" {
+		t.Errorf("executeNative() = %q, want the server to see the client certificate", result.Stdout)
+	}
+
+	rc.ClientCert, rc.ClientKey = "", ""
+	if _, err := executeNative(context.Background(), rc, data.Config{}); err == nil {
+		t.Error("executeNative() without a client certificate succeeded, want the handshake refused")
+	}
+	rc.ClientCert, rc.ClientKey = certPath, filepath.Join(t.TempDir(), "missing.key")
+	if _, err := executeNative(context.Background(), rc, data.Config{}); err == nil || !strings.Contains(err.Error(), "missing.key") {
+		t.Errorf("executeNative() with a missing key error = %v, want the key reported", err)
+	}
+	rc.ClientKey = certPath
+	if _, err := executeNative(context.Background(), rc, data.Config{}); err == nil || !strings.Contains(err.Error(), "Invalid client certificate") {
+		t.Errorf("executeNative() with a mismatched pair error = %v, want the pair rejected", err)
+	}
+}
+
+func TestExecuteNativeCACertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("trusted"))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	ca, garbage := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "garbage.pem")
+	if err := os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(garbage, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rc := &data.RequestConfig{Host: serverHost(t, server.URL), Method: http.MethodGet, CACert: ca, NoDefaultHeaders: true}
+	result, err := executeNative(context.Background(), rc, data.Config{})
+	if err != nil {
+		t.Fatalf("executeNative() with the CA bundle error = %v", err)
+	}
+	if result.Stdout != "trusted" {
+		t.Errorf("executeNative() = %q, want trusted", result.Stdout)
+	}
+	tests := map[string]struct {
+		ca, want string
+	}{
+		"no bundle": {"", "certificate"},
+		"missing":   {filepath.Join(dir, "missing.pem"), "Failed to read the CA certificate bundle"},
+		"garbage":   {garbage, "No valid PEM certificate"},
+	}
+	for name, tt := range tests {
+		rc.CACert = tt.ca
+		if _, err := executeNative(context.Background(), rc, data.Config{}); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: executeNative() error = %v, want %q", name, err, tt.want)
+		}
+	}
+}
+
+func TestExecuteNativeMaxResponseBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+	tests := map[string]struct {
+		limit     int64
+		want      string
+		truncated bool
+	}{
+		"unlimited":    {0, "0123456789", false},
+		"under":        {16, "0123456789", false},
+		"at the limit": {10, "0123456789", false},
+		"over":         {4, "0123", true},
+	}
+	for name, tt := range tests {
+		rc := &data.RequestConfig{Host: serverHost(t, server.URL), Method: http.MethodGet, MaxResponseBytes: tt.limit, NoDefaultHeaders: true}
+		result, err := executeNative(context.Background(), rc, data.Config{})
+		if err != nil {
+			t.Errorf("%s: executeNative() error = %v", name, err)
+			continue
+		}
+		if result.Stdout != tt.want || result.Truncated != tt.truncated {
+			t.Errorf("%s: executeNative() = %q, truncated %v, want %q, %v", name, result.Stdout, result.Truncated, tt.want, tt.truncated)
+		}
+	}
+}
+
+func TestExecuteNativeFinalURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new?moved=1", http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+	tests := map[string]struct {
+		path     string
+		follow   bool
+		wantURL  string
+		wantCode int
+	}{
+		"redirect":     {"/old", true, server.URL + "/new?moved=1", http.StatusOK},
+		"not followed": {"/old", false, server.URL + "/old", http.StatusFound},
+		"no redirect":  {"/new", true, server.URL + "/new", http.StatusOK},
+	}
+	for name, tt := range tests {
+		rc := &data.RequestConfig{Host: serverHost(t, server.URL+tt.path), Method: http.MethodGet, FollowRedirects: tt.follow, NoDefaultHeaders: true}
+		result, err := executeNative(context.Background(), rc, data.Config{})
+		if err != nil {
+			t.Errorf("%s: executeNative() error = %v", name, err)
+			continue
+		}
+		if result.FinalURL != tt.wantURL || result.StatusCode != tt.wantCode {
+			t.Errorf("%s: executeNative() FinalURL = %q, status %d, want %q, %d", name, result.FinalURL, result.StatusCode, tt.wantURL, tt.wantCode)
+		}
+	}
+}
+
+func TestExecuteNativeConnectTimeout(t *testing.T) {
+	// A non-routable address never answers the connection attempt
+	rc := &data.RequestConfig{Host: serverHost(t, "http://10.255.255.1:81/"), Method: http.MethodGet, NoDefaultHeaders: true}
+	start := time.Now()
+	_, err := executeNative(context.Background(), rc, data.Config{Timeout: 30, ConnectTimeout: 100 * time.Millisecond})
+	elapsed := time.Since(start)
+	var netErr net.Error
+	if err == nil || !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Skipf("the address does not time out in this network: %v", err)
+	}
+	if elapsed > 5*time.Second {
+		t.Errorf("executeNative() timed out after %v, want the connect timeout of 100ms", elapsed)
+	}
+}
+
+func TestExecuteNativeCompressBody(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(reader)
+		_, _ = w.Write([]byte(r.Header.Get("Content-Encoding") + " " + string(body)))
+	}))
+	defer server.Close()
+	rc := &data.RequestConfig{
+		Host:             serverHost(t, server.URL),
+		Method:           http.MethodPost,
+		Backend:          data.NativeBackend,
+		Body:             []string{`{"name": "vortex"}`},
+		CompressBody:     true,
+		NoDefaultHeaders: true,
+	}
+	result, err := Execute(context.Background(), rc, data.Config{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if want := `gzip {"name": "vortex"}`; result.Stdout != want {
+		t.Errorf("the server received %q, want %q", result.Stdout, want)
+	}
+}
+
+func TestExecuteNativeStreamsLargeBodyFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, "%v %d %d %t", r.TransferEncoding, r.ContentLength, len(body), bytes.Equal(body, bytes.Repeat([]byte("0123456789"), 10<<10)))
+	}))
+	defer server.Close()
+	bodyFile := filepath.Join(t.TempDir(), "upload.bin")
+	if err := os.WriteFile(bodyFile, bytes.Repeat([]byte("0123456789"), 10<<10), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		threshold int64
+		want      string
+	}{
+		"over the threshold":  {1 << 10, "[chunked] -1 102400 true"},
+		"under the threshold": {1 << 20, "[] 102400 102400 true"},
+		"never streamed":      {-1, "[] 102400 102400 true"},
+	}
+	for name, tt := range tests {
+		rc := &data.RequestConfig{Host: serverHost(t, server.URL), Method: http.MethodPut, BodyFile: bodyFile, NoDefaultHeaders: true}
+		result, err := executeNative(context.Background(), rc, data.Config{StreamBodyThreshold: tt.threshold})
+		if err != nil {
+			t.Errorf("%s: executeNative() error = %v", name, err)
+			continue
+		}
+		if result.Stdout != tt.want {
+			t.Errorf("%s: the server received %q, want %q", name, result.Stdout, tt.want)
+		}
+	}
+}
+
+func TestExecuteNativeResolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Host))
+	}))
+	defer server.Close()
+	port := serverHost(t, server.URL).Port()
+	// The host does not resolve, so the request only succeeds through the override
+	host := serverHost(t, "http://vortex.invalid:"+port+"/")
+	rc := &data.RequestConfig{
+		Host:             host,
+		Method:           http.MethodGet,
+		Resolve:          []string{"other.invalid:" + port + ":127.0.0.2", "vortex.invalid:" + port + ":127.0.0.1"},
+		NoDefaultHeaders: true,
+	}
+	result, err := executeNative(context.Background(), rc, data.Config{})
+	if err != nil {
+		t.Fatalf("executeNative() error = %v", err)
+	}
+	if want := "vortex.invalid:" + port; result.Stdout != want {
+		t.Errorf("executeNative() Host header = %q, want %q", result.Stdout, want)
+	}
+}
+
+func TestExecuteNativeForceBody(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "%q %q", r.Header.Values("Content-Length"), r.TransferEncoding)
+	}))
+	defer server.Close()
+	tests := map[string]struct {
+		method string
+		force  bool
+		want   string
+	}{
+		"delete":        {http.MethodDelete, false, `[] []`},
+		"delete forced": {http.MethodDelete, true, `["0"] []`},
+		"put forced":    {http.MethodPut, true, `["0"] []`},
+		"post forced":   {http.MethodPost, true, `["0"] []`},
+	}
+	for name, tt := range tests {
+		rc := &data.RequestConfig{
+			Host:             serverHost(t, server.URL),
+			Method:           tt.method,
+			Backend:          data.NativeBackend,
+			ForceBody:        tt.force,
+			NoDefaultHeaders: true,
+		}
+		// Execute sends the forced body from an empty file, executeNative without it
+		for _, execute := range []func(context.Context, *data.RequestConfig, data.Config) (data.RequestResult, error){Execute, executeNative} {
+			result, err := execute(context.Background(), rc, data.Config{})
+			if err != nil {
+				t.Errorf("%s: Execute() error = %v", name, err)
+				continue
+			}
+			if result.Stdout != tt.want {
+				t.Errorf("%s: the server received Content-Length and Transfer-Encoding %s, want %s", name, result.Stdout, tt.want)
+			}
+		}
+	}
+}
+
+func TestExecuteNativeTimings(t *testing.T) {
+	const delay = 20 * time.Millisecond
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		_, _ = w.Write([]byte("ok"))
+	})
+	for _, secure := range []bool{false, true} {
+		server := httptest.NewUnstartedServer(handler)
+		if secure {
+			server.StartTLS()
+		} else {
+			server.Start()
+		}
+		defer server.Close()
+		rc := &data.RequestConfig{Host: serverHost(t, server.URL), Method: http.MethodGet, InsecureSkipVerify: true, NoDefaultHeaders: true}
+		result, err := executeNative(context.Background(), rc, data.Config{})
+		if err != nil {
+			t.Errorf("TLS %v: executeNative() error = %v", secure, err)
+			continue
+		}
+		timings := result.Timings
+		// The host is an IP address, so there is no DNS lookup
+		if timings.DNS != 0 || timings.Connect <= 0 || timings.TTFB < delay || timings.Total < timings.TTFB {
+			t.Errorf("TLS %v: executeNative() Timings = %+v, want a connection, a TTFB of at least %v and a total above it", secure, timings, delay)
+		}
+		if (timings.TLS > 0) != secure {
+			t.Errorf("TLS %v: executeNative() TLS timing = %v", secure, timings.TLS)
+		}
+	}
+}
+
+func TestExecuteNativeBinaryBodyFile(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(r.Header.Get("Content-Type")))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	// Every byte value, with the newlines a text body would normalize
+	blob := make([]byte, 0, 260)
+	for i := 0; i < 256; i++ {
+		blob = append(blob, byte(i))
+	}
+	blob = append(blob, '\r', '\n', '\n', '\r')
+	if err := os.WriteFile(filepath.Join(dir, "blob.bin"), blob, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tmpl := "[Host]\n" + server.URL + "\n[Method]\nPUT\n[Backend]\nnative\n[Body]\n@blob.bin\n"
+	rc, err := data.ParseTemplate(filepath.Join(dir, "upload.ini"), tmpl, data.Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	rc.NoDefaultHeaders = true
+	result, err := Execute(context.Background(), rc, data.Config{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !bytes.Equal(received, blob) {
+		t.Errorf("the server received %q, want the bytes of the file %q", received, blob)
+	}
+	if result.Stdout != "application/octet-stream" {
+		t.Errorf("the server received Content-Type %q, want application/octet-stream", result.Stdout)
+	}
+}
+
+func TestExecuteNativeArtifacts(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Artifact", "headers")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	rc := &data.RequestConfig{
+		Host:             serverHost(t, server.URL),
+		Method:           http.MethodPost,
+		Backend:          data.NativeBackend,
+		NoDefaultHeaders: true,
+		OutputFile:       filepath.Join(dir, "body", "response.json"),
+		HeaderFile:       filepath.Join(dir, "headers", "response.txt"),
+		TraceFile:        filepath.Join(dir, "trace", "nested", "response.trace"),
+	}
+	result, err := Execute(context.Background(), rc, data.Config{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.StatusCode != http.StatusCreated || result.Stdout != "" {
+		t.Errorf("Execute() = %d, %q, want 201 with the body written to the output file", result.StatusCode, result.Stdout)
+	}
+	read := func(path string) string {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("reading the artifact: %v", err)
+		}
+		return string(contents)
+	}
+	if got, want := read(rc.OutputFile), `{"id": 1}`; got != want {
+		t.Errorf("output file = %q, want %q", got, want)
+	}
+	headers := read(rc.HeaderFile)
+	if !strings.HasPrefix(headers, "HTTP/1.1 201 Created\r\n") || !strings.Contains(headers, "X-Artifact: headers\r\n") || !strings.HasSuffix(headers, "\r\n\r\n") {
+		t.Errorf("header file = %q, want the status line and headers", headers)
+	}
+	trace := read(rc.TraceFile)
+	for _, line := range []string{"status: 201\n", "url: " + server.URL + "\n", "total: "} {
+		if !strings.Contains(trace, line) {
+			t.Errorf("trace file = %q, want the line %q", trace, line)
+		}
+	}
+
+	// Unset paths write no artifact
+	rc = &data.RequestConfig{Host: serverHost(t, server.URL), Backend: data.NativeBackend, NoDefaultHeaders: true, TraceFile: filepath.Join(dir, "only.trace")}
+	if _, err := Execute(context.Background(), rc, data.Config{}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Errorf("the artifact directory holds %d entries, want the trace added alone", len(entries))
+	}
+}
+
+func TestExecuteNativeStream(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	received := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		if r.URL.Path == "/ndjson" {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			_, _ = io.WriteString(w, "{\"n\":1}\n{\"n\":2}\r\n\n{\"n\":3}")
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{"data: first\n\n", "event: update\ndata: {\"n\": 2}\r\n\r\n", ": comment\ndata: a\ndata: b\n\n"}
+		for _, event := range events {
+			_, _ = io.WriteString(w, event)
+			flusher.Flush()
+			// The next event is only sent once the callback received this one
+			select {
+			case <-received:
+			case <-time.After(5 * time.Second):
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	tests := map[string]struct {
+		path string
+		want []string
+	}{
+		"events": {"/sse", []string{"data: first", "event: update\ndata: {\"n\": 2}", ": comment\ndata: a\ndata: b"}},
+		"lines":  {"/ndjson", []string{`{"n":1}`, `{"n":2}`, "", `{"n":3}`}},
+	}
+	for name, tt := range tests {
+		var got []string
+		host := serverHost(t, server.URL)
+		host.Path = tt.path
+		rc := &data.RequestConfig{Host: host, Backend: data.NativeBackend, NoDefaultHeaders: true, Stream: true}
+		rc.OnStreamLine = func(line string) error {
+			got = append(got, line)
+			if tt.path == "/sse" {
+				received <- line
+			}
+			return nil
+		}
+		result, err := Execute(context.Background(), rc, data.Config{})
+		if err != nil {
+			t.Errorf("%s: Execute() error = %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: OnStreamLine() received %q, want %q", name, got, tt.want)
+		}
+		if result.StatusCode != http.StatusOK || result.StreamedEvents != len(tt.want) || result.Stdout != "" {
+			t.Errorf("%s: Execute() = %d, %d events, %q, want 200 and %d events without body", name, result.StatusCode, result.StreamedEvents, result.Stdout, len(tt.want))
+		}
+	}
+}
+
+func TestExecuteNativeStreamStops(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		// An endless stream, one event every few milliseconds
+		for i := 0; r.Context().Err() == nil; i++ {
+			fmt.Fprintf(w, "data: %d\n\n", i)
+			w.(http.Flusher).Flush()
+			time.Sleep(5 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+	newConfig := func(onLine func(string) error) *data.RequestConfig {
+		return &data.RequestConfig{Host: serverHost(t, server.URL), Backend: data.NativeBackend, NoDefaultHeaders: true, Stream: true, OnStreamLine: onLine}
+	}
+
+	failure := errors.New("enough")
+	count := 0
+	_, err := Execute(context.Background(), newConfig(func(string) error {
+		if count++; count == 3 {
+			return failure
+		}
+		return nil
+	}), data.Config{})
+	if !errors.Is(err, failure) || count != 3 {
+		t.Errorf("Execute() error = %v after %d events, want the callback error after 3", err, count)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err = Execute(ctx, newConfig(func(string) error {
+		cancel()
+		return nil
+	}), data.Config{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Execute() error = %v, want context.Canceled", err)
+	}
+
+	if _, err := Execute(context.Background(), newConfig(nil), data.Config{}); err == nil || !strings.Contains(err.Error(), "needs an OnStreamLine callback") {
+		t.Errorf("Execute() without a callback error = %v, want it rejected", err)
+	}
+	rc := newConfig(func(string) error { return nil })
+	rc.Backend = "curl"
+	if _, err := Execute(context.Background(), rc, data.Config{}); err == nil || !strings.Contains(err.Error(), "only supported by the native backend") {
+		t.Errorf("Execute() with curl error = %v, want streaming rejected", err)
+	}
+}
+
+func TestExecuteNativeHTTPVersion(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})
+	secure := httptest.NewUnstartedServer(handler)
+	secure.EnableHTTP2 = true
+	secure.StartTLS()
+	defer secure.Close()
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	tests := map[string]struct {
+		server  *httptest.Server
+		version string
+		want    string
+		err     string
+	}{
+		"forced 1.1":      {secure, data.HTTPVersion11, "HTTP/1.1", ""},
+		"forced 2":        {secure, data.HTTPVersion2, "HTTP/2.0", ""},
+		"cleartext 1.1":   {plain, data.HTTPVersion11, "HTTP/1.1", ""},
+		"cleartext 2":     {plain, data.HTTPVersion2, "", "only supports HTTP/2 over https"},
+		"3 not supported": {secure, data.HTTPVersion3, "", "The native backend does not support HTTP/3, use curl"},
+	}
+	for name, tt := range tests {
+		rc := &data.RequestConfig{Host: serverHost(t, tt.server.URL), Backend: data.NativeBackend, NoDefaultHeaders: true, InsecureSkipVerify: true, HTTPVersion: tt.version}
+		result, err := Execute(context.Background(), rc, data.Config{})
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: Execute() error = %v, want %q", name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || result.Stdout != tt.want {
+			t.Errorf("%s: Execute() = %q, %v, want the server to receive %s", name, result.Stdout, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/larayavrs/vortex/pkg"
+	"github.com/pkg/errors"
+)
+
+// oauth2Token is an access token obtained from a token endpoint.
+type oauth2Token struct {
+	value   string
+	expires time.Time
+}
+
+// oauth2Tokens caches the access tokens in memory, keyed by the credentials they were obtained
+// with, until they expire.
+var oauth2Tokens = struct {
+	sync.Mutex
+	byCredentials map[data.OAuth2Credentials]oauth2Token
+}{byCredentials: make(map[data.OAuth2Credentials]oauth2Token)}
+
+// oauth2TokenResponse is the JSON response of a token endpoint.
+type oauth2TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// applyOAuth2Token obtains the access token of a request using the oauth2 authentication type and
+// stores it in a copy of its Auth configuration, shared with other requests otherwise, for
+// ApplyAuth to add it. Other requests are left alone.
+func applyOAuth2Token(ctx context.Context, rc *data.RequestConfig, cfg data.Config) error {
+	if rc.Auth == nil || rc.Auth.Type != data.AuthOAuth2 {
+		return nil
+	}
+	creds, err := rc.Auth.OAuth2Credentials(rc.ExpansionConfig(cfg))
+	if err != nil {
+		return err
+	}
+	token, err := fetchOAuth2Token(ctx, creds, rc.Verbose, cfg)
+	if err != nil {
+		return err
+	}
+	auth := *rc.Auth
+	auth.Token = token
+	rc.Auth = &auth
+	return nil
+}
+
+// fetchOAuth2Token returns the cached access token of the credentials, or requests a new one from
+// the token endpoint with the client credentials grant, through the native backend. A token is
+// cached for the duration of its `expires_in`, and not at all without one.
+func fetchOAuth2Token(ctx context.Context, creds data.OAuth2Credentials, verbose bool, cfg data.Config) (string, error) {
+	oauth2Tokens.Lock()
+	cached, ok := oauth2Tokens.byCredentials[creds]
+	oauth2Tokens.Unlock()
+	if ok && cfg.Now().Before(cached.expires) {
+		return cached.value, nil
+	}
+	host, err := url.Parse(creds.TokenURL)
+	if err != nil {
+		return "", errors.Wrap(err, "Invalid oauth2 token_url")
+	}
+	form := url.Values{"grant_type": {"client_credentials"}, "client_id": {creds.ClientID}}
+	if creds.ClientSecret != "" {
+		form.Set("client_secret", creds.ClientSecret)
+	}
+	if creds.Scope != "" {
+		form.Set("scope", creds.Scope)
+	}
+	tokenRequest := &data.RequestConfig{
+		Host:    host,
+		Method:  http.MethodPost,
+		Backend: "native",
+		Headers: []string{"Content-Type: application/x-www-form-urlencoded", "Accept: application/json"},
+		Body:    []string{form.Encode()},
+	}
+	if verbose {
+		cfg.Log().Log("Requesting an oauth2 access token", "url", host.Redacted())
+	}
+	result, err := executeNative(ctx, tokenRequest, cfg)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to request the oauth2 access token")
+	}
+	if result.StatusCode != http.StatusOK {
+		return "", errors.Errorf("Failed to request the oauth2 access token, the endpoint returned status %d: %q", result.StatusCode, pkg.Ellipsize(0, 200, result.Stdout))
+	}
+	var response oauth2TokenResponse
+	if err := json.Unmarshal([]byte(result.Stdout), &response); err != nil {
+		return "", errors.Wrap(err, "Invalid oauth2 token response")
+	}
+	if response.AccessToken == "" {
+		return "", errors.New("The oauth2 token response holds no access_token")
+	}
+	if response.ExpiresIn > 0 {
+		oauth2Tokens.Lock()
+		oauth2Tokens.byCredentials[creds] = oauth2Token{
+			value:   response.AccessToken,
+			expires: cfg.Now().Add(time.Duration(response.ExpiresIn) * time.Second),
+		}
+		oauth2Tokens.Unlock()
+	}
+	return response.AccessToken, nil
+}
@@ -0,0 +1,75 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+func TestExecuteOAuth2Token(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	var issued atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "client_credentials" ||
+			r.Form.Get("client_id") != "vortex" || r.Form.Get("client_secret") != "secret" || r.Form.Get("scope") != "read" {
+			http.Error(w, `{"error": "invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": 60}`, issued.Add(1))
+	}))
+	defer tokenServer.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := data.Config{Clock: func() time.Time { return now }}
+	request := func(secret string) (data.RequestResult, error) {
+		rc := &data.RequestConfig{
+			Host:    serverHost(t, server.URL),
+			Method:  http.MethodGet,
+			Backend: data.NativeBackend,
+			Auth: &data.AuthConfig{Type: data.AuthOAuth2, Params: map[string]string{
+				"token_url":     tokenServer.URL,
+				"client_id":     "vortex",
+				"client_secret": secret,
+				"scope":         "read",
+			}},
+			NoDefaultHeaders: true,
+		}
+		return Execute(context.Background(), rc, cfg)
+	}
+	steps := []struct {
+		name    string
+		elapsed time.Duration
+		want    string
+	}{
+		{"first request", 0, "Bearer token-1"},
+		{"before expiry", 59 * time.Second, "Bearer token-1"},
+		{"after expiry", 61 * time.Second, "Bearer token-2"},
+	}
+	start := now
+	for _, step := range steps {
+		now = start.Add(step.elapsed)
+		result, err := request("secret")
+		if err != nil {
+			t.Errorf("%s: Execute() error = %v", step.name, err)
+			continue
+		}
+		if result.Stdout != step.want {
+			t.Errorf("%s: the server received Authorization %q, want %q", step.name, result.Stdout, step.want)
+		}
+	}
+	if _, err := request("wrong"); err == nil {
+		t.Error("Execute() with rejected client credentials succeeded, want an error")
+	}
+}
@@ -0,0 +1,42 @@
+package backend
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/pkg/errors"
+)
+
+// The function WriteResult prints the outcome of a request executed with Execute in the
+// OutputFormat of the configuration. The OutputFormatRaw format writes the response body as is,
+// followed by the WriteOut format of the RequestConfig expanded with FormatWriteOut,
+// while the OutputFormatJSON format writes a data.RunSummary as a JSON object on a single line,
+// so the summaries of successive requests can be piped into other tools. The summary includes the
+// response body when the configuration sets SummaryBody.
+//
+// Parameters:
+//   - w: The writer receiving the output, usually the standard output.
+//   - rc: The executed request configuration.
+//   - result: The result returned by Execute.
+//   - err: The error returned by Execute. It is reported in the summary, and nothing is written in
+//     the raw format.
+//   - cfg: The configuration selecting the output format.
+//
+// Returns:
+//   - An error if the output format is unknown or the output cannot be written.
+func WriteResult(w io.Writer, rc *data.RequestConfig, result data.RequestResult, err error, cfg data.Config) error {
+	switch cfg.OutputFormat {
+	case "", data.OutputFormatRaw:
+		if err != nil {
+			return nil
+		}
+		_, writeErr := io.WriteString(w, result.Stdout+result.FormatWriteOut(rc.WriteOut))
+		return errors.Wrap(writeErr, "Failed to write the response body")
+	case data.OutputFormatJSON:
+		summary := data.NewRunSummary(rc, result, err, cfg.SummaryBody)
+		return errors.Wrap(json.NewEncoder(w).Encode(summary), "Failed to write the run summary")
+	default:
+		return errors.Errorf("Unknown output format %q, expected %s or %s", cfg.OutputFormat, data.OutputFormatRaw, data.OutputFormatJSON)
+	}
+}
@@ -0,0 +1,40 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/larayavrs/vortex/pkg"
+	"github.com/pkg/errors"
+)
+
+// postProcess runs the post-process command line with the response body on its stdin and replaces
+// the body with its output. When the command fails, the body is kept, the exit code of the command
+// is recorded in the result and an error holding the beginning of its standard error is returned.
+func postProcess(ctx context.Context, cmdline string, result *data.RequestResult) error {
+	tokens, err := pkg.TokenizeLine(cmdline)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid post-process command: %q", cmdline)
+	}
+	if len(tokens) == 0 {
+		return errors.Errorf("Empty post-process command: %q", cmdline)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, tokens[0], tokens[1:]...)
+	cmd.Stdin = strings.NewReader(result.Stdout)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return errors.Wrapf(err, "Failed to run the post-process command %s", tokens[0])
+		}
+		result.PostProcessExitCode = exitErr.ExitCode()
+		return errors.Errorf("The post-process command %s exited with code %d: %s", tokens[0], result.PostProcessExitCode, pkg.Ellipsize(0, 200, strings.TrimSpace(stderr.String())))
+	}
+	result.Stdout = stdout.String()
+	return nil
+}
@@ -0,0 +1,54 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+func TestExecutePostProcess(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"user": {"name": "vortex"}}`))
+	}))
+	defer server.Close()
+	// A jq-like filter extracting the name, failing with a message for any other filter
+	dir := t.TempDir()
+	jq := filepath.Join(dir, "fake jq")
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" != '.user.name' ]; then echo \"jq: error: unknown filter $1\" >&2; exit 3; fi\n" +
+		"sed -e 's/.*\"name\": \"\\([^\"]*\\)\".*/\\1/'\n"
+	if err := os.WriteFile(jq, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		cmdline, stdout, err string
+		exitCode             int
+	}{
+		"transformed": {`'` + jq + `' .user.name`, "vortex", "", 0},
+		"failing":     {`'` + jq + `' .user.id`, `{"user": {"name": "vortex"}}`, "exited with code 3: jq: error: unknown filter .user.id", 3},
+		"missing":     {filepath.Join(dir, "missing"), `{"user": {"name": "vortex"}}`, "Failed to run the post-process command", 0},
+		"unquoted":    {`jq '.user`, `{"user": {"name": "vortex"}}`, "Invalid post-process command", 0},
+	}
+	for name, tt := range tests {
+		rc := &data.RequestConfig{Host: serverHost(t, server.URL), Backend: data.NativeBackend, NoDefaultHeaders: true, PostProcess: tt.cmdline}
+		result, err := Execute(context.Background(), rc, data.Config{})
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: Execute() error = %v", name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: Execute() error = %v, want %q", name, err, tt.err)
+		}
+		// The result of the request is kept whatever the post-process outcome
+		if result.StatusCode != http.StatusAccepted || result.Stdout != tt.stdout || result.PostProcessExitCode != tt.exitCode {
+			t.Errorf("%s: Execute() = %d, %q, exit code %d, want 202, %q, exit code %d", name, result.StatusCode, result.Stdout, result.PostProcessExitCode, tt.stdout, tt.exitCode)
+		}
+	}
+}
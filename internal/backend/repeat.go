@@ -0,0 +1,167 @@
+package backend
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/larayavrs/vortex/internal/disk"
+	"github.com/pkg/errors"
+)
+
+// RepeatStats aggregates the outcomes of the requests sent by RunRepeat.
+type RepeatStats struct {
+	// Count is the number of requests run, including the failed ones.
+	Count int
+
+	// Succeeded is the number of requests that succeeded, as told by BatchResult.Failure.
+	Succeeded int
+
+	// Failed is the number of requests that failed, as told by BatchResult.Failure.
+	Failed int
+
+	// StatusCounts counts the responses by status code. The requests that got no response are
+	// counted under 0.
+	StatusCounts map[int]int
+
+	// Min, Max and Mean are the shortest, longest and average Duration of the requests that got a
+	// response.
+	Min, Max, Mean time.Duration
+
+	// P50, P95 and P99 are the percentiles of the Duration of the requests that got a response, by
+	// the nearest-rank method.
+	P50, P95, P99 time.Duration
+
+	// Elapsed is the wall-clock duration of the whole run.
+	Elapsed time.Duration
+}
+
+// The method SuccessRate returns the share of the requests that succeeded, between 0 and 1, or 0
+// when no request was run.
+func (rs RepeatStats) SuccessRate() float64 {
+	if rs.Count == 0 {
+		return 0
+	}
+	return float64(rs.Succeeded) / float64(rs.Count)
+}
+
+// The function RunRepeat sends the same request count times with ExecuteBatch, running up to
+// concurrency of them at the same time, for quick load sanity checks. The request is prepared once:
+// the default headers are applied, a body typed on stdin is read, the variable references are
+// expanded, the request is confirmed when it asks for it and the inline body is written to a single
+// temporary file, read by every iteration and removed at the end. Each iteration then runs on a
+// Clone of the prepared request, so the RequestConfig given is left untouched.
+//
+// Parameters:
+//   - ctx: The context governing the run. Cancelling it stops the pending requests.
+//   - rc: The request configuration to send.
+//   - count: The number of requests to send, at least 1.
+//   - concurrency: The maximum number of requests running at the same time. Zero or less runs the
+//     requests one after the other.
+//   - cfg: The configuration passed to Execute for each request.
+//
+// Returns:
+//   - The RepeatStats of the run, holding the statistics of the requests run so far when the
+//     context is cancelled.
+//   - An error if the request cannot be prepared or the context is cancelled. The failed requests
+//     are counted in the RepeatStats rather than reported as an error.
+func RunRepeat(ctx context.Context, rc *data.RequestConfig, count, concurrency int, cfg data.Config) (RepeatStats, error) {
+	if count < 1 {
+		return RepeatStats{}, errors.Errorf("Invalid repeat count %d, expected at least 1", count)
+	}
+	prepared, err := prepareRepeat(rc, cfg)
+	if err != nil {
+		return RepeatStats{}, err
+	}
+	defer prepared.Close()
+	configs := make([]*data.RequestConfig, count)
+	for i := range configs {
+		configs[i] = prepared.Clone()
+		if prepared.TempfileName != "" {
+			configs[i].Body, configs[i].BodyFile, configs[i].TempfileName = nil, prepared.TempfileName, ""
+		}
+	}
+	start := time.Now()
+	results, _ := ExecuteBatch(ctx, configs, cfg, BatchOptions{Concurrency: concurrency})
+	stats := newRepeatStats(results)
+	stats.Elapsed = time.Since(start)
+	if ctx.Err() != nil {
+		return stats, errors.Wrapf(ctx.Err(), "Repeat stopped after %d of %d requests", stats.Count, count)
+	}
+	return stats, nil
+}
+
+// prepareRepeat returns a copy of the request ready to be cloned for every iteration of RunRepeat,
+// as described by RunRepeat. The inline body is written to a temporary file unless it is
+// compressed, which CreateBodyTempfile does from the Body.
+func prepareRepeat(rc *data.RequestConfig, cfg data.Config) (*data.RequestConfig, error) {
+	prepared := rc.Clone()
+	prepared.TempfileName = ""
+	if !prepared.NoDefaultHeaders {
+		defaults, err := disk.LoadDefaultHeaders()
+		if err != nil {
+			return nil, err
+		}
+		prepared.ApplyDefaultHeaders(defaults)
+		prepared.NoDefaultHeaders = true
+	}
+	if err := disk.ReadStdinBody(prepared, disk.Stdin); err != nil {
+		return nil, err
+	}
+	if err := prepared.Interpolate(cfg); err != nil {
+		return nil, err
+	}
+	if err := disk.ConfirmRequest(prepared, cfg, disk.Stdin, disk.Prompt); err != nil {
+		return nil, err
+	}
+	prepared.Confirm = false
+	prepared.InferContentType()
+	if !prepared.CompressBody {
+		if err := prepared.CreateBodyTempfile(); err != nil {
+			return nil, err
+		}
+	}
+	return prepared, nil
+}
+
+// newRepeatStats aggregates the results of the requests of RunRepeat. The requests never started
+// because the run was cancelled are left out.
+func newRepeatStats(results []BatchResult) RepeatStats {
+	stats := RepeatStats{StatusCounts: make(map[int]int)}
+	var durations []time.Duration
+	for _, result := range results {
+		if result.Err != nil && result.Result.Duration == 0 && errors.Is(result.Err, context.Canceled) {
+			continue
+		}
+		stats.Count++
+		if result.Failure() == nil {
+			stats.Succeeded++
+		} else {
+			stats.Failed++
+		}
+		stats.StatusCounts[result.Result.StatusCode]++
+		if result.Err == nil {
+			durations = append(durations, result.Result.Duration)
+		}
+	}
+	if len(durations) == 0 {
+		return stats
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	var total time.Duration
+	for _, duration := range durations {
+		total += duration
+	}
+	stats.Min, stats.Max = durations[0], durations[len(durations)-1]
+	stats.Mean = total / time.Duration(len(durations))
+	stats.P50, stats.P95, stats.P99 = percentile(durations, 50), percentile(durations, 95), percentile(durations, 99)
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of the sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
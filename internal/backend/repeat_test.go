@@ -0,0 +1,82 @@
+package backend
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+func TestRunRepeat(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"n":1}` {
+			t.Errorf("request body = %q, want the template body", body)
+		}
+		if requests.Add(1)%3 == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	host, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc := &data.RequestConfig{Host: host, Method: http.MethodPost, Backend: "native", Body: []string{`{"n":1}`}, NoDefaultHeaders: true}
+	stats, err := RunRepeat(context.Background(), rc, 9, 3, data.Config{NoHistory: true})
+	if err != nil {
+		t.Fatalf("RunRepeat() error = %v", err)
+	}
+	if stats.Count != 9 || stats.Succeeded != 6 || stats.Failed != 3 {
+		t.Errorf("Count = %d, Succeeded = %d, Failed = %d, want 9, 6 and 3", stats.Count, stats.Succeeded, stats.Failed)
+	}
+	if stats.StatusCounts[200] != 6 || stats.StatusCounts[500] != 3 {
+		t.Errorf("StatusCounts = %v, want 6 200s and 3 500s", stats.StatusCounts)
+	}
+	if stats.SuccessRate() < 0.66 || stats.SuccessRate() > 0.67 {
+		t.Errorf("SuccessRate() = %v, want 2/3", stats.SuccessRate())
+	}
+	if stats.Min > stats.P50 || stats.P50 > stats.P99 || stats.P99 > stats.Max {
+		t.Errorf("Min %v, P50 %v, P99 %v, Max %v are not ordered", stats.Min, stats.P50, stats.P99, stats.Max)
+	}
+	if len(rc.Body) != 1 || rc.TempfileName != "" {
+		t.Errorf("RunRepeat() modified the request: Body = %q, TempfileName = %q", rc.Body, rc.TempfileName)
+	}
+	if _, err := RunRepeat(context.Background(), rc, 0, 1, data.Config{}); err == nil {
+		t.Error("RunRepeat() with a count of 0 succeeded, want an error")
+	}
+}
+
+func TestNewRepeatStats(t *testing.T) {
+	var results []BatchResult
+	for i := 1; i <= 100; i++ {
+		results = append(results, BatchResult{Result: data.RequestResult{StatusCode: 200, Duration: time.Duration(i) * time.Millisecond}})
+	}
+	results = append(results, BatchResult{Err: context.Canceled})
+	stats := newRepeatStats(results)
+	if stats.Count != 100 || stats.Succeeded != 100 {
+		t.Errorf("Count = %d, Succeeded = %d, want the cancelled request left out", stats.Count, stats.Succeeded)
+	}
+	want := map[string][2]time.Duration{
+		"Min":  {stats.Min, time.Millisecond},
+		"Max":  {stats.Max, 100 * time.Millisecond},
+		"Mean": {stats.Mean, 50500 * time.Microsecond},
+		"P50":  {stats.P50, 50 * time.Millisecond},
+		"P95":  {stats.P95, 95 * time.Millisecond},
+		"P99":  {stats.P99, 99 * time.Millisecond},
+	}
+	for name, durations := range want {
+		if durations[0] != durations[1] {
+			t.Errorf("%s = %v, want %v", name, durations[0], durations[1])
+		}
+	}
+}
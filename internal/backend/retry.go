@@ -0,0 +1,66 @@
+package backend
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/pkg/errors"
+)
+
+// curlTimeoutExitCode is the exit code of curl when the operation timed out.
+const curlTimeoutExitCode = 28
+
+// executeWithRetry runs the request with execute, sending it again as described by the Retry
+// configuration of the RequestConfig: only the responses whose status code is listed in RetryOn,
+// and the timeouts when RetryOnTimeout is set, are retried, waiting for the Retry-After delay of
+// 429 and 503 responses, or the configured Delay. The Attempts of the result count the requests
+// sent.
+func executeWithRetry(ctx context.Context, rc *data.RequestConfig, cfg data.Config) (data.RequestResult, error) {
+	retry := rc.Retry
+	for attempt := 1; ; attempt++ {
+		result, err := execute(ctx, rc, cfg)
+		result.Attempts = attempt
+		if retry == nil || attempt >= retry.Attempts || ctx.Err() != nil || !shouldRetry(retry, result, err) {
+			return result, err
+		}
+		delay := retry.Delay
+		if result.StatusCode == http.StatusTooManyRequests || result.StatusCode == http.StatusServiceUnavailable {
+			if retryAfter, ok := data.RetryAfter(result.Headers, cfg.Now()); ok {
+				delay = retryAfter
+				if retry.MaxRetryAfter > 0 {
+					delay = min(delay, retry.MaxRetryAfter)
+				}
+			}
+		}
+		if rc.Verbose {
+			cfg.Log().Log("Retrying the request", "attempt", attempt+1, "status", result.StatusCode, "delay", delay)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+	}
+}
+
+// shouldRetry reports whether the outcome of an attempt is retried by the RetryConfig.
+func shouldRetry(retry *data.RetryConfig, result data.RequestResult, err error) bool {
+	if isTimeout(result, err) {
+		return retry.RetryOnTimeout
+	}
+	return err == nil && retry.RetriesStatus(result.StatusCode)
+}
+
+// isTimeout reports whether an attempt failed because the request timed out.
+func isTimeout(result data.RequestResult, err error) bool {
+	if err == nil {
+		return result.ExitCode == curlTimeoutExitCode && result.StatusCode == 0
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
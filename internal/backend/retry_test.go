@@ -0,0 +1,125 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+// retryServer answers with the statuses in turn, then with 200, setting the Retry-After header of
+// the failed responses when it is not empty. It returns the server and the count of requests.
+func retryServer(t *testing.T, retryAfter string, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(count.Add(1))
+		if n > len(statuses) {
+			_, _ = w.Write([]byte("ok"))
+			return
+		}
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(statuses[n-1])
+		_, _ = w.Write([]byte(strconv.Itoa(statuses[n-1])))
+	}))
+	t.Cleanup(server.Close)
+	return server, &count
+}
+
+func TestExecuteRetryOn(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	tests := map[string]struct {
+		statuses []int
+		retry    *data.RetryConfig
+		status   int
+		attempts int
+	}{
+		"503 then success": {[]int{503}, &data.RetryConfig{Attempts: 3, RetryOn: []int{429, 503}}, 200, 2},
+		"400 not retried":  {[]int{400}, &data.RetryConfig{Attempts: 3, RetryOn: []int{429, 503}}, 400, 1},
+		"attempts spent":   {[]int{429, 429, 429}, &data.RetryConfig{Attempts: 2, RetryOn: []int{429}}, 429, 2},
+		"no retry config":  {[]int{503}, nil, 503, 1},
+	}
+	for name, tt := range tests {
+		server, count := retryServer(t, "", tt.statuses...)
+		rc := &data.RequestConfig{Host: serverHost(t, server.URL), Backend: data.NativeBackend, NoDefaultHeaders: true, Retry: tt.retry}
+		result, err := Execute(context.Background(), rc, data.Config{})
+		if err != nil {
+			t.Errorf("%s: Execute() error = %v", name, err)
+			continue
+		}
+		if result.StatusCode != tt.status || result.Attempts != tt.attempts || int(count.Load()) != tt.attempts {
+			t.Errorf("%s: Execute() = %d after %d attempts, %d requests, want %d after %d", name, result.StatusCode, result.Attempts, count.Load(), tt.status, tt.attempts)
+		}
+	}
+}
+
+func TestExecuteRetryAfter(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	tests := map[string]struct {
+		retryAfter string
+		retry      data.RetryConfig
+		min, max   time.Duration
+	}{
+		"header":     {"1", data.RetryConfig{Delay: time.Hour}, time.Second, 5 * time.Second},
+		"capped":     {"3600", data.RetryConfig{Delay: time.Hour, MaxRetryAfter: 20 * time.Millisecond}, 20 * time.Millisecond, time.Second},
+		"configured": {"", data.RetryConfig{Delay: 20 * time.Millisecond}, 20 * time.Millisecond, time.Second},
+		"past date":  {"Mon, 01 Jan 2024 00:00:00 GMT", data.RetryConfig{Delay: time.Hour}, 0, time.Second},
+	}
+	for name, tt := range tests {
+		server, _ := retryServer(t, tt.retryAfter, http.StatusServiceUnavailable)
+		retry := tt.retry
+		retry.Attempts, retry.RetryOn = 2, []int{503}
+		rc := &data.RequestConfig{Host: serverHost(t, server.URL), Backend: data.NativeBackend, NoDefaultHeaders: true, Retry: &retry}
+		start := time.Now()
+		result, err := Execute(context.Background(), rc, data.Config{})
+		elapsed := time.Since(start)
+		if err != nil || result.StatusCode != http.StatusOK {
+			t.Errorf("%s: Execute() = %d, %v, want the retry to succeed", name, result.StatusCode, err)
+		}
+		if elapsed < tt.min || elapsed > tt.max {
+			t.Errorf("%s: the retry took %v, want between %v and %v", name, elapsed, tt.min, tt.max)
+		}
+	}
+
+	// Cancelling the context ends the wait
+	server, _ := retryServer(t, "3600", http.StatusTooManyRequests)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	rc := &data.RequestConfig{Host: serverHost(t, server.URL), Backend: data.NativeBackend, NoDefaultHeaders: true, Retry: &data.RetryConfig{Attempts: 2, RetryOn: []int{429}}}
+	start := time.Now()
+	result, _ := Execute(ctx, rc, data.Config{})
+	if elapsed := time.Since(start); elapsed > 5*time.Second || result.StatusCode != http.StatusTooManyRequests || result.Attempts != 1 {
+		t.Errorf("Execute() = %d after %d attempts in %v, want the 429 returned once cancelled", result.StatusCode, result.Attempts, elapsed)
+	}
+}
+
+func TestExecuteRetryOnTimeout(t *testing.T) {
+	t.Setenv(data.HistoryFileVariable, filepath.Join(t.TempDir(), "history.jsonl"))
+	// The fake curl times out on its first call only
+	marker := filepath.Join(t.TempDir(), "called")
+	fakeBackend(t, "curl", `if [ ! -e '`+marker+`' ]; then : > '`+marker+`'; exit 28; fi
+printf 'ok\n--vortex-write-out--\nhttp_code=200\n'
+`)
+	for _, retryOnTimeout := range []bool{false, true} {
+		_ = os.Remove(marker)
+		rc := stepConfigs("curl", 1)[0]
+		rc.Retry = &data.RetryConfig{Attempts: 3, RetryOnTimeout: retryOnTimeout}
+		result, _ := Execute(context.Background(), rc, data.Config{})
+		wantAttempts, wantStatus := 1, 0
+		if retryOnTimeout {
+			wantAttempts, wantStatus = 2, 200
+		}
+		if result.Attempts != wantAttempts || result.StatusCode != wantStatus {
+			t.Errorf("RetryOnTimeout %v: Execute() = %d after %d attempts, want %d after %d", retryOnTimeout, result.StatusCode, result.Attempts, wantStatus, wantAttempts)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package backend
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+// timingTrace records when the phases of a native request start and end, to fill the Timings of
+// its result. Only the first occurrence of each event is kept, so the connection attempts of a
+// dual-stack host do not overwrite each other.
+type timingTrace struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	firstByte    time.Time
+}
+
+// newTimingTrace returns a timingTrace whose request starts now.
+func newTimingTrace() *timingTrace {
+	return &timingTrace{start: time.Now()}
+}
+
+// mark sets the time of an event, unless it already happened.
+func (t *timingTrace) mark(event *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if event.IsZero() {
+		*event = time.Now()
+	}
+}
+
+// clientTrace returns the httptrace.ClientTrace recording the events of the request.
+func (t *timingTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { t.mark(&t.dnsDone) },
+		ConnectStart:      func(string, string) { t.mark(&t.connectStart) },
+		TLSHandshakeStart: func() { t.mark(&t.tlsStart) },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				t.mark(&t.connectDone)
+			}
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				t.mark(&t.tlsDone)
+			}
+		},
+		GotFirstResponseByte: func() { t.mark(&t.firstByte) },
+	}
+}
+
+// timings returns the Timings of the request, which ended at the given time.
+func (t *timingTrace) timings(end time.Time) data.Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	between := func(from, to time.Time) time.Duration {
+		if from.IsZero() || to.IsZero() {
+			return 0
+		}
+		return to.Sub(from)
+	}
+	return data.Timings{
+		DNS:     between(t.dnsStart, t.dnsDone),
+		Connect: between(t.connectStart, t.connectDone),
+		TLS:     between(t.tlsStart, t.tlsDone),
+		TTFB:    between(t.start, t.firstByte),
+		Total:   end.Sub(t.start),
+	}
+}
@@ -0,0 +1,236 @@
+package data
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Authentication types of the [Auth] section of a template.
+const (
+	AuthBasic  = "basic"
+	AuthBearer = "bearer"
+	AuthAWSV4  = "awsv4"
+	AuthHMAC   = "hmac"
+	AuthOAuth2 = "oauth2"
+)
+
+// authorizationHeader is the name of the header carrying the credentials of a request.
+const authorizationHeader = "Authorization"
+
+// AuthConfig describes how a request authenticates, as declared by the [Auth] section of a
+// template.
+type AuthConfig struct {
+	// Type is the authentication scheme: AuthBasic, AuthBearer, AuthAWSV4, AuthHMAC or AuthOAuth2.
+	Type string
+
+	// Params holds the other `key = value` settings of the section, keyed by lowercased name.
+	// Their variable references are expanded when the authentication is applied.
+	Params map[string]string
+
+	// Token is the access token of the AuthOAuth2 type, obtained from the token endpoint by
+	// Execute before the authentication is applied.
+	Token string
+}
+
+// OAuth2Credentials are the settings of the OAuth2 client credentials grant, used to obtain the
+// access token of the AuthOAuth2 type.
+type OAuth2Credentials struct {
+	// TokenURL is the URL of the token endpoint.
+	TokenURL string
+
+	// ClientID and ClientSecret identify the client to the token endpoint.
+	ClientID     string
+	ClientSecret string
+
+	// Scope, if set, is the space-separated list of scopes requested.
+	Scope string
+}
+
+// The method OAuth2Credentials returns the client credentials of the `token_url`, `client_id`,
+// `client_secret` and `scope` settings of the AuthOAuth2 type, with their variable references
+// expanded with ExpandTemplate.
+//
+// Parameters:
+//   - cfg: The configuration providing the interpolation syntax.
+//
+// Returns:
+//   - The client credentials.
+//   - An error if a setting cannot be expanded, or the token URL or the client ID is missing.
+func (ac *AuthConfig) OAuth2Credentials(cfg Config) (OAuth2Credentials, error) {
+	var creds OAuth2Credentials
+	settings := map[string]*string{
+		"token_url":     &creds.TokenURL,
+		"client_id":     &creds.ClientID,
+		"client_secret": &creds.ClientSecret,
+		"scope":         &creds.Scope,
+	}
+	for name, target := range settings {
+		value, err := ac.param(name, "", cfg)
+		if err != nil {
+			return creds, err
+		}
+		*target = value
+	}
+	if creds.TokenURL == "" || creds.ClientID == "" {
+		return creds, errors.New("Missing token_url or client_id for oauth2 authentication")
+	}
+	return creds, nil
+}
+
+// parseAuthLine applies a `key = value` line of the [Auth] section to the RequestConfig.
+func parseAuthLine(rc *RequestConfig, line string) error {
+	key, value, found := splitKeyValue(line)
+	if !found || key == "" {
+		return errors.Errorf("Malformed [Auth] setting, expected key = value: %q", line)
+	}
+	if rc.Auth == nil {
+		rc.Auth = &AuthConfig{Params: make(map[string]string)}
+	}
+	key = strings.ToLower(key)
+	if key == "type" {
+		rc.Auth.Type = strings.ToLower(value)
+		return nil
+	}
+	rc.Auth.Params[key] = value
+	return nil
+}
+
+// param returns the expanded value of a setting, or the value of the fallback environment
+// variable when the setting is missing.
+func (ac *AuthConfig) param(name, fallbackEnv string, cfg Config) (string, error) {
+	value, ok := ac.Params[name]
+	if !ok {
+		if fallbackEnv == "" {
+			return "", nil
+		}
+		return cfg.getenv(fallbackEnv), nil
+	}
+	expanded, err := ExpandTemplate(value, cfg)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to expand the [Auth] setting %s", name)
+	}
+	return expanded, nil
+}
+
+// The method ApplyAuth adds the headers authenticating the request, as described by its Auth
+// configuration, replacing the headers a previous call added:
+//   - basic: an `Authorization: Basic` header built from the `user` and `password` settings.
+//   - bearer: an `Authorization: Bearer` header holding the `token` setting.
+//   - awsv4: the headers of an AWS Signature Version 4, computed by SignAWSV4 from the `region`,
+//     `service`, `access_key`, `secret_key` and `session_token` settings. The credentials and the
+//     region default to the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN and
+//     AWS_REGION environment variables.
+//   - hmac: a custom HMAC signature, computed by SignHMAC from the `key`, `algorithm`, `header`,
+//     `template` and `encoding` settings.
+//   - oauth2: an `Authorization: Bearer` header holding the Token of the Auth configuration,
+//     which Execute obtains with the OAuth2Credentials before calling ApplyAuth.
+//
+// The variable references of the settings are expanded with ExpandTemplate, with the configuration
+// returned by ExpansionConfig, which also applies to the environment variables the settings
+// default to. Since the headers are computed before the backend runs, they work with every
+// backend. The signature covers the final headers and body, so ApplyAuth must run after every
+// other change to the request.
+//
+// Parameters:
+//   - cfg: The configuration providing the interpolation syntax and the current time.
+//
+// Returns:
+//   - An error if the authentication type is unknown, a required setting is missing or the
+//     request cannot be signed.
+func (rc *RequestConfig) ApplyAuth(cfg Config) error {
+	if rc.Auth == nil {
+		return nil
+	}
+	cfg = rc.ExpansionConfig(cfg)
+	switch rc.Auth.Type {
+	case AuthBasic:
+		user, err := rc.Auth.param("user", "", cfg)
+		if err != nil {
+			return err
+		}
+		password, err := rc.Auth.param("password", "", cfg)
+		if err != nil {
+			return err
+		}
+		credentials := base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+		rc.setHeader(authorizationHeader, "Basic "+credentials)
+	case AuthBearer:
+		token, err := rc.Auth.param("token", "", cfg)
+		if err != nil {
+			return err
+		}
+		if token == "" {
+			return errors.New("Missing token for bearer authentication")
+		}
+		rc.setHeader(authorizationHeader, "Bearer "+token)
+	case AuthAWSV4:
+		var creds AWSCredentials
+		settings := []struct {
+			target            *string
+			name, fallbackEnv string
+		}{
+			{&creds.AccessKeyID, "access_key", "AWS_ACCESS_KEY_ID"},
+			{&creds.SecretAccessKey, "secret_key", "AWS_SECRET_ACCESS_KEY"},
+			{&creds.SessionToken, "session_token", "AWS_SESSION_TOKEN"},
+			{&creds.Region, "region", "AWS_REGION"},
+			{&creds.Service, "service", ""},
+		}
+		for _, setting := range settings {
+			value, err := rc.Auth.param(setting.name, setting.fallbackEnv, cfg)
+			if err != nil {
+				return err
+			}
+			*setting.target = value
+		}
+		return rc.SignAWSV4(creds, cfg.Now())
+	case AuthHMAC:
+		var signer HMACSigner
+		settings := map[string]*string{
+			"key":       &signer.Key,
+			"algorithm": &signer.Algorithm,
+			"header":    &signer.Header,
+			"template":  &signer.Template,
+			"encoding":  &signer.Encoding,
+		}
+		for name, target := range settings {
+			value, err := rc.Auth.param(name, "", cfg)
+			if err != nil {
+				return err
+			}
+			*target = value
+		}
+		return rc.SignHMAC(signer)
+	case AuthOAuth2:
+		if rc.Auth.Token == "" {
+			return errors.New("Missing access token for oauth2 authentication")
+		}
+		rc.setHeader(authorizationHeader, "Bearer "+rc.Auth.Token)
+	case "":
+		return errors.New("Missing authentication type in the [Auth] section")
+	default:
+		return errors.Errorf("Unsupported authentication type: %q", rc.Auth.Type)
+	}
+	return nil
+}
+
+// setHeader replaces the headers of the request with the given name, compared case-insensitively,
+// by a single header with the given value.
+func (rc *RequestConfig) setHeader(name, value string) {
+	rc.removeHeaders(name)
+	rc.Headers = append(rc.Headers, name+": "+value)
+}
+
+// removeHeaders removes the headers of the request with the given names, compared
+// case-insensitively.
+func (rc *RequestConfig) removeHeaders(names ...string) {
+	kept := rc.Headers[:0]
+	for _, header := range rc.Headers {
+		headerName, _, _ := SplitHeader(header)
+		if !isSensitiveHeader(headerName, names) {
+			kept = append(kept, header)
+		}
+	}
+	rc.Headers = kept
+}
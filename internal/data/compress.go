@@ -0,0 +1,58 @@
+package data
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// contentEncodingHeader is the name of the header declaring the encoding of the request body.
+const contentEncodingHeader = "Content-Encoding"
+
+// The method CompressedBody returns the request body, read from the BodyFile or joined from the
+// Body lines, compressed with gzip.
+//
+// Returns:
+//   - The gzipped body, or nil when the request has no body or its method ignores it.
+//   - An error if the body file cannot be read or the body cannot be compressed.
+func (rc *RequestConfig) CompressedBody() ([]byte, error) {
+	if rc.IgnoresBody() {
+		return nil, nil
+	}
+	var body []byte
+	switch {
+	case rc.BodyFile != "":
+		contents, err := os.ReadFile(rc.BodyFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read the body file: %s", rc.BodyFile)
+		}
+		body = contents
+	case len(rc.Body) > 0:
+		body = []byte(strings.Join(rc.Body, "\n"))
+	default:
+		return nil, nil
+	}
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(body); err != nil {
+		return nil, errors.Wrap(err, "Failed to compress the request body")
+	}
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "Failed to compress the request body")
+	}
+	return compressed.Bytes(), nil
+}
+
+// The method AddContentEncoding adds the `Content-Encoding: gzip` header to a request whose body
+// is compressed, as requested by CompressBody, unless the headers already declare an encoding.
+func (rc *RequestConfig) AddContentEncoding() {
+	if !rc.CompressBody || rc.IgnoresBody() || (len(rc.Body) == 0 && rc.BodyFile == "") {
+		return
+	}
+	if !rc.hasHeader(contentEncodingHeader) {
+		rc.Headers = append(rc.Headers, contentEncodingHeader+": gzip")
+	}
+}
@@ -0,0 +1,65 @@
+package data
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// gunzip decompresses the gzipped bytes.
+func gunzip(t *testing.T, compressed []byte) string {
+	t.Helper()
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	plain, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("decompressing error = %v", err)
+	}
+	return string(plain)
+}
+
+func TestCompressBody(t *testing.T) {
+	rc := &RequestConfig{Method: "POST", Body: []string{`{"name":`, `"vortex"}`}, CompressBody: true}
+	rc.AddContentEncoding()
+	rc.AddContentEncoding()
+	if want := []string{"Content-Encoding: gzip"}; !reflect.DeepEqual(rc.Headers, want) {
+		t.Errorf("AddContentEncoding() Headers = %q, want %q once", rc.Headers, want)
+	}
+	compressed, err := rc.CompressedBody()
+	if err != nil {
+		t.Fatalf("CompressedBody() error = %v", err)
+	}
+	if got := gunzip(t, compressed); got != "{\"name\":\n\"vortex\"}" {
+		t.Errorf("CompressedBody() decompresses to %q, want the body lines", got)
+	}
+
+	if err := rc.CreateBodyTempfile(); err != nil {
+		t.Fatalf("CreateBodyTempfile() error = %v", err)
+	}
+	defer rc.RemoveBodyTempfile(true)
+	written, err := os.ReadFile(rc.TempfileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := gunzip(t, written); got != "{\"name\":\n\"vortex\"}" {
+		t.Errorf("the body file decompresses to %q, want the body lines", got)
+	}
+
+	for name, other := range map[string]*RequestConfig{
+		"not compressed": {Method: "POST", Body: []string{"a"}},
+		"no body":        {Method: "POST", CompressBody: true},
+		"head":           {Method: "HEAD", Body: []string{"a"}, CompressBody: true},
+		"own encoding":   {Method: "POST", Body: []string{"a"}, CompressBody: true, Headers: []string{"content-encoding: br"}},
+	} {
+		headers := append([]string(nil), other.Headers...)
+		other.AddContentEncoding()
+		if !reflect.DeepEqual(other.Headers, headers) {
+			t.Errorf("%s: AddContentEncoding() Headers = %q, want %q", name, other.Headers, headers)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// contentTypeHeader is the name of the header declaring the media type of the request body.
+const contentTypeHeader = "Content-Type"
+
+// The method InferContentType adds a Content-Type header matching the request body when the
+// headers declare none. A body that is valid JSON is sent as `application/json`, and a
+// well-formed XML document as `application/xml`. A BodyFile holding binary data, such as an
+// image or a protobuf message, is sent with the media type sniffed from its first bytes, or as
+// `application/octet-stream`, so curl does not announce it as a form. Nothing is added for other
+// bodies, text body files or methods that ignore the body. It should be called once the body is
+// interpolated.
+//
+// Returns:
+//   - The inferred content type, or an empty string when no header was added.
+func (rc *RequestConfig) InferContentType() string {
+	if rc.IgnoresBody() || rc.hasHeader(contentTypeHeader) {
+		return ""
+	}
+	var contentType string
+	if rc.BodyFile != "" {
+		contentType = sniffBinaryContentType(rc.BodyFile)
+	} else if len(rc.Body) > 0 {
+		body := []byte(strings.TrimSpace(strings.Join(rc.Body, "\n")))
+		switch {
+		case json.Valid(body):
+			contentType = "application/json"
+		case isWellFormedXML(body):
+			contentType = "application/xml"
+		}
+	}
+	if contentType == "" {
+		return ""
+	}
+	rc.Headers = append(rc.Headers, contentTypeHeader+": "+contentType)
+	return contentType
+}
+
+// sniffBinaryContentType returns the media type of a body file holding binary data, as detected by
+// http.DetectContentType from its first bytes, and an empty string for a text file or a file that
+// cannot be read, which fails later with a clearer error.
+func sniffBinaryContentType(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return ""
+	}
+	contentType := http.DetectContentType(head[:n])
+	if strings.HasPrefix(contentType, "text/") {
+		return ""
+	}
+	return contentType
+}
+
+// hasHeader reports whether the RequestConfig sets the header, compared case-insensitively.
+func (rc *RequestConfig) hasHeader(name string) bool {
+	for _, header := range rc.Headers {
+		if headerName, _, _ := SplitHeader(header); strings.EqualFold(headerName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWellFormedXML reports whether the body is a well-formed XML document with a single root
+// element.
+func isWellFormedXML(body []byte) bool {
+	if !bytes.HasPrefix(body, []byte("<")) {
+		return false
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	depth, roots := 0, 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return roots == 1 && depth == 0
+		}
+		if err != nil {
+			return false
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				roots++
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(bytes.TrimSpace(t)) > 0 {
+				return false
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestInferContentType(t *testing.T) {
+	tests := map[string]struct {
+		method  string
+		headers []string
+		body    []string
+		want    []string
+	}{
+		"json":          {"POST", nil, []string{`{"a": 1}`}, []string{"Content-Type: application/json"}},
+		"xml":           {"POST", nil, []string{`<?xml version="1.0"?>`, `<user id="1">`, `  <name>vortex</name>`, `</user>`}, []string{"Content-Type: application/xml"}},
+		"soap":          {"POST", nil, []string{`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body/></soap:Envelope>`}, []string{"Content-Type: application/xml"}},
+		"malformed xml": {"POST", nil, []string{`<user><name>vortex</user>`}, nil},
+		"two roots":     {"POST", nil, []string{`<a/><b/>`}, nil},
+		"text":          {"POST", nil, []string{"name=vortex"}, nil},
+		"explicit type": {"POST", []string{"content-type: text/xml"}, []string{`<user/>`}, []string{"content-type: text/xml"}},
+		"ignored body":  {"HEAD", nil, []string{`<user/>`}, nil},
+		"empty body":    {"POST", nil, nil, nil},
+	}
+	for name, tt := range tests {
+		rc := &RequestConfig{Method: tt.method, Headers: tt.headers, Body: tt.body}
+		rc.InferContentType()
+		if !reflect.DeepEqual(rc.Headers, tt.want) {
+			t.Errorf("%s: InferContentType() Headers = %q, want %q", name, rc.Headers, tt.want)
+		}
+	}
+}
+
+func TestBinaryBodyFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"image.png": append([]byte("\x89PNG\r\n\x1a\n"), 0, 0, 0, 0x0d, 'I', 'H', 'D', 'R'),
+		"blob.bin":  {0x08, 0x96, 0x01, 0x00, 0xff, '\r', '\n', 0x12},
+		"notes.txt": []byte("line one\r\nline two\n"),
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), contents, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := map[string]struct {
+		file string
+		want []string
+	}{
+		"png":  {"image.png", []string{"Content-Type: image/png"}},
+		"blob": {"blob.bin", []string{"Content-Type: application/octet-stream"}},
+		"text": {"notes.txt", nil},
+	}
+	for name, tt := range tests {
+		rc, err := ParseTemplate(filepath.Join(dir, "upload.ini"), "[Host]\nhttps://example.com\n[Method]\nPUT\n[Body]\n@"+tt.file+"\n", Config{})
+		if err != nil {
+			t.Errorf("%s: ParseTemplate() error = %v", name, err)
+			continue
+		}
+		if rc.BodyFile != filepath.Join(dir, tt.file) || rc.Body != nil {
+			t.Errorf("%s: BodyFile = %q, Body = %q, want the file alone", name, rc.BodyFile, rc.Body)
+		}
+		rc.InferContentType()
+		if !reflect.DeepEqual(rc.Headers, tt.want) {
+			t.Errorf("%s: InferContentType() Headers = %q, want %q", name, rc.Headers, tt.want)
+		}
+		if err := rc.CreateBodyTempfile(); err != nil || rc.TempfileName != "" {
+			t.Errorf("%s: CreateBodyTempfile() = %q, %v, want the file sent as is", name, rc.TempfileName, err)
+		}
+		args, err := BuildCurlArgs(rc, Config{})
+		if err != nil {
+			t.Errorf("%s: BuildCurlArgs() error = %v", name, err)
+			continue
+		}
+		if i := slices.Index(args, "--data-binary"); i < 0 || args[i+1] != "@"+rc.BodyFile {
+			t.Errorf("%s: BuildCurlArgs() = %q, want --data-binary @%s", name, args, rc.BodyFile)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package data
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// curlWriteOutMarker separates the response body from the metadata that curl prints after
+	// the transfer through its `-w` option. It starts with a newline so it never glues onto the
+	// last line of the body.
+	curlWriteOutMarker = "\n--vortex-write-out--\n"
+
+	// curlWriteOut is the `-w` format passed to curl. Each variable is printed on its own line
+	// as a `key=value` pair after the marker, so it can be parsed back by ParseCurlWriteOut.
+	curlWriteOut = curlWriteOutMarker +
+		"http_code=%{http_code}\n" +
+		"remote_ip=%{remote_ip}\n" +
+		"url_effective=%{url_effective}\n" +
+		"time_namelookup=%{time_namelookup}\n" +
+		"time_connect=%{time_connect}\n" +
+		"time_appconnect=%{time_appconnect}\n" +
+		"time_starttransfer=%{time_starttransfer}\n" +
+		"time_total=%{time_total}\n"
+)
+
+// The function BuildCurlArgs builds the command line arguments, without the program name, used
+// to perform the request described by the RequestConfig with curl. The request body is read from
+// the BodyFile or the temporary file created by `CreateBodyTempfile` with `--data-binary @path`.
+// An inline body without temporary file is passed with `--data-raw`, so a leading `@` is sent
+// literally, and an empty body forced by ForceBody is passed as `--data-raw ""`. A `-w` format is
+// added so the status code, the resolved remote IP, the final URL and the timings can be recovered
+// from the output with ParseCurlWriteOut. HEAD requests use `--head`, so the response headers are
+// printed in place of the body.
+//
+// Parameters:
+//   - rc: The request configuration to translate into curl arguments.
+//   - cfg: The configuration providing the request and connect timeouts.
+//
+// Returns:
+//   - A slice of strings containing the curl arguments.
+//   - An error if the request configuration has no host.
+func BuildCurlArgs(rc *RequestConfig, cfg Config) ([]string, error) {
+	return buildCurlArgs(rc, cfg, false)
+}
+
+// buildCurlArgs builds the curl arguments of BuildCurlArgs. When shareable is true, the arguments
+// are meant to be read and run by a person: the body is passed inline instead of through the
+// temporary file, and the output options used to parse the result are left out.
+func buildCurlArgs(rc *RequestConfig, cfg Config, shareable bool) ([]string, error) {
+	if rc.Host == nil {
+		return nil, errors.New("Cannot build curl arguments without a host")
+	}
+	var args []string
+	if !shareable || rc.QuietOutput() {
+		args = append(args, "--silent", "--show-error")
+	}
+	// Disable URL globbing so the brackets of IPv6 literals and of query keys like `a[0]` are sent
+	// as written instead of being expanded as ranges
+	args = append(args, "--globoff")
+	if strings.EqualFold(rc.Method, http.MethodHead) {
+		// Unlike `--request HEAD`, `--head` prints the headers and does not wait for a body
+		args = append(args, "--head")
+	} else if rc.Method != "" {
+		args = append(args, "--request", rc.Method)
+	}
+	for _, header := range rc.Headers {
+		args = append(args, "--header", header)
+	}
+	// Inline bodies use `--data-raw` so that a body starting with `@` is not read as a filename,
+	// while files use `--data-binary`, which keeps their newlines unlike `--data`
+	bodyPath := rc.BodyPath()
+	inlineBody := rc.BodyFile == "" && len(rc.Body) > 0 && !rc.IgnoresBody()
+	switch {
+	case inlineBody && (shareable || bodyPath == ""):
+		args = append(args, "--data-raw", strings.Join(rc.Body, "\n"))
+	case rc.forcesEmptyBody() && (shareable || bodyPath == ""):
+		args = append(args, "--data-raw", "")
+	case bodyPath != "":
+		args = append(args, "--data-binary", "@"+bodyPath)
+	}
+	if rc.FollowRedirects {
+		args = append(args, "--location")
+	}
+	if rc.HTTPVersion != "" {
+		if err := rc.CheckHTTPVersion("curl", httpVersions...); err != nil {
+			return nil, err
+		}
+		args = append(args, curlHTTPVersionFlags[rc.HTTPVersion])
+	}
+	if rc.InsecureSkipVerify {
+		args = append(args, "--insecure")
+	}
+	if rc.ClientCert != "" {
+		args = append(args, "--cert", rc.ClientCert)
+	}
+	if rc.ClientKey != "" {
+		args = append(args, "--key", rc.ClientKey)
+	}
+	if rc.CACert != "" {
+		args = append(args, "--cacert", rc.CACert)
+	}
+	for _, entry := range rc.Resolve {
+		args = append(args, "--resolve", entry)
+	}
+	if rc.AcceptCompression {
+		args = append(args, "--compressed")
+	}
+	if connectTimeout := cfg.EffectiveConnectTimeout(); connectTimeout > 0 {
+		args = append(args, "--connect-timeout", formatSeconds(connectTimeout))
+	}
+	if cfg.Timeout != UnsetTimeout && cfg.Timeout > 0 {
+		args = append(args, "--max-time", strconv.Itoa(int(cfg.Timeout)))
+	}
+	if rc.MaxDownloadBytes > 0 {
+		args = append(args, "--max-filesize", strconv.FormatInt(rc.MaxDownloadBytes, 10))
+	}
+	if rc.OutputFile != "" {
+		args = append(args, "--output", rc.OutputFile)
+	}
+	if rc.HeaderFile != "" {
+		args = append(args, "--dump-header", rc.HeaderFile)
+	}
+	if !shareable {
+		args = append(args, "--write-out", curlWriteOut)
+	}
+	for _, options := range rc.BackendOptions {
+		args = append(args, options...)
+	}
+	socket, target, isSocket, err := rc.UnixSocket()
+	if err != nil {
+		return nil, err
+	}
+	if isSocket {
+		return append(args, "--unix-socket", socket, target.String()), nil
+	}
+	return append(args, rc.Host.String()), nil
+}
+
+// The method ToCurlString returns a single curl command performing the request, with every
+// argument shell-quoted so it can be pasted into a terminal or a bug report. Unlike BuildCurlArgs,
+// the body is written inline with `--data-raw`, unless it is read from the BodyFile, and the
+// options vortex uses to parse the output of curl are left out, except for `--silent --show-error`
+// when the RequestConfig is quiet. The timeouts are those the request is executed with: the
+// Timeout of the RequestConfig when set, otherwise the ones of the configuration. FromCurl reads
+// it back.
+//
+// Parameters:
+//   - cfg: The configuration providing the request and connect timeouts.
+//
+// Returns:
+//   - The curl command, or an empty string if the request has no host.
+func (rc *RequestConfig) ToCurlString(cfg Config) string {
+	return rc.toCurlString(cfg, nil)
+}
+
+// The method ToRedactedCurlString returns the same command as ToCurlString, with the values of the
+// sensitive headers of the configuration, see SensitiveHeaderNames, and the passwords redacted as
+// RedactArgs does, so it can be shared without leaking them.
+//
+// Parameters:
+//   - cfg: The configuration providing the timeouts and the sensitive header names.
+//
+// Returns:
+//   - The curl command, or an empty string if the request has no host.
+func (rc *RequestConfig) ToRedactedCurlString(cfg Config) string {
+	return rc.toCurlString(cfg, cfg.SensitiveHeaderNames())
+}
+
+// toCurlString implements ToCurlString, redacting the arguments when sensitive is not nil.
+func (rc *RequestConfig) toCurlString(cfg Config, sensitive []string) string {
+	if rc.Timeout > 0 {
+		cfg.Timeout = rc.Timeout
+	}
+	args, err := buildCurlArgs(rc, cfg, true)
+	if err != nil {
+		return ""
+	}
+	if sensitive != nil {
+		args = RedactArgs("curl", args, sensitive)
+	}
+	quoted := make([]string, 0, len(args)+1)
+	quoted = append(quoted, "curl")
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellSafeChars are the characters that never need quoting in a POSIX shell.
+const shellSafeChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+=:,./-"
+
+// shellQuote quotes the argument for a POSIX shell, wrapping it in single quotes unless it only
+// holds safe characters. A single quote inside the argument closes the quoting, is escaped with a
+// backslash and reopens it.
+func shellQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, shellSafeChars) == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// The function ParseCurlWriteOut splits the output of a curl invocation built with BuildCurlArgs
+// into the response body and the metadata printed by the `-w` format. When the output does not
+// contain the metadata, for example because a backend option overrode `-w`, the whole output is
+// returned as the body and the metadata is empty.
+//
+// Parameters:
+//   - stdout: The standard output captured from curl.
+//
+// Returns:
+//   - The response body.
+//   - A map with the metadata variables, keyed by their curl name (e.g. "remote_ip").
+func ParseCurlWriteOut(stdout string) (string, map[string]string) {
+	meta := make(map[string]string)
+	idx := strings.LastIndex(stdout, curlWriteOutMarker)
+	if idx < 0 {
+		return stdout, meta
+	}
+	for _, line := range strings.Split(stdout[idx+len(curlWriteOutMarker):], "\n") {
+		if key, value, found := strings.Cut(line, "="); found {
+			meta[key] = value
+		}
+	}
+	return stdout[:idx], meta
+}
+
+// The method ApplyCurlWriteOut fills the result fields that curl reports through its `-w` format,
+// such as the status code, the remote IP, the final URL and the Timings, from the metadata returned
+// by ParseCurlWriteOut. The cumulative times printed by curl are turned into the duration of each
+// phase. Missing or malformed values leave the corresponding fields untouched.
+func (rr *RequestResult) ApplyCurlWriteOut(meta map[string]string) {
+	if code, err := strconv.Atoi(meta["http_code"]); err == nil {
+		rr.StatusCode = code
+	}
+	rr.RemoteIP = meta["remote_ip"]
+	rr.FinalURL = meta["url_effective"]
+	lookup, lookupOK := curlSeconds(meta["time_namelookup"])
+	connect, connectOK := curlSeconds(meta["time_connect"])
+	appConnect, appConnectOK := curlSeconds(meta["time_appconnect"])
+	if lookupOK {
+		rr.Timings.DNS = lookup
+	}
+	if connectOK && connect > 0 {
+		rr.Timings.Connect = connect - lookup
+	}
+	if connectOK && appConnectOK && appConnect > 0 {
+		rr.Timings.TLS = appConnect - connect
+	}
+	if ttfb, ok := curlSeconds(meta["time_starttransfer"]); ok {
+		rr.Timings.TTFB = ttfb
+	}
+	if total, ok := curlSeconds(meta["time_total"]); ok {
+		rr.Timings.Total = total
+	}
+}
+
+// curlSeconds parses a time printed by the curl `-w` format, a decimal number of seconds.
+func curlSeconds(value string) (time.Duration, bool) {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
@@ -0,0 +1,179 @@
+package data
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/larayavrs/vortex/pkg"
+	"github.com/pkg/errors"
+)
+
+// curlValueFlags lists the short curl flags understood by FromCurl that take a value, which may be
+// attached to the flag as in `-XPOST`.
+const curlValueFlags = "XHdub"
+
+// curlBooleanFlags lists the curl flags that never take a value. Unknown flags not listed here are
+// assumed to take the next argument as their value when it does not look like another flag.
+var curlBooleanFlags = map[string]bool{
+	"-s": true, "--silent": true,
+	"-S": true, "--show-error": true,
+	"-v": true, "--verbose": true,
+	"-i": true, "--include": true,
+	"-f": true, "--fail": true,
+	"-N": true, "--no-buffer": true,
+	"-#": true, "--progress-bar": true,
+	"--http1.0": true, "--http1.1": true, "--http2": true, "--http3": true,
+	"--tlsv1.2": true, "--tlsv1.3": true,
+}
+
+// The function FromCurl builds a RequestConfig from a curl command line, such as the ones copied
+// from the developer tools of a browser, making it the inverse of BuildCurlArgs. The command is
+// tokenized with TokenizeLine, after joining the lines continued with a trailing backslash, and
+// the leading `curl` program name is optional. The following flags are interpreted:
+//   - `-X`/`--request`: the method. `-I`/`--head` sends a HEAD request.
+//   - `-H`/`--header`: a request header. `-b`/`--cookie` adds a Cookie header.
+//   - `-d`/`--data`, `--data-raw`, `--data-binary`, `--data-ascii`: the body, read from a file when
+//     it starts with `@` (except for `--data-raw`). Several of them are joined with `&` and the
+//     method defaults to POST.
+//   - `-u`/`--user`: basic authentication credentials, sent as an Authorization header.
+//   - `-L`/`--location`, `-k`/`--insecure`, `-f`/`--fail` and `--compressed`: their RequestConfig
+//     counterparts.
+//   - `-g`/`--globoff`: ignored, since BuildCurlArgs always disables URL globbing.
+//   - `--url` or the positional argument: the target URL.
+//
+// Any other flag is kept, with its value, in the BackendOptions, and the backend is set to curl.
+//
+// Parameters:
+//   - cmd: The curl command line.
+//
+// Returns:
+//   - The RequestConfig described by the command.
+//   - An error if the command cannot be tokenized, a flag is missing its value, or the command
+//     does not hold exactly one valid URL.
+func FromCurl(cmd string) (*RequestConfig, error) {
+	cmd = strings.NewReplacer("\\\r\n", " ", "\\\n", " ").Replace(cmd)
+	tokens, err := pkg.TokenizeLine(cmd)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to tokenize the curl command")
+	}
+	if len(tokens) > 0 && filepath.Base(tokens[0]) == "curl" {
+		tokens = tokens[1:]
+	}
+	rc := &RequestConfig{Backend: "curl"}
+	var rawURL string
+	var data []string
+	for i := 0; i < len(tokens); i++ {
+		if bundled := tokens[i]; isBundledCurlFlags(bundled) {
+			// Unbundle `-sSL` into `-s` followed by `-SL`, which is unbundled in turn
+			rest := append([]string{"-" + bundled[2:]}, tokens[i+1:]...)
+			tokens = append(tokens[:i], bundled[:2])
+			tokens = append(tokens, rest...)
+		}
+		flag, value, attached := splitCurlFlag(tokens[i])
+		if flag == "" {
+			if rawURL != "" {
+				return nil, errors.Errorf("The curl command holds more than one URL: %s, %s", rawURL, tokens[i])
+			}
+			rawURL = tokens[i]
+			continue
+		}
+		if isCurlValueFlag(flag) && !attached {
+			if i+1 >= len(tokens) {
+				return nil, errors.Errorf("Missing value of the curl flag %s", flag)
+			}
+			i++
+			value = tokens[i]
+		}
+		switch flag {
+		case "-X", "--request":
+			rc.Method = strings.ToUpper(value)
+		case "-I", "--head":
+			rc.Method = http.MethodHead
+		case "-H", "--header":
+			rc.Headers = append(rc.Headers, value)
+		case "-b", "--cookie":
+			rc.Headers = append(rc.Headers, "Cookie: "+value)
+		case "-d", "--data", "--data-ascii", "--data-binary":
+			if strings.HasPrefix(value, "@") {
+				rc.BodyFile = strings.TrimPrefix(value, "@")
+				continue
+			}
+			data = append(data, value)
+		case "--data-raw":
+			data = append(data, value)
+		case "-u", "--user":
+			credentials := base64.StdEncoding.EncodeToString([]byte(value))
+			rc.Headers = append(rc.Headers, "Authorization: Basic "+credentials)
+		case "-L", "--location":
+			rc.FollowRedirects = true
+		case "-f", "--fail", "--fail-with-body":
+			rc.FailOnHTTPError = true
+		case "-k", "--insecure":
+			rc.InsecureSkipVerify = true
+		case "--compressed":
+			rc.AcceptCompression = true
+		case "-g", "--globoff":
+			// BuildCurlArgs always disables URL globbing
+		case "--url":
+			if rawURL != "" {
+				return nil, errors.Errorf("The curl command holds more than one URL: %s, %s", rawURL, value)
+			}
+			rawURL = value
+		default:
+			option := []string{tokens[i]}
+			if !attached && !curlBooleanFlags[flag] && i+1 < len(tokens) && !strings.HasPrefix(tokens[i+1], "-") && !strings.Contains(tokens[i+1], "://") {
+				i++
+				option = append(option, tokens[i])
+			}
+			rc.BackendOptions = append(rc.BackendOptions, option)
+		}
+	}
+	if rawURL == "" {
+		return nil, errors.New("The curl command holds no URL")
+	}
+	host, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid URL in the curl command")
+	}
+	rc.Host = host
+	if err := rc.NormalizeHost(); err != nil {
+		return nil, err
+	}
+	if len(data) > 0 {
+		rc.Body = strings.Split(strings.Join(data, "&"), "\n")
+	}
+	if rc.Method == "" && (len(data) > 0 || rc.BodyFile != "") {
+		rc.Method = http.MethodPost
+	}
+	return rc, nil
+}
+
+// isCurlValueFlag reports whether the flag interpreted by FromCurl takes a value.
+func isCurlValueFlag(flag string) bool {
+	switch flag {
+	case "--request", "--header", "--cookie", "--data", "--data-raw", "--data-binary", "--data-ascii", "--user", "--url":
+		return true
+	}
+	return len(flag) == 2 && strings.ContainsRune(curlValueFlags, rune(flag[1]))
+}
+
+// isBundledCurlFlags reports whether the argument bundles several short flags, as in `-sSL`.
+// Short flags taking a value end the bundle, so `-XPOST` is a single flag with its value.
+func isBundledCurlFlags(arg string) bool {
+	return len(arg) > 2 && arg[0] == '-' && arg[1] != '-' && !strings.ContainsRune(curlValueFlags, rune(arg[1]))
+}
+
+// splitCurlFlag splits a curl argument into the flag and its attached value, as in `-XPOST`. The
+// flag is empty when the argument is a positional one.
+func splitCurlFlag(arg string) (string, string, bool) {
+	if !strings.HasPrefix(arg, "-") || arg == "-" {
+		return "", "", false
+	}
+	if strings.HasPrefix(arg, "--") || len(arg) == 2 {
+		return arg, "", false
+	}
+	return arg[:2], arg[2:], true
+}
@@ -0,0 +1,56 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFromCurl(t *testing.T) {
+	tests := map[string]struct {
+		cmd  string
+		want RequestConfig
+	}{
+		"get": {
+			`curl 'https://example.com/users?page=2' -H 'Accept: application/json'`,
+			RequestConfig{Headers: []string{"Accept: application/json"}},
+		},
+		"post": {
+			"curl -X POST https://example.com/users \\\n  -H 'Content-Type: application/json' \\\n  --data '{\"name\": \"vortex\"}' -L",
+			RequestConfig{Method: "POST", Headers: []string{"Content-Type: application/json"}, Body: []string{`{"name": "vortex"}`}, FollowRedirects: true},
+		},
+		"implicit post": {
+			`curl -d a=1 -d b=2 https://example.com/form`,
+			RequestConfig{Method: "POST", Body: []string{"a=1&b=2"}},
+		},
+		"basic auth": {
+			`curl -sSL -u alice:secret https://example.com/me`,
+			RequestConfig{Headers: []string{"Authorization: Basic YWxpY2U6c2VjcmV0"}, FollowRedirects: true, BackendOptions: [][]string{{"-s"}, {"-S"}}},
+		},
+		"unknown flags": {
+			`curl --retry 3 --tcp-nodelay -XDELETE https://example.com/users/1`,
+			RequestConfig{Method: "DELETE", BackendOptions: [][]string{{"--retry", "3"}, {"--tcp-nodelay"}}},
+		},
+	}
+	for name, tt := range tests {
+		rc, err := FromCurl(tt.cmd)
+		if err != nil {
+			t.Errorf("%s: FromCurl() error = %v", name, err)
+			continue
+		}
+		if rc.Backend != "curl" || rc.Method != tt.want.Method || rc.FollowRedirects != tt.want.FollowRedirects {
+			t.Errorf("%s: FromCurl() Backend = %q, Method = %q, FollowRedirects = %v, want curl, %q, %v",
+				name, rc.Backend, rc.Method, rc.FollowRedirects, tt.want.Method, tt.want.FollowRedirects)
+		}
+		if !reflect.DeepEqual(rc.Headers, tt.want.Headers) || !reflect.DeepEqual(rc.Body, tt.want.Body) {
+			t.Errorf("%s: FromCurl() Headers = %q, Body = %q, want %q, %q", name, rc.Headers, rc.Body, tt.want.Headers, tt.want.Body)
+		}
+		if !reflect.DeepEqual(rc.BackendOptions, tt.want.BackendOptions) {
+			t.Errorf("%s: FromCurl() BackendOptions = %q, want %q", name, rc.BackendOptions, tt.want.BackendOptions)
+		}
+	}
+	for _, cmd := range []string{"curl -H 'Accept: */*'", "curl https://a.example.com https://b.example.com", "curl -H", `curl "https://example.com`} {
+		if _, err := FromCurl(cmd); err == nil {
+			t.Errorf("FromCurl(%q) succeeded, want an error", cmd)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package data
+
+import (
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/larayavrs/vortex/pkg"
+)
+
+func TestToCurlStringRoundTrip(t *testing.T) {
+	cmd := `curl --globoff --request POST --header 'Content-Type: application/json' ` +
+		`--header 'Authorization: Bearer token' --data-raw '{"name": "vortex"}' https://example.com/api?q=1`
+	rc, err := FromCurl(cmd)
+	if err != nil {
+		t.Fatalf("FromCurl() error = %v", err)
+	}
+	got := rc.ToCurlString(NewConfig())
+	gotArgs, err := pkg.TokenizeLine(got)
+	if err != nil {
+		t.Fatalf("TokenizeLine(%q) error = %v", got, err)
+	}
+	wantArgs, err := pkg.TokenizeLine(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotArgs, wantArgs) {
+		t.Errorf("ToCurlString() = %q, want the arguments of %q", gotArgs, wantArgs)
+	}
+	again, err := FromCurl(got)
+	if err != nil {
+		t.Fatalf("FromCurl(ToCurlString()) error = %v", err)
+	}
+	if !reflect.DeepEqual(again.Headers, rc.Headers) || !reflect.DeepEqual(again.Body, rc.Body) || again.Method != rc.Method {
+		t.Errorf("FromCurl(ToCurlString()) = %+v, want %+v", again, rc)
+	}
+}
+
+func TestToCurlStringConfig(t *testing.T) {
+	rc, err := FromCurl(`curl -H 'Authorization: Bearer token' https://example.com`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{Timeout: 30}
+	if got := rc.ToCurlString(cfg); !strings.Contains(got, "--max-time 30") {
+		t.Errorf("ToCurlString() = %q, want the timeout of the configuration", got)
+	}
+	rc.Timeout = 5
+	if got := rc.ToCurlString(cfg); !strings.Contains(got, "--max-time 5") {
+		t.Errorf("ToCurlString() = %q, want the timeout of the request", got)
+	}
+	if got := rc.ToCurlString(NewConfig()); strings.Contains(got, "***") {
+		t.Errorf("ToCurlString() = %q, want the header unredacted", got)
+	}
+	if got := rc.ToRedactedCurlString(NewConfig()); !strings.Contains(got, "'Authorization: Bearer ***'") || strings.Contains(got, "token") {
+		t.Errorf("ToRedactedCurlString() = %q, want the header redacted", got)
+	}
+}
+
+func TestBuildCurlArgsCompressed(t *testing.T) {
+	rc := &RequestConfig{Host: &url.URL{Scheme: "https", Host: "example.com"}, AcceptCompression: true}
+	args, err := BuildCurlArgs(rc, Config{})
+	if err != nil {
+		t.Fatalf("BuildCurlArgs() error = %v", err)
+	}
+	if !slices.Contains(args, "--compressed") {
+		t.Errorf("BuildCurlArgs() = %q, want --compressed", args)
+	}
+}
+
+func TestBuildCurlArgsArtifacts(t *testing.T) {
+	rc := &RequestConfig{Host: &url.URL{Scheme: "https", Host: "example.com"}, OutputFile: "out/body.json", HeaderFile: "out/headers.txt"}
+	args, err := BuildCurlArgs(rc, Config{})
+	if err != nil {
+		t.Fatalf("BuildCurlArgs() error = %v", err)
+	}
+	for flag, want := range map[string]string{"--output": rc.OutputFile, "--dump-header": rc.HeaderFile, "--write-out": curlWriteOut} {
+		if i := slices.Index(args, flag); i < 0 || args[i+1] != want {
+			t.Errorf("BuildCurlArgs() = %q, want %s %q", args, flag, want)
+		}
+	}
+	rc.OutputFile, rc.HeaderFile = "", ""
+	args, err = BuildCurlArgs(rc, Config{})
+	if err != nil {
+		t.Fatalf("BuildCurlArgs() error = %v", err)
+	}
+	if slices.Contains(args, "--output") || slices.Contains(args, "--dump-header") {
+		t.Errorf("BuildCurlArgs() = %q, want no artifact written", args)
+	}
+}
+
+func TestBuildCurlArgsIPv6Host(t *testing.T) {
+	tests := map[string]struct {
+		host, wantHost string
+	}{
+		"with port":    {"http://[::1]:8080/path", "[::1]:8080"},
+		"without port": {"http://[::1]/path", "[::1]"},
+	}
+	for name, tt := range tests {
+		rc, err := ParseTemplate("", "[Host]\n"+tt.host+"\n", Config{})
+		if err != nil {
+			t.Errorf("%s: ParseTemplate() error = %v", name, err)
+			continue
+		}
+		if rc.Host.Host != tt.wantHost {
+			t.Errorf("%s: Host.Host = %q, want %q", name, rc.Host.Host, tt.wantHost)
+		}
+		args, err := BuildCurlArgs(rc, Config{})
+		if err != nil {
+			t.Errorf("%s: BuildCurlArgs() error = %v", name, err)
+			continue
+		}
+		if !slices.Contains(args, "--globoff") || args[len(args)-1] != tt.host {
+			t.Errorf("%s: BuildCurlArgs() = %q, want --globoff and %s last", name, args, tt.host)
+		}
+	}
+}
+
+func TestBuildCurlArgsConnectTimeout(t *testing.T) {
+	tests := map[string]struct {
+		cfg  Config
+		want string
+	}{
+		"connect timeout": {Config{Timeout: 30, ConnectTimeout: 1500 * time.Millisecond}, "1.5"},
+		"total timeout":   {Config{Timeout: 30}, "30"},
+		"neither":         {Config{Timeout: UnsetTimeout}, ""},
+	}
+	for name, tt := range tests {
+		args, err := BuildCurlArgs(&RequestConfig{Host: &url.URL{Scheme: "https", Host: "example.com"}}, tt.cfg)
+		if err != nil {
+			t.Fatalf("%s: BuildCurlArgs() error = %v", name, err)
+		}
+		got := ""
+		if i := slices.Index(args, "--connect-timeout"); i >= 0 {
+			got = args[i+1]
+		}
+		if got != tt.want {
+			t.Errorf("%s: --connect-timeout = %q, want %q in %q", name, got, tt.want, args)
+		}
+	}
+}
+
+func TestBuildCurlArgsBody(t *testing.T) {
+	host := &url.URL{Scheme: "https", Host: "example.com"}
+	inline := &RequestConfig{Host: host, Method: "POST", Body: []string{"@not-a-file", "second line"}}
+	args, err := BuildCurlArgs(inline, Config{})
+	if err != nil {
+		t.Fatalf("BuildCurlArgs() error = %v", err)
+	}
+	if i := slices.Index(args, "--data-raw"); i < 0 || args[i+1] != "@not-a-file\nsecond line" || slices.Contains(args, "--data-binary") {
+		t.Errorf("BuildCurlArgs() of an inline body = %q, want it sent with --data-raw", args)
+	}
+
+	tempfile := &RequestConfig{Host: host, Method: "POST", Body: []string{"@not-a-file"}}
+	if err := tempfile.CreateBodyTempfile(); err != nil {
+		t.Fatalf("CreateBodyTempfile() error = %v", err)
+	}
+	defer tempfile.RemoveBodyTempfile(true)
+	args, err = BuildCurlArgs(tempfile, Config{})
+	if err != nil {
+		t.Fatalf("BuildCurlArgs() error = %v", err)
+	}
+	if i := slices.Index(args, "--data-binary"); i < 0 || args[i+1] != "@"+tempfile.TempfileName || slices.Contains(args, "--data-raw") {
+		t.Errorf("BuildCurlArgs() of a body file = %q, want --data-binary @%s", args, tempfile.TempfileName)
+	}
+
+	file := &RequestConfig{Host: host, Method: "POST", BodyFile: "/data/upload.json"}
+	args, err = BuildCurlArgs(file, Config{})
+	if err != nil {
+		t.Fatalf("BuildCurlArgs() error = %v", err)
+	}
+	if i := slices.Index(args, "--data-binary"); i < 0 || args[i+1] != "@/data/upload.json" {
+		t.Errorf("BuildCurlArgs() of a BodyFile = %q, want --data-binary @/data/upload.json", args)
+	}
+}
+
+func TestApplyCurlWriteOutTimings(t *testing.T) {
+	stdout := "body\n--vortex-write-out--\nhttp_code=200\nremote_ip=203.0.113.7\nurl_effective=https://example.com/\n" +
+		"time_namelookup=0.004000\ntime_connect=0.010000\ntime_appconnect=0.030000\ntime_starttransfer=0.050000\ntime_total=0.062500\n"
+	body, meta := ParseCurlWriteOut(stdout)
+	if body != "body" {
+		t.Errorf("ParseCurlWriteOut() body = %q, want %q", body, "body")
+	}
+	var rr RequestResult
+	rr.ApplyCurlWriteOut(meta)
+	want := Timings{
+		DNS:     4 * time.Millisecond,
+		Connect: 6 * time.Millisecond,
+		TLS:     20 * time.Millisecond,
+		TTFB:    50 * time.Millisecond,
+		Total:   62500 * time.Microsecond,
+	}
+	if rr.Timings != want || rr.StatusCode != 200 || rr.RemoteIP != "203.0.113.7" || rr.FinalURL != "https://example.com/" {
+		t.Errorf("ApplyCurlWriteOut() = %+v, want the status, address, URL and timings %+v", rr, want)
+	}
+
+	// Plain HTTP has no TLS handshake, which curl reports as zero
+	_, meta = ParseCurlWriteOut("\n--vortex-write-out--\ntime_namelookup=0.001000\ntime_connect=0.002000\ntime_appconnect=0.000000\ntime_total=bogus\n")
+	rr = RequestResult{}
+	rr.ApplyCurlWriteOut(meta)
+	if want := (Timings{DNS: time.Millisecond, Connect: time.Millisecond}); rr.Timings != want {
+		t.Errorf("ApplyCurlWriteOut() over HTTP = %+v, want %+v", rr.Timings, want)
+	}
+}
@@ -0,0 +1,189 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The method Diff compares the RequestResult with another one, such as the responses of the same
+// request sent to two environments, and describes what changed, one difference per line:
+//   - `status: 200 -> 500` when the status codes differ.
+//   - `header + Name: value`, `header - Name: value` and `header Name: old -> new` for the headers
+//     only the other result has, only this result has, or whose values differ.
+//   - When both bodies are JSON, `body $.path: old -> new`, `body + $.path: value` and
+//     `body - $.path: value` for the values that differ, ignoring the order of the object keys.
+//   - Otherwise, `body - line` and `body + line` for the lines of the body only this result or only
+//     the other one holds, in order.
+//
+// Parameters:
+//   - other: The result compared with this one, whose values are the new ones.
+//
+// Returns:
+//   - The differences, or an empty string when the results have the same status, headers and body.
+func (rr RequestResult) Diff(other RequestResult) string {
+	var lines []string
+	if rr.StatusCode != other.StatusCode {
+		lines = append(lines, fmt.Sprintf("status: %d -> %d", rr.StatusCode, other.StatusCode))
+	}
+	lines = append(lines, diffHeaders(rr, other)...)
+	lines = append(lines, diffBodies(rr.Stdout, other.Stdout)...)
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// diffHeaders describes the differences between the response headers of two results, sorted by
+// header name.
+func diffHeaders(before, after RequestResult) []string {
+	names := make(map[string]bool)
+	for name := range before.Headers {
+		names[name] = true
+	}
+	for name := range after.Headers {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	var lines []string
+	for _, name := range sorted {
+		oldValues, inOld := before.Headers[name]
+		newValues, inNew := after.Headers[name]
+		oldValue, newValue := strings.Join(oldValues, ", "), strings.Join(newValues, ", ")
+		switch {
+		case !inOld:
+			lines = append(lines, fmt.Sprintf("header + %s: %s", name, newValue))
+		case !inNew:
+			lines = append(lines, fmt.Sprintf("header - %s: %s", name, oldValue))
+		case oldValue != newValue:
+			lines = append(lines, fmt.Sprintf("header %s: %s -> %s", name, oldValue, newValue))
+		}
+	}
+	return lines
+}
+
+// diffBodies describes the differences between two response bodies, comparing their values when
+// both are JSON and their lines otherwise.
+func diffBodies(before, after string) []string {
+	if before == after {
+		return nil
+	}
+	var oldValue, newValue any
+	if json.Unmarshal([]byte(before), &oldValue) == nil && json.Unmarshal([]byte(after), &newValue) == nil {
+		return diffJSON("$", oldValue, newValue, nil)
+	}
+	return diffLines(strings.Split(before, "\n"), strings.Split(after, "\n"))
+}
+
+// diffJSON appends the differences between two decoded JSON values, found at the given path, to
+// the lines. Objects are compared key by key and arrays index by index.
+func diffJSON(path string, before, after any, lines []string) []string {
+	switch oldValue := before.(type) {
+	case map[string]any:
+		newValue, ok := after.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool)
+		for key := range oldValue {
+			keys[key] = true
+		}
+		for key := range newValue {
+			keys[key] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+		for _, key := range sorted {
+			lines = diffJSONMember(path+"."+key, oldValue, newValue, key, lines)
+		}
+		return lines
+	case []any:
+		newValue, ok := after.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < max(len(oldValue), len(newValue)); i++ {
+			itemPath := path + "[" + strconv.Itoa(i) + "]"
+			switch {
+			case i >= len(oldValue):
+				lines = append(lines, fmt.Sprintf("body + %s: %s", itemPath, encodeJSONValue(newValue[i])))
+			case i >= len(newValue):
+				lines = append(lines, fmt.Sprintf("body - %s: %s", itemPath, encodeJSONValue(oldValue[i])))
+			default:
+				lines = diffJSON(itemPath, oldValue[i], newValue[i], lines)
+			}
+		}
+		return lines
+	}
+	if !reflect.DeepEqual(before, after) {
+		lines = append(lines, fmt.Sprintf("body %s: %s -> %s", path, encodeJSONValue(before), encodeJSONValue(after)))
+	}
+	return lines
+}
+
+// diffJSONMember appends the differences of the member of two JSON objects to the lines.
+func diffJSONMember(path string, before, after map[string]any, key string, lines []string) []string {
+	oldMember, inOld := before[key]
+	newMember, inNew := after[key]
+	switch {
+	case !inOld:
+		return append(lines, fmt.Sprintf("body + %s: %s", path, encodeJSONValue(newMember)))
+	case !inNew:
+		return append(lines, fmt.Sprintf("body - %s: %s", path, encodeJSONValue(oldMember)))
+	}
+	return diffJSON(path, oldMember, newMember, lines)
+}
+
+// encodeJSONValue encodes a decoded JSON value back into compact JSON for the diff lines.
+func encodeJSONValue(value any) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+// diffLines describes the lines removed from before and added by after, in order, keeping their
+// longest common subsequence unchanged.
+func diffLines(before, after []string) []string {
+	// common[i][j] is the length of the longest common subsequence of before[i:] and after[j:]
+	common := make([][]int, len(before)+1)
+	for i := range common {
+		common[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+	var lines []string
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			i++
+			j++
+		case j == len(after) || (i < len(before) && common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, "body - "+before[i])
+			i++
+		default:
+			lines = append(lines, "body + "+after[j])
+			j++
+		}
+	}
+	return lines
+}
@@ -0,0 +1,54 @@
+package data
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRequestResultDiff(t *testing.T) {
+	tests := map[string]struct {
+		before, after RequestResult
+		want          string
+	}{
+		"identical": {
+			RequestResult{StatusCode: 200, Headers: http.Header{"X-Id": {"1"}}, Stdout: "ok"},
+			RequestResult{StatusCode: 200, Headers: http.Header{"X-Id": {"1"}}, Stdout: "ok"},
+			"",
+		},
+		"status": {
+			RequestResult{StatusCode: 200},
+			RequestResult{StatusCode: 503},
+			"status: 200 -> 503\n",
+		},
+		"headers": {
+			RequestResult{Headers: http.Header{"Content-Type": {"text/plain"}, "X-Old": {"1"}, "X-Same": {"a", "b"}}},
+			RequestResult{Headers: http.Header{"Content-Type": {"application/json"}, "X-New": {"2"}, "X-Same": {"a", "b"}}},
+			"header Content-Type: text/plain -> application/json\nheader + X-New: 2\nheader - X-Old: 1\n",
+		},
+		"reordered json keys": {
+			RequestResult{Stdout: `{"id": 1, "tags": ["a", "b"], "user": {"name": "vortex", "admin": false}}`},
+			RequestResult{Stdout: `{"user": {"admin": false, "name": "vortex"}, "tags": ["a", "b"], "id": 1}`},
+			"",
+		},
+		"json values": {
+			RequestResult{Stdout: `{"id": 1, "tags": ["a"], "old": null}`},
+			RequestResult{Stdout: `{"id": "1", "tags": ["a", "b"], "new": {"x": true}}`},
+			"body $.id: 1 -> \"1\"\nbody + $.new: {\"x\":true}\nbody - $.old: null\nbody + $.tags[1]: \"b\"\n",
+		},
+		"text lines": {
+			RequestResult{Stdout: "one\ntwo\nthree"},
+			RequestResult{Stdout: "one\n2\nthree\nfour"},
+			"body - two\nbody + 2\nbody + four\n",
+		},
+		"json and text": {
+			RequestResult{StatusCode: 200, Stdout: `{"ok": true}`},
+			RequestResult{StatusCode: 502, Stdout: "Bad Gateway"},
+			"status: 200 -> 502\nbody - {\"ok\": true}\nbody + Bad Gateway\n",
+		},
+	}
+	for name, tt := range tests {
+		if got := tt.before.Diff(tt.after); got != tt.want {
+			t.Errorf("%s: Diff() = %q, want %q", name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package data
+
+import (
+	"github.com/pkg/errors"
+)
+
+// The exit codes of the process, telling scripts why a request failed. The errors of each category
+// implement ExitCoder, and ExitCode maps an error to its code.
+const (
+	// ExitOK is the exit code of a successful run.
+	ExitOK = 0
+
+	// ExitFailure is the exit code of the failures outside of the other categories, such as an
+	// unreadable template file.
+	ExitFailure = 1
+
+	// ExitParseError is the exit code of a template that cannot be parsed, reported as a ParseError.
+	ExitParseError = 2
+
+	// ExitNoBackend is the exit code of a request whose backend is not installed.
+	ExitNoBackend = 3
+
+	// ExitRequestError is the exit code of a request that could not be sent or whose response could
+	// not be received, such as a network failure or a backend that cannot run.
+	ExitRequestError = 4
+
+	// ExitHTTPError is the exit code of a response with a 4xx or 5xx status code when the request
+	// sets FailOnHTTPError.
+	ExitHTTPError = 5
+
+	// ExitExpectationFailed is the exit code of a response that does not meet the expectations of
+	// the [Expect] section, reported as an ExpectationError.
+	ExitExpectationFailed = 6
+)
+
+// ExitCoder is implemented by the errors mapped to a specific exit code of the process.
+type ExitCoder interface {
+	error
+
+	// ExitCode returns the exit code of the process failing with the error.
+	ExitCode() int
+}
+
+// The function ExitCode returns the exit code of the process failing with the error. The first
+// error of the chain implementing ExitCoder, as found by errors.As, provides the code, so the
+// wrapped errors keep their category.
+//
+// Parameters:
+//   - err: The error the process fails with, or nil.
+//
+// Returns:
+//   - ExitOK for a nil error, the code of the ExitCoder of the chain, or ExitFailure otherwise.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var coder ExitCoder
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+	return ExitFailure
+}
+
+// ExpectationError is the error returned by CheckExpectations for a response that does not meet
+// the expectations of the [Expect] section.
+type ExpectationError struct {
+	// Err describes the failed expectation.
+	Err error
+}
+
+// The method Error describes the failed expectation.
+func (e *ExpectationError) Error() string {
+	return e.Err.Error()
+}
+
+// The method Unwrap returns the underlying error, so errors.Is and errors.As can inspect it.
+func (e *ExpectationError) Unwrap() error {
+	return e.Err
+}
+
+// The method ExitCode returns ExitExpectationFailed.
+func (e *ExpectationError) ExitCode() int {
+	return ExitExpectationFailed
+}
@@ -0,0 +1,336 @@
+package data
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultInterpolationOpen is the token that opens a variable reference when no custom
+	// interpolation syntax is configured, as in `${PORT}`.
+	DefaultInterpolationOpen = "${"
+
+	// DefaultInterpolationClose is the token that closes a variable reference when no custom
+	// interpolation syntax is configured.
+	DefaultInterpolationClose = "}"
+)
+
+// InterpolationSyntax describes the delimiters that surround a variable reference in a template.
+// Switching to a custom syntax (for example `%{VAR}` or `@@VAR@@`) avoids collisions with request
+// bodies that legitimately contain the default `${...}` sequence, which is then left untouched.
+type InterpolationSyntax struct {
+	// Open is the token that starts a variable reference. It must not be empty.
+	Open string
+
+	// Close is the token that ends a variable reference. It must not be empty.
+	Close string
+}
+
+// The method InterpolationDelims returns the opening and closing delimiters used to find
+// variable references during template expansion. When the Interpolation field is nil, or one
+// of its delimiters is empty, the defaults `${` and `}` are returned.
+func (c Config) InterpolationDelims() (string, string) {
+	if c.Interpolation == nil || c.Interpolation.Open == "" || c.Interpolation.Close == "" {
+		return DefaultInterpolationOpen, DefaultInterpolationClose
+	}
+	return c.Interpolation.Open, c.Interpolation.Close
+}
+
+// The function ExpandTemplate replaces every variable reference in the given template text with
+// the value of the matching environment variable. References are recognized using the delimiters
+// returned by `Config.InterpolationDelims`, so text written with any other syntax is copied
+// verbatim. Only references whose name is a valid identifier (letters, digits and underscores,
+// not starting with a digit) are expanded; anything else between the delimiters is left literal.
+// Variables that are not set expand to an empty string, and so do all variables in the
+// configuration returned by ExpansionConfig for a remote template. As in the shell, `${VAR:-default}` expands
+// to the literal default when the variable is unset or empty, and `${VAR:+alternate}` to the
+// literal alternate when it is set and not empty, and to nothing otherwise. The words cannot hold
+// the closing delimiter and are not expanded themselves. Doubling the first character of the
+// opening delimiter escapes it, so `$$` stands for a literal `$` and `$${HOME}` is kept as
+// `${HOME}`. Delimiters that already start with a doubled character, like `@@`, have no escape.
+//
+// Parameters:
+//   - tmpl: The raw template text to expand.
+//   - cfg: The configuration providing the interpolation syntax.
+//
+// Returns:
+//   - The expanded template text.
+//   - An error if a variable reference is opened but never closed.
+func ExpandTemplate(tmpl string, cfg Config) (string, error) {
+	openDelim, closeDelim := cfg.InterpolationDelims()
+	return expandReferences(tmpl, openDelim, closeDelim, cfg.getenv, false)
+}
+
+// expandBody expands the references of a body line like ExpandTemplate, keeping an opening
+// delimiter that is never closed, such as the `${` of a shell or JavaScript snippet, literally.
+func expandBody(line string, cfg Config) string {
+	openDelim, closeDelim := cfg.InterpolationDelims()
+	expanded, _ := expandReferences(line, openDelim, closeDelim, cfg.getenv, true)
+	return expanded
+}
+
+// getenv returns the value of the environment variable, or an empty string when the configuration
+// does not expand variables from the environment.
+func (c Config) getenv(name string) string {
+	if c.noEnvironment {
+		return ""
+	}
+	return os.Getenv(name)
+}
+
+// The method ExpansionConfig returns the configuration expanding the variable references of the
+// request. For a request parsed from a remote template, unless the configuration sets
+// RemoteEnvironment, it expands every reference as if the variable were not set, so
+// `${TOKEN:-anonymous}` expands to `anonymous` whatever the environment holds.
+//
+// Parameters:
+//   - cfg: The configuration the request is executed with.
+//
+// Returns:
+//   - The configuration to expand the variable references of the request with.
+func (rc *RequestConfig) ExpansionConfig(cfg Config) Config {
+	if rc.remote && !cfg.RemoteEnvironment {
+		cfg.noEnvironment = true
+	}
+	return cfg
+}
+
+// The method Interpolate expands the variable references of the request headers and body lines
+// with ExpandTemplate, so they can be templated the same way as the host, defaults included. It
+// must run before the body is written by CreateBodyTempfile, and it only expands the request once,
+// so calling it again does not unescape the literal `$` sequences a second time. A body read from
+// BodyFile is sent as is. The backend name and each of the backend options of the [Backend]
+// section are expanded as well, so `${VORTEX_BACKEND:-curl}` selects the backend per environment.
+// An option is expanded once tokenized, so a variable holding several flags is passed to the
+// backend as a single argument. The options are expanded into new slices, leaving those shared
+// with other requests untouched. The references of a remote template are expanded with the
+// configuration returned by ExpansionConfig. The expanded headers are then checked with
+// ValidateHeaders.
+//
+// Parameters:
+//   - cfg: The configuration providing the interpolation syntax.
+//
+// Returns:
+//   - An error if a header, backend name or backend option holds an unterminated variable
+//     reference, or if an expanded header is malformed. Body lines, which often hold shell or
+//     JavaScript snippets, keep an unterminated reference literally instead.
+func (rc *RequestConfig) Interpolate(cfg Config) error {
+	if rc.interpolated {
+		return nil
+	}
+	cfg = rc.ExpansionConfig(cfg)
+	backend, err := ExpandTemplate(rc.Backend, cfg)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to expand the backend %q", rc.Backend)
+	}
+	rc.Backend = strings.TrimSpace(backend)
+	// The options may be shared with other requests by ApplyDefaults, so they are expanded into new
+	// slices
+	expandedOptions := make([][]string, len(rc.BackendOptions))
+	for i, options := range rc.BackendOptions {
+		expandedOptions[i] = make([]string, len(options))
+		for j, option := range options {
+			expanded, err := ExpandTemplate(option, cfg)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to expand the backend option %q", option)
+			}
+			expandedOptions[i][j] = expanded
+		}
+	}
+	if rc.BackendOptions != nil {
+		rc.BackendOptions = expandedOptions
+	}
+	for i, header := range rc.Headers {
+		expanded, err := ExpandTemplate(header, cfg)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to expand header %q", header)
+		}
+		rc.Headers[i] = expanded
+	}
+	if err := rc.ValidateHeaders(); err != nil {
+		return err
+	}
+	for i, line := range rc.Body {
+		rc.Body[i] = expandBody(line, cfg)
+	}
+	rc.interpolated = true
+	return nil
+}
+
+// The function ExpandVariables expands the variable references in the values of a set of
+// variables, such as the variables of an environment file, with the syntax of ExpandTemplate. A
+// reference to another variable of the set is replaced by its expanded value, whatever the order
+// in which they are declared, while other references are resolved with the fallback, usually
+// os.Getenv. References that form a cycle, such as `A=${B}` and `B=${A}`, or `A=${A}`, are
+// rejected instead of being expanded forever.
+//
+// Parameters:
+//   - vars: The variables whose values are expanded. The map is not modified.
+//   - cfg: The configuration providing the interpolation syntax.
+//   - fallback: The function resolving the references to variables outside of the set.
+//
+// Returns:
+//   - A new map holding the expanded values of the variables.
+//   - An error naming the variables of a reference cycle, as in `A -> B -> A`, or if a value holds
+//     an unterminated reference.
+func ExpandVariables(vars map[string]string, cfg Config, fallback func(name string) string) (map[string]string, error) {
+	openDelim, closeDelim := cfg.InterpolationDelims()
+	expanded := make(map[string]string, len(vars))
+	// resolve expands the variable, path holding the variables being expanded that led to it
+	var resolve func(name string, path []string) (string, error)
+	resolve = func(name string, path []string) (string, error) {
+		if value, ok := expanded[name]; ok {
+			return value, nil
+		}
+		for i, visiting := range path {
+			if visiting == name {
+				cycle := append(append([]string{}, path[i:]...), name)
+				return "", errors.Errorf("Variable reference cycle detected: %s", strings.Join(cycle, " -> "))
+			}
+		}
+		path = append(path, name)
+		var refErr error
+		value, err := expandReferences(vars[name], openDelim, closeDelim, func(ref string) string {
+			if _, ok := vars[ref]; !ok || refErr != nil {
+				return fallback(ref)
+			}
+			value, err := resolve(ref, path)
+			if err != nil {
+				refErr = err
+			}
+			return value
+		}, false)
+		if refErr != nil {
+			return "", refErr
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "Failed to expand variable %s", name)
+		}
+		expanded[name] = value
+		return value, nil
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := resolve(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
+
+// escapeSequence returns the doubled first character of the opening delimiter, which stands for
+// a single literal copy of that character, or an empty string when the delimiter itself starts
+// with that sequence and escaping is therefore not possible.
+func escapeSequence(openDelim string) string {
+	r, size := utf8.DecodeRuneInString(openDelim)
+	if r == utf8.RuneError {
+		return ""
+	}
+	escape := openDelim[:size] + openDelim[:size]
+	if strings.HasPrefix(openDelim, escape) {
+		return ""
+	}
+	return escape
+}
+
+// expandReferences performs the actual scan over the text, delegating the resolution of each
+// well-formed reference name to the lookup function. An opening delimiter that is never closed is
+// an error, unless keepUnterminated is true, in which case the rest of the text is kept literally.
+func expandReferences(text, openDelim, closeDelim string, lookup func(name string) string, keepUnterminated bool) (string, error) {
+	var builder strings.Builder
+	builder.Grow(len(text))
+	escape := escapeSequence(openDelim)
+	pos := 0
+	for {
+		start := strings.Index(text[pos:], openDelim)
+		if escape != "" {
+			if escaped := strings.Index(text[pos:], escape); escaped >= 0 && (start < 0 || escaped <= start) {
+				builder.WriteString(text[pos : pos+escaped])
+				builder.WriteString(escape[:len(escape)/2])
+				pos += escaped + len(escape)
+				continue
+			}
+		}
+		if start < 0 {
+			builder.WriteString(text[pos:])
+			break
+		}
+		start += pos
+		builder.WriteString(text[pos:start])
+		nameStart := start + len(openDelim)
+		end := strings.Index(text[nameStart:], closeDelim)
+		if end < 0 && keepUnterminated {
+			builder.WriteString(text[start:])
+			break
+		}
+		if end < 0 {
+			return "", errors.Errorf("Unterminated variable reference at position %d", start)
+		}
+		end += nameStart
+		name, operator, word := splitReference(text[nameStart:end])
+		if !isInterpolationName(name) {
+			// Not a reference we understand, keep the opening delimiter and continue scanning after it
+			builder.WriteString(openDelim)
+			pos = nameStart
+			continue
+		}
+		value := lookup(name)
+		switch {
+		case operator == defaultOperator && value == "":
+			value = word
+		case operator == alternateOperator && value != "":
+			value = word
+		case operator == alternateOperator:
+			value = ""
+		}
+		builder.WriteString(value)
+		pos = end + len(closeDelim)
+	}
+	return builder.String(), nil
+}
+
+// Operators of a variable reference, written between the name and a literal word as in the shell.
+const (
+	// defaultOperator substitutes the word when the variable is unset or empty, as in
+	// `${TOKEN:-anonymous}`.
+	defaultOperator = ":-"
+
+	// alternateOperator substitutes the word when the variable is set and not empty, and nothing
+	// otherwise, as in `${DEBUG:+--verbose}`.
+	alternateOperator = ":+"
+)
+
+// splitReference splits the text between the delimiters of a reference into the variable name,
+// the operator and the word following it, both empty when the reference has no operator.
+func splitReference(reference string) (string, string, string) {
+	for _, operator := range []string{defaultOperator, alternateOperator} {
+		if name, word, found := strings.Cut(reference, operator); found && isInterpolationName(name) {
+			return name, operator, word
+		}
+	}
+	return reference, "", ""
+}
+
+// isInterpolationName reports whether the given name is a valid variable identifier.
+func isInterpolationName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
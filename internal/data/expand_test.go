@@ -0,0 +1,105 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInterpolateLeavesSharedBackendOptions(t *testing.T) {
+	t.Setenv("VORTEX_TEST_TOKEN", "secret")
+	defaults := &RequestConfig{Backend: "curl", BackendOptions: [][]string{{"--header", "X: a$${VORTEX_TEST_TOKEN} ${VORTEX_TEST_TOKEN}"}}}
+	first, second := &RequestConfig{}, &RequestConfig{}
+	ApplyDefaults(first, defaults)
+	ApplyDefaults(second, defaults)
+	for _, rc := range []*RequestConfig{first, second} {
+		if err := rc.Interpolate(Config{}); err != nil {
+			t.Fatalf("Interpolate() error = %v", err)
+		}
+		want := [][]string{{"--header", "X: a${VORTEX_TEST_TOKEN} secret"}}
+		if !reflect.DeepEqual(rc.BackendOptions, want) {
+			t.Errorf("BackendOptions = %q, want %q", rc.BackendOptions, want)
+		}
+	}
+	if want := "X: a$${VORTEX_TEST_TOKEN} ${VORTEX_TEST_TOKEN}"; defaults.BackendOptions[0][1] != want {
+		t.Errorf("the default options are modified to %q", defaults.BackendOptions[0][1])
+	}
+}
+
+func TestInterpolateHeaderDefaults(t *testing.T) {
+	t.Setenv("VORTEX_TEST_SET", "token")
+	t.Setenv("VORTEX_TEST_EMPTY", "")
+	rc := &RequestConfig{
+		Headers: []string{
+			"X-Unset: ${VORTEX_TEST_UNSET:-anonymous}",
+			"X-Empty: ${VORTEX_TEST_EMPTY:-anonymous}",
+			"X-Set: ${VORTEX_TEST_SET:-anonymous}",
+			"X-Alternate: ${VORTEX_TEST_SET:+present}",
+			"X-No-Alternate: ${VORTEX_TEST_UNSET:+present}",
+		},
+		Body: []string{`{"user": "${VORTEX_TEST_UNSET:-guest}"}`},
+	}
+	if err := rc.Interpolate(Config{}); err != nil {
+		t.Fatalf("Interpolate() error = %v", err)
+	}
+	want := []string{
+		"X-Unset: anonymous",
+		"X-Empty: anonymous",
+		"X-Set: token",
+		"X-Alternate: present",
+		"X-No-Alternate: ",
+	}
+	if !reflect.DeepEqual(rc.Headers, want) {
+		t.Errorf("Headers = %q, want %q", rc.Headers, want)
+	}
+	if want := []string{`{"user": "guest"}`}; !reflect.DeepEqual(rc.Body, want) {
+		t.Errorf("Body = %q, want %q", rc.Body, want)
+	}
+}
+
+func TestInterpolateKeepsUnterminatedBodyReferences(t *testing.T) {
+	t.Setenv("VORTEX_TEST_ID", "42")
+	rc := &RequestConfig{
+		Body: []string{
+			`const s = "${" + name;`,
+			`{"id": "${VORTEX_TEST_ID}", "price": "$${VORTEX_TEST_ID}", "open": "${VORTEX_TEST_ID"}`,
+		},
+	}
+	if err := rc.Interpolate(Config{}); err != nil {
+		t.Fatalf("Interpolate() error = %v", err)
+	}
+	want := []string{
+		`const s = "${" + name;`,
+		`{"id": "42", "price": "${VORTEX_TEST_ID}", "open": "${VORTEX_TEST_ID"}`,
+	}
+	if !reflect.DeepEqual(rc.Body, want) {
+		t.Errorf("Body = %q, want %q", rc.Body, want)
+	}
+	rc = &RequestConfig{Headers: []string{"X-Id: ${VORTEX_TEST_ID"}}
+	if err := rc.Interpolate(Config{}); err == nil {
+		t.Error("Interpolate() of an unterminated header reference succeeded, want an error")
+	}
+}
+
+func TestExpandTemplateCustomSyntax(t *testing.T) {
+	t.Setenv("VORTEX_TEST_HOST", "example.com")
+	tests := map[string]struct {
+		syntax     *InterpolationSyntax
+		tmpl, want string
+	}{
+		"default":        {nil, "https://${VORTEX_TEST_HOST}/", "https://example.com/"},
+		"percent":        {&InterpolationSyntax{Open: "%{", Close: "}"}, `https://%{VORTEX_TEST_HOST}/ {"a": "${b}"}`, `https://example.com/ {"a": "${b}"}`},
+		"percent escape": {&InterpolationSyntax{Open: "%{", Close: "}"}, "%%{VORTEX_TEST_HOST}", "%{VORTEX_TEST_HOST}"},
+		"at signs":       {&InterpolationSyntax{Open: "@@", Close: "@@"}, "https://@@VORTEX_TEST_HOST@@/${VORTEX_TEST_HOST}", "https://example.com/${VORTEX_TEST_HOST}"},
+		"half syntax":    {&InterpolationSyntax{Open: "%{"}, "https://${VORTEX_TEST_HOST}/%{VORTEX_TEST_HOST}", "https://example.com/%{VORTEX_TEST_HOST}"},
+	}
+	for name, tt := range tests {
+		got, err := ExpandTemplate(tt.tmpl, Config{Interpolation: tt.syntax})
+		if err != nil {
+			t.Errorf("%s: ExpandTemplate() error = %v", name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: ExpandTemplate() = %q, want %q", name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package data
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/larayavrs/vortex/pkg"
+	"github.com/pkg/errors"
+)
+
+// expectSnippetLength is the number of runes of the response body quoted when a body expectation
+// fails.
+const expectSnippetLength = 80
+
+// The method CheckExpectations checks a response against the assertions of the [Expect] section
+// of the template the RequestConfig was parsed from. The status code is compared with ExpectStatus,
+// the response body must contain ExpectBody and match ExpectBodyRegex, and it is validated against
+// the ExpectSchema JSON Schema, when they are set.
+//
+// Parameters:
+//   - rr: The result of the request.
+//
+// Returns:
+//   - An error if the schema cannot be read, or an ExpectationError if the response does not meet
+//     the expectations. A failed body expectation quotes the beginning of the body.
+func (rc *RequestConfig) CheckExpectations(rr RequestResult) error {
+	if rc.ExpectStatus != 0 && rr.StatusCode != rc.ExpectStatus {
+		return &ExpectationError{Err: errors.Errorf("Expectation failed, expected status %d but got %d", rc.ExpectStatus, rr.StatusCode)}
+	}
+	if rc.ExpectBody != "" && !strings.Contains(rr.Stdout, rc.ExpectBody) {
+		return &ExpectationError{Err: errors.Errorf("Expectation failed, the body does not contain %q: %q", rc.ExpectBody, bodySnippet(rr.Stdout))}
+	}
+	if rc.ExpectBodyRegex != "" {
+		pattern, err := regexp.Compile(rc.ExpectBodyRegex)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid body regular expression %q", rc.ExpectBodyRegex)
+		}
+		if !pattern.MatchString(rr.Stdout) {
+			return &ExpectationError{Err: errors.Errorf("Expectation failed, the body does not match %q: %q", rc.ExpectBodyRegex, bodySnippet(rr.Stdout))}
+		}
+	}
+	if rc.ExpectSchema == "" {
+		return nil
+	}
+	schema, err := os.ReadFile(rc.ExpectSchema)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to read the JSON schema: %s", rc.ExpectSchema)
+	}
+	if err := rr.ValidateJSONSchema(schema); err != nil {
+		return &ExpectationError{Err: errors.Wrapf(err, "Expectation failed for schema %s", rc.ExpectSchema)}
+	}
+	return nil
+}
+
+// bodySnippet returns the beginning of the body, ellipsized, to quote it in an error.
+func bodySnippet(body string) string {
+	return pkg.Ellipsize(0, expectSnippetLength, body)
+}
@@ -0,0 +1,46 @@
+package data
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckExpectationsBody(t *testing.T) {
+	body := `{"status": "ok", "id": 42, "padding": "` + strings.Repeat("x", 200) + `"}`
+	tests := map[string]struct {
+		tmpl, want string
+	}{
+		"substring match":    {"[Expect]\nbody = \"status\": \"ok\"\n", ""},
+		"substring mismatch": {"[Expect]\nbody = \"status\": \"failed\"\n", `the body does not contain "\"status\": \"failed\"": "{\"status\": \"ok\"`},
+		"regex match":        {"[Expect]\nbody_regex = \"id\": \\d+\n", ""},
+		"regex mismatch":     {"[Expect]\nbody_regex = ^\\[\n", `the body does not match "^\\["`},
+	}
+	for name, tt := range tests {
+		rc, err := ParseTemplate("expect.ini", "[Host]\nhttps://example.com\n"+tt.tmpl, Config{})
+		if err != nil {
+			t.Errorf("%s: ParseTemplate() error = %v", name, err)
+			continue
+		}
+		err = rc.CheckExpectations(RequestResult{StatusCode: 200, Stdout: body})
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%s: CheckExpectations() error = %v", name, err)
+			}
+			continue
+		}
+		var expectErr *ExpectationError
+		if !errors.As(err, &expectErr) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: CheckExpectations() error = %v, want an ExpectationError with %q", name, err, tt.want)
+			continue
+		}
+		// The snippet of the body is ellipsized
+		if strings.Contains(err.Error(), strings.Repeat("x", 100)) || !strings.HasSuffix(err.Error(), `..."`) {
+			t.Errorf("%s: CheckExpectations() error = %v, want an ellipsized snippet of the body", name, err)
+		}
+	}
+	_, err := ParseTemplate("expect.ini", "[Host]\nhttps://example.com\n[Expect]\nbody_regex = ([a-z]\n", Config{})
+	if err == nil || !strings.Contains(err.Error(), "Invalid [Expect] body_regex") {
+		t.Errorf("ParseTemplate() of an invalid regex error = %v, want it rejected at parse time", err)
+	}
+}
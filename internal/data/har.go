@@ -0,0 +1,96 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// harDocument is the subset of the HTTP Archive (HAR) format read by FromHAR.
+type harDocument struct {
+	Log struct {
+		Entries []struct {
+			Request harRequest `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// harRequest is the request of a HAR entry.
+type harRequest struct {
+	Method   string         `json:"method"`
+	URL      string         `json:"url"`
+	Headers  []harNameValue `json:"headers"`
+	PostData *struct {
+		MimeType string         `json:"mimeType"`
+		Text     string         `json:"text"`
+		Params   []harNameValue `json:"params"`
+	} `json:"postData"`
+}
+
+// harNameValue is a name and value pair, used by HAR for headers and form parameters.
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// The function FromHAR decodes an HTTP Archive (HAR), as exported from the network panel of a
+// browser, and builds one RequestConfig per recorded request, in the order of the archive. Entries
+// whose URL does not use the http or https scheme, such as websockets or `data:` URLs, are skipped.
+// The HTTP/2 pseudo-headers, whose name starts with `:`, are dropped since they are derived from
+// the method and URL. The body is the posted text, or the posted form parameters joined with `&`
+// when the archive only recorded those.
+//
+// Parameters:
+//   - r: The reader providing the HAR JSON document.
+//
+// Returns:
+//   - A slice with the RequestConfig of every http entry.
+//   - An error if the document cannot be decoded or an entry holds an invalid URL.
+func FromHAR(r io.Reader) ([]*RequestConfig, error) {
+	var doc harDocument
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, errors.Wrap(err, "Failed to decode the HAR document")
+	}
+	var configs []*RequestConfig
+	for i, entry := range doc.Log.Entries {
+		req := entry.Request
+		host, err := url.Parse(req.URL)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid URL in HAR entry %d", i)
+		}
+		if scheme := strings.ToLower(host.Scheme); scheme != "http" && scheme != "https" {
+			continue
+		}
+		rc := &RequestConfig{
+			Host:   host,
+			Method: strings.ToUpper(req.Method),
+		}
+		if err := rc.NormalizeHost(); err != nil {
+			return nil, errors.Wrapf(err, "Invalid URL in HAR entry %d", i)
+		}
+		for _, header := range req.Headers {
+			if strings.HasPrefix(header.Name, ":") {
+				continue
+			}
+			rc.Headers = append(rc.Headers, header.Name+": "+header.Value)
+		}
+		if req.PostData != nil {
+			body := req.PostData.Text
+			if body == "" && len(req.PostData.Params) > 0 {
+				params := make([]string, 0, len(req.PostData.Params))
+				for _, param := range req.PostData.Params {
+					params = append(params, url.QueryEscape(param.Name)+"="+url.QueryEscape(param.Value))
+				}
+				body = strings.Join(params, "&")
+			}
+			if body != "" {
+				rc.Body = strings.Split(body, "\n")
+			}
+		}
+		configs = append(configs, rc)
+	}
+	return configs, nil
+}
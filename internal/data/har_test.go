@@ -0,0 +1,63 @@
+package data
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const harFixture = `{
+  "log": {
+    "version": "1.2",
+    "entries": [
+      {"request": {
+        "method": "get",
+        "url": "https://example.com/users?page=2",
+        "headers": [{"name": ":authority", "value": "example.com"}, {"name": "Accept", "value": "application/json"}]
+      }},
+      {"request": {"method": "GET", "url": "wss://example.com/socket", "headers": []}},
+      {"request": {
+        "method": "POST",
+        "url": "https://example.com/users",
+        "headers": [{"name": "Content-Type", "value": "application/json"}],
+        "postData": {"mimeType": "application/json", "text": "{\n  \"name\": \"vortex\"\n}"}
+      }},
+      {"request": {
+        "method": "POST",
+        "url": "https://example.com/login",
+        "headers": [],
+        "postData": {"mimeType": "application/x-www-form-urlencoded", "params": [{"name": "user", "value": "a b"}, {"name": "pass", "value": "&"}]}
+      }}
+    ]
+  }
+}`
+
+func TestFromHAR(t *testing.T) {
+	configs, err := FromHAR(strings.NewReader(harFixture))
+	if err != nil {
+		t.Fatalf("FromHAR() error = %v", err)
+	}
+	if len(configs) != 3 {
+		t.Fatalf("FromHAR() = %d requests, want the 3 http entries", len(configs))
+	}
+	want := []struct {
+		method, host string
+		headers      []string
+		body         []string
+	}{
+		{"GET", "https://example.com/users?page=2", []string{"Accept: application/json"}, nil},
+		{"POST", "https://example.com/users", []string{"Content-Type: application/json"}, []string{"{", `  "name": "vortex"`, "}"}},
+		{"POST", "https://example.com/login", nil, []string{"user=a+b&pass=%26"}},
+	}
+	for i, rc := range configs {
+		if rc.Method != want[i].method || rc.Host.String() != want[i].host {
+			t.Errorf("entry %d = %s %s, want %s %s", i, rc.Method, rc.Host, want[i].method, want[i].host)
+		}
+		if !reflect.DeepEqual(rc.Headers, want[i].headers) || !reflect.DeepEqual(rc.Body, want[i].body) {
+			t.Errorf("entry %d Headers = %q, Body = %q, want %q, %q", i, rc.Headers, rc.Body, want[i].headers, want[i].body)
+		}
+	}
+	if _, err := FromHAR(strings.NewReader("{not json")); err == nil {
+		t.Error("FromHAR() of an invalid document succeeded, want an error")
+	}
+}
@@ -0,0 +1,291 @@
+package data
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultSensitiveHeaders lists the header names whose values are considered secrets, such as
+// credentials and session cookies. Header names are compared case-insensitively.
+var DefaultSensitiveHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"Proxy-Authorization",
+}
+
+// The function SplitHeader splits a header line of the form `Name: value` into its name and
+// value, trimming the surrounding whitespace of both parts.
+//
+// Parameters:
+//   - line: The header line to split.
+//
+// Returns:
+//   - The header name.
+//   - The header value, which may be empty.
+//   - A boolean that is false when the line has no `:` separator.
+func SplitHeader(line string) (string, string, bool) {
+	name, value, found := strings.Cut(line, ":")
+	if !found {
+		return strings.TrimSpace(line), "", false
+	}
+	return strings.TrimSpace(name), strings.TrimSpace(value), true
+}
+
+// The method ValidateHeaders checks that every header of the RequestConfig is a `Name: value` line
+// whose name is a valid RFC 7230 token, made of letters, digits and the symbols allowed by the
+// RFC, such as `-`, `_` and `.`, and whose value holds no line break. An empty value, as in
+// `X-Debug:`, is valid. Interpolate runs it once the variable references of the headers are
+// expanded, since a header such as `${AUTH_HEADER}` is only well-formed afterwards.
+//
+// Returns:
+//   - An error listing the malformed header lines, or nil when they are all valid.
+func (rc *RequestConfig) ValidateHeaders() error {
+	return validateHeaderLines(rc.Headers, "")
+}
+
+// validateHeaderLines checks the header lines like ValidateHeaders, skipping those holding a
+// variable reference opened by openDelim, which are checked once interpolated, unless it is empty.
+func validateHeaderLines(headers []string, openDelim string) error {
+	var problems []string
+	for _, header := range headers {
+		if openDelim != "" && strings.Contains(header, openDelim) {
+			continue
+		}
+		if problem := headerProblem(header); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("Malformed headers, expected Name: value:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// headerProblem describes what makes the header line malformed, as checked by ValidateHeaders, or
+// returns an empty string when it is valid.
+func headerProblem(header string) string {
+	name, value, found := SplitHeader(header)
+	switch {
+	case !found:
+		return fmt.Sprintf("%q is missing the : separator", header)
+	case !isHeaderToken(name):
+		return fmt.Sprintf("%q has an invalid name %q", header, name)
+	case strings.ContainsAny(value, "\r\n\x00"):
+		return fmt.Sprintf("%q has a line break in its value", header)
+	}
+	return ""
+}
+
+// The method ApplyDefaultHeaders merges default headers, such as those of the machine-wide headers
+// file, beneath the headers of the RequestConfig: a default header is only added when the request
+// sets no header of the same name, compared case-insensitively. Nothing is added when the request
+// sets NoDefaultHeaders. Applying the same defaults again has no effect.
+//
+// Parameters:
+//   - defaults: The default `Name: value` header lines.
+func (rc *RequestConfig) ApplyDefaultHeaders(defaults []string) {
+	if rc.NoDefaultHeaders || len(defaults) == 0 {
+		return
+	}
+	rc.Headers = mergeHeaders(defaults, rc.Headers)
+}
+
+// isHeaderToken reports whether the header name is a non-empty RFC 7230 token.
+func isHeaderToken(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		isAlnum := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if !isAlnum && !strings.ContainsRune("!#$%&'*+-.^_`|~", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isSensitiveHeader reports whether the header name is part of the given sensitive set.
+func isSensitiveHeader(name string, sensitive []string) bool {
+	for _, candidate := range sensitive {
+		if strings.EqualFold(name, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactedValue replaces the secret part of a redacted value.
+const redactedValue = "***"
+
+// The method SensitiveHeaderNames returns the header names whose values are redacted whenever a
+// command or request is printed. When the SensitiveHeaders field is nil, DefaultSensitiveHeaders
+// is returned.
+func (c Config) SensitiveHeaderNames() []string {
+	if c.SensitiveHeaders == nil {
+		return DefaultSensitiveHeaders
+	}
+	return c.SensitiveHeaders
+}
+
+// The function RedactHeader masks the value of a `Name: value` header line when the name is one of
+// the sensitive header names. An authentication scheme such as `Bearer` or `Basic` is kept so the
+// printed header remains recognizable, e.g. `Authorization: Bearer ***`. Other headers are
+// returned unchanged.
+//
+// Parameters:
+//   - line: The header line to redact.
+//   - sensitive: The header names whose values must be masked, compared case-insensitively.
+//
+// Returns:
+//   - The header line with its value masked if it is sensitive.
+func RedactHeader(line string, sensitive []string) string {
+	name, value, found := SplitHeader(line)
+	if !found || value == "" || !isSensitiveHeader(name, sensitive) {
+		return line
+	}
+	return name + ": " + redactHeaderValue(value)
+}
+
+// The function RedactOutputLine masks the value of a sensitive header printed on a line of the
+// diagnostics of a backend, such as the `> Authorization: Bearer token` lines of `curl -v`, as
+// RedactHeader does. The `> ` and `< ` markers of the request and response lines are kept, and
+// other lines are returned unchanged.
+//
+// Parameters:
+//   - line: The line printed by the backend.
+//   - sensitive: The header names whose values must be masked.
+//
+// Returns:
+//   - The line with the value of its header masked if it is sensitive.
+func RedactOutputLine(line string, sensitive []string) string {
+	for _, marker := range []string{"> ", "< "} {
+		if header, found := strings.CutPrefix(line, marker); found {
+			return marker + RedactHeader(header, sensitive)
+		}
+	}
+	return RedactHeader(line, sensitive)
+}
+
+// redactHeaderValue masks a sensitive header value, keeping its authentication scheme.
+func redactHeaderValue(value string) string {
+	if scheme, _, hasScheme := strings.Cut(value, " "); hasScheme {
+		return scheme + " " + redactedValue
+	}
+	return redactedValue
+}
+
+// passwordOptions are the `--name=value` options of the backends whose whole value is a secret.
+var passwordOptions = []string{"--password=", "--http-password=", "--proxy-password="}
+
+// The function RedactArgs returns a copy of the arguments of a backend suitable for printing, in
+// which the values of the sensitive headers are masked with RedactHeader, along with passwords.
+// The headers are recognized in the form the backend passes them: `-H`/`--header` followed by the
+// header for curl and the custom backends, `--header=Name: value` for wget, and positional
+// `Name:value` request items for httpie. The password of `-u`/`--user` credentials, and of the
+// httpie `-a`/`--auth` ones, is masked, as well as the value of the wget `--password=` options
+// and the password of the URLs. The original slice is left untouched so the real arguments are
+// still the ones executed.
+//
+// Parameters:
+//   - backend: The name of the backend the arguments are built for.
+//   - args: The backend arguments to redact.
+//   - sensitive: The header names whose values must be masked.
+//
+// Returns:
+//   - A redacted copy of the arguments.
+func RedactArgs(backend string, args []string, sensitive []string) []string {
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+	for i := 0; i < len(redacted); i++ {
+		arg := redacted[i]
+		hasValue := i+1 < len(redacted)
+		switch {
+		case (arg == "-H" || arg == "--header") && hasValue:
+			redacted[i+1] = RedactHeader(redacted[i+1], sensitive)
+			i++
+		case (arg == "-u" || arg == "--user") && hasValue, backend == "httpie" && (arg == "-a" || arg == "--auth") && hasValue:
+			redacted[i+1] = redactCredentials(redacted[i+1])
+			i++
+		case strings.HasPrefix(arg, "--header="):
+			redacted[i] = "--header=" + RedactHeader(strings.TrimPrefix(arg, "--header="), sensitive)
+		case hasPasswordOption(arg):
+			option, _, _ := strings.Cut(arg, "=")
+			redacted[i] = option + "=" + redactedValue
+		case strings.Contains(arg, "://"):
+			if u, err := url.Parse(arg); err == nil && u.User != nil {
+				redacted[i] = u.Redacted()
+			}
+		case backend == "httpie" && !strings.HasPrefix(arg, "-"):
+			redacted[i] = redactHTTPieItem(arg, sensitive)
+		}
+	}
+	return redacted
+}
+
+// hasPasswordOption reports whether the argument is one of the passwordOptions.
+func hasPasswordOption(arg string) bool {
+	for _, option := range passwordOptions {
+		if strings.HasPrefix(arg, option) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactCredentials masks the password of `user:password` credentials.
+func redactCredentials(credentials string) string {
+	if user, _, found := strings.Cut(credentials, ":"); found {
+		return user + ":" + redactedValue
+	}
+	return credentials
+}
+
+// redactHTTPieItem masks the value of an httpie `Name:value` header request item when the header
+// is sensitive. The other request items, such as `key==value` query parameters, are returned
+// unchanged.
+func redactHTTPieItem(item string, sensitive []string) string {
+	name, value, found := strings.Cut(item, ":")
+	if !found || value == "" || strings.HasPrefix(value, "=") || !isSensitiveHeader(name, sensitive) {
+		return item
+	}
+	return name + ":" + redactHeaderValue(value)
+}
+
+// The function ParseResponseHeaders extracts the headers of the last HTTP response printed in the
+// given text, such as the output of `curl --head` or the `--server-response` lines wget writes to
+// stderr. Every status line starting with `HTTP/` opens a new header block, which ends at the first
+// blank line or line that is not a `Name: value` header, so redirects only keep the final response.
+//
+// Parameters:
+//   - text: The text holding the printed status lines and headers.
+//
+// Returns:
+//   - The headers of the last response, or nil if no status line was found.
+func ParseResponseHeaders(text string) http.Header {
+	var headers http.Header
+	inBlock := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "HTTP/") {
+			headers = make(http.Header)
+			inBlock = true
+			continue
+		}
+		if !inBlock {
+			continue
+		}
+		name, value, found := SplitHeader(line)
+		if !found || name == "" || strings.ContainsAny(name, " \t") {
+			inBlock = false
+			continue
+		}
+		headers.Add(name, value)
+	}
+	return headers
+}
@@ -0,0 +1,280 @@
+package data
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// HistoryFileVariable is the environment variable naming the history file, overriding the default
+// `vortex/history.jsonl` file of the user configuration directory.
+const HistoryFileVariable = "VORTEX_HISTORY_FILE"
+
+// HistoryEntry records an executed request in the history.
+type HistoryEntry struct {
+	// Time is when the request was executed.
+	Time time.Time `json:"time"`
+
+	// Method is the HTTP method of the request.
+	Method string `json:"method"`
+
+	// URL is the requested URL, with its password and the values of its query parameters redacted.
+	URL string `json:"url"`
+
+	// Headers are the request headers, with the values of sensitive headers redacted.
+	Headers []string `json:"headers,omitempty"`
+
+	// StatusCode is the HTTP status code of the response, or zero when none was received.
+	StatusCode int `json:"status"`
+
+	// Duration is how long the request took.
+	Duration time.Duration `json:"duration"`
+
+	// Error is the error that prevented the request from completing, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// historyMu serializes the writes to the history file of concurrent requests.
+var historyMu sync.Mutex
+
+// The function HistoryPath returns the path of the history file: the value of the
+// HistoryFileVariable environment variable when set, otherwise `vortex/history.jsonl` in the user
+// configuration directory.
+//
+// Returns:
+//   - The path of the history file.
+//   - An error if the user configuration directory cannot be determined.
+func HistoryPath() (string, error) {
+	if path := os.Getenv(HistoryFileVariable); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to locate the history file")
+	}
+	return filepath.Join(dir, "vortex", "history.jsonl"), nil
+}
+
+// The function NewHistoryEntry builds the history entry of an executed request, redacting the
+// password and the query parameter values of the URL, which often hold API keys and tokens, and
+// the values of the sensitive headers.
+//
+// Parameters:
+//   - rc: The executed request configuration.
+//   - result: The result of the request.
+//   - start: When the request started, usually taken from Config.Now.
+//   - duration: How long the request took.
+//   - err: The error returned by the execution, if any.
+//   - sensitive: The header names whose values must be redacted.
+//
+// Returns:
+//   - The HistoryEntry of the request, timestamped at the start of the execution.
+func NewHistoryEntry(rc *RequestConfig, result RequestResult, start time.Time, duration time.Duration, err error, sensitive []string) HistoryEntry {
+	entry := HistoryEntry{
+		Time:       start,
+		Method:     rc.Method,
+		StatusCode: result.StatusCode,
+		Duration:   duration,
+	}
+	if entry.Method == "" {
+		entry.Method = "GET"
+	}
+	if rc.Host != nil {
+		host := *rc.Host
+		host.RawQuery = redactQuery(host.RawQuery)
+		entry.URL = host.Redacted()
+	}
+	for _, header := range rc.Headers {
+		entry.Headers = append(entry.Headers, RedactHeader(header, sensitive))
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	return entry
+}
+
+// redactQuery replaces the value of every parameter of the raw query with redactedValue, keeping
+// the keys and the delimiters, `&` or `;`, between the parameters.
+func redactQuery(rawQuery string) string {
+	var redacted strings.Builder
+	for rawQuery != "" {
+		end := strings.IndexAny(rawQuery, "&;")
+		if end < 0 {
+			end = len(rawQuery)
+		}
+		if key, _, found := strings.Cut(rawQuery[:end], "="); found {
+			redacted.WriteString(key + "=" + redactedValue)
+		} else {
+			redacted.WriteString(key)
+		}
+		if end < len(rawQuery) {
+			redacted.WriteByte(rawQuery[end])
+			end++
+		}
+		rawQuery = rawQuery[end:]
+	}
+	return redacted.String()
+}
+
+// The function AppendHistory appends an entry to the history file, as a line of JSON, creating the
+// file and its directory when needed. The file is only readable by its owner.
+//
+// Parameters:
+//   - entry: The entry to record.
+//
+// Returns:
+//   - An error if the history file cannot be located or written.
+func AppendHistory(entry HistoryEntry) error {
+	path, err := HistoryPath()
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode the history entry")
+	}
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errors.Wrapf(err, "Failed to create the history directory: %s", filepath.Dir(path))
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to open the history file: %s", path)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return errors.Wrapf(err, "Failed to write the history file: %s", path)
+	}
+	return errors.Wrapf(file.Close(), "Failed to close the history file: %s", path)
+}
+
+// The function LoadHistory reads the most recent entries of the history file. Lines that cannot be
+// decoded, such as a line cut short by an interrupted write, are skipped.
+//
+// Parameters:
+//   - limit: The maximum number of entries to return. Zero or less returns every entry.
+//
+// Returns:
+//   - The most recent entries, oldest first. The slice is empty when there is no history yet.
+//   - An error if the history file cannot be located or read.
+func LoadHistory(limit int) ([]HistoryEntry, error) {
+	path, err := HistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to open the history file: %s", path)
+	}
+	defer file.Close()
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+		if limit > 0 && len(entries) > 2*limit {
+			entries = append(entries[:0], entries[len(entries)-limit:]...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "Failed to read the history file: %s", path)
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// lastResponseFile is the name of the file holding the last response, next to the history file.
+const lastResponseFile = "last-response.json"
+
+// The function LastResponsePath returns the path of the file holding the last response recorded by
+// SaveLastResponse, in the directory of the history file.
+//
+// Returns:
+//   - The path of the last response file.
+//   - An error if the history file cannot be located.
+func LastResponsePath() (string, error) {
+	path, err := HistoryPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), lastResponseFile), nil
+}
+
+// The function SaveLastResponse records the result of a request as the last response, replacing
+// the previous one, so it can be inspected later with LoadLastResponse. The history only records
+// the requests, while the last response keeps the full body and headers, readable by the user
+// only. The values of the sensitive response headers, such as Set-Cookie, are redacted.
+//
+// Parameters:
+//   - result: The result of the request.
+//   - sensitive: The header names whose values are redacted, compared case-insensitively.
+//
+// Returns:
+//   - An error if the file cannot be located or written.
+func SaveLastResponse(result RequestResult, sensitive []string) error {
+	path, err := LastResponsePath()
+	if err != nil {
+		return err
+	}
+	if result.Headers != nil {
+		headers := make(http.Header, len(result.Headers))
+		for name, values := range result.Headers {
+			if isSensitiveHeader(name, sensitive) {
+				values = []string{redactedValue}
+			}
+			headers[name] = values
+		}
+		result.Headers = headers
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode the last response")
+	}
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errors.Wrapf(err, "Failed to create the history directory: %s", filepath.Dir(path))
+	}
+	return errors.Wrapf(os.WriteFile(path, encoded, 0o600), "Failed to write the last response: %s", path)
+}
+
+// The function LoadLastResponse reads the last response recorded by SaveLastResponse.
+//
+// Returns:
+//   - The result of the last request.
+//   - An error if no response was recorded yet, or the file cannot be read or decoded.
+func LoadLastResponse() (RequestResult, error) {
+	var result RequestResult
+	path, err := LastResponsePath()
+	if err != nil {
+		return result, err
+	}
+	encoded, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return result, errors.New("No response recorded yet, send a request first")
+	}
+	if err != nil {
+		return result, errors.Wrapf(err, "Failed to read the last response: %s", path)
+	}
+	if err := json.Unmarshal(encoded, &result); err != nil {
+		return result, errors.Wrapf(err, "Invalid last response file: %s", path)
+	}
+	return result, nil
+}
@@ -0,0 +1,107 @@
+package data
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultHMACTemplate is the message signed by an HMAC signer without template: the method, the
+// path and the body, separated by newlines.
+const DefaultHMACTemplate = "{method}\n{path}\n{body}"
+
+// hmacAlgorithms maps the supported HMAC algorithms to their hash function.
+var hmacAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha1":   sha1.New,
+}
+
+// HMACSigner describes a custom HMAC signature of a request, sent in a header.
+type HMACSigner struct {
+	// Key is the secret key of the HMAC.
+	Key string
+
+	// Algorithm is the hash function of the HMAC, `sha256` or `sha1`. If empty, sha256 is used.
+	Algorithm string
+
+	// Header is the name of the header receiving the signature.
+	Header string
+
+	// Template describes the signed message. The `{method}`, `{host}`, `{path}`, `{query}` and
+	// `{body}` placeholders are replaced by the parts of the request, and the `\n` sequences by
+	// newlines. If empty, DefaultHMACTemplate is used.
+	Template string
+
+	// Encoding is how the signature is written in the header, `hex` or `base64`. If empty, hex is
+	// used.
+	Encoding string
+}
+
+// The method SignHMAC computes the HMAC of the message described by the signer template and sets
+// it as the value of the signer header, replacing a previous signature. The signature is computed
+// before the backend runs, so it works with every backend.
+//
+// Parameters:
+//   - signer: The key, algorithm, header and template of the signature.
+//
+// Returns:
+//   - An error if the request has no host, the key or header is missing, the algorithm or encoding
+//     is not supported, or the body file cannot be read.
+func (rc *RequestConfig) SignHMAC(signer HMACSigner) error {
+	if rc.Host == nil {
+		return errors.New("Cannot sign a request without a host")
+	}
+	if signer.Key == "" || signer.Header == "" {
+		return errors.New("Incomplete HMAC signer, the key and header are required")
+	}
+	algorithm := strings.ToLower(signer.Algorithm)
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	newHash, ok := hmacAlgorithms[algorithm]
+	if !ok {
+		return errors.Errorf("Unsupported HMAC algorithm: %q", signer.Algorithm)
+	}
+	encode := hex.EncodeToString
+	switch strings.ToLower(signer.Encoding) {
+	case "", "hex":
+	case "base64":
+		encode = base64.StdEncoding.EncodeToString
+	default:
+		return errors.Errorf("Unsupported HMAC encoding: %q", signer.Encoding)
+	}
+	payload, err := rc.signedPayload()
+	if err != nil {
+		return err
+	}
+	template := signer.Template
+	if template == "" {
+		template = DefaultHMACTemplate
+	}
+	method := strings.ToUpper(rc.Method)
+	if method == "" {
+		method = "GET"
+	}
+	path := rc.Host.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	message := strings.NewReplacer(
+		`\n`, "\n",
+		"{method}", method,
+		"{host}", rc.Host.Host,
+		"{path}", path,
+		"{query}", rc.Host.RawQuery,
+		"{body}", string(payload),
+	).Replace(template)
+	mac := hmac.New(newHash, []byte(signer.Key))
+	mac.Write([]byte(message))
+	rc.setHeader(signer.Header, encode(mac.Sum(nil)))
+	return nil
+}
@@ -0,0 +1,65 @@
+package data
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestSignHMAC(t *testing.T) {
+	tests := map[string]struct {
+		signer HMACSigner
+		want   string
+	}{
+		"default template": {
+			HMACSigner{Key: "secret", Header: "X-Signature"},
+			"X-Signature: eb506611b070aac9c29e876d0e593b110630501739d04be1023f34a153634dad",
+		},
+		"sha256": {
+			HMACSigner{Key: "secret", Algorithm: "SHA256", Header: "X-Signature", Template: `{method}\n{path}\n{body}`},
+			"X-Signature: eb506611b070aac9c29e876d0e593b110630501739d04be1023f34a153634dad",
+		},
+		"sha1 base64": {
+			HMACSigner{Key: "secret", Algorithm: "sha1", Header: "X-Signature", Encoding: "base64"},
+			"X-Signature: NR1SC5zeaDqkUz8CSu15N0pHRb0=",
+		},
+		"host and query": {
+			HMACSigner{Key: "secret", Header: "X-Signature", Template: "{method} {host} {path} {query}"},
+			"X-Signature: ceb7926684036cfa3397cc22ba5afb7fddfb361d27a3491cf6c0d8e04f2f8f4d",
+		},
+	}
+	for name, tt := range tests {
+		rc := &RequestConfig{
+			Method:  "POST",
+			Host:    &url.URL{Scheme: "https", Host: "example.com", Path: "/v1/users", RawQuery: "a=1"},
+			Headers: []string{"X-Signature: stale"},
+			Body:    []string{`{"a":1}`},
+		}
+		if err := rc.SignHMAC(tt.signer); err != nil {
+			t.Errorf("%s: SignHMAC() error = %v", name, err)
+			continue
+		}
+		if want := []string{tt.want}; !reflect.DeepEqual(rc.Headers, want) {
+			t.Errorf("%s: SignHMAC() headers = %q, want %q", name, rc.Headers, want)
+		}
+	}
+}
+
+func TestSignHMACErrors(t *testing.T) {
+	host := &url.URL{Scheme: "https", Host: "example.com"}
+	tests := map[string]struct {
+		rc     *RequestConfig
+		signer HMACSigner
+	}{
+		"no host":               {&RequestConfig{}, HMACSigner{Key: "secret", Header: "X-Signature"}},
+		"missing key":           {&RequestConfig{Host: host}, HMACSigner{Header: "X-Signature"}},
+		"missing header":        {&RequestConfig{Host: host}, HMACSigner{Key: "secret"}},
+		"unsupported algorithm": {&RequestConfig{Host: host}, HMACSigner{Key: "secret", Header: "X-Signature", Algorithm: "md5"}},
+		"unsupported encoding":  {&RequestConfig{Host: host}, HMACSigner{Key: "secret", Header: "X-Signature", Encoding: "base32"}},
+	}
+	for name, tt := range tests {
+		if err := tt.rc.SignHMAC(tt.signer); err == nil {
+			t.Errorf("%s: SignHMAC() succeeded, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package data
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultHostScheme is the scheme assumed for hosts written without one, as in `localhost:8080`.
+const DefaultHostScheme = "http"
+
+// supportedHostSchemes lists the URL schemes a host can use.
+var supportedHostSchemes = []string{"http", "https", UnixSocketScheme, UnixSocketTLSScheme}
+
+// defaultPorts maps the host schemes to the port they use when the host names none.
+var defaultPorts = map[string]int{
+	"http":              80,
+	"https":             443,
+	UnixSocketScheme:    80,
+	UnixSocketTLSScheme: 443,
+}
+
+// The method EffectivePort returns the port the request connects to: the port written in the host,
+// or the default port of its scheme, 80 for http and 443 for https, when it names none. The port
+// is needed to build options that override how a host is resolved.
+//
+// Returns:
+//   - The effective port, or 0 when the request has no host, or neither a valid port nor a known
+//     scheme.
+func (rc *RequestConfig) EffectivePort() int {
+	if rc.Host == nil {
+		return 0
+	}
+	if port := rc.Host.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err == nil {
+			return n
+		}
+		return 0
+	}
+	return defaultPorts[strings.ToLower(rc.Host.Scheme)]
+}
+
+// The method NormalizeHost validates the host of the request and fixes up hosts written without a
+// scheme. A missing scheme defaults to DefaultHostScheme, so `localhost:8080` is no longer parsed
+// with `localhost` as scheme and `8080` as opaque data. The scheme is lowercased and must be http,
+// https or one of the Unix socket schemes, and http and https hosts must name a host.
+//
+// Returns:
+//   - An error if the host is missing, uses an unsupported scheme or does not name a host.
+func (rc *RequestConfig) NormalizeHost() error {
+	if rc.Host == nil {
+		return errors.New("Missing host")
+	}
+	if isSchemeless(rc.Host) {
+		host, err := url.Parse(DefaultHostScheme + "://" + rc.Host.String())
+		if err != nil {
+			return errors.Wrap(err, "Invalid host URL")
+		}
+		rc.Host = host
+	}
+	rc.Host.Scheme = strings.ToLower(rc.Host.Scheme)
+	supported := false
+	for _, scheme := range supportedHostSchemes {
+		if rc.Host.Scheme == scheme {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return errors.Errorf("Unsupported URL scheme %q in host %s, expected one of: %s", rc.Host.Scheme, rc.Host.Redacted(), strings.Join(supportedHostSchemes, ", "))
+	}
+	if rc.Host.Host == "" && (rc.Host.Scheme == "http" || rc.Host.Scheme == "https") {
+		return errors.Errorf("Missing host name in URL %s", rc.Host.Redacted())
+	}
+	return nil
+}
+
+// isSchemeless reports whether the URL was written without a scheme: either no scheme was parsed
+// at all, or a `name:port` host was mistaken for a scheme followed by an opaque port number.
+func isSchemeless(u *url.URL) bool {
+	if u.Scheme == "" {
+		return true
+	}
+	if u.Opaque == "" || u.Host != "" {
+		return false
+	}
+	port, _, _ := strings.Cut(u.Opaque, "/")
+	for _, r := range port {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return port != ""
+}
@@ -0,0 +1,66 @@
+package data
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestEffectivePort(t *testing.T) {
+	tests := map[string]int{
+		"http://example.com":        80,
+		"https://example.com/x":     443,
+		"https://example.com:8443/": 8443,
+		"ftp://example.com":         0,
+	}
+	for host, want := range tests {
+		u, err := url.Parse(host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := (&RequestConfig{Host: u}).EffectivePort(); got != want {
+			t.Errorf("EffectivePort() of %s = %d, want %d", host, got, want)
+		}
+	}
+	if got := (&RequestConfig{}).EffectivePort(); got != 0 {
+		t.Errorf("EffectivePort() without a host = %d, want 0", got)
+	}
+}
+
+func TestNormalizeHost(t *testing.T) {
+	tests := map[string]struct {
+		host, want, wantErr string
+	}{
+		"schemeless port": {"localhost:8080/health", "http://localhost:8080/health", ""},
+		"schemeless name": {"example.com/users", "http://example.com/users", ""},
+		"https":           {"https://example.com/x", "https://example.com/x", ""},
+		"uppercase":       {"HTTPS://example.com", "https://example.com", ""},
+		"unix socket":     {"http+unix:///var/run/app.sock:/health", "http+unix:///var/run/app.sock:/health", ""},
+		"ftp":             {"ftp://example.com/file", "", "Unsupported URL scheme"},
+		"no host":         {"http:///path", "", "Missing host name"},
+	}
+	for name, tt := range tests {
+		u, err := url.Parse(tt.host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc := &RequestConfig{Host: u}
+		err = rc.NormalizeHost()
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: NormalizeHost() error = %v, want %q", name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: NormalizeHost() error = %v", name, err)
+			continue
+		}
+		if got := rc.Host.String(); got != tt.want {
+			t.Errorf("%s: NormalizeHost() = %q, want %q", name, got, tt.want)
+		}
+	}
+	if err := (&RequestConfig{}).NormalizeHost(); err == nil {
+		t.Error("NormalizeHost() without a host succeeded, want an error")
+	}
+}
@@ -0,0 +1,53 @@
+package data
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The HTTP versions a request can be forced to use with the HTTPVersion of its RequestConfig.
+const (
+	// HTTPVersion11 forces HTTP/1.1.
+	HTTPVersion11 = "1.1"
+
+	// HTTPVersion2 forces HTTP/2.
+	HTTPVersion2 = "2"
+
+	// HTTPVersion3 forces HTTP/3.
+	HTTPVersion3 = "3"
+)
+
+// httpVersions lists the HTTP versions, as written in the error of CheckHTTPVersion.
+var httpVersions = []string{HTTPVersion11, HTTPVersion2, HTTPVersion3}
+
+// curlHTTPVersionFlags maps each HTTP version to the curl option forcing it.
+var curlHTTPVersionFlags = map[string]string{
+	HTTPVersion11: "--http1.1",
+	HTTPVersion2:  "--http2",
+	HTTPVersion3:  "--http3",
+}
+
+// The method CheckHTTPVersion checks that the HTTPVersion of the RequestConfig, when set, is a known
+// HTTP version supported by the backend.
+//
+// Parameters:
+//   - backend: The name of the backend, used in the error.
+//   - supported: The HTTP versions the backend can be forced to use.
+//
+// Returns:
+//   - An error if the version is unknown or not supported by the backend, or nil.
+func (rc *RequestConfig) CheckHTTPVersion(backend string, supported ...string) error {
+	if rc.HTTPVersion == "" {
+		return nil
+	}
+	if _, ok := curlHTTPVersionFlags[rc.HTTPVersion]; !ok {
+		return errors.Errorf("Unsupported HTTP version %q, expected one of %s", rc.HTTPVersion, strings.Join(httpVersions, ", "))
+	}
+	for _, version := range supported {
+		if version == rc.HTTPVersion {
+			return nil
+		}
+	}
+	return errors.Errorf("The %s backend does not support HTTP/%s, use curl", backend, rc.HTTPVersion)
+}
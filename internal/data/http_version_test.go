@@ -0,0 +1,48 @@
+package data
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBuildArgsHTTPVersion(t *testing.T) {
+	host := &url.URL{Scheme: "https", Host: "example.com"}
+	tests := map[string]struct {
+		version  string
+		flag     string
+		wgetErr  string
+		httpieOK bool
+	}{
+		"default": {"", "", "", true},
+		"1.1":     {HTTPVersion11, "--http1.1", "", true},
+		"2":       {HTTPVersion2, "--http2", "The wget backend does not support HTTP/2, use curl", false},
+		"3":       {HTTPVersion3, "--http3", "The wget backend does not support HTTP/3, use curl", false},
+	}
+	for name, tt := range tests {
+		rc := &RequestConfig{Host: host, HTTPVersion: tt.version}
+		args, err := BuildCurlArgs(rc, Config{})
+		if err != nil {
+			t.Errorf("%s: BuildCurlArgs() error = %v", name, err)
+			continue
+		}
+		for _, flag := range []string{"--http1.1", "--http2", "--http3"} {
+			if slices.Contains(args, flag) != (flag == tt.flag) {
+				t.Errorf("%s: BuildCurlArgs() = %q, want the HTTP version flag %q alone", name, args, tt.flag)
+			}
+		}
+		_, err = BuildWgetArgs(rc, Config{})
+		if (err == nil) != (tt.wgetErr == "") || (err != nil && err.Error() != tt.wgetErr) {
+			t.Errorf("%s: BuildWgetArgs() error = %v, want %q", name, err, tt.wgetErr)
+		}
+		if _, err := BuildHTTPieArgs(rc, Config{}); (err == nil) != tt.httpieOK {
+			t.Errorf("%s: BuildHTTPieArgs() error = %v, want it supported: %v", name, err, tt.httpieOK)
+		}
+	}
+
+	rc := &RequestConfig{Host: host, HTTPVersion: "2.0"}
+	if _, err := BuildCurlArgs(rc, Config{}); err == nil || !strings.Contains(err.Error(), `Unsupported HTTP version "2.0", expected one of 1.1, 2, 3`) {
+		t.Errorf("BuildCurlArgs() error = %v, want the unknown version rejected", err)
+	}
+}
@@ -0,0 +1,166 @@
+package data
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The function BuildHTTPieArgs builds the command line arguments, without the program name, used
+// to perform the request described by the RequestConfig with HTTPie. The response headers and
+// body are both printed to stdout, so the status code can be recovered with ParseHTTPieOutput.
+// Only the headers are printed for HEAD requests.
+//
+// Parameters:
+//   - rc: The request configuration to translate into HTTPie arguments.
+//   - cfg: The configuration providing the request timeout.
+//
+// Returns:
+//   - A slice of strings containing the HTTPie arguments.
+//   - An error if the request configuration has no host or uses an unsupported feature.
+func BuildHTTPieArgs(rc *RequestConfig, cfg Config) ([]string, error) {
+	if rc.Host == nil {
+		return nil, errors.New("Cannot build HTTPie arguments without a host")
+	}
+	if _, _, isSocket, _ := rc.UnixSocket(); isSocket {
+		return nil, errors.New("The httpie backend does not support Unix socket hosts")
+	}
+	if len(rc.Resolve) > 0 {
+		return nil, errors.New("The httpie backend does not support host overrides, use curl or native")
+	}
+	if rc.OutputFile != "" || rc.HeaderFile != "" {
+		return nil, errors.New("The httpie backend does not support output files, use curl, wget or native")
+	}
+	if rc.MaxDownloadBytes > 0 {
+		return nil, errors.New("The httpie backend does not support a maximum download size, use curl or native")
+	}
+	if err := rc.CheckHTTPVersion("httpie", HTTPVersion11); err != nil {
+		return nil, err
+	}
+	args := []string{"--ignore-stdin", "--pretty=none"}
+	if strings.EqualFold(rc.Method, http.MethodHead) {
+		args = append(args, "--print=h")
+	} else {
+		args = append(args, "--print=hb")
+	}
+	if rc.FollowRedirects {
+		// Print the intermediate responses too, so the final URL can be rebuilt from their Location
+		args = append(args, "--follow", "--all")
+	}
+	if rc.InsecureSkipVerify {
+		args = append(args, "--verify=no")
+	} else if rc.CACert != "" {
+		args = append(args, "--verify", rc.CACert)
+	}
+	if rc.ClientCert != "" {
+		args = append(args, "--cert", rc.ClientCert)
+	}
+	if rc.ClientKey != "" {
+		args = append(args, "--cert-key", rc.ClientKey)
+	}
+	if cfg.Timeout != UnsetTimeout && cfg.Timeout > 0 {
+		args = append(args, "--timeout", strconv.Itoa(int(cfg.Timeout)))
+	}
+	for _, options := range rc.BackendOptions {
+		args = append(args, options...)
+	}
+	method := rc.Method
+	if method == "" {
+		method = "GET"
+	}
+	args = append(args, method, rc.Host.String())
+	for _, header := range rc.Headers {
+		name, value, _ := SplitHeader(header)
+		if value == "" {
+			// HTTPie sends a header with an empty value when its name is followed by `;`
+			args = append(args, name+";")
+			continue
+		}
+		args = append(args, name+":"+value)
+	}
+	if bodyPath := rc.BodyPath(); bodyPath != "" {
+		args = append(args, "@"+bodyPath)
+	}
+	return args, nil
+}
+
+// The function ParseHTTPieOutput splits the output of an HTTPie invocation built with
+// BuildHTTPieArgs into the response body and the status code found on the status line of the
+// printed response headers. When the output has no headers, it is returned whole as the body.
+// When redirects were followed, only the final response is considered.
+func ParseHTTPieOutput(stdout string) (string, int) {
+	starts := httpieResponseStarts(stdout)
+	if len(starts) == 0 {
+		return stdout, 0
+	}
+	stdout = stdout[starts[len(starts)-1]:]
+	head, body := stdout, ""
+	for _, separator := range []string{"\r\n\r\n", "\n\n"} {
+		if idx := strings.Index(stdout, separator); idx >= 0 {
+			head, body = stdout[:idx], stdout[idx+len(separator):]
+			break
+		}
+	}
+	statusLine, _, _ := strings.Cut(head, "\n")
+	fields := strings.Fields(statusLine)
+	if len(fields) < 2 {
+		return body, 0
+	}
+	code, _ := strconv.Atoi(fields[1])
+	return body, code
+}
+
+// The function ParseHTTPieFinalURL rebuilds the URL of the final response from the output of an
+// HTTPie invocation that followed redirects, by resolving the Location header of every redirect
+// response against the URL it was returned for.
+//
+// Parameters:
+//   - stdout: The standard output captured from HTTPie.
+//   - host: The URL the request was sent to.
+//
+// Returns:
+//   - The URL of the final response, which is the host when no redirect was followed.
+func ParseHTTPieFinalURL(stdout string, host *url.URL) string {
+	current := host
+	starts := httpieResponseStarts(stdout)
+	for i, start := range starts {
+		end := len(stdout)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		location := ParseResponseHeaders(stdout[start:end]).Get("Location")
+		if location == "" {
+			continue
+		}
+		if next, err := current.Parse(location); err == nil {
+			current = next
+		}
+	}
+	return current.String()
+}
+
+// httpieResponseStarts returns the offsets of the status lines starting each response printed in
+// the output of HTTPie, which are at the very beginning or after a blank line.
+func httpieResponseStarts(stdout string) []int {
+	var starts []int
+	if strings.HasPrefix(stdout, "HTTP/") {
+		starts = append(starts, 0)
+	}
+	for _, separator := range []string{"\n\nHTTP/", "\n\r\nHTTP/"} {
+		for offset := 0; ; {
+			idx := strings.Index(stdout[offset:], separator)
+			if idx < 0 {
+				break
+			}
+			start := offset + idx + len(separator) - len("HTTP/")
+			starts = append(starts, start)
+			offset = start
+		}
+	}
+	sort.Ints(starts)
+	return starts
+}
@@ -0,0 +1,24 @@
+package data
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseHTTPieFinalURL(t *testing.T) {
+	host := &url.URL{Scheme: "https", Host: "example.com", Path: "/old"}
+	tests := map[string]struct {
+		stdout, want string
+	}{
+		"redirects": {
+			"HTTP/1.1 302 Found\nLocation: /middle\n\nHTTP/1.1 301 Moved Permanently\nLocation: https://api.example.com/new\n\nHTTP/1.1 200 OK\nContent-Type: text/plain\n\nok\n",
+			"https://api.example.com/new",
+		},
+		"no redirect": {"HTTP/1.1 200 OK\nContent-Type: text/plain\n\nok\n", "https://example.com/old"},
+	}
+	for name, tt := range tests {
+		if got := ParseHTTPieFinalURL(tt.stdout, host); got != tt.want {
+			t.Errorf("%s: ParseHTTPieFinalURL() = %q, want %q", name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The function ResolveInclude locates the file referenced by an include directive. Absolute
+// names are used as they are. Relative names are first resolved against the directory of the
+// including template and then against each directory of the search path, in order, much like
+// a C include path. The first existing regular file wins. A remote template, as told by
+// IsRemoteTemplate, cannot include local files.
+//
+// Parameters:
+//   - name: The filename referenced by the include directive.
+//   - includingFile: The path of the template containing the directive.
+//   - searchPaths: Additional directories to search when the file is not found next to the
+//     including template.
+//
+// Returns:
+//   - The path of the resolved include file.
+//   - An error listing every location that was searched if the file could not be found, or if
+//     the including template is remote.
+func ResolveInclude(name, includingFile string, searchPaths []string) (string, error) {
+	if IsRemoteTemplate(includingFile) {
+		return "", errors.Errorf("The remote template %s cannot include the local file %s", includingFile, name)
+	}
+	if filepath.IsAbs(name) {
+		if isRegularFile(name) {
+			return name, nil
+		}
+		return "", errors.Errorf("Include file not found: %s", name)
+	}
+	candidates := make([]string, 0, len(searchPaths)+1)
+	candidates = append(candidates, filepath.Join(filepath.Dir(includingFile), name))
+	for _, dir := range searchPaths {
+		candidates = append(candidates, filepath.Join(dir, name))
+	}
+	for _, candidate := range candidates {
+		if isRegularFile(candidate) {
+			return candidate, nil
+		}
+	}
+	return "", errors.Errorf("Include file not found: %s (searched: %s)", name, strings.Join(candidates, ", "))
+}
+
+// isRegularFile reports whether the path exists and is not a directory.
+func isRegularFile(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && !fi.IsDir()
+}
+
+const (
+	// includeDirective starts a template line that splices the contents of another template, as in
+	// `@include common.ini`.
+	includeDirective = "@include"
+
+	// extendsDirective starts a template line naming the parent template it extends, as in
+	// `@extends base.ini`. Its lines are kept under the extendsSection pseudo-section.
+	extendsDirective = "@extends"
+	extendsSection   = extendsDirective
+
+	// noDefaultHeadersDirective is a template line opting out of the default headers applied by
+	// ApplyDefaultHeaders. It is kept under a pseudo-section of the same name.
+	noDefaultHeadersDirective = "@no-default-headers"
+
+	// confirmDirective is a template line asking for a confirmation before sending the request
+	// with a destructive method. It is kept under a pseudo-section of the same name.
+	confirmDirective = "@confirm"
+
+	// bodyTerminator ends a [Body] section explicitly. The lines between the [Body] header and it
+	// are all part of the body, even those that would otherwise be read as a section or a directive.
+	// It is matched case-insensitively, like the section names.
+	bodyTerminator = "[/Body]"
+
+	// maxIncludeDepth bounds how deeply includes can be nested.
+	maxIncludeDepth = 16
+)
+
+// templateLine is a meaningful line of a template, together with the section it belongs to and the
+// file it was read from, which differs from the parsed template for included lines, and its line
+// number in that file.
+type templateLine struct {
+	filename string
+	line     int
+	section  string
+	text     string
+}
+
+// readTemplateLines splits the template into its meaningful lines, dropping blank lines, comments
+// and section headers, and splicing the lines of the included templates in place of the include
+// directives. Included templates start outside of any section and do not change the section of
+// the lines following the directive. The chain of templates being included is used to reject
+// include cycles. The arguments of extends directives are kept under the extendsSection, and the
+// no-default-headers and confirm directives under their own pseudo-section.
+//
+// The lines of a [Body] section are kept verbatim, with their indentation and the blank lines
+// between them, since a body is not made of settings. Only the blank lines surrounding the body and
+// the comment lines preceding it are dropped, the latter unless the configuration keeps them. Outside
+// of the body, trailing comments are stripped as described by stripTrailingComment. When a later
+// line is the bodyTerminator, every line up to it is part of the body, without any escape, and the
+// lines following it are outside of any section. Templates with Windows line endings are read like
+// those with Unix ones, as described by splitTemplateLines.
+func readTemplateLines(filename, tmpl string, cfg Config, chain []string) ([]templateLine, error) {
+	rawLines := splitTemplateLines(tmpl)
+	if err := checkTemplateVersion(rawLines); err != nil {
+		return nil, err
+	}
+	var lines []templateLine
+	section := ""
+	bodyStarted, pendingBlanks := false, 0
+	bodyEnd := -1
+	seen := make(map[string]bool)
+	for i, rawLine := range rawLines {
+		if i == bodyEnd {
+			section, bodyEnd = "", -1
+			continue
+		}
+		if bodyEnd >= 0 {
+			if strings.TrimSpace(rawLine) == "" {
+				if bodyStarted {
+					pendingBlanks++
+				}
+				continue
+			}
+			for ; pendingBlanks > 0; pendingBlanks-- {
+				lines = append(lines, templateLine{filename: filename, line: i + 1 - pendingBlanks, section: section})
+			}
+			lines = append(lines, templateLine{filename: filename, line: i + 1, section: section, text: rawLine})
+			bodyStarted = true
+			continue
+		}
+		if name, ok := sectionName(rawLine); ok {
+			if problem := checkSection(name, seen); problem != "" {
+				if cfg.Strict {
+					return nil, &ParseError{Filename: filename, Line: i + 1, Section: name, Msg: "Invalid template, " + problem}
+				}
+				cfg.Log().Log("Template warning: "+problem, "template", filename, "line", i+1)
+			}
+			section = name
+			bodyStarted, pendingBlanks = false, 0
+			if name == sectionBody {
+				bodyEnd = findBodyTerminator(rawLines, i+1)
+			}
+			continue
+		}
+		line := strings.TrimSpace(rawLine)
+		if section == sectionBody && !isDirectiveLine(line) {
+			switch {
+			case line == "":
+				if bodyStarted {
+					pendingBlanks++
+				}
+			case !bodyStarted && strings.HasPrefix(line, commentPrefix) && !cfg.KeepBodyComments:
+			default:
+				for ; pendingBlanks > 0; pendingBlanks-- {
+					lines = append(lines, templateLine{filename: filename, line: i + 1 - pendingBlanks, section: section})
+				}
+				lines = append(lines, templateLine{filename: filename, line: i + 1, section: section, text: unescapeBodyLine(rawLine, bodyStarted)})
+				bodyStarted = true
+			}
+			continue
+		}
+		line = stripTrailingComment(line)
+		if line == "" || strings.HasPrefix(line, commentPrefix) {
+			continue
+		}
+		if _, found := cutDirective(line, noDefaultHeadersDirective); found {
+			lines = append(lines, templateLine{filename: filename, line: i + 1, section: noDefaultHeadersDirective})
+			continue
+		}
+		if _, found := cutDirective(line, confirmDirective); found {
+			lines = append(lines, templateLine{filename: filename, line: i + 1, section: confirmDirective})
+			continue
+		}
+		if parent, found := cutDirective(line, extendsDirective); found {
+			lines = append(lines, templateLine{filename: filename, line: i + 1, section: extendsSection, text: parent})
+			continue
+		}
+		name, found := cutDirective(line, includeDirective)
+		if !found {
+			lines = append(lines, templateLine{filename: filename, line: i + 1, section: section, text: line})
+			continue
+		}
+		included, err := includeTemplateLines(name, filename, cfg, chain)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, included...)
+	}
+	return lines, nil
+}
+
+// splitTemplateLines splits the template into lines, without its byte order mark. The carriage
+// return ending the lines of a template written with Windows line endings is removed, so it never
+// leaks into the body or the header values, while a carriage return inside a line is kept.
+func splitTemplateLines(tmpl string) []string {
+	lines := strings.Split(TrimBOM(tmpl), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// findBodyTerminator returns the index of the first bodyTerminator line from the given index, or -1
+// when the body is not terminated explicitly.
+func findBodyTerminator(rawLines []string, from int) int {
+	for i := from; i < len(rawLines); i++ {
+		if isBodyTerminator(rawLines[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// isBodyTerminator reports whether the line is the bodyTerminator, whatever its case.
+func isBodyTerminator(line string) bool {
+	return strings.EqualFold(strings.TrimSpace(line), bodyTerminator)
+}
+
+// commentEscape stands for a literal `#` where stripTrailingComment would otherwise start a
+// comment, as in the header `X-Tag: a \# b`.
+const commentEscape = `\#`
+
+// stripTrailingComment removes a trailing `# comment` from a line outside of the body. The `#` only
+// starts a comment when it follows whitespace and is followed by whitespace or ends the line, so
+// URL fragments and values such as `#fff` are kept. A commentEscape following whitespace is read
+// as a literal `#`.
+func stripTrailingComment(line string) string {
+	var builder strings.Builder
+	for i := 0; i < len(line); i++ {
+		afterSpace := i > 0 && (line[i-1] == ' ' || line[i-1] == '\t')
+		switch {
+		case afterSpace && strings.HasPrefix(line[i:], commentEscape):
+			builder.WriteByte('#')
+			i++
+		case afterSpace && line[i] == '#' && (i+1 == len(line) || line[i+1] == ' ' || line[i+1] == '\t'):
+			return strings.TrimSpace(builder.String())
+		default:
+			builder.WriteByte(line[i])
+		}
+	}
+	return builder.String()
+}
+
+// escapeTrailingComment escapes with commentEscape every `#` of the line that
+// stripTrailingComment would read as the start of a comment, so the line is read back unchanged.
+func escapeTrailingComment(line string) string {
+	var builder strings.Builder
+	for i := 0; i < len(line); i++ {
+		afterSpace := i > 0 && (line[i-1] == ' ' || line[i-1] == '\t')
+		if afterSpace && line[i] == '#' && (i+1 == len(line) || line[i+1] == ' ' || line[i+1] == '\t') {
+			builder.WriteString(commentEscape)
+			continue
+		}
+		builder.WriteByte(line[i])
+	}
+	return builder.String()
+}
+
+// bodyEscape starts a [Body] line that would otherwise be read as a section, a directive or, before
+// the body starts, a comment, so it is kept as a body line without it.
+const bodyEscape = `\`
+
+// needsBodyEscape reports whether the line must be escaped with bodyEscape to be read as a line of
+// the body, started telling whether a line of the body precedes it. The bodyTerminator is escaped
+// like a section. A line that is already escaped needs another escape.
+func needsBodyEscape(line string, started bool) bool {
+	trimmed := strings.TrimSpace(line)
+	if _, isSection := sectionName(trimmed); isSection || isDirectiveLine(trimmed) || isBodyTerminator(trimmed) {
+		return true
+	}
+	if !started && strings.HasPrefix(trimmed, commentPrefix) {
+		return true
+	}
+	rest, escaped := strings.CutPrefix(trimmed, bodyEscape)
+	return escaped && needsBodyEscape(rest, started)
+}
+
+// unescapeBodyLine removes the bodyEscape of a [Body] line escaped as described by needsBodyEscape,
+// and returns other lines unchanged.
+func unescapeBodyLine(rawLine string, started bool) string {
+	indent := len(rawLine) - len(strings.TrimLeft(rawLine, " \t"))
+	rest, escaped := strings.CutPrefix(rawLine[indent:], bodyEscape)
+	if !escaped || !needsBodyEscape(rest, started) {
+		return rawLine
+	}
+	return rawLine[:indent] + rest
+}
+
+// isDirectiveLine reports whether the trimmed line is an include, extends, no-default-headers or
+// confirm directive.
+func isDirectiveLine(line string) bool {
+	_, isInclude := cutDirective(line, includeDirective)
+	_, isExtends := cutDirective(line, extendsDirective)
+	_, isNoDefaultHeaders := cutDirective(line, noDefaultHeadersDirective)
+	_, isConfirm := cutDirective(line, confirmDirective)
+	return isInclude || isExtends || isNoDefaultHeaders || isConfirm
+}
+
+// cutDirective reports whether the line is the given directive and returns its argument.
+func cutDirective(line, directive string) (string, bool) {
+	arg, found := strings.CutPrefix(line, directive)
+	if !found || (arg != "" && arg[0] != ' ' && arg[0] != '\t') {
+		return "", false
+	}
+	return strings.TrimSpace(arg), true
+}
+
+// checkIncludeChain rejects including or extending the template at the absolute path abs when it
+// is already part of the chain, or when the chain is too deep.
+func checkIncludeChain(abs string, chain []string) error {
+	if len(chain) >= maxIncludeDepth {
+		return errors.Errorf("Templates are nested more than %d levels deep: %s", maxIncludeDepth, abs)
+	}
+	for i, including := range chain {
+		if including == abs {
+			cycle := append(append([]string{}, chain[i:]...), abs)
+			return errors.Errorf("Include cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	return nil
+}
+
+// includeTemplateLines resolves an include directive and reads the lines of the included template.
+func includeTemplateLines(name, filename string, cfg Config, chain []string) ([]templateLine, error) {
+	if name == "" {
+		return nil, errors.Errorf("Missing filename in %s directive", includeDirective)
+	}
+	path, err := ResolveInclude(name, filename, cfg.IncludePaths)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to resolve include file: %s", path)
+	}
+	if err := checkIncludeChain(abs, chain); err != nil {
+		return nil, err
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read include file: %s", path)
+	}
+	lines, err := readTemplateLines(path, string(contents), cfg, append(chain, abs))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to include %s", path)
+	}
+	return lines, nil
+}
@@ -0,0 +1,161 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTemplates writes the templates, by name, into a new directory and returns its path.
+func writeTemplates(t *testing.T, templates map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, contents := range templates {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestParseTemplateIncludesSharedHeaders(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"common.ini": "[Headers]\nAuthorization: Bearer token\nUser-Agent: vortex\n",
+	})
+	tmpl := "@include common.ini\n[Host]\nhttps://example.com\n[Headers]\nAccept: application/json\n"
+	rc, err := ParseTemplate(filepath.Join(dir, "get.ini"), tmpl, Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	want := []string{"Authorization: Bearer token", "User-Agent: vortex", "Accept: application/json"}
+	if !reflect.DeepEqual(rc.Headers, want) {
+		t.Errorf("Headers = %q, want %q", rc.Headers, want)
+	}
+}
+
+func TestParseTemplateRejectsBadIncludes(t *testing.T) {
+	templates := map[string]string{
+		"a.ini": "@include b.ini\n",
+		"b.ini": "@include a.ini\n",
+	}
+	for i := 0; i <= maxIncludeDepth; i++ {
+		templates[fmt.Sprintf("deep%d.ini", i)] = fmt.Sprintf("@include deep%d.ini\n", i+1)
+	}
+	dir := writeTemplates(t, templates)
+	tests := map[string]struct {
+		tmpl, want string
+	}{
+		"missing": {"@include missing.ini\n[Host]\nhttps://example.com\n", "missing.ini"},
+		"cycle":   {"@include a.ini\n[Host]\nhttps://example.com\n", "Include cycle detected"},
+		"depth":   {"@include deep0.ini\n[Host]\nhttps://example.com\n", "nested more than"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseTemplate(filepath.Join(dir, "main.ini"), tt.tmpl, Config{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseTemplate() error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveIncludeSearchPaths(t *testing.T) {
+	local := writeTemplates(t, map[string]string{"local.ini": ""})
+	first := writeTemplates(t, map[string]string{"shared.ini": "", "first.ini": ""})
+	second := writeTemplates(t, map[string]string{"shared.ini": "", "second.ini": ""})
+	if err := os.Mkdir(filepath.Join(first, "dir.ini"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(second, "dir.ini"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	including := filepath.Join(local, "main.ini")
+	searchPaths := []string{first, second}
+	tests := map[string]string{
+		"local.ini":  filepath.Join(local, "local.ini"),
+		"shared.ini": filepath.Join(first, "shared.ini"),
+		"first.ini":  filepath.Join(first, "first.ini"),
+		"second.ini": filepath.Join(second, "second.ini"),
+		// A directory is skipped in favor of a file later in the search path
+		"dir.ini": filepath.Join(second, "dir.ini"),
+	}
+	for name, want := range tests {
+		got, err := ResolveInclude(name, including, searchPaths)
+		if err != nil {
+			t.Errorf("ResolveInclude(%q) error = %v", name, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ResolveInclude(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestResolveIncludeNotFound(t *testing.T) {
+	local, first := t.TempDir(), t.TempDir()
+	_, err := ResolveInclude("missing.ini", filepath.Join(local, "main.ini"), []string{first})
+	if err == nil {
+		t.Fatal("ResolveInclude() of a missing file succeeded, want an error")
+	}
+	for _, searched := range []string{filepath.Join(local, "missing.ini"), filepath.Join(first, "missing.ini")} {
+		if !strings.Contains(err.Error(), searched) {
+			t.Errorf("ResolveInclude() error = %v, want it to list %s", err, searched)
+		}
+	}
+	if _, err := ResolveInclude(filepath.Join(first, "missing.ini"), filepath.Join(local, "main.ini"), nil); err == nil {
+		t.Error("ResolveInclude() of a missing absolute file succeeded, want an error")
+	}
+}
+
+func TestParseTemplateIncludePaths(t *testing.T) {
+	shared := writeTemplates(t, map[string]string{"auth.ini": "[Headers]\nAuthorization: Bearer token\n"})
+	dir := t.TempDir()
+	tmpl := "@include auth.ini\n[Host]\nhttps://example.com\n"
+	if _, err := ParseTemplate(filepath.Join(dir, "get.ini"), tmpl, Config{}); err == nil {
+		t.Error("ParseTemplate() without include paths succeeded, want the include not found")
+	}
+	rc, err := ParseTemplate(filepath.Join(dir, "get.ini"), tmpl, Config{IncludePaths: []string{t.TempDir(), shared}})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if want := []string{"Authorization: Bearer token"}; !reflect.DeepEqual(rc.Headers, want) {
+		t.Errorf("Headers = %q, want %q", rc.Headers, want)
+	}
+}
+
+func TestParseTemplateCRLF(t *testing.T) {
+	crlf := func(s string) string { return strings.ReplaceAll(s, "\n", "\r\n") }
+	common := "[Headers]\nAuthorization: Bearer token\n"
+	tmpl := "@include common.ini\n[Host]\nhttps://example.com/users\n[Method]\nPOST\n[Headers]\nContent-Type: application/json\nX-Trace: a b\n" +
+		"[Query]\npage=2\n[Body]\n{\n  \"name\": \"vortex\",\n  \"tags\": [\"a\"]\n}\n"
+	lf := writeTemplates(t, map[string]string{"common.ini": common})
+	want, err := ParseTemplate(filepath.Join(lf, "post.ini"), tmpl, Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() of the LF template error = %v", err)
+	}
+	windows := writeTemplates(t, map[string]string{"common.ini": crlf(common)})
+	got, err := ParseTemplate(filepath.Join(windows, "post.ini"), crlf(tmpl), Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() of the CRLF template error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Headers, want.Headers) || !reflect.DeepEqual(got.Body, want.Body) || got.Host.String() != want.Host.String() || got.Method != want.Method {
+		t.Errorf("ParseTemplate() of the CRLF template = %q %s %q %q, want %q %s %q %q", got.Method, got.Host, got.Headers, got.Body, want.Method, want.Host, want.Headers, want.Body)
+	}
+	for _, line := range append(got.Headers, got.Body...) {
+		if strings.Contains(line, "\r") {
+			t.Errorf("ParseTemplate() of the CRLF template kept a carriage return in %q", line)
+		}
+	}
+
+	// A carriage return inside a line is not a line ending
+	rc, err := ParseTemplate("post.ini", "[Host]\r\nhttps://example.com\r\n[Body]\r\na\rb\r\n", Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if want := []string{"a\rb"}; !reflect.DeepEqual(rc.Body, want) {
+		t.Errorf("Body = %q, want %q", rc.Body, want)
+	}
+}
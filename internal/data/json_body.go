@@ -0,0 +1,99 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// jsonObject is a JSON object keeping its members in the order they were added, so the body built
+// from a [Json] section follows the order of its lines.
+type jsonObject struct {
+	keys   []string
+	values map[string]any
+}
+
+// newJSONObject returns an empty jsonObject.
+func newJSONObject() *jsonObject {
+	return &jsonObject{values: make(map[string]any)}
+}
+
+// MarshalJSON encodes the members of the object in order.
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		encodedValue, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		buf.Write(encodedValue)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// set sets the member of the object, appending it when it is new.
+func (o *jsonObject) set(key string, value any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// addJSONField adds a line of the [Json] section to the object: `key=value` sets a string, while
+// `key:=raw` sets a raw JSON value, such as a number, a boolean, null, an array or an object. The
+// dots of a key, as in `user.id`, separate the names of nested objects.
+func addJSONField(obj *jsonObject, line string) error {
+	key, value, found := strings.Cut(line, "=")
+	if !found {
+		return errors.Errorf("Malformed [Json] field, expected key=value or key:=raw: %q", line)
+	}
+	var member any = strings.TrimSpace(value)
+	if rawKey, isRaw := strings.CutSuffix(key, ":"); isRaw {
+		key = rawKey
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, []byte(strings.TrimSpace(value))); err != nil {
+			return errors.Wrapf(err, "Invalid raw JSON value of the [Json] field %s", strings.TrimSpace(key))
+		}
+		member = json.RawMessage(compact.Bytes())
+	}
+	names := strings.Split(strings.TrimSpace(key), ".")
+	for _, name := range names {
+		if name == "" {
+			return errors.Errorf("Invalid [Json] key %q, expected dot-separated names", strings.TrimSpace(key))
+		}
+	}
+	parent := obj
+	for i, name := range names[:len(names)-1] {
+		existing, ok := parent.values[name]
+		if !ok {
+			child := newJSONObject()
+			parent.set(name, child)
+			parent = child
+			continue
+		}
+		child, isObject := existing.(*jsonObject)
+		if !isObject {
+			return errors.Errorf("The [Json] key %q is nested in %q, which is not an object", strings.TrimSpace(key), strings.Join(names[:i+1], "."))
+		}
+		parent = child
+	}
+	last := names[len(names)-1]
+	if _, isObject := parent.values[last].(*jsonObject); isObject {
+		return errors.Errorf("The [Json] key %q replaces the object holding other keys", strings.TrimSpace(key))
+	}
+	parent.set(last, member)
+	return nil
+}
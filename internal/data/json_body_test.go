@@ -0,0 +1,63 @@
+package data
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTemplateJSONSection(t *testing.T) {
+	tests := map[string]struct {
+		fields  string
+		headers string
+		want    string
+		wantCT  []string
+	}{
+		"strings":     {"name=vortex\ngreeting = hello world \nempty=\n", "", `{"name":"vortex","greeting":"hello world","empty":""}`, []string{"Content-Type: application/json"}},
+		"raw values":  {"id:=5\nratio:=0.5\nadmin:=true\nmanager:=null\ntags:=[\"a\", \"b\"]\nid_text=5\n", "", `{"id":5,"ratio":0.5,"admin":true,"manager":null,"tags":["a","b"],"id_text":"5"}`, []string{"Content-Type: application/json"}},
+		"nested keys": {"user.id:=5\nuser.name=vortex\nuser.address.city=Lima\nactive:=true\n", "", `{"user":{"id":5,"name":"vortex","address":{"city":"Lima"}},"active":true}`, []string{"Content-Type: application/json"}},
+		"equals kept": {"query=a=b\n", "", `{"query":"a=b"}`, []string{"Content-Type: application/json"}},
+		"own type":    {"name=vortex\n", "[Headers]\ncontent-type: application/vnd.api+json\n", `{"name":"vortex"}`, []string{"content-type: application/vnd.api+json"}},
+	}
+	for name, tt := range tests {
+		tmpl := "[Host]\nhttps://example.com\n[Method]\nPOST\n" + tt.headers + "[Json]\n" + tt.fields
+		rc, err := ParseTemplate("post.ini", tmpl, Config{})
+		if err != nil {
+			t.Errorf("%s: ParseTemplate() error = %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(rc.Body, []string{tt.want}) {
+			t.Errorf("%s: Body = %q, want %q", name, rc.Body, tt.want)
+		}
+		if !reflect.DeepEqual(rc.Headers, tt.wantCT) {
+			t.Errorf("%s: Headers = %q, want %q", name, rc.Headers, tt.wantCT)
+		}
+	}
+}
+
+func TestParseTemplateJSONSectionErrors(t *testing.T) {
+	tests := map[string]struct {
+		sections string
+		line     int
+		want     string
+	}{
+		"invalid raw":      {"[Json]\nname=vortex\nid:=five\n", 5, "Invalid raw JSON value of the [Json] field id"},
+		"missing equals":   {"[Json]\nname\n", 4, "Malformed [Json] field"},
+		"empty name":       {"[Json]\nuser..id=1\n", 4, `Invalid [Json] key "user..id"`},
+		"nested in string": {"[Json]\nuser=vortex\nuser.id:=1\n", 5, `is nested in "user", which is not an object`},
+		"replaced object":  {"[Json]\nuser.id:=1\nuser=vortex\n", 5, "replaces the object holding other keys"},
+		"body and json":    {"[Json]\nname=vortex\n[Body]\n{}\n", 4, "cannot declare both a [Body] and a [Json] section"},
+	}
+	for name, tt := range tests {
+		_, err := ParseTemplate("post.ini", "[Host]\nhttps://example.com\n"+tt.sections, Config{})
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: ParseTemplate() error = %v, want a ParseError for %q", name, err, tt.want)
+			continue
+		}
+		if parseErr.Line != tt.line || parseErr.Section != "Json" {
+			t.Errorf("%s: ParseError at line %d in [%s], want line %d in [Json]", name, parseErr.Line, parseErr.Section, tt.line)
+		}
+	}
+}
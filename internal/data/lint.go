@@ -0,0 +1,86 @@
+package data
+
+import (
+	"net"
+	"strings"
+)
+
+// LintIssue describes a potentially insecure setting found in a request configuration.
+type LintIssue struct {
+	// Rule is a short, stable identifier of the check that produced the issue, such as
+	// "insecure-skip-verify". It can be used to filter or silence specific checks.
+	Rule string
+
+	// Message is a human readable explanation of the issue.
+	Message string
+}
+
+// The method String returns the issue formatted as `rule: message`.
+func (li LintIssue) String() string {
+	return li.Rule + ": " + li.Message
+}
+
+// The function LintSecurity inspects a request configuration for settings that are likely to be
+// insecure. It flags disabled TLS verification, plaintext HTTP to hosts other than the local
+// machine, credentials written literally in the template instead of being interpolated from the
+// environment, and Authorization headers sent over plaintext HTTP.
+//
+// Parameters:
+//   - rc: The request configuration to inspect, as parsed from the template.
+//   - cfg: The configuration providing the interpolation syntax used to recognize references.
+//
+// Returns:
+//   - A slice with one LintIssue per problem found, or nil when the configuration is clean.
+func LintSecurity(rc *RequestConfig, cfg Config) []LintIssue {
+	var issues []LintIssue
+	if rc.InsecureSkipVerify {
+		issues = append(issues, LintIssue{
+			Rule:    "insecure-skip-verify",
+			Message: "TLS certificate verification is disabled",
+		})
+	}
+	plaintext := rc.Host != nil && strings.EqualFold(rc.Host.Scheme, "http") && !isLoopbackHost(rc.Host.Hostname())
+	if plaintext {
+		issues = append(issues, LintIssue{
+			Rule:    "plaintext-http",
+			Message: "Request to " + rc.Host.Host + " is sent over plaintext http",
+		})
+	}
+	openDelim, _ := cfg.InterpolationDelims()
+	if rc.Host != nil && rc.Host.User != nil {
+		if password, ok := rc.Host.User.Password(); ok && !strings.Contains(password, openDelim) {
+			issues = append(issues, LintIssue{
+				Rule:    "literal-secret",
+				Message: "The host URL contains a literal password",
+			})
+		}
+	}
+	for _, header := range rc.Headers {
+		name, value, _ := SplitHeader(header)
+		if !isSensitiveHeader(name, DefaultSensitiveHeaders) {
+			continue
+		}
+		if value != "" && !strings.Contains(value, openDelim) {
+			issues = append(issues, LintIssue{
+				Rule:    "literal-secret",
+				Message: "Header " + name + " contains a literal secret, consider an environment variable",
+			})
+		}
+		if plaintext && strings.EqualFold(name, "Authorization") {
+			issues = append(issues, LintIssue{
+				Rule:    "auth-over-http",
+				Message: "Authorization header is sent over plaintext http",
+			})
+		}
+	}
+	return issues
+}
+
+// isLoopbackHost reports whether the hostname refers to the local machine.
+func isLoopbackHost(hostname string) bool {
+	if strings.EqualFold(hostname, "localhost") || strings.HasSuffix(strings.ToLower(hostname), ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(hostname)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,105 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Logger receives the verbose messages emitted while templates are processed and requests are
+// executed, such as the chosen backend, the executed command and the time it took. Each message
+// comes with optional key/value pairs, given as alternating keys and values.
+type Logger interface {
+	Log(msg string, keyvals ...any)
+}
+
+// writerLogger is a Logger writing one line per message to an io.Writer.
+type writerLogger struct {
+	mu   sync.Mutex
+	w    io.Writer
+	json bool
+}
+
+// The function NewTextLogger returns a Logger writing each message to w as a single line of the
+// form `msg key=value key=value`. Values containing spaces or quotes are quoted.
+func NewTextLogger(w io.Writer) Logger {
+	return &writerLogger{w: w}
+}
+
+// The function NewJSONLogger returns a Logger writing each message to w as a JSON object on its
+// own line, with the message under the "msg" key followed by the key/value pairs.
+func NewJSONLogger(w io.Writer) Logger {
+	return &writerLogger{w: w, json: true}
+}
+
+// defaultLogger is the Logger used when the Config does not provide one.
+var defaultLogger = NewTextLogger(os.Stderr)
+
+// The method Log returns the Logger configured in the Logger field, or a text Logger writing to
+// stderr when none is configured.
+func (c Config) Log() Logger {
+	if c.Logger == nil {
+		return defaultLogger
+	}
+	return c.Logger
+}
+
+// Log writes the message and its key/value pairs as a single line.
+func (l *writerLogger) Log(msg string, keyvals ...any) {
+	var line string
+	if l.json {
+		line = formatJSONLine(msg, keyvals)
+	} else {
+		line = formatTextLine(msg, keyvals)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = io.WriteString(l.w, line+"\n")
+}
+
+// formatTextLine renders a message and its key/value pairs as `msg key=value`.
+func formatTextLine(msg string, keyvals []any) string {
+	var builder strings.Builder
+	builder.WriteString(msg)
+	for i := 0; i < len(keyvals); i += 2 {
+		builder.WriteString(" " + fmt.Sprint(keyvals[i]) + "=")
+		value := "<missing>"
+		if i+1 < len(keyvals) {
+			value = fmt.Sprint(keyvals[i+1])
+		}
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		builder.WriteString(value)
+	}
+	return builder.String()
+}
+
+// formatJSONLine renders a message and its key/value pairs as a JSON object, keeping the order in
+// which the keys were given.
+func formatJSONLine(msg string, keyvals []any) string {
+	var builder strings.Builder
+	encoded, _ := json.Marshal(msg)
+	builder.WriteString(`{"msg":` + string(encoded))
+	for i := 0; i < len(keyvals); i += 2 {
+		key, _ := json.Marshal(fmt.Sprint(keyvals[i]))
+		var value any = "<missing>"
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		if stringer, ok := value.(fmt.Stringer); ok {
+			value = stringer.String()
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			encoded, _ = json.Marshal(fmt.Sprint(value))
+		}
+		builder.WriteString("," + string(key) + ":" + string(encoded))
+	}
+	builder.WriteString("}")
+	return builder.String()
+}
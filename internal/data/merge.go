@@ -0,0 +1,213 @@
+package data
+
+import (
+	"net/url"
+	"strings"
+)
+
+// The method MergeParent completes the RequestConfig with the settings of the parent it extends,
+// as declared with an `@extends` directive. The settings of the RequestConfig take precedence:
+//   - Host: the parent host is used when the RequestConfig has none, or only a query or a [Path],
+//     which is appended to the path of the parent. The query parameters of both are merged,
+//     dropping the parent parameters whose key the child also sets. Parameters are separated by
+//     the Config.Delimiter of the configuration the RequestConfig is parsed with, `&` by default.
+//   - The other settings are merged as described by ApplyDefaults.
+//
+// Parameters:
+//   - parent: The parent request configuration. Nothing is merged when it is nil.
+func (rc *RequestConfig) MergeParent(parent *RequestConfig) {
+	if parent == nil {
+		return
+	}
+	rc.Host = mergeHosts(rc.Host, parent.Host, Config{QueryDelim: &rc.queryDelim}.Delimiter())
+	fillUnsetFields(rc, parent)
+}
+
+// The function ApplyDefaults fills the settings a parsed RequestConfig leaves unset with those of
+// a defaults RequestConfig shared by a suite of templates, such as a common backend, timeout or
+// header. A setting is unset when:
+//   - Host: it is nil. The default host is then copied.
+//   - Method, Backend, the TLS files, the output files and the body expectations: they are empty, or nil
+//     for Auth.
+//   - Resolve: the RequestConfig has no host override.
+//     The backend options are only taken along with the default backend, unless the RequestConfig
+//     has its own.
+//   - Body, BodyFile and BodyFromStdin: they are all empty.
+//   - Timeout, MaxResponseBytes and ExpectStatus: they are zero.
+//   - Headers: no header of the RequestConfig has the same name, compared case-insensitively. The
+//     default headers come first, followed by the headers of the RequestConfig.
+//   - PathParams: the RequestConfig has no parameter with the same name.
+//   - The boolean flags: they are false, which cannot be told apart from an explicit false, so a
+//     flag enabled by the defaults is always enabled.
+//
+// Parameters:
+//   - rc: The request configuration to complete.
+//   - defaults: The default settings. Nothing is applied when it is nil.
+func ApplyDefaults(rc *RequestConfig, defaults *RequestConfig) {
+	if defaults == nil {
+		return
+	}
+	if rc.Host == nil && defaults.Host != nil {
+		host := *defaults.Host
+		rc.Host = &host
+	}
+	fillUnsetFields(rc, defaults)
+}
+
+// fillUnsetFields fills the settings of rc, other than the host, that are unset with those of
+// from, as described by ApplyDefaults.
+func fillUnsetFields(rc, from *RequestConfig) {
+	if rc.Method == "" {
+		rc.Method = from.Method
+	}
+	rc.Headers = mergeHeaders(from.Headers, rc.Headers)
+	if len(rc.Body) == 0 && rc.BodyFile == "" && !rc.BodyFromStdin {
+		rc.Body = append([]string(nil), from.Body...)
+		rc.BodyFile = from.BodyFile
+		rc.BodyFromStdin = from.BodyFromStdin
+	}
+	if rc.Backend == "" {
+		rc.Backend = from.Backend
+		if len(rc.BackendOptions) == 0 {
+			rc.BackendOptions = from.BackendOptions
+		}
+	}
+	if rc.ClientCert == "" && rc.ClientKey == "" {
+		rc.ClientCert, rc.ClientKey = from.ClientCert, from.ClientKey
+	}
+	if rc.CACert == "" {
+		rc.CACert = from.CACert
+	}
+	if rc.OutputFile == "" {
+		rc.OutputFile = from.OutputFile
+	}
+	if rc.HeaderFile == "" {
+		rc.HeaderFile = from.HeaderFile
+	}
+	if rc.TraceFile == "" {
+		rc.TraceFile = from.TraceFile
+	}
+	if rc.OnStreamLine == nil {
+		rc.OnStreamLine = from.OnStreamLine
+	}
+	if rc.Retry == nil {
+		rc.Retry = from.Retry
+	}
+	if rc.PostProcess == "" {
+		rc.PostProcess = from.PostProcess
+	}
+	if rc.WriteOut == "" {
+		rc.WriteOut = from.WriteOut
+	}
+	if len(rc.Resolve) == 0 {
+		rc.Resolve = from.Resolve
+	}
+	if rc.Auth == nil {
+		rc.Auth = from.Auth
+	}
+	if rc.ExpectSchema == "" {
+		rc.ExpectSchema = from.ExpectSchema
+	}
+	if rc.ExpectBody == "" {
+		rc.ExpectBody = from.ExpectBody
+	}
+	if rc.ExpectBodyRegex == "" {
+		rc.ExpectBodyRegex = from.ExpectBodyRegex
+	}
+	if rc.ExpectStatus == 0 {
+		rc.ExpectStatus = from.ExpectStatus
+	}
+	if rc.Timeout == 0 {
+		rc.Timeout = from.Timeout
+	}
+	if rc.HTTPVersion == "" {
+		rc.HTTPVersion = from.HTTPVersion
+	}
+	if rc.MaxDownloadBytes == 0 {
+		rc.MaxDownloadBytes = from.MaxDownloadBytes
+	}
+	if rc.MaxResponseBytes == 0 {
+		rc.MaxResponseBytes = from.MaxResponseBytes
+	}
+	for name, value := range from.PathParams {
+		if _, ok := rc.PathParams[name]; ok {
+			continue
+		}
+		if rc.PathParams == nil {
+			rc.PathParams = make(map[string]string)
+		}
+		rc.PathParams[name] = value
+	}
+	rc.StrictPathParams = rc.StrictPathParams || from.StrictPathParams
+	rc.FollowRedirects = rc.FollowRedirects || from.FollowRedirects
+	rc.FailOnHTTPError = rc.FailOnHTTPError || from.FailOnHTTPError
+	rc.InsecureSkipVerify = rc.InsecureSkipVerify || from.InsecureSkipVerify
+	rc.AcceptCompression = rc.AcceptCompression || from.AcceptCompression
+	rc.CompressBody = rc.CompressBody || from.CompressBody
+	rc.ForceBody = rc.ForceBody || from.ForceBody
+	rc.Verbose = rc.Verbose || from.Verbose
+	rc.Quiet = rc.Quiet || from.Quiet
+	rc.Stream = rc.Stream || from.Stream
+	rc.NoDefaultHeaders = rc.NoDefaultHeaders || from.NoDefaultHeaders
+	rc.Confirm = rc.Confirm || from.Confirm
+	rc.Tempfile = rc.Tempfile || from.Tempfile
+}
+
+// mergeHosts returns the child host, or a copy of the parent host when the child has none or only
+// holds a path and a query, with the path of the child appended and the query parameters of both
+// merged on delim.
+func mergeHosts(child, parent *url.URL, delim string) *url.URL {
+	if parent == nil {
+		return child
+	}
+	if child == nil {
+		merged := *parent
+		return &merged
+	}
+	merged := *child
+	if child.Scheme == "" && child.Host == "" && child.Opaque == "" {
+		// The child only holds a [Path], appended to the path of the parent
+		merged = *parent
+		joinHostPath(&merged, &url.URL{Path: child.Path, RawPath: child.RawPath}, delim)
+	}
+	merged.RawQuery = mergeQueries(parent.RawQuery, child.RawQuery, delim)
+	return &merged
+}
+
+// mergeQueries returns the parameters of the parent query whose key the child query does not set,
+// followed by the parameters of the child query. Both queries are split and joined on delim.
+func mergeQueries(parent, child, delim string) string {
+	if parent == "" || child == "" {
+		return parent + child
+	}
+	childKeys := make(map[string]bool)
+	childParams := strings.Split(child, delim)
+	for _, param := range childParams {
+		key, _, _ := strings.Cut(param, "=")
+		childKeys[key] = true
+	}
+	var merged []string
+	for _, param := range strings.Split(parent, delim) {
+		if key, _, _ := strings.Cut(param, "="); !childKeys[key] {
+			merged = append(merged, param)
+		}
+	}
+	return strings.Join(append(merged, childParams...), delim)
+}
+
+// mergeHeaders returns the parent headers whose name the child headers do not set, followed by
+// the child headers.
+func mergeHeaders(parent, child []string) []string {
+	childNames := make(map[string]bool, len(child))
+	for _, header := range child {
+		name, _, _ := SplitHeader(header)
+		childNames[strings.ToLower(name)] = true
+	}
+	merged := make([]string, 0, len(parent)+len(child))
+	for _, header := range parent {
+		if name, _, _ := SplitHeader(header); !childNames[strings.ToLower(name)] {
+			merged = append(merged, header)
+		}
+	}
+	return append(merged, child...)
+}
@@ -0,0 +1,69 @@
+package data
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestMergeQueries(t *testing.T) {
+	tests := []struct {
+		parent, child, delim, want string
+	}{
+		{"a=1&b=2", "b=3", "&", "a=1&b=3"},
+		{"a=1;b=2", "b=3;c=4", ";", "a=1;b=3;c=4"},
+		{"", "b=3", ";", "b=3"},
+		{"a=1;b=2", "", ";", "a=1;b=2"},
+	}
+	for _, tt := range tests {
+		if got := mergeQueries(tt.parent, tt.child, tt.delim); got != tt.want {
+			t.Errorf("mergeQueries(%q, %q, %q) = %q, want %q", tt.parent, tt.child, tt.delim, got, tt.want)
+		}
+	}
+}
+
+func TestMergeParentUsesDelimiter(t *testing.T) {
+	parent, err := url.Parse("https://example.com/api?page=1;limit=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc := &RequestConfig{Host: &url.URL{Path: "users", RawQuery: "limit=50"}, queryDelim: ";"}
+	rc.MergeParent(&RequestConfig{Host: parent})
+	if want := "https://example.com/api/users?page=1;limit=50"; rc.Host.String() != want {
+		t.Errorf("Host = %s, want %s", rc.Host, want)
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	defaults := &RequestConfig{
+		Host:    &url.URL{Scheme: "https", Host: "default.example.com"},
+		Backend: "httpie",
+		Timeout: 30,
+		Headers: []string{"User-Agent: vortex", "Accept: */*"},
+	}
+
+	omitted := &RequestConfig{Headers: []string{"accept: application/json"}}
+	ApplyDefaults(omitted, defaults)
+	if omitted.Backend != "httpie" || omitted.Timeout != 30 || omitted.Host.String() != "https://default.example.com" {
+		t.Errorf("ApplyDefaults() Backend = %q, Timeout = %d, Host = %v, want the defaults", omitted.Backend, omitted.Timeout, omitted.Host)
+	}
+	if want := []string{"User-Agent: vortex", "accept: application/json"}; !reflect.DeepEqual(omitted.Headers, want) {
+		t.Errorf("ApplyDefaults() Headers = %q, want %q", omitted.Headers, want)
+	}
+	omitted.Host.Path = "/changed"
+	if defaults.Host.Path != "" {
+		t.Error("ApplyDefaults() shares the default host with the RequestConfig")
+	}
+
+	set := &RequestConfig{Host: &url.URL{Scheme: "https", Host: "api.example.com"}, Backend: "wget", Timeout: 5}
+	ApplyDefaults(set, defaults)
+	if set.Backend != "wget" || set.Timeout != 5 || set.Host.Host != "api.example.com" {
+		t.Errorf("ApplyDefaults() Backend = %q, Timeout = %d, Host = %v, want the settings of the template", set.Backend, set.Timeout, set.Host)
+	}
+
+	unchanged := &RequestConfig{Backend: "curl"}
+	ApplyDefaults(unchanged, nil)
+	if unchanged.Backend != "curl" || unchanged.Host != nil {
+		t.Errorf("ApplyDefaults() without defaults changed the RequestConfig to %+v", unchanged)
+	}
+}
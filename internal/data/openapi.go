@@ -0,0 +1,278 @@
+package data
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// openAPIDefaultServer is the server used when an OpenAPI spec declares none, or only a relative
+// one, so the scaffolded host can be fixed by hand.
+const openAPIDefaultServer = "http://localhost"
+
+// openAPIMethods lists the operation keys of an OpenAPI path item, in the order they are searched.
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPISpec is the subset of an OpenAPI 3 document read by FromOpenAPIOperation.
+type openAPISpec struct {
+	Servers []struct {
+		URL       string `json:"url"`
+		Variables map[string]struct {
+			Default string `json:"default"`
+		} `json:"variables"`
+	} `json:"servers"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]*openAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+// openAPIOperation is an operation of an OpenAPI path item.
+type openAPIOperation struct {
+	OperationID string             `json:"operationId"`
+	Parameters  []openAPIParameter `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema  *openAPISchema  `json:"schema"`
+			Example json.RawMessage `json:"example"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+// openAPIParameter is a parameter of an OpenAPI operation.
+type openAPIParameter struct {
+	Name     string          `json:"name"`
+	In       string          `json:"in"`
+	Required bool            `json:"required"`
+	Example  json.RawMessage `json:"example"`
+	Schema   *openAPISchema  `json:"schema"`
+}
+
+// openAPISchema is the subset of a JSON schema used to scaffold request bodies.
+type openAPISchema struct {
+	Ref        string                    `json:"$ref"`
+	Type       string                    `json:"type"`
+	Properties map[string]*openAPISchema `json:"properties"`
+	Required   []string                  `json:"required"`
+	Items      *openAPISchema            `json:"items"`
+	Example    json.RawMessage           `json:"example"`
+	Enum       []json.RawMessage         `json:"enum"`
+}
+
+// The function FromOpenAPIOperation scaffolds a RequestConfig for the operation of an OpenAPI 3
+// spec with the given operationId. The host is the first server URL, with its variables set to
+// their defaults, followed by the path of the operation, whose `{param}` placeholders are kept for
+// SubstitutePathParams. Required header and query parameters are seeded with their example, and
+// the JSON body is the example of the request body, or a skeleton holding the required fields of
+// its schema. A request body whose schema cannot be scaffolded is replaced by a commented
+// placeholder. Only specs written in JSON are supported.
+//
+// Parameters:
+//   - specPath: The path of the OpenAPI spec.
+//   - operationID: The operationId of the operation to scaffold.
+//
+// Returns:
+//   - The scaffolded RequestConfig.
+//   - An error if the spec cannot be read or decoded, or holds no operation with that ID.
+func FromOpenAPIOperation(specPath, operationID string) (*RequestConfig, error) {
+	contents, err := os.ReadFile(specPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read the OpenAPI spec: %s", specPath)
+	}
+	var spec openAPISpec
+	if err := json.Unmarshal(contents, &spec); err != nil {
+		return nil, errors.Wrapf(err, "Failed to decode the OpenAPI spec %s, only JSON specs are supported", specPath)
+	}
+	path, method, op, err := spec.findOperation(operationID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to scaffold a request from %s", specPath)
+	}
+	host, err := url.Parse(strings.TrimSuffix(spec.serverURL(), "/") + path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid URL for operation %s", operationID)
+	}
+	rc := &RequestConfig{Host: host, Method: strings.ToUpper(method)}
+	if err := rc.NormalizeHost(); err != nil {
+		return nil, errors.Wrapf(err, "Invalid URL for operation %s", operationID)
+	}
+	query := url.Values{}
+	for _, param := range op.Parameters {
+		if !param.Required {
+			continue
+		}
+		switch param.In {
+		case "header":
+			rc.Headers = append(rc.Headers, param.Name+": "+exampleText(param.Example, param.Schema))
+		case "query":
+			query.Add(param.Name, exampleText(param.Example, param.Schema))
+		}
+	}
+	if len(query) > 0 {
+		rc.Host.RawQuery = query.Encode()
+	}
+	if op.RequestBody == nil || len(op.RequestBody.Content) == 0 {
+		return rc, nil
+	}
+	mediaTypes := make([]string, 0, len(op.RequestBody.Content))
+	for mediaType := range op.RequestBody.Content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+	mediaType := mediaTypes[0]
+	if _, ok := op.RequestBody.Content["application/json"]; ok {
+		mediaType = "application/json"
+	}
+	content := op.RequestBody.Content[mediaType]
+	rc.Headers = append(rc.Headers, "Content-Type: "+mediaType)
+	var body any
+	if len(content.Example) > 0 {
+		err = json.Unmarshal(content.Example, &body)
+	} else if content.Schema != nil && strings.HasSuffix(mediaType, "json") {
+		body, err = spec.skeleton(content.Schema, 0)
+	} else {
+		err = errors.Errorf("Unsupported request body of type %s", mediaType)
+	}
+	if err != nil {
+		rc.Body = []string{commentPrefix + " TODO: write the " + mediaType + " request body (" + err.Error() + ")"}
+		return rc, nil
+	}
+	out, err := json.MarshalIndent(body, "", "  ")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to encode the body of operation %s", operationID)
+	}
+	rc.Body = strings.Split(string(out), "\n")
+	return rc, nil
+}
+
+// findOperation returns the path, method and definition of the operation with the given ID.
+func (spec *openAPISpec) findOperation(operationID string) (string, string, *openAPIOperation, error) {
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for _, method := range openAPIMethods {
+			raw, ok := spec.Paths[path][method]
+			if !ok {
+				continue
+			}
+			var op openAPIOperation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return "", "", nil, errors.Wrapf(err, "Invalid operation %s %s", strings.ToUpper(method), path)
+			}
+			if op.OperationID == operationID {
+				return path, method, &op, nil
+			}
+		}
+	}
+	return "", "", nil, errors.Errorf("Operation not found: %s", operationID)
+}
+
+// serverURL returns the first server URL of the spec with its variables set to their defaults, or
+// openAPIDefaultServer when the spec declares no absolute server URL.
+func (spec *openAPISpec) serverURL() string {
+	if len(spec.Servers) == 0 {
+		return openAPIDefaultServer
+	}
+	server := spec.Servers[0]
+	serverURL := server.URL
+	for name, variable := range server.Variables {
+		serverURL = strings.ReplaceAll(serverURL, "{"+name+"}", variable.Default)
+	}
+	if !strings.Contains(serverURL, "://") {
+		return openAPIDefaultServer + "/" + strings.TrimPrefix(serverURL, "/")
+	}
+	return serverURL
+}
+
+// openAPIMaxDepth bounds the nesting of the skeletons built from recursive schemas.
+const openAPIMaxDepth = 8
+
+// skeleton builds a value matching the schema: its example when it has one, otherwise an object
+// holding its required properties, or every property when none is required, and zero values.
+func (spec *openAPISpec) skeleton(schema *openAPISchema, depth int) (any, error) {
+	if depth > openAPIMaxDepth {
+		return nil, nil
+	}
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		resolved, ok := spec.Components.Schemas[name]
+		if !ok || name == schema.Ref {
+			return nil, errors.Errorf("unsupported schema reference %s", schema.Ref)
+		}
+		return spec.skeleton(resolved, depth+1)
+	}
+	if len(schema.Example) > 0 {
+		var example any
+		err := json.Unmarshal(schema.Example, &example)
+		return example, err
+	}
+	if len(schema.Enum) > 0 {
+		var value any
+		err := json.Unmarshal(schema.Enum[0], &value)
+		return value, err
+	}
+	switch schema.Type {
+	case "string":
+		return "", nil
+	case "integer", "number":
+		return 0, nil
+	case "boolean":
+		return false, nil
+	case "array":
+		if schema.Items == nil {
+			return []any{}, nil
+		}
+		item, err := spec.skeleton(schema.Items, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return []any{item}, nil
+	case "object", "":
+		if schema.Type == "" && schema.Properties == nil {
+			return nil, errors.New("schema without a type")
+		}
+		names := schema.Required
+		if len(names) == 0 {
+			for name := range schema.Properties {
+				names = append(names, name)
+			}
+		}
+		object := make(map[string]any, len(names))
+		for _, name := range names {
+			property, ok := schema.Properties[name]
+			if !ok {
+				object[name] = nil
+				continue
+			}
+			value, err := spec.skeleton(property, depth+1)
+			if err != nil {
+				// A property that cannot be scaffolded is left for the user to fill
+				value = nil
+			}
+			object[name] = value
+		}
+		return object, nil
+	}
+	return nil, errors.Errorf("unsupported schema type %s", schema.Type)
+}
+
+// exampleText returns the example of a parameter as text, or an empty string without example.
+func exampleText(example json.RawMessage, schema *openAPISchema) string {
+	if len(example) == 0 && schema != nil {
+		example = schema.Example
+	}
+	if len(example) == 0 {
+		return ""
+	}
+	var text string
+	if err := json.Unmarshal(example, &text); err == nil {
+		return text
+	}
+	return string(example)
+}
@@ -0,0 +1,93 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const openAPIFixture = `{
+  "openapi": "3.0.3",
+  "servers": [{"url": "https://{env}.example.com/v1", "variables": {"env": {"default": "api"}}}],
+  "paths": {
+    "/users/{id}": {
+      "get": {
+        "operationId": "getUser",
+        "parameters": [
+          {"name": "id", "in": "path", "required": true},
+          {"name": "X-Tenant", "in": "header", "required": true, "example": "acme"},
+          {"name": "fields", "in": "query", "required": true, "schema": {"type": "string", "example": "name"}},
+          {"name": "debug", "in": "query"}
+        ]
+      }
+    },
+    "/users": {
+      "post": {
+        "operationId": "createUser",
+        "requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}}
+      },
+      "put": {
+        "operationId": "uploadUsers",
+        "requestBody": {"content": {"text/csv": {"schema": {"type": "string"}}}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "User": {
+        "type": "object",
+        "required": ["name", "age", "tags"],
+        "properties": {
+          "name": {"type": "string", "example": "vortex"},
+          "age": {"type": "integer"},
+          "tags": {"type": "array", "items": {"type": "string"}},
+          "nickname": {"type": "string"}
+        }
+      }
+    }
+  }
+}`
+
+func TestFromOpenAPIOperation(t *testing.T) {
+	spec := filepath.Join(t.TempDir(), "openapi.json")
+	if err := os.WriteFile(spec, []byte(openAPIFixture), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := FromOpenAPIOperation(spec, "getUser")
+	if err != nil {
+		t.Fatalf("FromOpenAPIOperation(getUser) error = %v", err)
+	}
+	if rc.Method != "GET" || rc.Host.String() != "https://api.example.com/v1/users/%7Bid%7D?fields=name" || rc.Host.Path != "/v1/users/{id}" {
+		t.Errorf("getUser = %s %s, want GET with the {id} placeholder and the required query", rc.Method, rc.Host)
+	}
+	if want := []string{"X-Tenant: acme"}; !reflect.DeepEqual(rc.Headers, want) || rc.Body != nil {
+		t.Errorf("getUser Headers = %q, Body = %q, want %q and no body", rc.Headers, rc.Body, want)
+	}
+
+	rc, err = FromOpenAPIOperation(spec, "createUser")
+	if err != nil {
+		t.Fatalf("FromOpenAPIOperation(createUser) error = %v", err)
+	}
+	if rc.Method != "POST" || rc.Host.String() != "https://api.example.com/v1/users" {
+		t.Errorf("createUser = %s %s, want POST https://api.example.com/v1/users", rc.Method, rc.Host)
+	}
+	wantBody := []string{"{", `  "age": 0,`, `  "name": "vortex",`, `  "tags": [`, `    ""`, "  ]", "}"}
+	if !reflect.DeepEqual(rc.Body, wantBody) || !reflect.DeepEqual(rc.Headers, []string{"Content-Type: application/json"}) {
+		t.Errorf("createUser Headers = %q, Body = %q, want the JSON content type and %q", rc.Headers, rc.Body, wantBody)
+	}
+
+	rc, err = FromOpenAPIOperation(spec, "uploadUsers")
+	if err != nil {
+		t.Fatalf("FromOpenAPIOperation(uploadUsers) error = %v", err)
+	}
+	if len(rc.Body) != 1 || !strings.HasPrefix(rc.Body[0], "# TODO") {
+		t.Errorf("uploadUsers Body = %q, want a commented placeholder", rc.Body)
+	}
+
+	if _, err := FromOpenAPIOperation(spec, "deleteUser"); err == nil || !strings.Contains(err.Error(), "Operation not found") {
+		t.Errorf("FromOpenAPIOperation(deleteUser) error = %v, want the operation not found", err)
+	}
+}
@@ -0,0 +1,123 @@
+package data
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// overrideSetters apply the value of a `key=value` override to a RequestConfig, keyed by the
+// lowercased override key.
+var overrideSetters = map[string]func(rc *RequestConfig, value string, cfg Config) error{
+	"host":    overrideHost,
+	"method":  func(rc *RequestConfig, value string, _ Config) error { rc.Method = strings.ToUpper(value); return nil },
+	"backend": func(rc *RequestConfig, value string, _ Config) error { rc.Backend = value; return nil },
+	"body": func(rc *RequestConfig, value string, _ Config) error {
+		rc.Body, rc.BodyFile, rc.BodyFromStdin = strings.Split(value, "\n"), "", false
+		return nil
+	},
+	"bodyfile": func(rc *RequestConfig, value string, _ Config) error {
+		rc.Body, rc.BodyFile, rc.BodyFromStdin = nil, value, false
+		return nil
+	},
+	"header": func(rc *RequestConfig, value string, _ Config) error {
+		if problem := headerProblem(value); problem != "" {
+			return errors.New(problem)
+		}
+		rc.Headers = mergeHeaders(rc.Headers, []string{value})
+		return nil
+	},
+	"query": func(rc *RequestConfig, value string, cfg Config) error {
+		if rc.Host == nil {
+			return errors.New("Cannot add a query parameter without a host")
+		}
+		delim := cfg.Delimiter()
+		split, err := splitQueryLine(value, cfg)
+		if err != nil {
+			return err
+		}
+		params := strings.Join(split, delim)
+		if rc.Host.RawQuery != "" && params != "" {
+			params = delim + params
+		}
+		rc.Host.RawQuery += params
+		return nil
+	},
+	"param": func(rc *RequestConfig, value string, _ Config) error {
+		name, paramValue, found := splitKeyValue(value)
+		if !found || name == "" {
+			return errors.Errorf("Malformed path parameter, expected name=value: %q", value)
+		}
+		if rc.PathParams == nil {
+			rc.PathParams = make(map[string]string)
+		}
+		rc.PathParams[name] = paramValue
+		if rc.Host == nil {
+			return nil
+		}
+		return rc.SubstitutePathParams(rc.PathParams)
+	},
+	"timeout": func(rc *RequestConfig, value string, _ Config) error {
+		seconds, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || seconds < 0 {
+			return errors.Errorf("Invalid timeout, expected a number of seconds: %q", value)
+		}
+		rc.Timeout = int32(seconds)
+		return nil
+	},
+}
+
+// overrideKeys lists the keys of the overrides, as written in the error of ApplyOverrides.
+var overrideKeys = []string{"Backend", "Body", "BodyFile", "Header", "Host", "Method", "Param", "Query", "Timeout"}
+
+// The method ApplyOverrides applies `key=value` overrides, such as those of the repeated `-set`
+// flag, to a parsed RequestConfig, for one-off tweaks that do not warrant editing the template.
+// Keys are case-insensitive:
+//   - Host replaces the URL of the request. A host without a path, such as
+//     `http://localhost:9000`, only replaces the scheme and authority, keeping the path and query
+//     of the template.
+//   - Method, Backend, Body, BodyFile and Timeout, in seconds, replace the setting.
+//   - Header adds a `Name: value` header, replacing the headers of the same name.
+//   - Query appends `key=value` parameters, encoded like those of the [Query] section and joined
+//     with the query delimiter of the configuration.
+//   - Param sets a `name=value` path parameter and substitutes it for the `{name}` placeholders
+//     left in the path.
+//
+// Parameters:
+//   - overrides: The `key=value` overrides, applied in order.
+//   - cfg: The configuration providing the query delimiter and interpolation syntax.
+//
+// Returns:
+//   - An error naming the malformed override, or listing the valid keys for an unknown one.
+func (rc *RequestConfig) ApplyOverrides(overrides []string, cfg Config) error {
+	for _, override := range overrides {
+		key, value, found := strings.Cut(override, "=")
+		if !found {
+			return errors.Errorf("Malformed override, expected key=value: %q", override)
+		}
+		setter, ok := overrideSetters[strings.ToLower(strings.TrimSpace(key))]
+		if !ok {
+			return errors.Errorf("Unknown override key %q, expected one of %s", key, strings.Join(overrideKeys, ", "))
+		}
+		if err := setter(rc, value, cfg); err != nil {
+			return errors.Wrapf(err, "Invalid override %s", key)
+		}
+	}
+	return nil
+}
+
+// overrideHost replaces the host of the request as described by ApplyOverrides.
+func overrideHost(rc *RequestConfig, value string, cfg Config) error {
+	host, err := parseHost(value, cfg)
+	if err != nil {
+		return err
+	}
+	if rc.Host != nil && host.Path == "" && host.RawQuery == "" {
+		retargeted := *rc.Host
+		retargeted.Scheme, retargeted.Opaque, retargeted.User, retargeted.Host = host.Scheme, host.Opaque, host.User, host.Host
+		host = &retargeted
+	}
+	rc.Host = host
+	return nil
+}
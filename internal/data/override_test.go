@@ -0,0 +1,35 @@
+package data
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestApplyOverridesQueryDelimiter(t *testing.T) {
+	delim := ";"
+	cfg := Config{QueryDelim: &delim}
+	rc := &RequestConfig{Host: &url.URL{Scheme: "https", Host: "example.com", RawQuery: "a=1"}}
+	if err := rc.ApplyOverrides([]string{"Query=b=2;c=3", "query=d=4"}, cfg); err != nil {
+		t.Fatalf("ApplyOverrides() error = %v", err)
+	}
+	if want := "a=1;b=2;c=3;d=4"; rc.Host.RawQuery != want {
+		t.Errorf("RawQuery = %q, want %q", rc.Host.RawQuery, want)
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	rc := &RequestConfig{Host: &url.URL{Scheme: "https", Host: "example.com", Path: "/users/{id}"}, Headers: []string{"X-Debug: 0"}}
+	overrides := []string{"Method=post", "header=X-Debug: 1", "Timeout=5", "Param=id=42", "Host=http://localhost:9000"}
+	if err := rc.ApplyOverrides(overrides, Config{}); err != nil {
+		t.Fatalf("ApplyOverrides() error = %v", err)
+	}
+	if rc.Method != "POST" || rc.Timeout != 5 || len(rc.Headers) != 1 || rc.Headers[0] != "X-Debug: 1" {
+		t.Errorf("Method = %q, Timeout = %d, Headers = %q", rc.Method, rc.Timeout, rc.Headers)
+	}
+	if want := "http://localhost:9000/users/42"; rc.Host.String() != want {
+		t.Errorf("Host = %s, want %s", rc.Host, want)
+	}
+	if err := rc.ApplyOverrides([]string{"Unknown=1"}, Config{}); err == nil {
+		t.Error("ApplyOverrides() with an unknown key succeeded, want an error")
+	}
+}
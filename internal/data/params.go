@@ -0,0 +1,57 @@
+package data
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// pathParamPattern matches a `{name}` placeholder in the escaped path of the host, where the
+// braces may have been percent-encoded while parsing the URL.
+var pathParamPattern = regexp.MustCompile(`(?i)(?:\{|%7B)([A-Za-z_][A-Za-z0-9_]*)(?:\}|%7D)`)
+
+// The method SubstitutePathParams replaces every `{name}` placeholder in the path of the host with
+// the URL-escaped value of the matching parameter, as in `/users/{id}/posts/{postId}`. Placeholders
+// without a matching parameter are an error when StrictPathParams is true, and are otherwise left
+// in the path as written.
+//
+// Parameters:
+//   - params: The values of the path parameters, keyed by placeholder name.
+//
+// Returns:
+//   - An error if the RequestConfig has no host, or if StrictPathParams is true and a placeholder
+//     has no matching parameter.
+func (rc *RequestConfig) SubstitutePathParams(params map[string]string) error {
+	if rc.Host == nil {
+		return errors.New("Cannot substitute path parameters without a host")
+	}
+	raw := rc.Host.RawPath
+	if raw == "" {
+		raw = rc.Host.EscapedPath()
+	}
+	var missing []string
+	escaped := pathParamPattern.ReplaceAllStringFunc(raw, func(placeholder string) string {
+		name := pathParamPattern.FindStringSubmatch(placeholder)[1]
+		value, ok := params[name]
+		if !ok {
+			missing = append(missing, name)
+			// Keep the braces encoded so the raw path remains a valid encoding of the path
+			return "%7B" + name + "%7D"
+		}
+		return url.PathEscape(value)
+	})
+	if len(missing) > 0 && rc.StrictPathParams {
+		sort.Strings(missing)
+		return errors.Errorf("Missing path parameters: %s", strings.Join(missing, ", "))
+	}
+	path, err := url.PathUnescape(escaped)
+	if err != nil {
+		return errors.Wrap(err, "Invalid path after substituting path parameters")
+	}
+	rc.Host.Path = path
+	rc.Host.RawPath = escaped
+	return nil
+}
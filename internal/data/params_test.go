@@ -0,0 +1,48 @@
+package data
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSubstitutePathParams(t *testing.T) {
+	tests := map[string]struct {
+		path   string
+		params map[string]string
+		strict bool
+		want   string
+	}{
+		"several":      {"/users/{id}/posts/{postId}", map[string]string{"id": "42", "postId": "7"}, true, "https://example.com/users/42/posts/7"},
+		"escaping":     {"/files/{name}", map[string]string{"name": "a b/c?"}, true, "https://example.com/files/a%20b%2Fc%3F"},
+		"lenient miss": {"/users/{id}/posts/{postId}", map[string]string{"id": "42"}, false, "https://example.com/users/42/posts/%7BpostId%7D"},
+	}
+	for name, tt := range tests {
+		rc := &RequestConfig{Host: &url.URL{Scheme: "https", Host: "example.com", Path: tt.path}, StrictPathParams: tt.strict}
+		if err := rc.SubstitutePathParams(tt.params); err != nil {
+			t.Errorf("%s: SubstitutePathParams() error = %v", name, err)
+			continue
+		}
+		if got := rc.Host.String(); got != tt.want {
+			t.Errorf("%s: Host = %q, want %q", name, got, tt.want)
+		}
+	}
+
+	rc := &RequestConfig{Host: &url.URL{Scheme: "https", Host: "example.com", Path: "/users/{id}/{org}"}, StrictPathParams: true}
+	err := rc.SubstitutePathParams(map[string]string{})
+	if err == nil || !strings.Contains(err.Error(), "Missing path parameters: id, org") {
+		t.Errorf("SubstitutePathParams() error = %v, want the missing parameters listed", err)
+	}
+}
+
+func TestParseTemplateParams(t *testing.T) {
+	t.Setenv("VORTEX_TEST_USER", "alice")
+	tmpl := "[Host]\nhttps://example.com/users/{user}/repos/{repo}\n[Params]\nuser = ${VORTEX_TEST_USER}\nrepo = my repo\n"
+	rc, err := ParseTemplate("", tmpl, Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if want := "https://example.com/users/alice/repos/my%20repo"; rc.Host.String() != want {
+		t.Errorf("Host = %q, want %q", rc.Host, want)
+	}
+}
@@ -0,0 +1,783 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/larayavrs/vortex/pkg"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TemplateFormatVersion is the newest template format version understood by ParseTemplate.
+	TemplateFormatVersion = 1
+
+	// TemplateVersionPrefix starts the comment, on the first line of a template, that declares
+	// the format version of the template, as in `# vortex-template v1`.
+	TemplateVersionPrefix = "# vortex-template v"
+
+	// commentPrefix starts a comment line in a template.
+	commentPrefix = "#"
+)
+
+// Names of the sections recognized by ParseTemplate.
+const (
+	sectionHost    = "Host"
+	sectionPath    = "Path"
+	sectionMethod  = "Method"
+	sectionHeaders = "Headers"
+	sectionQuery   = "Query"
+	sectionBody    = "Body"
+	sectionJSON    = "Json"
+	sectionBackend = "Backend"
+	sectionTLS     = "TLS"
+	sectionParams  = "Params"
+	sectionExpect  = "Expect"
+	sectionAuth    = "Auth"
+	sectionResolve = "Resolve"
+	sectionTimeout = "Timeout"
+)
+
+// knownSections maps the name of each section recognized by ParseTemplate to whether it can be
+// declared more than once in a template, its lines being merged.
+var knownSections = map[string]bool{
+	sectionHost:    false,
+	sectionPath:    false,
+	sectionMethod:  false,
+	sectionHeaders: true,
+	sectionQuery:   true,
+	sectionBody:    false,
+	sectionJSON:    true,
+	sectionBackend: false,
+	sectionTLS:     true,
+	sectionParams:  true,
+	sectionExpect:  true,
+	sectionAuth:    true,
+	sectionResolve: true,
+	sectionTimeout: false,
+}
+
+// ParseError is the error returned by ParseTemplate for a template line holding an invalid value,
+// such as a malformed URL or header, or declaring an unknown section in strict mode. It is wrapped
+// in the errors of ParseTemplate, so it can be retrieved with errors.As.
+type ParseError struct {
+	// Filename is the template holding the line, which is an included template for included lines.
+	// It may be empty.
+	Filename string
+
+	// Line is the number of the line in the template, starting at 1.
+	Line int
+
+	// Section is the name of the section of the line, without brackets, or an empty string for a
+	// line outside of any section.
+	Section string
+
+	// Msg describes what is wrong with the line.
+	Msg string
+
+	// Err is the underlying error, if any, also described by Msg.
+	Err error
+}
+
+// The method Error formats the error as `line 12 in [Host]: Msg`.
+func (e *ParseError) Error() string {
+	if e.Section == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	}
+	return fmt.Sprintf("line %d in [%s]: %s", e.Line, e.Section, e.Msg)
+}
+
+// The method Unwrap returns the underlying error, so errors.Is and errors.As can inspect it.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// The method ExitCode returns ExitParseError.
+func (e *ParseError) ExitCode() int {
+	return ExitParseError
+}
+
+// lineError returns a ParseError locating the error at the template line.
+func lineError(tl templateLine, err error) error {
+	return &ParseError{Filename: tl.filename, Line: tl.line, Section: tl.section, Msg: err.Error(), Err: err}
+}
+
+// The function TemplateVersionHeader returns the comment line declaring the current template
+// format version, which is written on the first line of generated templates.
+func TemplateVersionHeader() string {
+	return TemplateVersionPrefix + strconv.Itoa(TemplateFormatVersion)
+}
+
+// The function ParseTemplate parses the contents of an INI-like template into a RequestConfig.
+// The template is made of sections, each introduced by a `[Name]` line:
+//   - [Host]: the target URL. Its variable references are expanded with ExpandTemplate before
+//     the URL is parsed, while those of the headers and body are left to Interpolate. A missing
+//     scheme defaults to http, as described by NormalizeHost.
+//   - [Path]: a path appended to the path of the host, so a base URL can be written once, with a
+//     single `/` between them whatever their slashes. It is expanded like the host, and the query
+//     and path parameters apply to the joined URL. In a template extending another, the path is
+//     appended to the host of the base template.
+//   - [Method]: the HTTP method.
+//   - [Headers]: one `Name: value` header per line, checked by ValidateHeaders unless it holds a
+//     variable reference, in which case Interpolate checks it once expanded. An `@path` line
+//     inserts the headers of a file, relative to the template, holding one `Name: value` header
+//     per line.
+//   - [Query]: `key=value` pairs separated by the query delimiter or written on separate lines,
+//     appended in order to the query of the host. Repeated keys are all kept. Keys and values are
+//     expanded, then percent-encoded, while the delimiter joining them is kept as is.
+//   - [Body]: the lines of the request body, kept verbatim up to the next section, or a single
+//     `@path` line reading the body from a file relative to the template, or a single `-` line
+//     setting BodyFromStdin. A leading `@@` stands for a literal `@`. Comment lines preceding the
+//     body are dropped unless KeepBodyComments is set in the configuration, while those within
+//     the body are part of it. A line starting with a `\` followed by what would otherwise end
+//     the body, such as `\[Name]` or `\@include`, or be dropped as a leading comment, is part of
+//     the body without the `\`. A `[/Body]` line, in any case, ends the body explicitly, every
+//     line before it being kept verbatim, so a body holding lines such as `[Host]` needs no
+//     escape. A body line that is literally `[/Body]` is written `\[/Body]`.
+//   - [Json]: `key=value` lines assembled into a JSON object set as the body, with an
+//     `application/json` Content-Type header unless the template sets one. The values are
+//     strings, while `key:=raw` lines hold raw JSON values such as numbers, booleans or arrays,
+//     and the dots of a key, as in `user.id=5`, build nested objects. It cannot be combined with
+//     a [Body].
+//   - [Backend]: the backend name, followed by one line of backend options per line. The
+//     options, passed as is to the backend, can read and write any file, so a remote template
+//     cannot set them.
+//   - [TLS]: `key = value` settings, the `cert` and `key` paths of a client certificate and the
+//     `ca` bundle, relative to the template.
+//   - [Params]: `name = value` path parameters, whose values are expanded with ExpandTemplate and
+//     substituted for the `{name}` placeholders of the host path.
+//   - [Expect]: `key = value` assertions on the response, checked by CheckExpectations. The
+//     `status` key sets the expected status code, the `body` key a string the body must contain,
+//     the `body_regex` key a regular expression the body must match, and the `schema` key names a
+//     JSON Schema file, relative to the template, the body must match.
+//   - [Auth]: `key = value` settings of the authentication applied by ApplyAuth. The `type` key
+//     selects the scheme, `basic`, `bearer`, `awsv4`, `hmac` or `oauth2`, and the others its
+//     parameters.
+//   - [Resolve]: one `host:port:address` override per line, connecting to the address instead of
+//     resolving the host, as with the curl `--resolve` option.
+//   - [Timeout]: the maximum duration of the request, in seconds, setting Timeout.
+//
+// A leading UTF-8 byte order mark is ignored. Outside of the [Body], blank lines and lines starting
+// with `#` are ignored, and so are trailing ` # comments`. A `\#` following whitespace stands for
+// a literal `#`, as in the header `X-Tag: a \# b`. An `@include path` line splices the lines
+// of another template, resolved relative to the including template and then in the IncludePaths of
+// the configuration. Included templates can include others, up to a bounded depth, but include cycles
+// are rejected. The sections of an included template do not leak into the including one.
+//
+// An `@extends path` line, resolved like an include, names a base template that is parsed on its
+// own and merged into this one with MergeParent: the settings of this template override those of
+// the base, headers and query parameters being merged by name. The [Query] of a template extending
+// another can be declared without a host, to refine the query of the base.
+//
+// An `@no-default-headers` line sets NoDefaultHeaders, so the machine-wide default headers are not
+// applied to the request, and an `@confirm` line sets Confirm, so sending the request with a
+// destructive method must be confirmed.
+//
+// The names of the known sections are matched case-insensitively and may be padded with spaces, so
+// `[ HEADERS ]` introduces the [Headers] section, while `[ 1 ]` is not a section header. Unknown
+// sections, such as a misspelled `[Header]`, and sections that cannot be merged but are declared
+// more than once in the same file are reported as warnings through the configured Logger, and
+// their lines are ignored or merged as before. When the configuration is Strict they are rejected
+// instead.
+//
+// A `# vortex-template vN` comment on the first line declares the template format version. Its
+// absence means version 1, while a version newer than TemplateFormatVersion is rejected.
+//
+// Parameters:
+//   - filename: The name of the template, used in error messages. It may be empty.
+//   - tmpl: The contents of the template.
+//   - cfg: The configuration providing the interpolation syntax, the query delimiter and the
+//     include search path.
+//
+// Returns:
+//   - The parsed RequestConfig.
+//   - An error if the format version is not supported, an include cannot be resolved or a section
+//     holds an invalid value. The errors caused by a line of the template wrap a ParseError holding
+//     its line number and section, as in `line 12 in [Host]: ...`.
+func ParseTemplate(filename, tmpl string, cfg Config) (*RequestConfig, error) {
+	var chain []string
+	if filename != "" {
+		if abs, err := filepath.Abs(filename); err == nil {
+			chain = append(chain, abs)
+		}
+	}
+	return parseTemplate(filename, tmpl, cfg, chain)
+}
+
+// parseTemplate implements ParseTemplate. The chain holds the absolute paths of the templates
+// being parsed, from the outermost child to the template itself, to reject extends cycles.
+func parseTemplate(filename, tmpl string, cfg Config, chain []string) (*RequestConfig, error) {
+	rc := &RequestConfig{remote: IsRemoteTemplate(filename), queryDelim: cfg.Delimiter()}
+	cfg = rc.ExpansionConfig(cfg)
+	lines, err := readTemplateLines(filename, tmpl, cfg, chain)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse template %s", filename)
+	}
+	openDelim, _ := cfg.InterpolationDelims()
+	var queryParts, pathParts []string
+	var backendLines, queryLines, bodyLines, pathLines, jsonLines []templateLine
+	var jsonBody *jsonObject
+	var parentLine *templateLine
+	for i, tl := range lines {
+		line := tl.text
+		switch tl.section {
+		case extendsSection:
+			if parentLine != nil {
+				return nil, errors.Errorf("Template %s extends more than one template", filename)
+			}
+			parentLine = &lines[i]
+		case noDefaultHeadersDirective:
+			rc.NoDefaultHeaders = true
+		case confirmDirective:
+			rc.Confirm = true
+		case sectionHost:
+			if rc.Host != nil {
+				return nil, errors.Wrapf(lineError(tl, errors.New("Template declares more than one host")), "Failed to parse template %s", filename)
+			}
+			host, err := parseHost(line, cfg)
+			if err != nil {
+				return nil, errors.Wrapf(lineError(tl, err), "Failed to parse template %s", filename)
+			}
+			rc.Host = host
+		case sectionPath:
+			pathLines = append(pathLines, tl)
+			pathParts = append(pathParts, line)
+		case sectionMethod:
+			rc.Method = strings.ToUpper(line)
+		case sectionHeaders:
+			if !strings.HasPrefix(line, headersFilePrefix) {
+				if problem := headerProblem(line); problem != "" && !strings.Contains(line, openDelim) {
+					err := errors.Errorf("Malformed header, expected Name: value: %s", problem)
+					return nil, errors.Wrapf(lineError(tl, err), "Failed to parse template %s", filename)
+				}
+				rc.Headers = append(rc.Headers, line)
+				break
+			}
+			path, err := resolveTemplatePath(tl.filename, strings.TrimPrefix(line, headersFilePrefix))
+			if err != nil {
+				return nil, errors.Wrapf(lineError(tl, err), "Failed to parse template %s", filename)
+			}
+			headers, err := ReadHeadersFile(path)
+			if err != nil {
+				return nil, errors.Wrapf(lineError(tl, err), "Failed to parse template %s", filename)
+			}
+			rc.Headers = append(rc.Headers, headers...)
+		case sectionQuery:
+			queryLines = append(queryLines, tl)
+			params, err := splitQueryLine(line, cfg)
+			if err != nil {
+				return nil, errors.Wrapf(lineError(tl, err), "Failed to parse template %s", filename)
+			}
+			queryParts = append(queryParts, params...)
+		case sectionBody:
+			bodyLines = append(bodyLines, tl)
+			rc.Body = append(rc.Body, line)
+		case sectionJSON:
+			if jsonBody == nil {
+				jsonBody = newJSONObject()
+			}
+			jsonLines = append(jsonLines, tl)
+			if err := addJSONField(jsonBody, line); err != nil {
+				return nil, errors.Wrapf(lineError(tl, err), "Failed to parse template %s", filename)
+			}
+		case sectionBackend:
+			backendLines = append(backendLines, tl)
+		case sectionTLS:
+			if err := parseTLSLine(rc, tl.filename, line); err != nil {
+				return nil, errors.Wrapf(lineError(tl, err), "Failed to parse template %s", filename)
+			}
+		case sectionParams:
+			if err := parseParamLine(rc, line, cfg); err != nil {
+				return nil, errors.Wrapf(lineError(tl, err), "Failed to parse template %s", filename)
+			}
+		case sectionExpect:
+			if err := parseExpectLine(rc, tl.filename, line); err != nil {
+				return nil, errors.Wrapf(lineError(tl, err), "Failed to parse template %s", filename)
+			}
+		case sectionAuth:
+			if err := parseAuthLine(rc, line); err != nil {
+				return nil, errors.Wrapf(lineError(tl, err), "Failed to parse template %s", filename)
+			}
+		case sectionResolve:
+			if err := parseResolveLine(rc, line); err != nil {
+				return nil, errors.Wrapf(lineError(tl, err), "Failed to parse template %s", filename)
+			}
+		case sectionTimeout:
+			seconds, err := strconv.ParseInt(line, 10, 32)
+			if err != nil || seconds < 0 {
+				err := errors.Errorf("Invalid timeout, expected a number of seconds: %q", line)
+				return nil, errors.Wrapf(lineError(tl, err), "Failed to parse template %s", filename)
+			}
+			rc.Timeout = int32(seconds)
+		}
+	}
+	if len(pathParts) > 0 {
+		if err := appendHostPath(rc, pathParts, parentLine != nil, cfg); err != nil {
+			return nil, errors.Wrapf(lineError(pathLines[0], err), "Failed to parse template %s", filename)
+		}
+	}
+	if len(queryParts) > 0 {
+		if rc.Host == nil && parentLine == nil {
+			err := lineError(queryLines[0], errors.New("Query without a host"))
+			return nil, errors.Wrapf(err, "Template %s declares a query without a host", filename)
+		}
+		if rc.Host == nil {
+			// Keep the query alone, MergeParent completes it with the host of the parent
+			rc.Host = &url.URL{}
+		}
+		if rc.Host.RawQuery != "" {
+			queryParts = append([]string{rc.Host.RawQuery}, queryParts...)
+		}
+		rc.Host.RawQuery = strings.Join(queryParts, cfg.Delimiter())
+	}
+	if len(bodyLines) > 0 {
+		// Resolve a body file relative to the template declaring it, which may be an include
+		if err := parseBodyReference(rc, bodyLines[0].filename); err != nil {
+			return nil, errors.Wrapf(lineError(bodyLines[0], err), "Failed to parse template %s", filename)
+		}
+	}
+	if jsonBody != nil {
+		if err := setJSONBody(rc, jsonBody, len(bodyLines) > 0); err != nil {
+			return nil, errors.Wrapf(lineError(jsonLines[0], err), "Failed to parse template %s", filename)
+		}
+	}
+	if len(backendLines) > 0 {
+		rc.Backend = backendLines[0].text
+		for _, tl := range backendLines[1:] {
+			if rc.remote {
+				err := errors.Errorf("The remote template %s cannot set backend options", filename)
+				return nil, errors.Wrapf(lineError(tl, err), "Failed to parse template %s", filename)
+			}
+			options, err := pkg.TokenizeLine(tl.text)
+			if err != nil {
+				return nil, errors.Wrapf(lineError(tl, err), "Failed to parse backend options of template %s", filename)
+			}
+			rc.BackendOptions = append(rc.BackendOptions, options)
+		}
+	}
+	if parentLine != nil {
+		parent, err := parseParentTemplate(parentLine, cfg, chain)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse template %s", filename)
+		}
+		rc.MergeParent(parent)
+	}
+	if len(rc.PathParams) > 0 {
+		if err := rc.SubstitutePathParams(rc.PathParams); err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse template %s", filename)
+		}
+	}
+	return rc, nil
+}
+
+// setJSONBody sets the object built from the [Json] section as the body of the request, along with
+// an `application/json` Content-Type header unless the template sets one.
+func setJSONBody(rc *RequestConfig, body *jsonObject, hasBody bool) error {
+	if hasBody {
+		return errors.New("A template cannot declare both a [Body] and a [Json] section")
+	}
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode the [Json] body")
+	}
+	rc.Body = []string{string(encoded)}
+	for _, header := range rc.Headers {
+		if name, _, _ := SplitHeader(header); strings.EqualFold(name, contentTypeHeader) {
+			return nil
+		}
+	}
+	rc.Headers = append(rc.Headers, contentTypeHeader+": application/json")
+	return nil
+}
+
+// appendHostPath joins the lines of the [Path] section and appends them to the path of the host.
+// Without a host, the path is kept alone when the template extends another, for MergeParent to
+// append it to the host of the parent.
+func appendHostPath(rc *RequestConfig, lines []string, extends bool, cfg Config) error {
+	expanded, err := ExpandTemplate(strings.Join(lines, ""), cfg)
+	if err != nil {
+		return err
+	}
+	path, err := url.Parse(expanded)
+	if err != nil || path.Scheme != "" || path.Host != "" {
+		return errors.Errorf("Invalid path %q, expected a path without scheme nor host", expanded)
+	}
+	if rc.Host == nil {
+		if !extends {
+			return errors.New("A [Path] requires a [Host]")
+		}
+		rc.Host = &url.URL{}
+	}
+	joinHostPath(rc.Host, path, cfg.Delimiter())
+	return nil
+}
+
+// joinHostPath appends the path, and the query, of the relative URL to the host, with a single
+// `/` between the paths. The queries are merged on delim.
+func joinHostPath(host, path *url.URL, delim string) {
+	escaped := path.EscapedPath()
+	if escaped != "" {
+		joined := strings.TrimRight(host.EscapedPath(), "/") + "/" + strings.TrimLeft(escaped, "/")
+		if unescaped, err := url.PathUnescape(joined); err == nil {
+			host.Path, host.RawPath = unescaped, joined
+		}
+	}
+	if path.RawQuery != "" {
+		host.RawQuery = mergeQueries(host.RawQuery, path.RawQuery, delim)
+	}
+}
+
+// parseParentTemplate resolves the template named by an extends directive like an include, and
+// parses it.
+func parseParentTemplate(tl *templateLine, cfg Config, chain []string) (*RequestConfig, error) {
+	if tl.text == "" {
+		return nil, errors.Errorf("Missing filename in %s directive", extendsDirective)
+	}
+	path, err := ResolveInclude(tl.text, tl.filename, cfg.IncludePaths)
+	if err != nil {
+		return nil, errors.Wrap(err, "Base template not found")
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to resolve base template: %s", path)
+	}
+	if err := checkIncludeChain(abs, chain); err != nil {
+		return nil, err
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read base template: %s", path)
+	}
+	return parseTemplate(path, string(contents), cfg, append(chain, abs))
+}
+
+// bodyFilePrefix starts the single line of a [Body] section that references a file holding the
+// body. A body whose first line really starts with the prefix escapes it by doubling it.
+const bodyFilePrefix = "@"
+
+// bodyStdinMarker is the single line of a [Body] section read from stdin instead.
+const bodyStdinMarker = "-"
+
+// parseBodyReference turns a [Body] made of a single `@path` line into a reference to that file,
+// resolved relative to the named template declaring the body, and unescapes a leading `@@` into a
+// literal `@`. A [Body] made of a single `-` line is read from stdin.
+func parseBodyReference(rc *RequestConfig, filename string) error {
+	if len(rc.Body) == 0 {
+		return nil
+	}
+	if len(rc.Body) == 1 && strings.TrimSpace(rc.Body[0]) == bodyStdinMarker {
+		rc.Body = nil
+		rc.BodyFromStdin = true
+		return nil
+	}
+	if strings.HasPrefix(rc.Body[0], bodyFilePrefix+bodyFilePrefix) {
+		rc.Body[0] = strings.TrimPrefix(rc.Body[0], bodyFilePrefix)
+		return nil
+	}
+	reference := strings.TrimSpace(rc.Body[0])
+	if len(rc.Body) != 1 || !strings.HasPrefix(reference, bodyFilePrefix) {
+		return nil
+	}
+	path, err := resolveTemplatePath(filename, strings.TrimPrefix(reference, bodyFilePrefix))
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return errors.Wrapf(err, "Body file not found: %s", path)
+	}
+	if fi.IsDir() {
+		return errors.Errorf("Body file is a directory: %s", path)
+	}
+	rc.Body = nil
+	rc.BodyFile = path
+	return nil
+}
+
+// headersFilePrefix starts a [Headers] line that references a file holding header lines.
+const headersFilePrefix = "@"
+
+// The function ReadHeadersFile reads the `Name: value` header lines of a headers file, such as the
+// files referenced by an `@path` line of a [Headers] section. Blank lines and comment lines are
+// skipped.
+//
+// Parameters:
+//   - path: The path of the headers file.
+//
+// Returns:
+//   - The header lines, normalized as `Name: value`.
+//   - An error if the file cannot be read or holds a malformed header line.
+func ReadHeadersFile(path string) ([]string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read the headers file: %s", path)
+	}
+	var headers []string
+	for i, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, commentPrefix) {
+			continue
+		}
+		name, value, found := SplitHeader(line)
+		if !found || name == "" {
+			return nil, errors.Errorf("Malformed header on line %d of %s, expected Name: value: %q", i+1, path, line)
+		}
+		headers = append(headers, name+": "+value)
+	}
+	return headers, nil
+}
+
+// parseTLSLine applies a `key = value` line of the [TLS] section to the RequestConfig.
+func parseTLSLine(rc *RequestConfig, filename, line string) error {
+	key, value, found := splitKeyValue(line)
+	if !found {
+		return errors.Errorf("Malformed [TLS] setting, expected key = value: %q", line)
+	}
+	var target *string
+	switch strings.ToLower(key) {
+	case "cert":
+		target = &rc.ClientCert
+	case "key":
+		target = &rc.ClientKey
+	case "ca":
+		target = &rc.CACert
+	default:
+		return errors.Errorf("Unknown [TLS] setting: %q", key)
+	}
+	path, err := resolveTemplatePath(filename, value)
+	if err != nil {
+		return err
+	}
+	*target = path
+	return nil
+}
+
+// parseExpectLine applies a `key = value` line of the [Expect] section to the RequestConfig.
+func parseExpectLine(rc *RequestConfig, filename, line string) error {
+	key, value, found := splitKeyValue(line)
+	if !found {
+		return errors.Errorf("Malformed [Expect] setting, expected key = value: %q", line)
+	}
+	switch strings.ToLower(key) {
+	case "schema":
+		path, err := resolveTemplatePath(filename, value)
+		if err != nil {
+			return err
+		}
+		rc.ExpectSchema = path
+	case "status":
+		status, err := strconv.Atoi(value)
+		if err != nil || status < 100 || status > 599 {
+			return errors.Errorf("Invalid [Expect] status %q, expected an HTTP status code", value)
+		}
+		rc.ExpectStatus = status
+	case "body":
+		rc.ExpectBody = value
+	case "body_regex":
+		if _, err := regexp.Compile(value); err != nil {
+			return errors.Wrapf(err, "Invalid [Expect] body_regex %q", value)
+		}
+		rc.ExpectBodyRegex = value
+	default:
+		return errors.Errorf("Unknown [Expect] setting: %q", key)
+	}
+	return nil
+}
+
+// parseParamLine adds a `name = value` line of the [Params] section to the path parameters,
+// expanding the variable references of the value.
+func parseParamLine(rc *RequestConfig, line string, cfg Config) error {
+	name, value, found := splitKeyValue(line)
+	if !found || name == "" {
+		return errors.Errorf("Malformed [Params] entry, expected name = value: %q", line)
+	}
+	value, err := ExpandTemplate(value, cfg)
+	if err != nil {
+		return err
+	}
+	if rc.PathParams == nil {
+		rc.PathParams = make(map[string]string)
+	}
+	rc.PathParams[name] = value
+	return nil
+}
+
+// splitKeyValue splits a `key = value` line, trimming the whitespace around both parts.
+func splitKeyValue(line string) (string, string, bool) {
+	key, value, found := strings.Cut(line, "=")
+	return strings.TrimSpace(key), strings.TrimSpace(value), found
+}
+
+// remoteTemplateSchemes are the URL prefixes of the template filenames fetched over HTTP.
+var remoteTemplateSchemes = []string{"http://", "https://"}
+
+// The function IsRemoteTemplate reports whether a template filename is an `http://` or `https://`
+// URL, fetched over HTTP instead of being read from disk. The references of a remote template to
+// local files, such as includes, `@path` bodies and headers files, TLS certificates or JSON
+// schemas, are refused by ParseTemplate, so a template downloaded from any host cannot read local
+// files and send them away. Neither can it set backend options, and its variable references are
+// not expanded from the environment unless the configuration sets RemoteEnvironment, as described
+// by ExpansionConfig.
+//
+// Parameters:
+//   - name: The template filename.
+//
+// Returns:
+//   - True if the filename is the URL of a remote template, false otherwise.
+func IsRemoteTemplate(name string) bool {
+	lower := strings.ToLower(name)
+	for _, scheme := range remoteTemplateSchemes {
+		if strings.HasPrefix(lower, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveTemplatePath resolves a path written in a template relative to the directory of the
+// template. Absolute paths, and paths in templates without a filename, are returned unchanged.
+// Remote templates cannot reference local files.
+func resolveTemplatePath(filename, path string) (string, error) {
+	if IsRemoteTemplate(filename) {
+		return "", errors.Errorf("The remote template %s cannot reference the local file %s", filename, path)
+	}
+	if filename == "" || filepath.IsAbs(path) {
+		return path, nil
+	}
+	return filepath.Join(filepath.Dir(filename), path), nil
+}
+
+// checkTemplateVersion validates the optional format version declared on the first line.
+func checkTemplateVersion(lines []string) error {
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], TemplateVersionPrefix) {
+		return nil
+	}
+	raw := strings.TrimSpace(strings.TrimPrefix(lines[0], TemplateVersionPrefix))
+	version, err := strconv.Atoi(raw)
+	if err != nil || version < 1 {
+		return errors.Errorf("Invalid template format version: %q", raw)
+	}
+	if version > TemplateFormatVersion {
+		return errors.Errorf("Unsupported template format version v%d, the newest supported version is v%d", version, TemplateFormatVersion)
+	}
+	return nil
+}
+
+// utf8BOM is the byte order mark some editors write at the start of UTF-8 files.
+const utf8BOM = "\ufeff"
+
+// The function TrimBOM removes the UTF-8 byte order mark starting the text, if any.
+//
+// Parameters:
+//   - text: The text to clean, usually the contents of a template.
+//
+// Returns:
+//   - The text without its leading byte order mark.
+func TrimBOM(text string) string {
+	return strings.TrimPrefix(text, utf8BOM)
+}
+
+// checkSection returns the problem with a section header declared in a template, or an empty
+// string when there is none. The seen map records the sections already declared in the file.
+func checkSection(name string, seen map[string]bool) string {
+	mergeable, known := knownSections[name]
+	if !known {
+		if suggestion := closestSection(name); suggestion != "" {
+			return fmt.Sprintf("unknown section [%s], did you mean [%s]?", name, suggestion)
+		}
+		return fmt.Sprintf("unknown section [%s]", name)
+	}
+	if seen[name] && !mergeable {
+		return fmt.Sprintf("duplicate section [%s]", name)
+	}
+	seen[name] = true
+	return ""
+}
+
+// closestSection returns the known section whose name is the closest to the given one, when it is
+// close enough to be a likely typo, or an empty string.
+func closestSection(name string) string {
+	best, bestDistance := "", 3
+	for known := range knownSections {
+		distance := editDistance(strings.ToLower(name), strings.ToLower(known))
+		if distance < bestDistance || (distance == bestDistance && known < best) {
+			best, bestDistance = known, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// sectionName reports whether the line is a section header and returns the section name. A known
+// section is matched case-insensitively and may be padded with spaces, so `[ HEADERS ]` returns the
+// canonical `Headers`. Other names are only section headers when written without padding, so a
+// body line such as `[ 1 ]` is not mistaken for an unknown section.
+func sectionName(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if len(line) < 2 || line[0] != '[' || line[len(line)-1] != ']' {
+		return "", false
+	}
+	name := strings.TrimSpace(line[1 : len(line)-1])
+	for known := range knownSections {
+		if strings.EqualFold(name, known) {
+			return known, true
+		}
+	}
+	if name == "" || name != line[1:len(line)-1] {
+		return "", false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return "", false
+		}
+	}
+	return name, true
+}
+
+// parseHost expands the variable references of the host line, parses it as a URL and normalizes
+// it with NormalizeHost.
+func parseHost(line string, cfg Config) (*url.URL, error) {
+	expanded, err := ExpandTemplate(line, cfg)
+	if err != nil {
+		return nil, err
+	}
+	host, err := url.Parse(expanded)
+	if err != nil && !strings.Contains(expanded, "://") {
+		// A scheme-less `127.0.0.1:8080` is not a valid URL, so retry with the default scheme
+		host, err = url.Parse(DefaultHostScheme + "://" + expanded)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid host URL")
+	}
+	rc := RequestConfig{Host: host}
+	if err := rc.NormalizeHost(); err != nil {
+		return nil, err
+	}
+	return rc.Host, nil
+}
@@ -0,0 +1,402 @@
+package data
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSectionName(t *testing.T) {
+	tests := map[string]struct {
+		name string
+		ok   bool
+	}{
+		"[Headers]":     {"Headers", true},
+		"[ HEADERS ]":   {"Headers", true},
+		"[body]":        {"Body", true},
+		"[Header]":      {"Header", true},
+		"[ Header ]":    {"", false},
+		"[ 1 ]":         {"", false},
+		"[1]":           {"1", true},
+		"[]":            {"", false},
+		`["a", "b"]`:    {"", false},
+		"not a section": {"", false},
+	}
+	for line, tt := range tests {
+		name, ok := sectionName(line)
+		if name != tt.name || ok != tt.ok {
+			t.Errorf("sectionName(%q) = %q, %v, want %q, %v", line, name, ok, tt.name, tt.ok)
+		}
+	}
+}
+
+func TestParseTemplateKeepsPaddedBodyArrays(t *testing.T) {
+	rc, err := ParseTemplate("", "[Host]\nhttps://example.com\n[ BODY ]\n{\"ids\":\n[ 1 ]\n}\n", Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if want := []string{`{"ids":`, "[ 1 ]", "}"}; !reflect.DeepEqual(rc.Body, want) {
+		t.Errorf("Body = %q, want %q", rc.Body, want)
+	}
+}
+
+func TestParseTemplateRemoteRefusesLocalFiles(t *testing.T) {
+	local := filepath.Join(t.TempDir(), "local.ini")
+	if err := os.WriteFile(local, []byte("X-Secret: token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"include":      "@include " + local + "\n[Host]\nhttps://example.com\n",
+		"extends":      "@extends " + local + "\n[Host]\nhttps://example.com\n",
+		"body file":    "[Host]\nhttps://example.com\n[Body]\n@" + local + "\n",
+		"headers file": "[Host]\nhttps://example.com\n[Headers]\n@" + local + "\n",
+		"tls":          "[Host]\nhttps://example.com\n[TLS]\ncert = " + local + "\n",
+		"schema":       "[Host]\nhttps://example.com\n[Expect]\nschema = " + local + "\n",
+	}
+	for name, tmpl := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseTemplate("https://templates.example.com/get.ini", tmpl, Config{})
+			if err == nil || !strings.Contains(err.Error(), "remote template") {
+				t.Errorf("ParseTemplate() error = %v, want the local file refused", err)
+			}
+		})
+	}
+	rc, err := ParseTemplate("https://templates.example.com/get.ini", "[Host]\nhttps://example.com\n[Body]\n@@handle\n", Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() of a self-contained remote template error = %v", err)
+	}
+	if want := []string{"@handle"}; !reflect.DeepEqual(rc.Body, want) {
+		t.Errorf("Body = %q, want %q", rc.Body, want)
+	}
+}
+
+func TestParseTemplateResolvesIncludedBodyFile(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "shared")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(sub, "body.ini"):     "[Body]\n@payload.json\n",
+		filepath.Join(sub, "payload.json"): "{}\n",
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(name, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filename := filepath.Join(dir, "post.ini")
+	rc, err := ParseTemplate(filename, "[Host]\nhttps://example.com\n@include shared/body.ini\n", Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if want := filepath.Join(sub, "payload.json"); rc.BodyFile != want {
+		t.Errorf("BodyFile = %q, want %q", rc.BodyFile, want)
+	}
+}
+
+func TestParseTemplateRemoteRefusesBackendOptions(t *testing.T) {
+	tmpl := "[Host]\nhttps://example.com\n[Backend]\ncurl\n-o ~/.bashrc\n"
+	_, err := ParseTemplate("https://templates.example.com/get.ini", tmpl, Config{})
+	if err == nil || !strings.Contains(err.Error(), "cannot set backend options") {
+		t.Errorf("ParseTemplate() error = %v, want the backend options refused", err)
+	}
+	rc, err := ParseTemplate("https://templates.example.com/get.ini", "[Host]\nhttps://example.com\n[Backend]\ncurl\n", Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() of a remote template naming its backend error = %v", err)
+	}
+	if rc.Backend != "curl" {
+		t.Errorf("Backend = %q, want curl", rc.Backend)
+	}
+}
+
+func TestParseTemplateRemoteEnvironment(t *testing.T) {
+	t.Setenv("VORTEX_TEST_SECRET", "s3cr3t")
+	tmpl := "[Host]\nhttps://example.com/${VORTEX_TEST_SECRET}\n[Query]\nkey=${VORTEX_TEST_SECRET:-none}\n" +
+		"[Headers]\nX-Secret: ${VORTEX_TEST_SECRET}\n[Auth]\ntype = bearer\ntoken = ${VORTEX_TEST_SECRET:-anonymous}\n"
+	tests := []struct {
+		name      string
+		filename  string
+		cfg       Config
+		wantURL   string
+		wantValue string
+		wantAuth  string
+	}{
+		{"remote", "https://templates.example.com/get.ini", Config{}, "https://example.com/?key=none", "", "Bearer anonymous"},
+		{"remote opted in", "https://templates.example.com/get.ini", Config{RemoteEnvironment: true}, "https://example.com/s3cr3t?key=s3cr3t", "s3cr3t", "Bearer s3cr3t"},
+		{"local", "get.ini", Config{}, "https://example.com/s3cr3t?key=s3cr3t", "s3cr3t", "Bearer s3cr3t"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc, err := ParseTemplate(tt.filename, tmpl, tt.cfg)
+			if err != nil {
+				t.Fatalf("ParseTemplate() error = %v", err)
+			}
+			if got := rc.Host.String(); got != tt.wantURL {
+				t.Errorf("Host = %q, want %q", got, tt.wantURL)
+			}
+			if err := rc.Interpolate(tt.cfg); err != nil {
+				t.Fatalf("Interpolate() error = %v", err)
+			}
+			if want := "X-Secret: " + tt.wantValue; rc.Headers[0] != want {
+				t.Errorf("Headers[0] = %q, want %q", rc.Headers[0], want)
+			}
+			if err := rc.ApplyAuth(tt.cfg); err != nil {
+				t.Fatalf("ApplyAuth() error = %v", err)
+			}
+			if want := "Authorization: " + tt.wantAuth; rc.Headers[len(rc.Headers)-1] != want {
+				t.Errorf("Headers = %q, want %q last", rc.Headers, want)
+			}
+		})
+	}
+}
+
+func TestParseTemplateFormatVersion(t *testing.T) {
+	body := "[Host]\nhttps://example.com\n"
+	tests := map[string]struct {
+		tmpl, wantErr string
+	}{
+		"versioned":   {TemplateVersionHeader() + "\n" + body, ""},
+		"unversioned": {body, ""},
+		"too new":     {"# vortex-template v99\n" + body, "Unsupported template format version v99"},
+		"invalid":     {"# vortex-template vnext\n" + body, "Invalid template format version"},
+	}
+	for name, tt := range tests {
+		rc, err := ParseTemplate("", tt.tmpl, Config{})
+		if tt.wantErr == "" {
+			if err != nil || rc.Host.String() != "https://example.com" {
+				t.Errorf("%s: ParseTemplate() = %v, %v, want the host parsed", name, rc, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: ParseTemplate() error = %v, want %q", name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseTemplateClientCertificate(t *testing.T) {
+	tmpl := "[Host]\nhttps://example.com\n[TLS]\ncert = certs/client.pem\nkey = /etc/vortex/client.key\n"
+	rc, err := ParseTemplate("/templates/get.ini", tmpl, Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	cert := filepath.Join("/templates", "certs", "client.pem")
+	if rc.ClientCert != cert || rc.ClientKey != "/etc/vortex/client.key" {
+		t.Fatalf("ClientCert = %q, ClientKey = %q, want the paths resolved next to the template", rc.ClientCert, rc.ClientKey)
+	}
+	tests := map[string]struct {
+		build func(*RequestConfig, Config) ([]string, error)
+		want  []string
+	}{
+		"curl":   {BuildCurlArgs, []string{"--cert", cert, "--key", "/etc/vortex/client.key"}},
+		"httpie": {BuildHTTPieArgs, []string{"--cert", cert, "--cert-key", "/etc/vortex/client.key"}},
+		"wget":   {BuildWgetArgs, []string{"--certificate=" + cert, "--private-key=/etc/vortex/client.key"}},
+	}
+	for name, tt := range tests {
+		args, err := tt.build(rc, Config{})
+		if err != nil {
+			t.Errorf("%s: build error = %v", name, err)
+			continue
+		}
+		if joined := strings.Join(args, "\x00"); !strings.Contains(joined, strings.Join(tt.want, "\x00")) {
+			t.Errorf("%s args = %q, want %q", name, args, tt.want)
+		}
+	}
+}
+
+func TestParseTemplateCACertificate(t *testing.T) {
+	rc, err := ParseTemplate("", "[Host]\nhttps://example.com\n[TLS]\nca = /etc/vortex/ca.pem\n", Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if rc.CACert != "/etc/vortex/ca.pem" {
+		t.Fatalf("CACert = %q, want /etc/vortex/ca.pem", rc.CACert)
+	}
+	tests := map[string]struct {
+		build func(*RequestConfig, Config) ([]string, error)
+		want  []string
+	}{
+		"curl":   {BuildCurlArgs, []string{"--cacert", "/etc/vortex/ca.pem"}},
+		"httpie": {BuildHTTPieArgs, []string{"--verify", "/etc/vortex/ca.pem"}},
+		"wget":   {BuildWgetArgs, []string{"--ca-certificate=/etc/vortex/ca.pem"}},
+	}
+	for name, tt := range tests {
+		args, err := tt.build(rc, Config{})
+		if err != nil {
+			t.Errorf("%s: build error = %v", name, err)
+			continue
+		}
+		if joined := strings.Join(args, "\x00"); !strings.Contains(joined, strings.Join(tt.want, "\x00")) {
+			t.Errorf("%s args = %q, want %q", name, args, tt.want)
+		}
+	}
+}
+
+func TestParseTemplateMultiLineBody(t *testing.T) {
+	tmpl := "[Host]\nhttps://example.com\n[Body]\n# the new user\n{\n  \"name\": \"vortex\",\n\n    \"nested\": {\"a\": 1}\n}\n\n[Backend]\ncurl\n"
+	rc, err := ParseTemplate("", tmpl, Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	want := []string{"{", `  "name": "vortex",`, "", `    "nested": {"a": 1}`, "}"}
+	if !reflect.DeepEqual(rc.Body, want) || rc.Backend != "curl" {
+		t.Errorf("Body = %q, Backend = %q, want %q and curl", rc.Body, rc.Backend, want)
+	}
+
+	tmpl = "[Host]\nhttps://example.com\n[Body]\nname=vortex\nkey = value # not a comment\n# kept inside the body\n"
+	rc, err = ParseTemplate("", tmpl, Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	want = []string{"name=vortex", "key = value # not a comment", "# kept inside the body"}
+	if !reflect.DeepEqual(rc.Body, want) {
+		t.Errorf("Body = %q, want the key=value lines kept literally %q", rc.Body, want)
+	}
+
+	rc, err = ParseTemplate("", "[Host]\nhttps://example.com\n[Body]\n# {\n#   \"key\": \"value\"\n# }\n", Config{KeepBodyComments: true})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if want := []string{"# {", `#   "key": "value"`, "# }"}; !reflect.DeepEqual(rc.Body, want) {
+		t.Errorf("Body with KeepBodyComments = %q, want %q", rc.Body, want)
+	}
+}
+
+func TestParseTemplateComments(t *testing.T) {
+	tmpl := "\ufeff# a request\n[Host]\nhttps://example.com # the API\n  # indented comment\n[Headers]\n" +
+		"Authorization: Bearer token # from the vault\nX-Tag: a#b\nX-Hash: color \\# red\n[Body]\n{\"a\": \"# kept\"} # kept too\n"
+	rc, err := ParseTemplate("", tmpl, Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if rc.Host.String() != "https://example.com" {
+		t.Errorf("Host = %v, want https://example.com", rc.Host)
+	}
+	if want := []string{"Authorization: Bearer token", "X-Tag: a#b", "X-Hash: color # red"}; !reflect.DeepEqual(rc.Headers, want) {
+		t.Errorf("Headers = %q, want %q", rc.Headers, want)
+	}
+	if want := []string{`{"a": "# kept"} # kept too`}; !reflect.DeepEqual(rc.Body, want) {
+		t.Errorf("Body = %q, want %q", rc.Body, want)
+	}
+}
+
+func TestParseTemplateSectionWarnings(t *testing.T) {
+	tests := map[string]struct {
+		tmpl, want string
+	}{
+		"unknown":   {"[Host]\nhttps://example.com\n[Cookies]\nid=1\n", "unknown section [Cookies]"},
+		"typo":      {"[Host]\nhttps://example.com\n[Header]\nAuthorization: Bearer token\n", "unknown section [Header], did you mean [Headers]?"},
+		"duplicate": {"[Host]\nhttps://example.com\n[Method]\nGET\n[Method]\nPOST\n", "duplicate section [Method]"},
+	}
+	for name, tt := range tests {
+		var logged bytes.Buffer
+		if _, err := ParseTemplate("get.ini", tt.tmpl, Config{Logger: NewTextLogger(&logged)}); err != nil {
+			t.Errorf("%s: ParseTemplate() error = %v, want a warning only", name, err)
+		}
+		if !strings.Contains(logged.String(), "Template warning: "+tt.want) {
+			t.Errorf("%s: logged %q, want the warning %q", name, logged.String(), tt.want)
+		}
+		_, err := ParseTemplate("get.ini", tt.tmpl, Config{Strict: true})
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: ParseTemplate() in strict mode error = %v, want a ParseError for %q", name, err, tt.want)
+		}
+	}
+
+	var logged bytes.Buffer
+	tmpl := "[Host]\nhttps://example.com\n[Headers]\nA: 1\n[Query]\na=1\n[Headers]\nB: 2\n"
+	rc, err := ParseTemplate("get.ini", tmpl, Config{Logger: NewTextLogger(&logged), Strict: true})
+	if err != nil {
+		t.Fatalf("ParseTemplate() of repeated mergeable sections error = %v", err)
+	}
+	if logged.Len() > 0 || !reflect.DeepEqual(rc.Headers, []string{"A: 1", "B: 2"}) {
+		t.Errorf("Headers = %q, logged %q, want the sections merged without warning", rc.Headers, logged.String())
+	}
+}
+
+func TestParseTemplatePath(t *testing.T) {
+	tests := map[string]struct {
+		host, path, want string
+	}{
+		"both slashes":     {"http://api.example.com/", "/v1/users", "http://api.example.com/v1/users"},
+		"no slash":         {"http://api.example.com", "v1/users", "http://api.example.com/v1/users"},
+		"base path":        {"http://api.example.com/api/", "/v1/users/", "http://api.example.com/api/v1/users/"},
+		"several lines":    {"http://api.example.com", "/v1\n/users", "http://api.example.com/v1/users"},
+		"merged query":     {"http://api.example.com/?page=1", "/users?limit=10", "http://api.example.com/users?page=1&limit=10"},
+		"escaped segments": {"http://api.example.com", "/files/a%2Fb", "http://api.example.com/files/a%2Fb"},
+	}
+	for name, tt := range tests {
+		rc, err := ParseTemplate("path.ini", "[Host]\n"+tt.host+"\n[Path]\n"+tt.path+"\n", Config{})
+		if err != nil {
+			t.Errorf("%s: ParseTemplate() error = %v", name, err)
+			continue
+		}
+		if got := rc.Host.String(); got != tt.want {
+			t.Errorf("%s: Host = %q, want %q", name, got, tt.want)
+		}
+	}
+
+	rc, err := ParseTemplate("path.ini", "[Host]\nhttp://api.example.com/\n[Path]\n/users/{id}/posts\n", Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if want := "/users/{id}/posts"; rc.Host.Path != want {
+		t.Errorf("Host path = %q, want the placeholder kept in %q", rc.Host.Path, want)
+	}
+	if err := rc.SubstitutePathParams(map[string]string{"id": "42"}); err != nil {
+		t.Fatalf("SubstitutePathParams() error = %v", err)
+	}
+	if want := "http://api.example.com/users/42/posts"; rc.Host.String() != want {
+		t.Errorf("Host once substituted = %q, want %q", rc.Host, want)
+	}
+
+	for name, tmpl := range map[string]string{
+		"no host":       "[Path]\n/v1/users\n",
+		"network path":  "[Host]\nhttp://api.example.com\n[Path]\n//other.example.com/v1\n",
+		"absolute path": "[Host]\nhttp://api.example.com\n[Path]\nhttp://other.example.com/v1\n",
+	} {
+		if _, err := ParseTemplate("path.ini", tmpl, Config{}); err == nil {
+			t.Errorf("%s: ParseTemplate() succeeded, want an error", name)
+		}
+	}
+}
+
+func TestParseTemplateErrorLocation(t *testing.T) {
+	tests := map[string]struct {
+		tmpl    string
+		line    int
+		section string
+	}{
+		"bad url":          {"# comment\n\n[Host]\nhttps://exa mple.com\n", 4, "Host"},
+		"malformed header": {"[Host]\nhttps://example.com\n[Headers]\nAccept: */*\nno colon here\n", 5, "Headers"},
+		"unknown section":  {"[Host]\nhttps://example.com\n\n[Cookies]\nid=1\n", 4, "Cookies"},
+	}
+	for name, tt := range tests {
+		_, err := ParseTemplate("get.ini", tt.tmpl, Config{Strict: true})
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: ParseTemplate() error = %v, want a ParseError", name, err)
+			continue
+		}
+		if parseErr.Line != tt.line || parseErr.Section != tt.section {
+			t.Errorf("%s: ParseError at line %d in [%s], want line %d in [%s]", name, parseErr.Line, parseErr.Section, tt.line, tt.section)
+		}
+		if prefix := fmt.Sprintf("line %d in [%s]: ", tt.line, tt.section); !strings.HasPrefix(parseErr.Error(), prefix) {
+			t.Errorf("%s: ParseError.Error() = %q, want it to start with %q", name, parseErr.Error(), prefix)
+		}
+		if got := parseErr.ExitCode(); got != ExitParseError {
+			t.Errorf("%s: ExitCode() = %d, want %d", name, got, ExitParseError)
+		}
+	}
+
+	err := &ParseError{Line: 1, Msg: "Invalid template"}
+	if got, want := err.Error(), "line 1: Invalid template"; got != want {
+		t.Errorf("ParseError.Error() outside of a section = %q, want %q", got, want)
+	}
+}
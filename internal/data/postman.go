@@ -0,0 +1,152 @@
+package data
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// postmanSchema identifies the version of the Postman collection format produced by
+// ToPostmanCollection.
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// postmanCollection is the subset of a Postman v2.1 collection written by ToPostmanCollection.
+type postmanCollection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item []postmanItem `json:"item"`
+}
+
+// postmanItem is a named request of a Postman collection.
+type postmanItem struct {
+	Name    string         `json:"name"`
+	Request postmanRequest `json:"request"`
+}
+
+// postmanRequest is the request of a Postman collection item.
+type postmanRequest struct {
+	Method string          `json:"method"`
+	Header []postmanHeader `json:"header"`
+	URL    struct {
+		Raw string `json:"raw"`
+	} `json:"url"`
+	Body *postmanBody `json:"body,omitempty"`
+}
+
+// postmanHeader is a header of a Postman request.
+type postmanHeader struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// postmanBody is the body of a Postman request, either raw text or a file.
+type postmanBody struct {
+	Mode string       `json:"mode"`
+	Raw  string       `json:"raw,omitempty"`
+	File *postmanFile `json:"file,omitempty"`
+}
+
+// postmanFile references the file sent as the body of a Postman request.
+type postmanFile struct {
+	Src string `json:"src"`
+}
+
+// The function ToPostmanCollection serializes request configurations into a minimal Postman v2.1
+// collection, with one item per request holding its method, URL, headers and raw body. Variable
+// references written with the default `${VAR}` syntax are converted to the `{{VAR}}` syntax of
+// Postman, so they can be defined as Postman variables, `{name}` path parameters become `:name` and
+// a body read from a file is exported as a file body.
+//
+// Parameters:
+//   - configs: The request configurations to export, in the order of the collection items.
+//   - name: The name of the collection.
+//
+// Returns:
+//   - The collection as indented JSON.
+//   - An error if a request has no host, or its URL or a header holds an unterminated variable
+//     reference.
+func ToPostmanCollection(configs []*RequestConfig, name string) ([]byte, error) {
+	var collection postmanCollection
+	collection.Info.Name = name
+	collection.Info.Schema = postmanSchema
+	collection.Item = make([]postmanItem, 0, len(configs))
+	for i, rc := range configs {
+		if rc.Host == nil {
+			return nil, errors.Errorf("Cannot export request %d without a host", i)
+		}
+		method := rc.Method
+		if method == "" {
+			method = http.MethodGet
+		}
+		item := postmanItem{Name: method + " " + rc.Host.Path}
+		item.Request.Method = method
+		item.Request.Header = make([]postmanHeader, 0, len(rc.Headers))
+		raw, err := toPostmanVariables(postmanURL(rc.Host), false)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to export the URL of request %d", i)
+		}
+		item.Request.URL.Raw = raw
+		for _, header := range rc.Headers {
+			header, err := toPostmanVariables(header, false)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to export a header of request %d", i)
+			}
+			key, value, _ := SplitHeader(header)
+			item.Request.Header = append(item.Request.Header, postmanHeader{Key: key, Value: value})
+		}
+		switch {
+		case rc.BodyFile != "":
+			item.Request.Body = &postmanBody{Mode: "file", File: &postmanFile{Src: rc.BodyFile}}
+		case len(rc.Body) > 0:
+			body, err := toPostmanVariables(strings.Join(rc.Body, "\n"), true)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to export the body of request %d", i)
+			}
+			item.Request.Body = &postmanBody{Mode: "raw", Raw: body}
+		}
+		collection.Item = append(collection.Item, item)
+	}
+	out, err := json.MarshalIndent(collection, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode the Postman collection")
+	}
+	return out, nil
+}
+
+// postmanSegmentPattern matches a path segment made of a single `{name}` path parameter.
+var postmanSegmentPattern = regexp.MustCompile(`^\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+// postmanURL returns the URL with its path parameters written as `:name`, the way Postman writes
+// them. Only the path segments made of a whole `{name}` placeholder are rewritten, the query and
+// the `${VAR}` references being kept as they are for toPostmanVariables.
+func postmanURL(u *url.URL) string {
+	base := *u
+	base.Path, base.RawPath, base.RawQuery, base.Fragment, base.RawFragment = "", "", "", "", ""
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		segments[i] = postmanSegmentPattern.ReplaceAllString(segment, ":$1")
+	}
+	raw := base.String() + strings.Join(segments, "/")
+	if u.RawQuery != "" {
+		raw += "?" + u.RawQuery
+	}
+	if u.Fragment != "" {
+		raw += "#" + u.EscapedFragment()
+	}
+	return raw
+}
+
+// toPostmanVariables rewrites the `${VAR}` references of the text into Postman `{{VAR}}` ones. An
+// unterminated reference is an error, unless keepUnterminated is true, as for the body, which
+// keeps it literally like Interpolate does.
+func toPostmanVariables(text string, keepUnterminated bool) (string, error) {
+	return expandReferences(text, DefaultInterpolationOpen, DefaultInterpolationClose, func(name string) string {
+		return "{{" + name + "}}"
+	}, keepUnterminated)
+}
@@ -0,0 +1,40 @@
+package data
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+)
+
+func TestToPostmanCollectionURL(t *testing.T) {
+	tests := map[string]struct {
+		host string
+		want string
+	}{
+		"path parameter":     {"https://example.com/users/{id}", "https://example.com/users/:id"},
+		"variable in path":   {"https://example.com/${BASE}/x", "https://example.com/{{BASE}}/x"},
+		"variable in query":  {"https://example.com/x?token=${TOKEN}", "https://example.com/x?token={{TOKEN}}"},
+		"braces in query":    {"https://example.com/x?q={a}", "https://example.com/x?q={a}"},
+		"partial segment":    {"https://example.com/x/{a}b", "https://example.com/x/{a}b"},
+		"parameter and vars": {"https://example.com/${BASE}/{id}?t=${T}", "https://example.com/{{BASE}}/:id?t={{T}}"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			host, err := url.Parse(tt.host)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := ToPostmanCollection([]*RequestConfig{{Host: host}}, "test")
+			if err != nil {
+				t.Fatalf("ToPostmanCollection() error = %v", err)
+			}
+			var collection postmanCollection
+			if err := json.Unmarshal(out, &collection); err != nil {
+				t.Fatal(err)
+			}
+			if got := collection.Item[0].Request.URL.Raw; got != tt.want {
+				t.Errorf("raw URL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
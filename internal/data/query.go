@@ -0,0 +1,65 @@
+package data
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultQueryDelim separates the parameters of a query string when no custom delimiter is
+// configured.
+const DefaultQueryDelim = "&"
+
+// The method Delimiter returns the delimiter separating the parameters of the query string
+// built from the [Query] section of a template. When the QueryDelim field is nil or empty, the
+// default `&` is returned.
+func (c Config) Delimiter() string {
+	if c.QueryDelim == nil || *c.QueryDelim == "" {
+		return DefaultQueryDelim
+	}
+	return *c.QueryDelim
+}
+
+// splitQueryLine splits a line of the [Query] section into its `key=value` parameters, in the
+// order they are written. Repeated keys are kept as separate parameters, so `tag=a` and `tag=b`
+// both reach the query string, and empty parameters left by stray delimiters are dropped. The
+// keys and values are expanded with ExpandTemplate, then percent-encoded with encodeQueryParam,
+// so a variable holding a delimiter or a space stays within its parameter.
+func splitQueryLine(line string, cfg Config) ([]string, error) {
+	var params []string
+	for _, param := range strings.Split(line, cfg.Delimiter()) {
+		if param = strings.TrimSpace(param); param != "" {
+			encoded, err := encodeQueryParam(param, cfg)
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, encoded)
+		}
+	}
+	return params, nil
+}
+
+// encodeQueryParam expands the key and the value of a `key=value` query parameter, and
+// percent-encodes them with url.QueryEscape, so spaces, delimiters and non-ASCII characters cannot
+// break the query string. Components that are already percent-encoded are decoded once expanded,
+// so they are not encoded twice.
+func encodeQueryParam(param string, cfg Config) (string, error) {
+	key, value, hasValue := strings.Cut(param, "=")
+	key, err := encodeQueryComponent(key, cfg)
+	if err != nil || !hasValue {
+		return key, err
+	}
+	value, err = encodeQueryComponent(value, cfg)
+	return key + "=" + value, err
+}
+
+// encodeQueryComponent expands and percent-encodes a key or a value of a query parameter.
+func encodeQueryComponent(component string, cfg Config) (string, error) {
+	expanded, err := ExpandTemplate(component, cfg)
+	if err != nil {
+		return "", err
+	}
+	if decoded, err := url.QueryUnescape(expanded); err == nil {
+		expanded = decoded
+	}
+	return url.QueryEscape(expanded), nil
+}
@@ -0,0 +1,75 @@
+package data
+
+import "testing"
+
+func TestConfigDelimiter(t *testing.T) {
+	amp, semicolon, empty := "&", ";", ""
+	tests := map[string]struct {
+		delim *string
+		want  string
+	}{
+		"nil":       {nil, "&"},
+		"empty":     {&empty, "&"},
+		"ampersand": {&amp, "&"},
+		"semicolon": {&semicolon, ";"},
+	}
+	for name, tt := range tests {
+		if got := (Config{QueryDelim: tt.delim}).Delimiter(); got != tt.want {
+			t.Errorf("%s: Delimiter() = %q, want %q", name, got, tt.want)
+		}
+	}
+}
+
+func TestParseTemplateEncodesQuery(t *testing.T) {
+	t.Setenv("VORTEX_TEST_SEARCH", "a b&c")
+	semicolon := ";"
+	tests := map[string]struct {
+		query string
+		cfg   Config
+		want  string
+	}{
+		"space":          {"q=a b", Config{}, "q=a+b"},
+		"ampersand":      {"q=a%26b&page=2", Config{}, "q=a%26b&page=2"},
+		"non-ascii":      {"name=café", Config{}, "name=caf%C3%A9"},
+		"variable":       {"q=${VORTEX_TEST_SEARCH}&page=1", Config{}, "q=a+b%26c&page=1"},
+		"custom delim":   {"q=a&b;page=1", Config{QueryDelim: &semicolon}, "q=a%26b;page=1"},
+		"escaped dollar": {"price=$$5", Config{}, "price=%245"},
+		"encoded ref":    {"q=%24%7BVORTEX_TEST_SEARCH%7D", Config{}, "q=%24%7BVORTEX_TEST_SEARCH%7D"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rc, err := ParseTemplate("", "[Host]\nhttps://example.com\n[Query]\n"+tt.query+"\n", tt.cfg)
+			if err != nil {
+				t.Fatalf("ParseTemplate() error = %v", err)
+			}
+			if rc.Host.RawQuery != tt.want {
+				t.Errorf("RawQuery = %q, want %q", rc.Host.RawQuery, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTemplateRepeatedQueryKeys(t *testing.T) {
+	semicolon := ";"
+	tests := map[string]struct {
+		host, query string
+		cfg         Config
+		want        string
+	}{
+		"repeated":      {"https://example.com", "tag=a\ntag=b", Config{}, "tag=a&tag=b"},
+		"mixed":         {"https://example.com", "tag=a&page=2\ntag=b\nsort=asc", Config{}, "tag=a&page=2&tag=b&sort=asc"},
+		"host query":    {"https://example.com/?tag=x", "tag=a\ntag=b", Config{}, "tag=x&tag=a&tag=b"},
+		"custom delim":  {"https://example.com", "tag=a;tag=b\ntag=c", Config{QueryDelim: &semicolon}, "tag=a;tag=b;tag=c"},
+		"same key pair": {"https://example.com", "tag=a\ntag=a", Config{}, "tag=a&tag=a"},
+	}
+	for name, tt := range tests {
+		rc, err := ParseTemplate("", "[Host]\n"+tt.host+"\n[Query]\n"+tt.query+"\n", tt.cfg)
+		if err != nil {
+			t.Errorf("%s: ParseTemplate() error = %v", name, err)
+			continue
+		}
+		if rc.Host.RawQuery != tt.want {
+			t.Errorf("%s: RawQuery = %q, want %q", name, rc.Host.RawQuery, tt.want)
+		}
+	}
+}
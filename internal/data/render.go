@@ -0,0 +1,70 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// renderFuncs are the functions available to the templates of Render.
+var renderFuncs = template.FuncMap{
+	"json":       decodeJSON,
+	"prettyJSON": indentJSON,
+}
+
+// The method Render formats the RequestResult with a user supplied text/template, to print a
+// report instead of the raw response, as in `Status: {{.StatusCode}} in {{.Duration}}`. All the
+// fields of the RequestResult, such as StatusCode, Duration, Headers, Stdout and FinalURL, are
+// available to the template, along with two functions:
+//   - json: decodes a JSON document, usually `.Stdout`, so its fields can be used, as in
+//     `{{(json .Stdout).user.name}}`.
+//   - prettyJSON: indents a JSON document or a decoded value with two spaces, as in
+//     `{{prettyJSON .Stdout}}` or `{{prettyJSON (json .Stdout).items}}`.
+//
+// Parameters:
+//   - tmpl: The text of the template.
+//
+// Returns:
+//   - The rendered report.
+//   - An error if the template is invalid or fails to execute, for example on a body that is not
+//     valid JSON.
+func (rr RequestResult) Render(tmpl string) (string, error) {
+	t, err := template.New("report").Funcs(renderFuncs).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", errors.Wrap(err, "Invalid report template")
+	}
+	var out strings.Builder
+	if err := t.Execute(&out, rr); err != nil {
+		return "", errors.Wrap(err, "Failed to render the report template")
+	}
+	return out.String(), nil
+}
+
+// decodeJSON decodes a JSON document for the json function of the report templates.
+func decodeJSON(doc string) (any, error) {
+	var value any
+	if err := json.Unmarshal([]byte(doc), &value); err != nil {
+		return nil, errors.Wrap(err, "Invalid JSON document")
+	}
+	return value, nil
+}
+
+// indentJSON indents a JSON document, or encodes and indents any other value, for the prettyJSON
+// function of the report templates.
+func indentJSON(value any) (string, error) {
+	if doc, ok := value.(string); ok {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, []byte(strings.TrimSpace(doc)), "", "  "); err != nil {
+			return "", errors.Wrap(err, "Invalid JSON document")
+		}
+		return indented.String(), nil
+	}
+	encoded, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to encode the value as JSON")
+	}
+	return string(encoded), nil
+}
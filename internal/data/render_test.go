@@ -0,0 +1,53 @@
+package data
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	result := RequestResult{
+		StatusCode: 200,
+		Duration:   1500 * time.Millisecond,
+		Headers:    http.Header{"Content-Type": {"application/json"}},
+		Stdout:     `{"user": {"name": "vortex", "roles": ["admin", "dev"]}}`,
+		FinalURL:   "https://example.com/users/1",
+	}
+	tests := map[string]struct {
+		tmpl, want string
+	}{
+		"status and duration": {"Status: {{.StatusCode}} in {{.Duration}}", "Status: 200 in 1.5s"},
+		"header and url":      {`{{.Headers.Get "Content-Type"}} from {{.FinalURL}}`, "application/json from https://example.com/users/1"},
+		"json field":          {"Hello {{(json .Stdout).user.name}}", "Hello vortex"},
+		"pretty json value":   {"{{prettyJSON (json .Stdout).user.roles}}", "[\n  \"admin\",\n  \"dev\"\n]"},
+		"pretty json body":    {`{{prettyJSON "{\"a\":1}"}}`, "{\n  \"a\": 1\n}"},
+	}
+	for name, tt := range tests {
+		got, err := result.Render(tt.tmpl)
+		if err != nil {
+			t.Errorf("%s: Render() error = %v", name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: Render() = %q, want %q", name, got, tt.want)
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := map[string]struct {
+		tmpl, want string
+	}{
+		"invalid template": {"{{.StatusCode", "Invalid report template"},
+		"unknown field":    {"{{.Status}}", "Failed to render the report template"},
+		"invalid json":     {"{{(json .Stdout).name}}", "Invalid JSON document"},
+	}
+	for name, tt := range tests {
+		_, err := RequestResult{Stdout: "not json"}.Render(tt.tmpl)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Render() error = %v, want %q", name, err, tt.want)
+		}
+	}
+}
@@ -1,24 +1,87 @@
 package data
 
 import (
+	"fmt"
+	"net/http"
 	"os"
 	"strings"
+	"sync/atomic"
 
 	"github.com/pkg/errors"
 )
 
+// bodilessMethods lists the HTTP methods whose requests are sent without a body.
+var bodilessMethods = []string{http.MethodHead, http.MethodOptions}
+
+// The method IgnoresBody reports whether the request method is sent without a body, which is the
+// case of HEAD and OPTIONS requests. Their Body and BodyFile are ignored.
+func (rc *RequestConfig) IgnoresBody() bool {
+	for _, method := range bodilessMethods {
+		if strings.EqualFold(rc.Method, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// The method BodyPath returns the path of the file holding the request body to send: the BodyFile
+// when the body is read from a file, otherwise the temporary file created by CreateBodyTempfile,
+// which is also used for a BodyFile when the body is compressed. It returns an empty string when the
+// request has no body or its method ignores it.
+func (rc *RequestConfig) BodyPath() string {
+	if rc.IgnoresBody() {
+		return ""
+	}
+	if rc.BodyFile != "" && !rc.CompressBody {
+		return rc.BodyFile
+	}
+	return rc.TempfileName
+}
+
+// The method QuietOutput reports whether the progress output of the backend is silenced, which is
+// the case when Quiet is set and Verbose is not.
+func (rc *RequestConfig) QuietOutput() bool {
+	return rc.Quiet && !rc.Verbose
+}
+
+// forcesEmptyBody reports whether an empty body is sent because ForceBody is set and the request
+// has no body of its own.
+func (rc *RequestConfig) forcesEmptyBody() bool {
+	return rc.ForceBody && len(rc.Body) == 0 && rc.BodyFile == "" && !rc.IgnoresBody()
+}
+
+// tempfileCounter numbers the body temporary files created by this process.
+var tempfileCounter atomic.Uint64
+
 // CreateBodyTempfile creates a temporary file to store the request body.
 // This method generates a temporary file with a unique name and writes
 // the contents of the Body field from the RequestConfig to this file.
 // The file is intended to be used for temporary storage during the request
 // and may be deleted or handled according to the Tempfile field in
-// the RequestConfig.
+// the RequestConfig. Nothing is created when the body is read from BodyFile or when the method
+// ignores the body, see IgnoresBody. The name of the file combines the process ID, a per-process
+// counter and a random suffix, so concurrent requests never share a file, even when they reuse the
+// same RequestConfig, and a TempfileName left by a previous request is never reused. When
+// CompressBody is set, the file holds the gzipped body, including that of a BodyFile. When ForceBody
+// is set, an empty file is created for an empty body.
 //
-// Returns an error if the file creation or writing process fails.s
+// Returns an error if the file creation or writing process fails.
 func (rc *RequestConfig) CreateBodyTempfile() error {
-	// Check if the Body field is empty
-	if len(rc.Body) == 0 {
-		return nil
+	// Check if the Body field is empty or the body is read from a file directly
+	if rc.IgnoresBody() || (!rc.CompressBody && (len(rc.Body) == 0 || rc.BodyFile != "")) {
+		if !rc.forcesEmptyBody() {
+			return nil
+		}
+	}
+	var payload []byte
+	if rc.CompressBody {
+		compressed, err := rc.CompressedBody()
+		if err != nil || (compressed == nil && !rc.forcesEmptyBody()) {
+			return err
+		}
+		payload = compressed
+	} else {
+		payload = []byte(strings.Join(rc.Body, "\n"))
 	}
 	tmpfile_dir := ""
 	if rc.Verbose {
@@ -30,12 +93,12 @@ func (rc *RequestConfig) CreateBodyTempfile() error {
 		tmpfile_dir = cwd
 	}
 	// Create a temporary file with a unique name
-	bodystr := strings.Join(rc.Body, "\n")
-	tmpfile, err := os.CreateTemp(tmpfile_dir, "vortex-body")
+	pattern := fmt.Sprintf("vortex-body-%d-%d-*", os.Getpid(), tempfileCounter.Add(1))
+	tmpfile, err := os.CreateTemp(tmpfile_dir, pattern)
 	if err != nil {
 		return errors.Wrap(err, "Failed to create temporary file")
 	}
-	if _, err := tmpfile.Write([]byte(bodystr)); err != nil {
+	if _, err := tmpfile.Write(payload); err != nil {
 		_ = tmpfile.Close()
 		return errors.Wrap(err, "Failed to write to temporary file")
 	}
@@ -71,3 +134,61 @@ func (rc *RequestConfig) RemoveBodyTempfile(force bool) error {
 	rc.TempfileName = ""
 	return errors.Wrap(err, "Failed to remove temporary file")
 }
+
+// The method Close releases the resources the RequestConfig holds once it is no longer needed,
+// so callers can `defer rc.Close()`. The temporary body file is removed with RemoveBodyTempfile,
+// unless Tempfile asks to keep it, and a file already removed by someone else is not an error.
+// Close can be called any number of times.
+//
+// Returns an error if the temporary body file cannot be removed.
+func (rc *RequestConfig) Close() error {
+	if err := rc.RemoveBodyTempfile(false); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// The method Clone returns a deep copy of the RequestConfig, so the copy can be executed, which
+// modifies its headers, body and authentication, without affecting the original. The
+// OnStreamLine callback is shared.
+//
+// Returns:
+//   - The copy of the RequestConfig.
+func (rc *RequestConfig) Clone() *RequestConfig {
+	clone := *rc
+	if rc.Host != nil {
+		host := *rc.Host
+		if rc.Host.User != nil {
+			userCopy := *rc.Host.User
+			host.User = &userCopy
+		}
+		clone.Host = &host
+	}
+	clone.Body = append([]string(nil), rc.Body...)
+	clone.Headers = append([]string(nil), rc.Headers...)
+	clone.Resolve = append([]string(nil), rc.Resolve...)
+	clone.BackendOptions = nil
+	for _, options := range rc.BackendOptions {
+		clone.BackendOptions = append(clone.BackendOptions, append([]string(nil), options...))
+	}
+	if rc.PathParams != nil {
+		clone.PathParams = make(map[string]string, len(rc.PathParams))
+		for name, value := range rc.PathParams {
+			clone.PathParams[name] = value
+		}
+	}
+	if rc.Retry != nil {
+		retry := *rc.Retry
+		retry.RetryOn = append([]int(nil), rc.Retry.RetryOn...)
+		clone.Retry = &retry
+	}
+	if rc.Auth != nil {
+		auth := *rc.Auth
+		auth.Params = make(map[string]string, len(rc.Auth.Params))
+		for name, value := range rc.Auth.Params {
+			auth.Params[name] = value
+		}
+		clone.Auth = &auth
+	}
+	return &clone
+}
@@ -0,0 +1,197 @@
+package data
+
+import (
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCreateBodyTempfileConcurrentNames(t *testing.T) {
+	const count = 50
+	template := RequestConfig{Method: "POST", Body: []string{`{"n":1}`}}
+	names := make([]string, count)
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rc := template
+			if err := rc.CreateBodyTempfile(); err != nil {
+				t.Errorf("CreateBodyTempfile() error = %v", err)
+				return
+			}
+			names[i] = rc.TempfileName
+		}(i)
+	}
+	wg.Wait()
+	seen := make(map[string]bool, count)
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		defer os.Remove(name)
+		if seen[name] {
+			t.Errorf("the body file %s is created more than once", name)
+		}
+		seen[name] = true
+	}
+	if len(seen) != count {
+		t.Errorf("%d distinct body files are created, want %d", len(seen), count)
+	}
+}
+
+func TestCreateBodyTempfileIgnoredBody(t *testing.T) {
+	for _, method := range []string{"HEAD", "OPTIONS", "head"} {
+		rc := &RequestConfig{Method: method, Body: []string{`{"n":1}`}}
+		if !rc.IgnoresBody() {
+			t.Errorf("IgnoresBody() of %s = false, want true", method)
+		}
+		if err := rc.CreateBodyTempfile(); err != nil {
+			t.Fatalf("CreateBodyTempfile() error = %v", err)
+		}
+		if rc.TempfileName != "" {
+			os.Remove(rc.TempfileName)
+			t.Errorf("CreateBodyTempfile() of a %s request created %s, want no body file", method, rc.TempfileName)
+		}
+	}
+	if (&RequestConfig{Method: "POST"}).IgnoresBody() {
+		t.Error("IgnoresBody() of POST = true, want false")
+	}
+}
+
+func TestBuildArgsHeadersOnly(t *testing.T) {
+	rc := &RequestConfig{Host: &url.URL{Scheme: "https", Host: "example.com"}, Method: "HEAD", Body: []string{"ignored"}}
+	curl, err := BuildCurlArgs(rc, Config{})
+	if err != nil {
+		t.Fatalf("BuildCurlArgs() error = %v", err)
+	}
+	if !slices.Contains(curl, "--head") || slices.Contains(curl, "--data-raw") || slices.Contains(curl, "--data-binary") {
+		t.Errorf("BuildCurlArgs() = %q, want --head and no body", curl)
+	}
+	httpie, err := BuildHTTPieArgs(rc, Config{})
+	if err != nil {
+		t.Fatalf("BuildHTTPieArgs() error = %v", err)
+	}
+	if !slices.Contains(httpie, "--print=h") {
+		t.Errorf("BuildHTTPieArgs() = %q, want --print=h", httpie)
+	}
+}
+
+func TestForceBody(t *testing.T) {
+	tests := map[string]struct {
+		rc        RequestConfig
+		shareable bool
+		wantFile  bool
+		want      []string
+	}{
+		"default":          {RequestConfig{Method: "POST"}, false, false, nil},
+		"forced":           {RequestConfig{Method: "POST", ForceBody: true}, false, true, []string{"--data-binary"}},
+		"forced shared":    {RequestConfig{Method: "POST", ForceBody: true}, true, true, []string{"--data-raw", ""}},
+		"forced with body": {RequestConfig{Method: "POST", ForceBody: true, Body: []string{"a"}}, true, true, []string{"--data-raw", "a"}},
+		"forced head":      {RequestConfig{Method: "HEAD", ForceBody: true}, false, false, nil},
+	}
+	for name, tt := range tests {
+		rc := tt.rc
+		rc.Host = &url.URL{Scheme: "https", Host: "example.com"}
+		if err := rc.CreateBodyTempfile(); err != nil {
+			t.Errorf("%s: CreateBodyTempfile() error = %v", name, err)
+			continue
+		}
+		if rc.TempfileName != "" {
+			defer os.Remove(rc.TempfileName)
+			if fi, err := os.Stat(rc.TempfileName); err != nil || (len(rc.Body) == 0 && fi.Size() != 0) {
+				t.Errorf("%s: the body file %s is not empty: %v", name, rc.TempfileName, err)
+			}
+		}
+		if (rc.TempfileName != "") != tt.wantFile {
+			t.Errorf("%s: CreateBodyTempfile() body file = %q, want one: %v", name, rc.TempfileName, tt.wantFile)
+		}
+		args, err := buildCurlArgs(&rc, Config{}, tt.shareable)
+		if err != nil {
+			t.Errorf("%s: buildCurlArgs() error = %v", name, err)
+			continue
+		}
+		i := slices.IndexFunc(args, func(arg string) bool { return arg == "--data-raw" || arg == "--data-binary" })
+		switch {
+		case tt.want == nil && i >= 0:
+			t.Errorf("%s: buildCurlArgs() = %q, want no body", name, args)
+		case tt.want != nil && (i < 0 || args[i] != tt.want[0]):
+			t.Errorf("%s: buildCurlArgs() = %q, want %s", name, args, tt.want[0])
+		case len(tt.want) == 2 && args[i+1] != tt.want[1]:
+			t.Errorf("%s: buildCurlArgs() %s = %q, want %q", name, args[i], args[i+1], tt.want[1])
+		}
+	}
+}
+
+func TestRequestConfigClose(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		rc := &RequestConfig{Method: "POST", Body: []string{"payload"}, Tempfile: keep}
+		if err := rc.CreateBodyTempfile(); err != nil {
+			t.Fatalf("CreateBodyTempfile() error = %v", err)
+		}
+		tempfile := rc.TempfileName
+		defer os.Remove(tempfile)
+		for i := 0; i < 3; i++ {
+			if err := rc.Close(); err != nil {
+				t.Errorf("Tempfile %v: Close() #%d error = %v", keep, i+1, err)
+			}
+		}
+		_, err := os.Stat(tempfile)
+		switch {
+		case !keep && !os.IsNotExist(err):
+			t.Errorf("Close() left the body file %s behind", tempfile)
+		case keep && err != nil:
+			t.Errorf("Close() removed the body file %s kept by Tempfile: %v", tempfile, err)
+		}
+	}
+
+	// A body file removed by someone else is not an error
+	rc := &RequestConfig{Method: "POST", Body: []string{"payload"}}
+	if err := rc.CreateBodyTempfile(); err != nil {
+		t.Fatalf("CreateBodyTempfile() error = %v", err)
+	}
+	os.Remove(rc.TempfileName)
+	if err := rc.Close(); err != nil {
+		t.Errorf("Close() of a removed body file error = %v, want nil", err)
+	}
+	if err := (&RequestConfig{}).Close(); err != nil {
+		t.Errorf("Close() without body file error = %v, want nil", err)
+	}
+}
+
+func TestQuietOutput(t *testing.T) {
+	host := &url.URL{Scheme: "https", Host: "example.com"}
+	tests := map[string]struct {
+		rc            RequestConfig
+		curlSilent    bool
+		wgetVerbosity string
+	}{
+		"default":        {RequestConfig{Host: host}, false, "--no-verbose"},
+		"quiet":          {RequestConfig{Host: host, Quiet: true}, true, "--quiet"},
+		"verbose wins":   {RequestConfig{Host: host, Quiet: true, Verbose: true}, false, "--no-verbose"},
+		"quiet redirect": {RequestConfig{Host: host, Quiet: true, FollowRedirects: true}, true, "--no-verbose"},
+	}
+	for name, tt := range tests {
+		if got, want := tt.rc.QuietOutput(), tt.curlSilent; got != want {
+			t.Errorf("%s: QuietOutput() = %v, want %v", name, got, want)
+		}
+		if got := tt.rc.ToCurlString(Config{}); strings.Contains(got, "--silent --show-error") != tt.curlSilent {
+			t.Errorf("%s: ToCurlString() = %q, want --silent --show-error: %v", name, got, tt.curlSilent)
+		}
+		args, err := BuildCurlArgs(&tt.rc, Config{})
+		if err != nil || !slices.Contains(args, "--silent") || !slices.Contains(args, "--show-error") {
+			t.Errorf("%s: BuildCurlArgs() = %q, %v, want the executed command silent", name, args, err)
+		}
+		args, err = BuildWgetArgs(&tt.rc, Config{})
+		if err != nil || len(args) == 0 || args[0] != tt.wgetVerbosity {
+			t.Errorf("%s: BuildWgetArgs() = %q, %v, want %s", name, args, err, tt.wgetVerbosity)
+		}
+		args, err = BuildHTTPieArgs(&tt.rc, Config{})
+		if err != nil || slices.Contains(args, "--quiet") {
+			t.Errorf("%s: BuildHTTPieArgs() = %q, %v, want the response kept", name, args, err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package data
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The function ParseResolveEntry splits a host override written as `host:port:address`, in the
+// format of the curl `--resolve` option, such as `example.com:443:127.0.0.1`. The address can be
+// an IPv6 address, with or without brackets, as in `example.com:443:[::1]`.
+//
+// Parameters:
+//   - entry: The host override to parse.
+//
+// Returns:
+//   - The host name, lowercased.
+//   - The port, between 1 and 65535.
+//   - The IP address the host resolves to, without brackets.
+//   - An error if the entry is not in the `host:port:address` format, or holds an invalid port or
+//     IP address.
+func ParseResolveEntry(entry string) (string, int, string, error) {
+	parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+	if len(parts) != 3 || parts[0] == "" {
+		return "", 0, "", errors.Errorf("Invalid resolve entry %q, expected host:port:address", entry)
+	}
+	port, err := strconv.Atoi(parts[1])
+	if err != nil || port < 1 || port > 65535 {
+		return "", 0, "", errors.Errorf("Invalid port %q in resolve entry %q", parts[1], entry)
+	}
+	address := strings.TrimSuffix(strings.TrimPrefix(parts[2], "["), "]")
+	if net.ParseIP(address) == nil {
+		return "", 0, "", errors.Errorf("Invalid IP address %q in resolve entry %q", parts[2], entry)
+	}
+	return strings.ToLower(parts[0]), port, address, nil
+}
+
+// The method ResolveAddress returns the address a connection to the host and port is made to
+// according to the Resolve overrides of the RequestConfig, the first matching entry winning.
+//
+// Parameters:
+//   - host: The host name being dialed, compared case-insensitively.
+//   - port: The port being dialed.
+//
+// Returns:
+//   - The `address:port` to dial instead, or an empty string when no entry matches.
+//   - An error if an entry of Resolve is invalid.
+func (rc *RequestConfig) ResolveAddress(host string, port int) (string, error) {
+	for _, entry := range rc.Resolve {
+		name, entryPort, address, err := ParseResolveEntry(entry)
+		if err != nil {
+			return "", err
+		}
+		if name == strings.ToLower(host) && entryPort == port {
+			return net.JoinHostPort(address, strconv.Itoa(port)), nil
+		}
+	}
+	return "", nil
+}
+
+// parseResolveLine validates a line of the [Resolve] section and appends it to the Resolve
+// overrides.
+func parseResolveLine(rc *RequestConfig, line string) error {
+	if _, _, _, err := ParseResolveEntry(line); err != nil {
+		return err
+	}
+	rc.Resolve = append(rc.Resolve, strings.TrimSpace(line))
+	return nil
+}
@@ -0,0 +1,80 @@
+package data
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseResolveEntry(t *testing.T) {
+	tests := map[string]struct {
+		entry, host, address string
+		port                 int
+		wantErr              bool
+	}{
+		"ipv4":            {entry: "Example.com:443:127.0.0.1", host: "example.com", port: 443, address: "127.0.0.1"},
+		"ipv6":            {entry: "example.com:80:::1", host: "example.com", port: 80, address: "::1"},
+		"bracketed ipv6":  {entry: "example.com:80:[::1]", host: "example.com", port: 80, address: "::1"},
+		"missing address": {entry: "example.com:443", wantErr: true},
+		"missing host":    {entry: ":443:127.0.0.1", wantErr: true},
+		"invalid port":    {entry: "example.com:https:127.0.0.1", wantErr: true},
+		"port too large":  {entry: "example.com:65536:127.0.0.1", wantErr: true},
+		"host name":       {entry: "example.com:443:localhost", wantErr: true},
+	}
+	for name, tt := range tests {
+		host, port, address, err := ParseResolveEntry(tt.entry)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: ParseResolveEntry(%q) succeeded, want an error", name, tt.entry)
+			}
+			continue
+		}
+		if err != nil || host != tt.host || port != tt.port || address != tt.address {
+			t.Errorf("%s: ParseResolveEntry(%q) = %q, %d, %q, %v, want %q, %d, %q", name, tt.entry, host, port, address, err, tt.host, tt.port, tt.address)
+		}
+	}
+}
+
+func TestResolveAddress(t *testing.T) {
+	rc := &RequestConfig{Resolve: []string{"example.com:443:127.0.0.1", "example.com:443:127.0.0.2", "example.com:8080:[::1]"}}
+	tests := map[string]struct {
+		host string
+		port int
+		want string
+	}{
+		"first match": {"EXAMPLE.com", 443, "127.0.0.1:443"},
+		"ipv6":        {"example.com", 8080, "[::1]:8080"},
+		"other port":  {"example.com", 80, ""},
+		"other host":  {"api.example.com", 443, ""},
+	}
+	for name, tt := range tests {
+		got, err := rc.ResolveAddress(tt.host, tt.port)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: ResolveAddress(%q, %d) = %q, %v, want %q", name, tt.host, tt.port, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseTemplateResolve(t *testing.T) {
+	rc, err := ParseTemplate("resolve.ini", "[Host]\nhttps://example.com\n[Resolve]\nexample.com:443:127.0.0.1\n  api.example.com:8443:[::1]  \n", Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	want := []string{"example.com:443:127.0.0.1", "api.example.com:8443:[::1]"}
+	if !reflect.DeepEqual(rc.Resolve, want) {
+		t.Errorf("Resolve = %q, want %q", rc.Resolve, want)
+	}
+	args, err := BuildCurlArgs(rc, Config{})
+	if err != nil {
+		t.Fatalf("BuildCurlArgs() error = %v", err)
+	}
+	if joined := strings.Join(args, "\x00"); !strings.Contains(joined, "--resolve\x00example.com:443:127.0.0.1\x00--resolve\x00api.example.com:8443:[::1]") {
+		t.Errorf("BuildCurlArgs() = %q, want a --resolve per entry", args)
+	}
+	if _, err := ParseTemplate("resolve.ini", "[Host]\nhttps://example.com\n[Resolve]\nexample.com=127.0.0.1\n", Config{}); err == nil {
+		t.Error("ParseTemplate() of an invalid [Resolve] entry succeeded, want an error")
+	}
+	if _, err := BuildWgetArgs(rc, Config{}); err == nil {
+		t.Error("BuildWgetArgs() with host overrides succeeded, want an error")
+	}
+}
@@ -0,0 +1,275 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ANSI escape sequences used to colorize JSON output.
+const (
+	colorReset  = "\x1b[0m"
+	colorKey    = "\x1b[34;1m"
+	colorString = "\x1b[32m"
+	colorNumber = "\x1b[36m"
+	colorLit    = "\x1b[35m"
+)
+
+// NoColor disables the colorized output of PrettyStdout, even when the standard output is a
+// terminal. It defaults to true when the NO_COLOR environment variable is set.
+var NoColor = os.Getenv("NO_COLOR") != ""
+
+// The method PrettyStdout returns the response body in a human friendly form, according to the
+// media type of the captured Content-Type response header:
+//   - JSON, including the `+json` media types, is re-indented with two spaces and, if the standard
+//     output is a terminal and NoColor is false, its keys, strings, numbers and literals are
+//     colorized with ANSI escape sequences.
+//   - XML, including the `+xml` media types, is re-indented with two spaces.
+//   - Any other body is returned unchanged.
+//
+// When no Content-Type header was captured, the media type is sniffed from the body. A body that
+// does not parse as the declared type is returned unchanged.
+//
+// Returns:
+//   - The formatted response body.
+//   - An error if the JSON body cannot be indented.
+func (rr RequestResult) PrettyStdout() (string, error) {
+	return rr.pretty(stdoutIsTerminal() && !NoColor)
+}
+
+// pretty formats the body according to its content type, colorizing JSON when requested.
+func (rr RequestResult) pretty(color bool) (string, error) {
+	switch rr.responseFormat() {
+	case "json":
+		return rr.prettyJSON(color)
+	case "xml":
+		return rr.prettyXML(), nil
+	}
+	return rr.Stdout, nil
+}
+
+// responseExtensions maps the media types of the responses to the extension of the files they are
+// saved to, for editors to pick their highlighting. JSON and XML are recognized by responseFormat.
+var responseExtensions = map[string]string{
+	"text/html":              ".html",
+	"text/css":               ".css",
+	"text/csv":               ".csv",
+	"text/markdown":          ".md",
+	"text/javascript":        ".js",
+	"application/javascript": ".js",
+	"application/yaml":       ".yaml",
+	"application/x-yaml":     ".yaml",
+	"text/yaml":              ".yaml",
+	"application/toml":       ".toml",
+	"application/graphql":    ".graphql",
+}
+
+// The method EditableStdout returns the response body as it is opened in an editor: formatted
+// like PrettyStdout, without colors, together with the extension of a file holding it, such as
+// `.json`, `.xml` or `.html`, chosen from the Content-Type response header and defaulting to
+// `.txt`.
+//
+// Returns:
+//   - The formatted response body.
+//   - The file extension, including the leading dot.
+//   - An error if the JSON body cannot be indented.
+func (rr RequestResult) EditableStdout() (string, string, error) {
+	body, err := rr.pretty(false)
+	if err != nil {
+		return "", "", err
+	}
+	switch rr.responseFormat() {
+	case "json":
+		return body, ".json", nil
+	case "xml":
+		return body, ".xml", nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(rr.Headers.Get(contentTypeHeader))
+	if ext, ok := responseExtensions[mediaType]; ok {
+		return body, ext, nil
+	}
+	return body, ".txt", nil
+}
+
+// responseFormat returns "json" or "xml" when the response body is of that format, according to
+// its Content-Type header or, without one, to its contents, and an empty string otherwise.
+func (rr RequestResult) responseFormat() string {
+	if header := rr.Headers.Get(contentTypeHeader); header != "" {
+		mediaType, _, err := mime.ParseMediaType(header)
+		if err != nil {
+			return ""
+		}
+		switch {
+		case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+			return "json"
+		case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+			return "xml"
+		}
+		return ""
+	}
+	body := []byte(strings.TrimSpace(rr.Stdout))
+	switch {
+	case json.Valid(body):
+		return "json"
+	case isWellFormedXML(body):
+		return "xml"
+	}
+	return ""
+}
+
+// prettyJSON indents a JSON body, colorizing it when requested, and leaves other bodies alone.
+func (rr RequestResult) prettyJSON(color bool) (string, error) {
+	body := []byte(strings.TrimSpace(rr.Stdout))
+	if len(body) == 0 || !json.Valid(body) {
+		return rr.Stdout, nil
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, body, "", "  "); err != nil {
+		return "", errors.Wrap(err, "Failed to indent the JSON response")
+	}
+	if !color {
+		return indented.String(), nil
+	}
+	return colorizeJSON(indented.String()), nil
+}
+
+// prettyXML indents a well-formed XML body with two spaces, keeping the elements holding only text
+// on a single line, and leaves other bodies alone.
+func (rr RequestResult) prettyXML() string {
+	body := []byte(strings.TrimSpace(rr.Stdout))
+	if !isWellFormedXML(body) {
+		return rr.Stdout
+	}
+	var tokens []xml.Token
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			break
+		}
+		if text, ok := token.(xml.CharData); ok && len(bytes.TrimSpace(text)) == 0 {
+			continue
+		}
+		tokens = append(tokens, xml.CopyToken(token))
+	}
+	var out bytes.Buffer
+	depth := 0
+	indent := func() {
+		if out.Len() > 0 {
+			out.WriteByte('\n')
+		}
+		out.WriteString(strings.Repeat("  ", depth))
+	}
+	for i := 0; i < len(tokens); i++ {
+		switch t := tokens[i].(type) {
+		case xml.ProcInst:
+			indent()
+			out.WriteString("<?" + t.Target + " " + string(t.Inst) + "?>")
+		case xml.Directive:
+			indent()
+			out.WriteString("<!" + string(t) + ">")
+		case xml.Comment:
+			indent()
+			out.WriteString("<!--" + string(t) + "-->")
+		case xml.CharData:
+			indent()
+			_ = xml.EscapeText(&out, bytes.TrimSpace(t))
+		case xml.EndElement:
+			depth--
+			indent()
+			out.WriteString("</" + xmlName(t.Name) + ">")
+		case xml.StartElement:
+			indent()
+			out.WriteString("<" + xmlName(t.Name))
+			for _, attr := range t.Attr {
+				out.WriteString(" " + xmlName(attr.Name) + `="`)
+				_ = xml.EscapeText(&out, []byte(attr.Value))
+				out.WriteByte('"')
+			}
+			// Elements without children, or with text only, are kept on a single line
+			if i+1 < len(tokens) {
+				if _, ok := tokens[i+1].(xml.EndElement); ok {
+					out.WriteString("/>")
+					i++
+					continue
+				}
+			}
+			if i+2 < len(tokens) {
+				text, isText := tokens[i+1].(xml.CharData)
+				if _, isEnd := tokens[i+2].(xml.EndElement); isText && isEnd {
+					out.WriteByte('>')
+					_ = xml.EscapeText(&out, bytes.TrimSpace(text))
+					out.WriteString("</" + xmlName(t.Name) + ">")
+					i += 2
+					continue
+				}
+			}
+			out.WriteByte('>')
+			depth++
+		}
+	}
+	return out.String()
+}
+
+// xmlName returns the qualified name of an XML element or attribute, as written in the document.
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// stdoutIsTerminal reports whether the process standard output is a terminal.
+func stdoutIsTerminal() bool {
+	fi, err := os.Stdout.Stat()
+	return err == nil && (fi.Mode()&os.ModeCharDevice) != 0
+}
+
+// colorizeJSON wraps the tokens of a valid, indented JSON document in ANSI color sequences.
+func colorizeJSON(doc string) string {
+	var builder strings.Builder
+	builder.Grow(len(doc) * 2)
+	for i := 0; i < len(doc); {
+		c := doc[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(doc) && doc[end] != '"' {
+				if doc[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end++
+			color := colorString
+			if strings.HasPrefix(strings.TrimLeft(doc[end:], " "), ":") {
+				color = colorKey
+			}
+			builder.WriteString(color + doc[i:end] + colorReset)
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(doc) && strings.IndexByte("0123456789.eE+-", doc[end]) >= 0 {
+				end++
+			}
+			builder.WriteString(colorNumber + doc[i:end] + colorReset)
+			i = end
+		case c == 't' || c == 'f' || c == 'n':
+			end := i + 1
+			for end < len(doc) && doc[end] >= 'a' && doc[end] <= 'z' {
+				end++
+			}
+			builder.WriteString(colorLit + doc[i:end] + colorReset)
+			i = end
+		default:
+			builder.WriteByte(c)
+			i++
+		}
+	}
+	return builder.String()
+}
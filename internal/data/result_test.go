@@ -0,0 +1,76 @@
+package data
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPrettyStdoutJSON(t *testing.T) {
+	noColor := NoColor
+	t.Cleanup(func() { NoColor = noColor })
+	NoColor = true
+	tests := map[string]struct {
+		stdout, want string
+	}{
+		"compact":  {`{"id":1,"tags":["a","b"]}`, "{\n  \"id\": 1,\n  \"tags\": [\n    \"a\",\n    \"b\"\n  ]\n}"},
+		"not json": {"plain text\n", "plain text\n"},
+		"empty":    {"", ""},
+	}
+	for name, tt := range tests {
+		got, err := RequestResult{Stdout: tt.stdout}.PrettyStdout()
+		if err != nil {
+			t.Errorf("%s: PrettyStdout() error = %v", name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: PrettyStdout() = %q, want %q", name, got, tt.want)
+		}
+	}
+}
+
+func TestColorizeJSON(t *testing.T) {
+	got := colorizeJSON(`{"id": -1.5, "ok": true, "name": "a\"b"}`)
+	want := "{" + colorKey + `"id"` + colorReset + ": " + colorNumber + "-1.5" + colorReset + ", " +
+		colorKey + `"ok"` + colorReset + ": " + colorLit + "true" + colorReset + ", " +
+		colorKey + `"name"` + colorReset + ": " + colorString + `"a\"b"` + colorReset + "}"
+	if got != want {
+		t.Errorf("colorizeJSON() = %q, want %q", got, want)
+	}
+}
+
+func TestPrettyStdoutContentType(t *testing.T) {
+	noColor := NoColor
+	t.Cleanup(func() { NoColor = noColor })
+	NoColor = true
+	xmlBody := `<?xml version="1.0"?><users><user id="1"><name>a &amp; b</name><tags/></user></users>`
+	indentedXML := "<?xml version=\"1.0\"?>\n<users>\n  <user id=\"1\">\n    <name>a &amp; b</name>\n    <tags/>\n  </user>\n</users>"
+	tests := map[string]struct {
+		contentType, stdout, want string
+	}{
+		"json":            {"application/json; charset=utf-8", `{"a":1}`, "{\n  \"a\": 1\n}"},
+		"json suffix":     {"application/problem+json", `{"a":1}`, "{\n  \"a\": 1\n}"},
+		"xml":             {"application/xml", xmlBody, indentedXML},
+		"text/xml":        {"text/xml", xmlBody, indentedXML},
+		"text":            {"text/plain", `{"a":1}`, `{"a":1}`},
+		"html":            {"text/html", "<p>hi</p>", "<p>hi</p>"},
+		"malformed xml":   {"application/xml", "<a><b></a>", "<a><b></a>"},
+		"sniffed json":    {"", `{"a":1}`, "{\n  \"a\": 1\n}"},
+		"sniffed xml":     {"", xmlBody, indentedXML},
+		"sniffed text":    {"", "plain", "plain"},
+		"invalid charset": {"application/json;;", `{"a":1}`, `{"a":1}`},
+	}
+	for name, tt := range tests {
+		rr := RequestResult{Stdout: tt.stdout, Headers: http.Header{}}
+		if tt.contentType != "" {
+			rr.Headers.Set("Content-Type", tt.contentType)
+		}
+		got, err := rr.PrettyStdout()
+		if err != nil {
+			t.Errorf("%s: PrettyStdout() error = %v", name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: PrettyStdout() = %q, want %q", name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package data
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryConfig describes when a failed request is sent again.
+type RetryConfig struct {
+	// Attempts is the maximum number of times the request is sent, the first one included. One or
+	// less sends it once.
+	Attempts int
+
+	// Delay is the pause between two attempts when the response sets no Retry-After header.
+	Delay time.Duration
+
+	// RetryOn lists the HTTP status codes whose responses are retried, such as 429 and 503. The
+	// responses with other status codes are returned immediately.
+	RetryOn []int
+
+	// RetryOnTimeout, if true, also retries the requests that timed out, either on the connection
+	// or waiting for the response.
+	RetryOnTimeout bool
+
+	// MaxRetryAfter, if positive, caps the delay read from a Retry-After header, so a server
+	// asking to come back in an hour does not stall the run.
+	MaxRetryAfter time.Duration
+}
+
+// The method RetriesStatus reports whether the responses with the given status code are retried,
+// as listed in RetryOn.
+//
+// Parameters:
+//   - status: The HTTP status code of the response.
+//
+// Returns:
+//   - True if the status code is one of RetryOn, false otherwise or for a nil RetryConfig.
+func (rc *RetryConfig) RetriesStatus(status int) bool {
+	if rc == nil {
+		return false
+	}
+	for _, code := range rc.RetryOn {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// The function RetryAfter reads the delay a server asks for in the Retry-After header of a 429 or
+// 503 response, written either as a number of seconds or as an HTTP date.
+//
+// Parameters:
+//   - headers: The headers of the response.
+//   - now: The current time, which an HTTP date is relative to.
+//
+// Returns:
+//   - The delay, zero for a date in the past.
+//   - False if the header is missing or malformed.
+func RetryAfter(headers http.Header, now time.Time) (time.Duration, bool) {
+	value := strings.TrimSpace(headers.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(date.Sub(now), 0), true
+}
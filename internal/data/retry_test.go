@@ -0,0 +1,47 @@
+package data
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetriesStatus(t *testing.T) {
+	retry := &RetryConfig{RetryOn: []int{429, 503}}
+	for status, want := range map[int]bool{429: true, 503: true, 400: false, 500: false, 200: false} {
+		if got := retry.RetriesStatus(status); got != want {
+			t.Errorf("RetriesStatus(%d) = %v, want %v", status, got, want)
+		}
+	}
+	var none *RetryConfig
+	if none.RetriesStatus(503) {
+		t.Error("RetriesStatus() of a nil RetryConfig = true, want false")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		"seconds":   {"120", 2 * time.Minute, true},
+		"spaces":    {" 3 ", 3 * time.Second, true},
+		"negative":  {"-5", 0, true},
+		"date":      {now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		"past date": {now.Add(-time.Hour).Format(http.TimeFormat), 0, true},
+		"missing":   {"", 0, false},
+		"malformed": {"soon", 0, false},
+	}
+	for name, tt := range tests {
+		headers := http.Header{}
+		if tt.value != "" {
+			headers.Set("Retry-After", tt.value)
+		}
+		got, ok := RetryAfter(headers, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: RetryAfter(%q) = %v, %v, want %v, %v", name, tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,417 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SchemaValidator validates a JSON document against a JSON Schema. It is the extension point used
+// by ValidateJSONSchema, so a complete implementation can replace the built-in one.
+type SchemaValidator interface {
+	// Validate checks the document against the schema and returns one SchemaViolation per failing
+	// location, or an error if the schema itself is invalid.
+	Validate(schema, document []byte) ([]SchemaViolation, error)
+}
+
+// SchemaViolation describes a location of a JSON document that does not match its schema.
+type SchemaViolation struct {
+	// Path locates the failing value, as in `$.items[2].name`.
+	Path string
+
+	// Message explains why the value does not match.
+	Message string
+}
+
+// The method String returns the violation formatted as `path: message`.
+func (sv SchemaViolation) String() string {
+	return sv.Path + ": " + sv.Message
+}
+
+// JSONSchemaValidator is the SchemaValidator used by ValidateJSONSchema. The default one supports
+// the commonly used subset of JSON Schema: type, enum, const, required, properties,
+// additionalProperties, items, the length, size and range bounds, pattern, allOf, anyOf, oneOf,
+// not and local `$ref` references to `#/definitions` and `#/$defs`. Unknown keywords are ignored.
+var JSONSchemaValidator SchemaValidator = basicSchemaValidator{}
+
+// The method ValidateJSONSchema validates the response body against a JSON Schema with the
+// JSONSchemaValidator.
+//
+// Parameters:
+//   - schema: The JSON Schema document.
+//
+// Returns:
+//   - An error if the body is not valid JSON, the schema is invalid, or the body does not match the
+//     schema, in which case every failing path is listed.
+func (rr RequestResult) ValidateJSONSchema(schema []byte) error {
+	body := []byte(strings.TrimSpace(rr.Stdout))
+	if !json.Valid(body) {
+		return errors.New("The response body is not valid JSON")
+	}
+	violations, err := JSONSchemaValidator.Validate(schema, body)
+	if err != nil {
+		return errors.Wrap(err, "Failed to validate the response body")
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	lines := make([]string, len(violations))
+	for i, violation := range violations {
+		lines[i] = violation.String()
+	}
+	return errors.Errorf("The response body does not match the schema:\n  - %s", strings.Join(lines, "\n  - "))
+}
+
+// basicSchemaValidator is the built-in SchemaValidator.
+type basicSchemaValidator struct{}
+
+// Validate implements SchemaValidator.
+func (basicSchemaValidator) Validate(schema, document []byte) ([]SchemaViolation, error) {
+	var root, doc any
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return nil, errors.Wrap(err, "Invalid JSON schema")
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(document)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, errors.Wrap(err, "Invalid JSON document")
+	}
+	v := &schemaWalker{root: root}
+	v.validate(root, doc, "$", 0)
+	return v.violations, v.err
+}
+
+// schemaMaxDepth bounds the nesting of `$ref` resolution, to stop on recursive references.
+const schemaMaxDepth = 64
+
+// schemaWalker walks a document alongside its schema, collecting the violations.
+type schemaWalker struct {
+	root       any
+	violations []SchemaViolation
+	err        error
+}
+
+// fail records a violation at the given path.
+func (w *schemaWalker) fail(path, format string, args ...any) {
+	w.violations = append(w.violations, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// matches reports whether the value matches the schema, without recording violations.
+func (w *schemaWalker) matches(schema, value any, path string, depth int) bool {
+	probe := &schemaWalker{root: w.root}
+	probe.validate(schema, value, path, depth)
+	if probe.err != nil && w.err == nil {
+		w.err = probe.err
+	}
+	return len(probe.violations) == 0
+}
+
+// validate checks the value against the schema and records the violations.
+func (w *schemaWalker) validate(schema, value any, path string, depth int) {
+	if depth > schemaMaxDepth {
+		w.err = errors.Errorf("Schema references are nested too deeply at %s", path)
+		return
+	}
+	if allowed, ok := schema.(bool); ok {
+		if !allowed {
+			w.fail(path, "no value is allowed")
+		}
+		return
+	}
+	s, ok := schema.(map[string]any)
+	if !ok {
+		w.err = errors.Errorf("Invalid schema at %s", path)
+		return
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		resolved, err := w.resolve(ref)
+		if err != nil {
+			w.err = err
+			return
+		}
+		w.validate(resolved, value, path, depth+1)
+		return
+	}
+	if types, ok := s["type"]; ok && !matchesType(types, value) {
+		w.fail(path, "expected %s, got %s", describeTypes(types), jsonType(value))
+		return
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		found := false
+		for _, candidate := range enum {
+			if jsonEqual(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			w.fail(path, "value is not one of the allowed values")
+		}
+	}
+	if constant, ok := s["const"]; ok && !jsonEqual(constant, value) {
+		w.fail(path, "value does not equal the constant")
+	}
+	switch typed := value.(type) {
+	case map[string]any:
+		w.validateObject(s, typed, path, depth)
+	case []any:
+		w.validateArray(s, typed, path, depth)
+	case string:
+		length := len([]rune(typed))
+		if limit, ok := schemaNumber(s, "minLength"); ok && float64(length) < limit {
+			w.fail(path, "string is shorter than %v characters", limit)
+		}
+		if limit, ok := schemaNumber(s, "maxLength"); ok && float64(length) > limit {
+			w.fail(path, "string is longer than %v characters", limit)
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				w.err = errors.Wrapf(err, "Invalid pattern at %s", path)
+				return
+			}
+			if !re.MatchString(typed) {
+				w.fail(path, "string does not match the pattern %s", pattern)
+			}
+		}
+	case json.Number:
+		n, _ := typed.Float64()
+		if limit, ok := schemaNumber(s, "minimum"); ok && n < limit {
+			w.fail(path, "%v is less than the minimum %v", typed, limit)
+		}
+		if limit, ok := schemaNumber(s, "maximum"); ok && n > limit {
+			w.fail(path, "%v is greater than the maximum %v", typed, limit)
+		}
+		if limit, ok := schemaNumber(s, "exclusiveMinimum"); ok && n <= limit {
+			w.fail(path, "%v is not greater than %v", typed, limit)
+		}
+		if limit, ok := schemaNumber(s, "exclusiveMaximum"); ok && n >= limit {
+			w.fail(path, "%v is not less than %v", typed, limit)
+		}
+	}
+	if all, ok := s["allOf"].([]any); ok {
+		for _, sub := range all {
+			w.validate(sub, value, path, depth+1)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if w.matches(sub, value, path, depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			w.fail(path, "value does not match any of the anyOf schemas")
+		}
+	}
+	if one, ok := s["oneOf"].([]any); ok {
+		matched := 0
+		for _, sub := range one {
+			if w.matches(sub, value, path, depth+1) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			w.fail(path, "value matches %d of the oneOf schemas instead of exactly one", matched)
+		}
+	}
+	if not, ok := s["not"]; ok && w.matches(not, value, path, depth+1) {
+		w.fail(path, "value matches the schema it must not match")
+	}
+}
+
+// validateObject checks the object keywords of the schema.
+func (w *schemaWalker) validateObject(s map[string]any, object map[string]any, path string, depth int) {
+	if required, ok := s["required"].([]any); ok {
+		for _, name := range required {
+			if key, ok := name.(string); ok {
+				if _, present := object[key]; !present {
+					w.fail(childPath(path, key), "is required")
+				}
+			}
+		}
+	}
+	properties, _ := s["properties"].(map[string]any)
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if property, ok := properties[key]; ok {
+			w.validate(property, object[key], childPath(path, key), depth+1)
+			continue
+		}
+		if additional, ok := s["additionalProperties"]; ok {
+			if allowed, isBool := additional.(bool); isBool && !allowed {
+				w.fail(childPath(path, key), "is not an allowed property")
+				continue
+			}
+			w.validate(additional, object[key], childPath(path, key), depth+1)
+		}
+	}
+	if limit, ok := schemaNumber(s, "minProperties"); ok && float64(len(object)) < limit {
+		w.fail(path, "object has fewer than %v properties", limit)
+	}
+	if limit, ok := schemaNumber(s, "maxProperties"); ok && float64(len(object)) > limit {
+		w.fail(path, "object has more than %v properties", limit)
+	}
+}
+
+// validateArray checks the array keywords of the schema.
+func (w *schemaWalker) validateArray(s map[string]any, array []any, path string, depth int) {
+	if items, ok := s["items"]; ok {
+		for i, item := range array {
+			w.validate(items, item, path+"["+strconv.Itoa(i)+"]", depth+1)
+		}
+	}
+	if limit, ok := schemaNumber(s, "minItems"); ok && float64(len(array)) < limit {
+		w.fail(path, "array has fewer than %v items", limit)
+	}
+	if limit, ok := schemaNumber(s, "maxItems"); ok && float64(len(array)) > limit {
+		w.fail(path, "array has more than %v items", limit)
+	}
+	if unique, ok := s["uniqueItems"].(bool); ok && unique {
+		for i := range array {
+			for j := i + 1; j < len(array); j++ {
+				if jsonEqual(array[i], array[j]) {
+					w.fail(path, "items %d and %d are equal", i, j)
+				}
+			}
+		}
+	}
+}
+
+// resolve returns the schema referenced by a local `$ref` such as `#/definitions/User`.
+func (w *schemaWalker) resolve(ref string) (any, error) {
+	if ref == "#" {
+		return w.root, nil
+	}
+	pointer, found := strings.CutPrefix(ref, "#/")
+	if !found {
+		return nil, errors.Errorf("Unsupported schema reference: %s", ref)
+	}
+	current := w.root
+	for _, token := range strings.Split(pointer, "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil, errors.Errorf("Unresolvable schema reference: %s", ref)
+		}
+		if current, ok = object[token]; !ok {
+			return nil, errors.Errorf("Unresolvable schema reference: %s", ref)
+		}
+	}
+	return current, nil
+}
+
+// childPath returns the path of a property of the object at path.
+func childPath(path, key string) string {
+	for _, r := range key {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return path + "[" + strconv.Quote(key) + "]"
+		}
+	}
+	return path + "." + key
+}
+
+// schemaNumber returns the numeric value of a schema keyword.
+func schemaNumber(s map[string]any, keyword string) (float64, bool) {
+	n, ok := s[keyword].(float64)
+	return n, ok
+}
+
+// jsonType returns the JSON Schema type name of a decoded value.
+func jsonType(value any) string {
+	switch typed := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if n, err := typed.Float64(); err == nil && n == math.Trunc(n) && !strings.ContainsAny(typed.String(), ".eE") {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	}
+	return "object"
+}
+
+// matchesType reports whether the value has the type, or one of the types, of a `type` keyword.
+func matchesType(types, value any) bool {
+	actual := jsonType(value)
+	check := func(expected any) bool {
+		return expected == actual || (expected == "number" && actual == "integer")
+	}
+	if list, ok := types.([]any); ok {
+		for _, expected := range list {
+			if check(expected) {
+				return true
+			}
+		}
+		return false
+	}
+	return check(types)
+}
+
+// describeTypes renders the value of a `type` keyword for messages.
+func describeTypes(types any) string {
+	if list, ok := types.([]any); ok {
+		names := make([]string, len(list))
+		for i, name := range list {
+			names[i] = fmt.Sprint(name)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(types)
+}
+
+// jsonEqual compares a value decoded from a schema with one decoded from the document, whose
+// numbers are json.Number.
+func jsonEqual(schemaValue, value any) bool {
+	switch typed := value.(type) {
+	case json.Number:
+		n, err := typed.Float64()
+		expected, ok := schemaValue.(float64)
+		return err == nil && ok && n == expected
+	case []any:
+		expected, ok := schemaValue.([]any)
+		if !ok || len(expected) != len(typed) {
+			return false
+		}
+		for i := range typed {
+			if !jsonEqual(expected[i], typed[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		expected, ok := schemaValue.(map[string]any)
+		if !ok || len(expected) != len(typed) {
+			return false
+		}
+		for key, item := range typed {
+			if !jsonEqual(expected[key], item) {
+				return false
+			}
+		}
+		return true
+	}
+	switch schemaValue.(type) {
+	case []any, map[string]any:
+		return false
+	}
+	return schemaValue == value
+}
@@ -0,0 +1,85 @@
+package data
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const userSchema = `{
+  "type": "object",
+  "required": ["id", "name"],
+  "properties": {
+    "id": {"type": "integer"},
+    "name": {"type": "string", "minLength": 1},
+    "tags": {"type": "array", "items": {"$ref": "#/$defs/tag"}}
+  },
+  "$defs": {"tag": {"type": "string"}}
+}`
+
+func TestValidateJSONSchema(t *testing.T) {
+	tests := map[string]struct {
+		body string
+		want []string
+	}{
+		"valid":            {`{"id": 1, "name": "vortex", "tags": ["a"]}`, nil},
+		"missing required": {`{"id": 1}`, []string{"$.name: is required"}},
+		"type mismatch":    {`{"id": "1", "name": "vortex", "tags": ["a", 2]}`, []string{"$.id: expected integer, got string", "$.tags[1]: expected string, got integer"}},
+		"not json":         {`<html>`, []string{"not valid JSON"}},
+	}
+	for name, tt := range tests {
+		err := RequestResult{Stdout: tt.body}.ValidateJSONSchema([]byte(userSchema))
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s: ValidateJSONSchema() error = %v", name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: ValidateJSONSchema() succeeded, want %q", name, tt.want)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: ValidateJSONSchema() error = %v, want it to list %q", name, err, want)
+			}
+		}
+	}
+}
+
+// stubSchemaValidator reports the same violation for every document.
+type stubSchemaValidator struct{}
+
+func (stubSchemaValidator) Validate([]byte, []byte) ([]SchemaViolation, error) {
+	return []SchemaViolation{{Path: "$.stub", Message: "rejected"}}, nil
+}
+
+func TestValidateJSONSchemaValidator(t *testing.T) {
+	validator := JSONSchemaValidator
+	t.Cleanup(func() { JSONSchemaValidator = validator })
+	JSONSchemaValidator = stubSchemaValidator{}
+	err := RequestResult{Stdout: `{}`}.ValidateJSONSchema([]byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "$.stub: rejected") {
+		t.Errorf("ValidateJSONSchema() error = %v, want the violation of the replaced validator", err)
+	}
+}
+
+func TestCheckExpectationsSchema(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{"user.json": userSchema})
+	rc, err := ParseTemplate(filepath.Join(dir, "get.ini"), "[Host]\nhttps://example.com\n[Expect]\nschema = user.json\n", Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if want := filepath.Join(dir, "user.json"); rc.ExpectSchema != want {
+		t.Fatalf("ExpectSchema = %q, want %q", rc.ExpectSchema, want)
+	}
+	if err := rc.CheckExpectations(RequestResult{Stdout: `{"id": 1, "name": "vortex"}`}); err != nil {
+		t.Errorf("CheckExpectations() of a matching body error = %v", err)
+	}
+	err = rc.CheckExpectations(RequestResult{Stdout: `{"id": 1}`})
+	var expectation *ExpectationError
+	if !errors.As(err, &expectation) || !strings.Contains(err.Error(), "$.name: is required") {
+		t.Errorf("CheckExpectations() error = %v, want an ExpectationError listing $.name", err)
+	}
+}
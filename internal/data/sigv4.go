@@ -0,0 +1,209 @@
+package data
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Names of the headers and formats of the AWS Signature Version 4.
+const (
+	awsV4Algorithm        = "AWS4-HMAC-SHA256"
+	awsDateHeader         = "X-Amz-Date"
+	awsSecurityToken      = "X-Amz-Security-Token"
+	awsContentSHA256      = "X-Amz-Content-Sha256"
+	awsTimeFormat         = "20060102T150405Z"
+	awsDateFormat         = "20060102"
+	awsScopeTerminator    = "aws4_request"
+	awsUnreservedURIChars = "-_.~"
+)
+
+// AWSCredentials holds what is needed to sign a request with the AWS Signature Version 4.
+type AWSCredentials struct {
+	// AccessKeyID identifies the credentials.
+	AccessKeyID string
+
+	// SecretAccessKey is the secret used to derive the signing key.
+	SecretAccessKey string
+
+	// SessionToken is the token of temporary credentials. It may be empty.
+	SessionToken string
+
+	// Region is the AWS region of the called service, such as `us-east-1`.
+	Region string
+
+	// Service is the signing name of the called service, such as `s3` or `execute-api`.
+	Service string
+}
+
+// The method SignAWSV4 signs the request with the AWS Signature Version 4: it computes the
+// signature of the canonical request, made of the method, path, query, headers and body hash, and
+// adds the `Authorization` and `X-Amz-Date` headers, along with `X-Amz-Security-Token` for
+// temporary credentials and `X-Amz-Content-Sha256` for S3. The headers of a previous signature are
+// replaced. Every header of the request is signed, so it must not be changed afterwards.
+//
+// Parameters:
+//   - creds: The credentials, region and service.
+//   - now: The signing time.
+//
+// Returns:
+//   - An error if the request has no host, the credentials are incomplete or the body file cannot
+//     be read.
+func (rc *RequestConfig) SignAWSV4(creds AWSCredentials, now time.Time) error {
+	if rc.Host == nil {
+		return errors.New("Cannot sign a request without a host")
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" || creds.Region == "" || creds.Service == "" {
+		return errors.New("Incomplete AWS credentials, the access key, secret key, region and service are required")
+	}
+	payload, err := rc.signedPayload()
+	if err != nil {
+		return err
+	}
+	payloadHash := sha256Hex(payload)
+	now = now.UTC()
+	amzDate := now.Format(awsTimeFormat)
+	rc.removeHeaders(authorizationHeader, awsDateHeader, awsSecurityToken, awsContentSHA256)
+	rc.Headers = append(rc.Headers, awsDateHeader+": "+amzDate)
+	if creds.SessionToken != "" {
+		rc.Headers = append(rc.Headers, awsSecurityToken+": "+creds.SessionToken)
+	}
+	if creds.Service == "s3" {
+		rc.Headers = append(rc.Headers, awsContentSHA256+": "+payloadHash)
+	}
+	canonicalRequest, signedHeaders := rc.awsCanonicalRequest(creds.Service, payloadHash)
+	scope := strings.Join([]string{now.Format(awsDateFormat), creds.Region, creds.Service, awsScopeTerminator}, "/")
+	stringToSign := strings.Join([]string{awsV4Algorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), now.Format(awsDateFormat))
+	for _, part := range []string{creds.Region, creds.Service, awsScopeTerminator} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	rc.Headers = append(rc.Headers, authorizationHeader+": "+awsV4Algorithm+
+		" Credential="+creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+	return nil
+}
+
+// signedPayload returns the body bytes as they are sent: compressed when CompressBody is set, read
+// from the BodyFile, or joined from the Body lines.
+func (rc *RequestConfig) signedPayload() ([]byte, error) {
+	switch {
+	case rc.IgnoresBody():
+		return nil, nil
+	case rc.CompressBody:
+		return rc.CompressedBody()
+	case rc.BodyFile != "":
+		contents, err := os.ReadFile(rc.BodyFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read the body file: %s", rc.BodyFile)
+		}
+		return contents, nil
+	}
+	return []byte(strings.Join(rc.Body, "\n")), nil
+}
+
+// awsCanonicalRequest builds the canonical request of the AWS Signature Version 4 and returns it
+// along with the list of signed headers.
+func (rc *RequestConfig) awsCanonicalRequest(service, payloadHash string) (string, string) {
+	method := strings.ToUpper(rc.Method)
+	if method == "" {
+		method = "GET"
+	}
+	target := rc.Host
+	if _, socketTarget, isSocket, err := rc.UnixSocket(); err == nil && isSocket {
+		target = socketTarget
+	}
+	headers := map[string]string{"host": target.Host}
+	for _, header := range rc.Headers {
+		name, value, _ := SplitHeader(header)
+		name = strings.ToLower(name)
+		value = strings.Join(strings.Fields(value), " ")
+		if previous, ok := headers[name]; ok && name != "host" {
+			value = previous + "," + value
+		}
+		headers[name] = value
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	return strings.Join([]string{
+		method,
+		awsCanonicalPath(target, service),
+		awsCanonicalQuery(target.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n"), signedHeaders
+}
+
+// awsCanonicalPath URI-encodes each segment of the path. Except for S3, the segments are encoded
+// again on top of the encoding of the URL, as expected by the AWS services.
+func awsCanonicalPath(target *url.URL, service string) string {
+	path := target.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	if service == "s3" {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = awsURIEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// awsCanonicalQuery encodes the query parameters sorted by name and value.
+func awsCanonicalQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			params = append(params, awsURIEncode(key)+"="+awsURIEncode(value))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// awsURIEncode percent-encodes every byte except the unreserved characters of RFC 3986.
+func awsURIEncode(text string) string {
+	var builder strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.IndexByte(awsUnreservedURIChars, c) >= 0 {
+			builder.WriteByte(c)
+			continue
+		}
+		builder.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+	}
+	return builder.String()
+}
+
+// sha256Hex returns the hexadecimal SHA-256 digest of the data.
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of the message with the given key.
+func hmacSHA256(key []byte, message string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
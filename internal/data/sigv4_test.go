@@ -0,0 +1,109 @@
+package data
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// awsTestCredentials are the credentials of the AWS Signature Version 4 test suite.
+var awsTestCredentials = AWSCredentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	Region:          "us-east-1",
+	Service:         "service",
+}
+
+func TestSignAWSV4(t *testing.T) {
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	tests := map[string]struct {
+		method, rawURL, signature string
+	}{
+		"get vanilla":      {"GET", "https://example.amazonaws.com/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		"get query order":  {"GET", "https://example.amazonaws.com/?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+		"post vanilla":     {"POST", "https://example.amazonaws.com/", "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b"},
+		"get empty method": {"", "https://example.amazonaws.com/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+	}
+	for name, tt := range tests {
+		host, err := url.Parse(tt.rawURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc := &RequestConfig{Method: tt.method, Host: host}
+		if err := rc.SignAWSV4(awsTestCredentials, now); err != nil {
+			t.Errorf("%s: SignAWSV4() error = %v", name, err)
+			continue
+		}
+		want := []string{
+			"X-Amz-Date: 20150830T123600Z",
+			"Authorization: AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=" + tt.signature,
+		}
+		if strings.Join(rc.Headers, "\n") != strings.Join(want, "\n") {
+			t.Errorf("%s: SignAWSV4() headers = %q, want %q", name, rc.Headers, want)
+		}
+	}
+}
+
+func TestSignAWSV4CanonicalRequest(t *testing.T) {
+	host, err := url.Parse("https://example.amazonaws.com/a b/?Param2=value2&Param1=value 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc := &RequestConfig{Method: "get", Host: host, Headers: []string{"X-Amz-Date: 20150830T123600Z", "My-Header1:  a   b  c "}}
+	got, signedHeaders := rc.awsCanonicalRequest("service", sha256Hex(nil))
+	want := strings.Join([]string{
+		"GET",
+		"/a%2520b/",
+		"Param1=value%201&Param2=value2",
+		"host:example.amazonaws.com",
+		"my-header1:a b c",
+		"x-amz-date:20150830T123600Z",
+		"",
+		"host;my-header1;x-amz-date",
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	}, "\n")
+	if got != want {
+		t.Errorf("awsCanonicalRequest() = %q, want %q", got, want)
+	}
+	if signedHeaders != "host;my-header1;x-amz-date" {
+		t.Errorf("awsCanonicalRequest() signed headers = %q", signedHeaders)
+	}
+}
+
+func TestSignAWSV4ReplacesSignature(t *testing.T) {
+	host, _ := url.Parse("https://example.amazonaws.com/")
+	rc := &RequestConfig{Host: host}
+	creds := awsTestCredentials
+	creds.SessionToken = "token"
+	for i := 0; i < 2; i++ {
+		if err := rc.SignAWSV4(creds, time.Date(2015, 8, 30, 12, 36, i, 0, time.UTC)); err != nil {
+			t.Fatalf("SignAWSV4() error = %v", err)
+		}
+	}
+	if len(rc.Headers) != 3 || rc.Headers[0] != "X-Amz-Date: 20150830T123601Z" || rc.Headers[1] != "X-Amz-Security-Token: token" {
+		t.Errorf("SignAWSV4() twice headers = %q, want a single signature", rc.Headers)
+	}
+	if !strings.Contains(rc.Headers[2], "SignedHeaders=host;x-amz-date;x-amz-security-token,") {
+		t.Errorf("SignAWSV4() Authorization = %q, want the session token signed", rc.Headers[2])
+	}
+}
+
+func TestSignAWSV4Errors(t *testing.T) {
+	host, _ := url.Parse("https://example.amazonaws.com/")
+	incomplete := awsTestCredentials
+	incomplete.Region = ""
+	tests := map[string]struct {
+		rc    *RequestConfig
+		creds AWSCredentials
+	}{
+		"no host":           {&RequestConfig{}, awsTestCredentials},
+		"missing region":    {&RequestConfig{Host: host}, incomplete},
+		"missing body file": {&RequestConfig{Host: host, Method: "POST", BodyFile: "/nonexistent/body"}, awsTestCredentials},
+	}
+	for name, tt := range tests {
+		if err := tt.rc.SignAWSV4(tt.creds, time.Now()); err == nil {
+			t.Errorf("%s: SignAWSV4() succeeded, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package data
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Schemes of the hosts that target a service listening on a Unix domain socket, written as
+// `http+unix:///var/run/app.sock:/health`: the socket path followed by `:` and the request path.
+const (
+	UnixSocketScheme    = "http+unix"
+	UnixSocketTLSScheme = "https+unix"
+)
+
+// The method UnixSocket reports whether the host targets a Unix domain socket and, if so, splits
+// it into the path of the socket and the URL to request through it. The returned URL uses the
+// http (or https) scheme with `localhost` as host, and keeps the request path and query.
+//
+// Returns:
+//   - The path of the Unix domain socket.
+//   - The URL to request once connected to the socket.
+//   - A boolean that is false when the host is not a Unix socket target.
+//   - An error if the host uses a socket scheme without a socket path.
+func (rc *RequestConfig) UnixSocket() (string, *url.URL, bool, error) {
+	if rc.Host == nil {
+		return "", nil, false, nil
+	}
+	scheme := strings.ToLower(rc.Host.Scheme)
+	if scheme != UnixSocketScheme && scheme != UnixSocketTLSScheme {
+		return "", nil, false, nil
+	}
+	socket, path, _ := strings.Cut(rc.Host.Path, ":")
+	if socket == "" {
+		return "", nil, true, errors.Errorf("Missing socket path in host %s", rc.Host.Redacted())
+	}
+	if path == "" {
+		path = "/"
+	}
+	target := &url.URL{
+		Scheme:   strings.TrimSuffix(scheme, "+unix"),
+		Host:     "localhost",
+		Path:     path,
+		RawQuery: rc.Host.RawQuery,
+		Fragment: rc.Host.Fragment,
+	}
+	return socket, target, true, nil
+}
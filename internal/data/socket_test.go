@@ -0,0 +1,47 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnixSocket(t *testing.T) {
+	tests := map[string]struct {
+		host, socket, target string
+		isSocket             bool
+	}{
+		"socket":       {"http+unix:///var/run/app.sock:/health?full=1", "/var/run/app.sock", "http://localhost/health?full=1", true},
+		"no path":      {"http+unix:///var/run/app.sock", "/var/run/app.sock", "http://localhost/", true},
+		"tls":          {"https+unix:///run/api.sock:/v1", "/run/api.sock", "https://localhost/v1", true},
+		"regular host": {"https://example.com/health", "", "", false},
+	}
+	for name, tt := range tests {
+		rc, err := ParseTemplate("", "[Host]\n"+tt.host+"\n", Config{})
+		if err != nil {
+			t.Errorf("%s: ParseTemplate() error = %v", name, err)
+			continue
+		}
+		socket, target, isSocket, err := rc.UnixSocket()
+		if err != nil || isSocket != tt.isSocket || socket != tt.socket {
+			t.Errorf("%s: UnixSocket() = %q, %v, %v, want %q, %v", name, socket, isSocket, err, tt.socket, tt.isSocket)
+			continue
+		}
+		if isSocket && target.String() != tt.target {
+			t.Errorf("%s: UnixSocket() target = %q, want %q", name, target, tt.target)
+		}
+	}
+}
+
+func TestBuildCurlArgsUnixSocket(t *testing.T) {
+	rc, err := ParseTemplate("", "[Host]\nhttp+unix:///var/run/app.sock:/health\n", Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	args, err := BuildCurlArgs(rc, Config{})
+	if err != nil {
+		t.Fatalf("BuildCurlArgs() error = %v", err)
+	}
+	if got := strings.Join(args, " "); !strings.HasSuffix(got, "--unix-socket /var/run/app.sock http://localhost/health") {
+		t.Errorf("BuildCurlArgs() = %q, want the socket and the localhost URL", args)
+	}
+}
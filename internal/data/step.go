@@ -0,0 +1,30 @@
+package data
+
+import (
+	"context"
+	"time"
+)
+
+// The method PauseStep waits for the configured `StepDelay` between two consecutive requests of a
+// batch or a run of templates, so the downstream system is not overwhelmed by back-to-back requests.
+// The wait is interrupted as soon as the given context is done. A zero or negative `StepDelay`
+// returns immediately.
+//
+// Parameters:
+//   - ctx: The context governing the run. Cancelling it aborts a pending delay.
+//
+// Returns:
+//   - The context error if the delay was interrupted, nil otherwise.
+func (c Config) PauseStep(ctx context.Context) error {
+	if c.StepDelay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(c.StepDelay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
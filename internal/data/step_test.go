@@ -0,0 +1,37 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPauseStep(t *testing.T) {
+	cfg := Config{StepDelay: 20 * time.Millisecond}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := cfg.PauseStep(context.Background()); err != nil {
+			t.Fatalf("PauseStep() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 3*cfg.StepDelay {
+		t.Errorf("3 steps took %v, want at least %v", elapsed, 3*cfg.StepDelay)
+	}
+	if err := (Config{}).PauseStep(context.Background()); err != nil {
+		t.Errorf("PauseStep() without a delay error = %v", err)
+	}
+}
+
+func TestPauseStepCancel(t *testing.T) {
+	cfg := Config{StepDelay: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	start := time.Now()
+	if err := cfg.PauseStep(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("PauseStep() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the cancelled pause took %v", elapsed)
+	}
+}
@@ -0,0 +1,75 @@
+package data
+
+import "time"
+
+// Output formats of WriteResult, selected by the OutputFormat of the Config.
+const (
+	// OutputFormatRaw prints the response body as it was received.
+	OutputFormatRaw = "raw"
+
+	// OutputFormatJSON prints a RunSummary of each request as a JSON object on its own line.
+	OutputFormatJSON = "json"
+)
+
+// RunSummary is the machine-readable outcome of a request, printed by the OutputFormatJSON format.
+type RunSummary struct {
+	// Method is the HTTP method of the request.
+	Method string `json:"method"`
+
+	// URL is the requested URL, with its password redacted.
+	URL string `json:"url"`
+
+	// StatusCode is the HTTP status code of the response, or zero when none was received.
+	StatusCode int `json:"status"`
+
+	// Duration is how long the request took.
+	Duration time.Duration `json:"duration"`
+
+	// ExitCode is the exit code of the backend process, always zero for the native backend.
+	ExitCode int `json:"exit_code"`
+
+	// ExpectStatus is the status code the template expects, or zero when it expects none.
+	ExpectStatus int `json:"expect_status,omitempty"`
+
+	// StatusMatched tells whether the status code is the expected one. It is true when no status
+	// is expected.
+	StatusMatched bool `json:"status_matched"`
+
+	// Error is the error that prevented the request from completing, if any.
+	Error string `json:"error,omitempty"`
+
+	// Body is the response body, only included when the Config sets SummaryBody.
+	Body *string `json:"body,omitempty"`
+}
+
+// The function NewRunSummary builds the RunSummary of an executed request.
+//
+// Parameters:
+//   - rc: The executed request configuration.
+//   - result: The result of the request, whose Duration is reported.
+//   - err: The error returned by the execution, if any.
+//   - includeBody: Whether the response body is included in the summary.
+//
+// Returns:
+//   - The RunSummary of the request.
+func NewRunSummary(rc *RequestConfig, result RequestResult, err error, includeBody bool) RunSummary {
+	summary := RunSummary{
+		Method:        rc.Method,
+		StatusCode:    result.StatusCode,
+		Duration:      result.Duration,
+		ExitCode:      result.ExitCode,
+		ExpectStatus:  rc.ExpectStatus,
+		StatusMatched: rc.ExpectStatus == 0 || rc.ExpectStatus == result.StatusCode,
+	}
+	if rc.Host != nil {
+		summary.URL = rc.Host.Redacted()
+	}
+	if err != nil {
+		summary.Error = err.Error()
+	}
+	if includeBody {
+		body := result.Stdout
+		summary.Body = &body
+	}
+	return summary
+}
@@ -4,7 +4,11 @@
 package data
 
 import (
+	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // The constants `UnsetTimeout` represents the value used to indicate that no timeout is set for a request.
@@ -20,11 +24,177 @@ type Config struct {
 	// If set to UnsetTimeout (-1), the request will have no timeout and could potentially run indefinitely.
 	Timeout int32
 
+	// ConnectTimeout limits the time spent establishing the connection, including name resolution,
+	// separately from the Timeout of the whole request. If zero, the Timeout is used. The httpie
+	// backend has no separate connect timeout and only honors the Timeout.
+	ConnectTimeout time.Duration
+
+	// Backend, if set, is the backend every request is executed with, as chosen explicitly, for
+	// example with a command line flag. It takes precedence over the VORTEX_BACKEND environment
+	// variable and the [Backend] section of the templates.
+	Backend string
+
 	// QueryDelim is a pointer to a string that specifies the delimiter used to separate
-	// multiple query parameters in a request. If nil, a default delimiter (e.g., "&") may be used.
+	// multiple query parameters in a request. If nil, DefaultQueryDelim ("&") is used.
 	QueryDelim *string
+
+	// Interpolation is a pointer to the syntax used to recognize variable references while
+	// expanding templates. If nil, the default `${VAR}` syntax is used.
+	Interpolation *InterpolationSyntax
+
+	// StepDelay is the pause inserted between consecutive requests of a batch run by ExecuteBatch,
+	// and of the templates sent one after the other. It acts as a simple rate control for
+	// downstream systems. Zero means no pause.
+	StepDelay time.Duration
+
+	// Strict, if true, rejects templates declaring unknown sections or repeating a section that
+	// cannot be merged. By default they are only reported as warnings through the Logger.
+	Strict bool
+
+	// KeepBodyComments, if true, keeps the comment lines starting a [Body] section of a template
+	// as part of the body. By default they are dropped, so a commented out body is not sent.
+	KeepBodyComments bool
+
+	// IncludePaths lists additional directories searched, in order, when an include directive
+	// cannot be resolved relative to the including template.
+	IncludePaths []string
+
+	// RemoteEnvironment, if true, expands the variable references of remote templates, see
+	// IsRemoteTemplate, from the environment like those of local templates. By default they are
+	// expanded as if no variable were set, so a template downloaded from any host cannot send the
+	// secrets of the environment away.
+	RemoteEnvironment bool
+
+	// SensitiveHeaders lists the header names whose values are masked whenever a command or a
+	// request is printed, for example in verbose output. If nil, DefaultSensitiveHeaders is used.
+	SensitiveHeaders []string
+
+	// StreamBodyThreshold is the size, in bytes, above which the native backend streams a body
+	// file with the chunked transfer encoding instead of announcing its length. If zero,
+	// DefaultStreamBodyThreshold is used, and a negative value disables chunked streaming.
+	StreamBodyThreshold int64
+
+	// CacheTTL is how long the responses of cacheable requests are cached on disk and reused by
+	// Execute. Zero disables the cache. Expired responses are only revalidated with a conditional
+	// request when they were received by the native backend, the only one capturing the ETag and
+	// Last-Modified headers; the responses of the other backends are fetched again.
+	CacheTTL time.Duration
+
+	// CacheDir is the directory holding the cached responses. If empty, a `vortex` directory in
+	// the user cache directory is used.
+	CacheDir string
+
+	// CacheMethods lists the HTTP methods whose responses can be cached. If nil, only GET and HEAD
+	// responses are cached.
+	CacheMethods []string
+
+	// DestructiveMethods lists the HTTP methods whose requests must be confirmed when they set
+	// Confirm. If nil, DELETE, PUT and PATCH requests are confirmed.
+	DestructiveMethods []string
+
+	// AssumeYes, if true, confirms the destructive requests without prompting, like a `--yes`
+	// flag. It is required to send them in non-interactive mode.
+	AssumeYes bool
+
+	// NoHistory, if true, does not record the executed requests in the history file read by
+	// LoadHistory, where they are otherwise recorded with their secrets redacted as described by
+	// NewHistoryEntry.
+	NoHistory bool
+
+	// KeepLastResponse, if true, records the result of every request that got a response as the
+	// last response, read by LoadLastResponse, with the values of the sensitive headers redacted.
+	// It is off by default, since the full response body is written under the user config
+	// directory.
+	KeepLastResponse bool
+
+	// OutputFormat selects how WriteResult prints the outcome of a request: OutputFormatRaw, the
+	// default when empty, prints the response body, while OutputFormatJSON prints a RunSummary.
+	OutputFormat string
+
+	// SummaryBody, if true, includes the response body in the RunSummary printed by the
+	// OutputFormatJSON format.
+	SummaryBody bool
+
+	// Clock returns the current time, used to expire cached responses. If nil, time.Now is used.
+	Clock func() time.Time
+
+	// Logger receives the verbose messages emitted while executing requests. If nil, messages are
+	// written as text lines to stderr.
+	Logger Logger
+
+	// Progress, if set, is called while the native backend downloads a response to an output
+	// file. If nil, a progress bar is drawn on stderr when the request is verbose and stderr is a
+	// terminal.
+	Progress ProgressFunc
+
+	// noEnvironment, set by ExpansionConfig for the requests of remote templates, expands every
+	// variable reference as if the variable were not set.
+	noEnvironment bool
+}
+
+// The method Now returns the current time according to the Clock of the configuration, or
+// time.Now when it has none.
+func (c Config) Now() time.Time {
+	if c.Clock == nil {
+		return time.Now()
+	}
+	return c.Clock()
+}
+
+// NativeBackend is the name of the backend that performs requests with the Go HTTP client instead
+// of an external tool, so it is always available.
+const NativeBackend = "native"
+
+// DefaultStreamBodyThreshold is the size, in bytes, above which the native backend streams a body
+// file with the chunked transfer encoding when the configuration sets no threshold.
+const DefaultStreamBodyThreshold = 32 << 20
+
+// The method StreamThreshold returns the size, in bytes, above which the native backend streams a
+// body file with the chunked transfer encoding: the StreamBodyThreshold when it is set, otherwise
+// DefaultStreamBodyThreshold. A negative value means bodies are never streamed in chunks.
+func (c Config) StreamThreshold() int64 {
+	if c.StreamBodyThreshold == 0 {
+		return DefaultStreamBodyThreshold
+	}
+	return c.StreamBodyThreshold
 }
 
+// The method EffectiveConnectTimeout returns the time allowed to establish a connection: the
+// ConnectTimeout when it is set, otherwise the total Timeout, or zero when neither is set.
+func (c Config) EffectiveConnectTimeout() time.Duration {
+	if c.ConnectTimeout > 0 {
+		return c.ConnectTimeout
+	}
+	if c.Timeout != UnsetTimeout && c.Timeout > 0 {
+		return time.Duration(c.Timeout) * time.Second
+	}
+	return 0
+}
+
+// DefaultDestructiveMethods lists the HTTP methods whose requests are confirmed when the
+// configuration does not list any.
+var DefaultDestructiveMethods = []string{http.MethodDelete, http.MethodPut, http.MethodPatch}
+
+// The method IsDestructive reports whether the requests with the given method must be confirmed
+// when they set Confirm: whether it is one of the DestructiveMethods, or of
+// DefaultDestructiveMethods when the field is nil. Methods are compared case-insensitively.
+func (c Config) IsDestructive(method string) bool {
+	methods := c.DestructiveMethods
+	if methods == nil {
+		methods = DefaultDestructiveMethods
+	}
+	for _, candidate := range methods {
+		if strings.EqualFold(candidate, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProgressFunc reports the progress of a download: the number of bytes written so far and the
+// total number of bytes expected, which is -1 when the response does not declare its length.
+type ProgressFunc func(written, total int64)
+
 // The function `NewConfig` creates and returns a new `Config` instance with default settings.
 // By default, the `Timeout` field is set to `UnsetTimeout`, indicating that no timeout is configured.
 // This function provides a convenient way to initialize a `Config` struct with default values,
@@ -46,6 +216,23 @@ type RequestConfig struct {
 	// This can be used for sending data in a POST, PUT, or similar HTTP request.
 	Body []string
 
+	// BodyFile, if set, is the path of a file whose contents are sent as the request body instead
+	// of the Body lines, without copying it through a temporary file. Its bytes are sent as they
+	// are, without joining lines or normalizing newlines, so it can hold binary data.
+	BodyFile string
+
+	// BodyFromStdin, if true, means the body is typed on stdin when the request is executed, as
+	// declared by a [Body] made of a single `-` line. It is only read in interactive mode.
+	BodyFromStdin bool
+
+	// PathParams holds the values substituted for the `{name}` placeholders of the host path by
+	// SubstitutePathParams, as declared in the [Params] section of a template.
+	PathParams map[string]string
+
+	// StrictPathParams, if true, makes SubstitutePathParams fail when a placeholder of the host path
+	// has no matching parameter instead of leaving it in the path.
+	StrictPathParams bool
+
 	// Method specifies the HTTP method to be used for the request, such as "GET", "POST", "PUT", etc.
 	// It determines the action to be performed on the resource identified by the Host.
 	Method string
@@ -54,6 +241,14 @@ type RequestConfig struct {
 	// These headers can be used to provide additional information such as content type or authorization tokens.
 	Headers []string
 
+	// NoDefaultHeaders, if true, opts the request out of the default headers applied by
+	// ApplyDefaultHeaders, as set by the `@no-default-headers` template directive.
+	NoDefaultHeaders bool
+
+	// Confirm, if true, asks for a confirmation before sending the request when its method is one
+	// of the DestructiveMethods of the Config, as set by the `@confirm` template directive.
+	Confirm bool
+
 	// Backend specifies the name or type of the backend service being used.
 	// This could refer to a specific service or API that is being called.
 	Backend string
@@ -62,17 +257,155 @@ type RequestConfig struct {
 	// These options are represented as a slice of slices, where each inner slice contains related options.
 	BackendOptions [][]string
 
+	// InsecureSkipVerify, if true, disables the verification of the server TLS certificate.
+	// This should only be used against development servers with self-signed certificates.
+	InsecureSkipVerify bool
+
+	// ClientCert is the path of the PEM encoded client certificate presented to servers that
+	// require mutual TLS authentication. It is used together with ClientKey.
+	ClientCert string
+
+	// ClientKey is the path of the PEM encoded private key matching ClientCert.
+	ClientKey string
+
+	// CACert is the path of a PEM encoded bundle of CA certificates trusted to verify the server
+	// certificate, used to trust a private CA without disabling verification.
+	CACert string
+
+	// FollowRedirects, if true, makes the backend follow the redirects returned by the server and
+	// report the response of the final location. Redirect responses are returned as they are
+	// otherwise.
+	FollowRedirects bool
+
+	// HTTPVersion, if set, forces the HTTP version of the request: HTTPVersion11, HTTPVersion2 or
+	// HTTPVersion3. curl supports them all with its `--http1.1`, `--http2` and `--http3` options,
+	// while the native backend supports HTTP/1.1 and HTTP/2 over TLS, and httpie and wget only
+	// HTTP/1.1. An unsupported version is an error rather than being silently ignored.
+	HTTPVersion string
+
+	// FailOnHTTPError, if true, makes Execute return an HTTPStatusError when the response has a
+	// 4xx or 5xx status code, like the curl `--fail` option, even though the backend succeeded.
+	// The response is still captured into the RequestResult.
+	FailOnHTTPError bool
+
 	// Verbose, if true, will output the command used to perform the request.
 	// This can be useful for debugging or logging the exact request being made.
 	Verbose bool
 
+	// Quiet, if true, silences the progress output of the backends: curl commands printed with
+	// ToCurlString get `--silent --show-error`, wget runs with `--quiet` unless it follows
+	// redirects, and the native backend draws no progress bar. Executed curl commands are always
+	// silent, and httpie prints no progress without downloads. Verbose wins when both are set.
+	Quiet bool
+
 	// Tempfile, if true, prevents the deletion of any temporary files generated during the request.
 	// This can be useful if the temporary file needs to be inspected or reused.
 	Tempfile bool
 
+	// AcceptCompression, if true, asks the server for a compressed response with an
+	// `Accept-Encoding: gzip, deflate` header and transparently decompresses it.
+	AcceptCompression bool
+
+	// Timeout, if positive, is the maximum duration, in seconds, of this request. It overrides the
+	// Timeout of the Config, for example to give a slow endpoint more time.
+	Timeout int32
+
+	// ForceBody, if true, sends an empty body with a `Content-Length: 0` header when Body and
+	// BodyFile are empty, for servers telling an empty body apart from a missing one. By default
+	// such requests are sent without a body.
+	ForceBody bool
+
+	// CompressBody, if true, gzips the request body before sending it and adds a
+	// `Content-Encoding: gzip` header.
+	CompressBody bool
+
+	// MaxResponseBytes limits the number of bytes of the response captured into the
+	// RequestResult. The excess is discarded and the result is flagged as truncated.
+	// Zero means no limit.
+	MaxResponseBytes int64
+
+	// MaxDownloadBytes, if positive, is the size above which the download of the response body is
+	// aborted, to avoid filling the disk. It maps to the curl `--max-filesize` option, while the
+	// native backend aborts before the transfer when the declared Content-Length exceeds it, and
+	// during the transfer otherwise. The httpie and wget backends reject it, since they cannot stop
+	// a single response. Zero means no limit.
+	MaxDownloadBytes int64
+
+	// OutputFile, if set, is the path of the file the response body is written to instead of
+	// being captured into the RequestResult. It is supported by the curl, wget and native
+	// backends.
+	OutputFile string
+
+	// HeaderFile, if set, is the path of the file the status line and headers of the response are
+	// written to, as with the curl `--dump-header` option. It is supported by the curl and native
+	// backends.
+	HeaderFile string
+
+	// Stream, if true, makes the native backend pass the response body to OnStreamLine as it is
+	// received instead of capturing it into the RequestResult, for Server-Sent Events and
+	// newline-delimited JSON streams. The request then lasts until the server closes the response
+	// or the context is cancelled. Other backends reject streamed requests.
+	Stream bool
+
+	// OnStreamLine receives the body of a streamed response, one line at a time, or one event at a
+	// time for a `text/event-stream` response, an event being its lines up to the blank line ending
+	// it, joined with newlines. Returning an error stops the request and is returned by Execute.
+	OnStreamLine func(string) error
+
+	// Retry, if set, sends the request again when it fails, as described by the RetryConfig.
+	Retry *RetryConfig
+
+	// PostProcess, if set, is a command line, tokenized with TokenizeLine, run by Execute once the
+	// response is received, such as `jq .items`. The response body is written to its stdin and
+	// its output replaces the Stdout of the RequestResult.
+	PostProcess string
+
+	// WriteOut, if set, is a format printed after the response body by WriteResult, expanded with
+	// FormatWriteOut, like the curl `-w` option.
+	WriteOut string
+
+	// TraceFile, if set, is the path of the file a trace of the request is written to once it
+	// completes: its status code, final URL, remote IP and Timings.
+	TraceFile string
+
+	// Resolve holds `host:port:address` overrides connecting to the address instead of resolving
+	// the host on that port, like the curl `--resolve` option, for example to test a service
+	// before its DNS records are updated.
+	Resolve []string
+
+	// Auth, if set, describes how the request authenticates, as applied by ApplyAuth.
+	Auth *AuthConfig
+
+	// ExpectSchema, if set, is the path of a JSON Schema the response body must match, as checked
+	// by CheckExpectations.
+	ExpectSchema string
+
+	// ExpectStatus, if not zero, is the HTTP status code the response must have, as checked by
+	// CheckExpectations.
+	ExpectStatus int
+
+	// ExpectBody, if set, is a string the response body must contain, as checked by
+	// CheckExpectations.
+	ExpectBody string
+
+	// ExpectBodyRegex, if set, is a regular expression the response body must match, as checked
+	// by CheckExpectations.
+	ExpectBodyRegex string
+
 	// TempfileName specifies the name of the temporary file that will be used during the request.
 	// If a temporary file is required, this name will be used, and the file will be created and managed accordingly.
 	TempfileName string
+
+	// interpolated records that Interpolate already expanded the headers and body.
+	interpolated bool
+
+	// remote records that the request was parsed from a remote template, whose variable references
+	// are not expanded from the environment unless the configuration sets RemoteEnvironment.
+	remote bool
+
+	// queryDelim records the query delimiter of the configuration the request was parsed with, as
+	// returned by Config.Delimiter, so MergeParent merges the queries with it.
+	queryDelim string
 }
 
 // The type TimeoutContextValueKey is an empty struct used as a key for storing and retrieving
@@ -97,4 +430,73 @@ type RequestResult struct {
 	// ExitCode represents the exit status code of the executed command or process.
 	// A value of 0 typically indicates success, while non-zero values indicate errors.
 	ExitCode int
+
+	// Attempts is the number of times the request was sent, more than one when it was retried as
+	// described by the Retry configuration of the RequestConfig.
+	Attempts int
+
+	// PostProcessExitCode is the exit status code of the PostProcess command of the RequestConfig,
+	// or 0 when it succeeded or none is set.
+	PostProcessExitCode int
+
+	// StatusCode is the HTTP status code of the response, or 0 if it could not be determined.
+	StatusCode int
+
+	// Headers holds the response headers. They are always captured by the native backend, while
+	// the external backends only capture them for HEAD requests. It is nil when not captured.
+	Headers http.Header
+
+	// FinalURL is the URL of the response, which is the redirect target when redirects were
+	// followed and the original host otherwise.
+	FinalURL string
+
+	// RemoteIP is the IP address the request was actually sent to after name resolution.
+	// It is empty when the backend cannot report it.
+	RemoteIP string
+
+	// Truncated is true when the response was larger than the RequestConfig MaxResponseBytes
+	// and Stdout only holds its beginning.
+	Truncated bool
+
+	// StreamedEvents is the number of lines or events passed to the OnStreamLine callback of a
+	// streamed request, whose Stdout stays empty.
+	StreamedEvents int
+
+	// FromCache is true when the result was read from the response cache instead of performing
+	// the request.
+	FromCache bool
+
+	// Duration is how long Execute took to obtain the result.
+	Duration time.Duration
+
+	// Timings breaks down how long the phases of the request took. It is filled by the curl and
+	// native backends, and left zero by the others.
+	Timings Timings
+}
+
+// Timings holds the duration of the phases of a request, as reported by the backend. A phase that
+// did not happen, such as the name resolution of an IP address or the TLS handshake of a plain
+// HTTP request, is zero.
+type Timings struct {
+	// DNS is the time spent resolving the host name.
+	DNS time.Duration
+
+	// Connect is the time spent establishing the TCP connection, after the name resolution.
+	Connect time.Duration
+
+	// TLS is the time spent on the TLS handshake, after the connection was established.
+	TLS time.Duration
+
+	// TTFB is the time from the start of the request until the first byte of the response was
+	// received.
+	TTFB time.Duration
+
+	// Total is the time the whole request took, including the transfer of the response body.
+	Total time.Duration
+}
+
+// formatSeconds renders a duration as a decimal number of seconds, as expected by the timeout
+// options of the backends.
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
 }
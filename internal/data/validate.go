@@ -0,0 +1,102 @@
+package data
+
+import (
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// requiredAuthParams lists, for each authentication type, the [Auth] settings it cannot do
+// without. The settings with an environment fallback, such as the AWS credentials, are not listed.
+var requiredAuthParams = map[string][]string{
+	AuthBasic:  {"user"},
+	AuthBearer: {"token"},
+	AuthAWSV4:  {"service"},
+	AuthHMAC:   {"key", "header"},
+	AuthOAuth2: {"token_url", "client_id"},
+}
+
+// The function ValidateTemplate checks a template file without sending its request, for example
+// to lint templates in continuous integration. The template is parsed in strict mode with
+// ParseTemplate, which reports the first syntax error, such as an unknown section or a malformed
+// header. The parsed request is then checked as a whole: its host with NormalizeHost, its method
+// with ValidateMethod, its headers with ValidateHeaders, except those holding a variable
+// reference, its query string, and its [Auth] type and required settings. Nothing is executed, no
+// variable of the headers and body is interpolated and no tempfile is created.
+//
+// Parameters:
+//   - path: The path of the template file.
+//
+// Returns:
+//   - An error if the template cannot be read or parsed, or listing every problem found in the
+//     parsed request, or nil when the template is valid.
+func ValidateTemplate(path string) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to read the template: %s", path)
+	}
+	rc, err := ParseTemplate(path, TrimBOM(string(contents)), Config{Strict: true})
+	if err != nil {
+		return err
+	}
+	var problems []string
+	check := func(err error) {
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	check(rc.NormalizeHost())
+	check(rc.ValidateMethod())
+	openDelim, _ := Config{}.InterpolationDelims()
+	check(validateHeaderLines(rc.Headers, openDelim))
+	if rc.Host != nil {
+		if _, err := url.ParseQuery(rc.Host.RawQuery); err != nil {
+			check(errors.Wrap(err, "Invalid query string"))
+		}
+	}
+	check(rc.Auth.validate())
+	if len(problems) > 0 {
+		return errors.Errorf("%d problems found in %s:\n  - %s", len(problems), path, strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// The method ValidateMethod checks that the method of the RequestConfig, when set, is a valid HTTP
+// method token, such as `GET` or a custom `PURGE`, so a typo such as `GE T` is reported before the
+// request is sent. An empty method stands for GET.
+//
+// Returns:
+//   - An error naming the invalid method, or nil.
+func (rc *RequestConfig) ValidateMethod() error {
+	if rc.Method == "" || isHeaderToken(rc.Method) {
+		return nil
+	}
+	return errors.Errorf("Invalid HTTP method: %q", rc.Method)
+}
+
+// validate checks that the authentication type is supported and that its required settings are
+// set, without expanding them. A nil AuthConfig is valid.
+func (ac *AuthConfig) validate() error {
+	if ac == nil {
+		return nil
+	}
+	if ac.Type == "" {
+		return errors.New("Missing authentication type in the [Auth] section")
+	}
+	required, ok := requiredAuthParams[ac.Type]
+	if !ok {
+		return errors.Errorf("Unsupported authentication type: %q", ac.Type)
+	}
+	var missing []string
+	for _, name := range required {
+		if ac.Params[name] == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("Missing %s for %s authentication", strings.Join(missing, " and "), ac.Type)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package data
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTemplate writes the contents to a template file of a temporary directory and returns its
+// path.
+func writeTemplate(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "request.ini")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateTemplate(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	clean := "\ufeff[Host]\nhttps://example.com/users?page=2\n[Method]\nPOST\n[Headers]\nContent-Type: application/json\n" +
+		"[Auth]\ntype = bearer\ntoken = ${VORTEX_TEST_UNSET_TOKEN}\n[Body]\n{\"name\": \"vortex\"}\n"
+	if err := ValidateTemplate(writeTemplate(t, clean)); err != nil {
+		t.Errorf("ValidateTemplate() of a clean template error = %v", err)
+	}
+	if entries, err := os.ReadDir(tmp); err != nil || len(entries) > 0 {
+		t.Errorf("ValidateTemplate() left %d temporary files, %v", len(entries), err)
+	}
+
+	broken := "[Host]\nexample.com/users?q=%zz\n[Method]\nGE T\n[Headers]\nX-Good: 2\n[Auth]\ntype = hmac\nkey = secret\n"
+	err := ValidateTemplate(writeTemplate(t, broken))
+	if err == nil {
+		t.Fatal("ValidateTemplate() of a broken template succeeded, want the problems")
+	}
+	for _, want := range []string{
+		"3 problems found in ",
+		`Invalid HTTP method: "GE T"`,
+		"Invalid query string",
+		"Missing header for hmac authentication",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("ValidateTemplate() error = %v, want it to report %q", err, want)
+		}
+	}
+
+	// Syntax errors are reported by the strict parsing alone
+	for _, tmpl := range []string{"[Host]\nhttps://example.com\n[Cookies]\nid=1\n", "[Host]\nhttps://example.com\n[Headers]\nX Bad: 1\n"} {
+		var parseErr *ParseError
+		if err := ValidateTemplate(writeTemplate(t, tmpl)); !errors.As(err, &parseErr) {
+			t.Errorf("ValidateTemplate(%q) error = %v, want a ParseError", tmpl, err)
+		}
+	}
+	if err := ValidateTemplate(filepath.Join(t.TempDir(), "missing.ini")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ValidateTemplate() of a missing file error = %v, want os.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,119 @@
+package data
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// wgetStatusLine matches the status lines printed by wget with `--server-response`.
+var wgetStatusLine = regexp.MustCompile(`(?m)^\s*HTTP/[0-9.]+ ([0-9]{3})`)
+
+// The function BuildWgetArgs builds the command line arguments, without the program name, used to
+// perform the request described by the RequestConfig with wget. The response body is written to
+// stdout, or to the OutputFile when one is set, and the server response headers are printed to
+// stderr so the status code can be recovered with ParseWgetStatus.
+//
+// Parameters:
+//   - rc: The request configuration to translate into wget arguments.
+//   - cfg: The configuration providing the request and connect timeouts.
+//
+// Returns:
+//   - A slice of strings containing the wget arguments.
+//   - An error if the request configuration has no host or uses an unsupported feature.
+func BuildWgetArgs(rc *RequestConfig, cfg Config) ([]string, error) {
+	if rc.Host == nil {
+		return nil, errors.New("Cannot build wget arguments without a host")
+	}
+	if _, _, isSocket, _ := rc.UnixSocket(); isSocket {
+		return nil, errors.New("The wget backend does not support Unix socket hosts")
+	}
+	if rc.MaxDownloadBytes > 0 {
+		// wget ignores --quota for a single download, so the limit could not be enforced
+		return nil, errors.New("The wget backend does not support a maximum download size, use curl or native")
+	}
+	if len(rc.Resolve) > 0 {
+		return nil, errors.New("The wget backend does not support host overrides, use curl or native")
+	}
+	verbosity := "--no-verbose"
+	if rc.QuietOutput() && !rc.FollowRedirects {
+		// The quiet mode drops the summary lines ParseWgetFinalURL reads after redirects, while the
+		// server response is still printed
+		verbosity = "--quiet"
+	}
+	if rc.HeaderFile != "" {
+		return nil, errors.New("The wget backend does not support header files, use curl or native")
+	}
+	if err := rc.CheckHTTPVersion("wget", HTTPVersion11); err != nil {
+		return nil, err
+	}
+	output := "-"
+	if rc.OutputFile != "" {
+		output = rc.OutputFile
+	}
+	args := []string{verbosity, "--server-response", "--content-on-error", "--output-document=" + output}
+	if rc.Method != "" {
+		args = append(args, "--method="+rc.Method)
+	}
+	for _, header := range rc.Headers {
+		args = append(args, "--header="+header)
+	}
+	if bodyPath := rc.BodyPath(); bodyPath != "" {
+		args = append(args, "--body-file="+bodyPath)
+	}
+	if !rc.FollowRedirects {
+		// wget follows redirects unless told otherwise
+		args = append(args, "--max-redirect=0")
+	}
+	if rc.InsecureSkipVerify {
+		args = append(args, "--no-check-certificate")
+	}
+	if rc.ClientCert != "" {
+		args = append(args, "--certificate="+rc.ClientCert)
+	}
+	if rc.ClientKey != "" {
+		args = append(args, "--private-key="+rc.ClientKey)
+	}
+	if rc.CACert != "" {
+		args = append(args, "--ca-certificate="+rc.CACert)
+	}
+	if rc.AcceptCompression {
+		args = append(args, "--compression=auto")
+	}
+	if cfg.Timeout != UnsetTimeout && cfg.Timeout > 0 {
+		args = append(args, "--timeout="+strconv.Itoa(int(cfg.Timeout)))
+	}
+	if connectTimeout := cfg.EffectiveConnectTimeout(); connectTimeout > 0 {
+		// Placed after --timeout, which also sets the connect timeout
+		args = append(args, "--connect-timeout="+formatSeconds(connectTimeout))
+	}
+	for _, options := range rc.BackendOptions {
+		args = append(args, options...)
+	}
+	return append(args, rc.Host.String()), nil
+}
+
+// The function ParseWgetStatus returns the status code of the last response reported by wget on
+// stderr, which is the final one when redirects were followed, or 0 if none was found.
+func ParseWgetStatus(stderr string) int {
+	matches := wgetStatusLine.FindAllStringSubmatch(stderr, -1)
+	if len(matches) == 0 {
+		return 0
+	}
+	code, _ := strconv.Atoi(matches[len(matches)-1][1])
+	return code
+}
+
+// wgetURLLine matches the `URL:` field of the summary line wget prints for each downloaded URL.
+var wgetURLLine = regexp.MustCompile(`URL: ?(\S+)`)
+
+// The function ParseWgetFinalURL returns the URL of the last download reported by wget on stderr,
+// which is the redirect target when redirects were followed, or an empty string if none was found.
+func ParseWgetFinalURL(stderr string) string {
+	matches := wgetURLLine.FindAllStringSubmatch(stderr, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[len(matches)-1][1]
+}
@@ -0,0 +1,50 @@
+package data
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBuildWgetArgsRejectsMaxDownloadBytes(t *testing.T) {
+	rc := &RequestConfig{Host: &url.URL{Scheme: "https", Host: "example.com"}, MaxDownloadBytes: 1024}
+	_, err := BuildWgetArgs(rc, Config{})
+	if err == nil || !strings.HasSuffix(err.Error(), "use curl or native") {
+		t.Errorf("BuildWgetArgs() error = %v, want the limit rejected", err)
+	}
+	rc.MaxDownloadBytes = 0
+	args, err := BuildWgetArgs(rc, Config{})
+	if err != nil {
+		t.Fatalf("BuildWgetArgs() error = %v", err)
+	}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--quota") {
+			t.Errorf("BuildWgetArgs() = %q, want no --quota", args)
+		}
+	}
+}
+
+func TestBuildWgetArgsCompression(t *testing.T) {
+	rc := &RequestConfig{Host: &url.URL{Scheme: "https", Host: "example.com"}, AcceptCompression: true}
+	args, err := BuildWgetArgs(rc, Config{})
+	if err != nil {
+		t.Fatalf("BuildWgetArgs() error = %v", err)
+	}
+	if !slices.Contains(args, "--compression=auto") {
+		t.Errorf("BuildWgetArgs() = %q, want --compression=auto", args)
+	}
+}
+
+func TestParseWgetFinalURL(t *testing.T) {
+	stderr := "HTTP request sent, awaiting response... 302 Found\n" +
+		"Location: https://example.com/new [following]\n" +
+		"2026-10-16 10:00:00 URL:https://example.com/old [0] -> \"-\" [1]\n" +
+		"2026-10-16 10:00:01 URL:https://example.com/new [12/12] -> \"-\" [1]\n"
+	if got, want := ParseWgetFinalURL(stderr), "https://example.com/new"; got != want {
+		t.Errorf("ParseWgetFinalURL() = %q, want %q", got, want)
+	}
+	if got := ParseWgetFinalURL("HTTP request sent, awaiting response... 200 OK\n"); got != "" {
+		t.Errorf("ParseWgetFinalURL() without a summary = %q, want an empty URL", got)
+	}
+}
@@ -0,0 +1,148 @@
+package data
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The method WriteTemplate serializes the RequestConfig into the template format read by
+// ParseTemplate, so requests imported from curl, HAR or OpenAPI can be saved as templates. The
+// template starts with the format version comment and holds the [Host], [Method], [Headers],
+// [Query], [Body], [Backend], [TLS], [Expect], [Auth] and [Resolve] sections the RequestConfig
+// needs, preceded by an `@no-default-headers` line when the RequestConfig sets NoDefaultHeaders
+// and an `@confirm` line when it sets Confirm. The path parameters are written in a [Params]
+// section and a positive Timeout in a [Timeout] section.
+// Body lines that would be read as a section, a directive or a leading comment are escaped with a
+// `\`, a first line starting with `@` is escaped as `@@`, and backend options are quoted when they
+// hold whitespace, quotes or `#`. Outside of the body, a `#` that would start a trailing comment is
+// escaped as `\#`, so parsing the template gives back the RequestConfig. Settings that have no
+// template equivalent, such as the verbose flag, are not written, and the blank lines surrounding
+// the body are lost.
+//
+// Parameters:
+//   - w: The writer receiving the template.
+//   - cfg: The configuration providing the query delimiter the template is parsed with.
+//
+// Returns:
+//   - An error if the RequestConfig has no host or the template cannot be written.
+func (rc *RequestConfig) WriteTemplate(w io.Writer, cfg Config) error {
+	if rc.Host == nil {
+		return errors.New("Cannot write a template without a host")
+	}
+	bw := bufio.NewWriter(w)
+	section := func(name string, lines ...string) {
+		if len(lines) == 0 {
+			return
+		}
+		bw.WriteString("\n[" + name + "]\n")
+		for _, line := range lines {
+			if name != sectionBody {
+				line = escapeTrailingComment(line)
+			}
+			bw.WriteString(line + "\n")
+		}
+	}
+	bw.WriteString(TemplateVersionHeader() + "\n")
+	if rc.NoDefaultHeaders {
+		bw.WriteString(noDefaultHeadersDirective + "\n")
+	}
+	if rc.Confirm {
+		bw.WriteString(confirmDirective + "\n")
+	}
+	host := *rc.Host
+	host.RawQuery = ""
+	section(sectionHost, host.String())
+	if rc.Method != "" {
+		section(sectionMethod, rc.Method)
+	}
+	section(sectionHeaders, rc.Headers...)
+	if rc.Host.RawQuery != "" {
+		section(sectionQuery, strings.Split(rc.Host.RawQuery, cfg.Delimiter())...)
+	}
+	section(sectionBody, rc.templateBody()...)
+	if rc.Backend != "" {
+		lines := []string{rc.Backend}
+		for _, options := range rc.BackendOptions {
+			lines = append(lines, quoteTokens(options))
+		}
+		section(sectionBackend, lines...)
+	}
+	section(sectionTLS, keyValueLines(map[string]string{"cert": rc.ClientCert, "key": rc.ClientKey, "ca": rc.CACert})...)
+	var expectStatus string
+	if rc.ExpectStatus != 0 {
+		expectStatus = strconv.Itoa(rc.ExpectStatus)
+	}
+	section(sectionExpect, keyValueLines(map[string]string{
+		"status":     expectStatus,
+		"body":       rc.ExpectBody,
+		"body_regex": rc.ExpectBodyRegex,
+		"schema":     rc.ExpectSchema,
+	})...)
+	if rc.Auth != nil {
+		lines := []string{"type = " + rc.Auth.Type}
+		section(sectionAuth, append(lines, keyValueLines(rc.Auth.Params)...)...)
+	}
+	section(sectionResolve, rc.Resolve...)
+	section(sectionParams, keyValueLines(rc.PathParams)...)
+	if rc.Timeout > 0 {
+		section(sectionTimeout, strconv.Itoa(int(rc.Timeout)))
+	}
+	return errors.Wrap(bw.Flush(), "Failed to write the template")
+}
+
+// templateBody returns the lines of the [Body] section of the RequestConfig, escaped as described
+// by WriteTemplate, or the `@path` reference of its BodyFile, or the `-` line of a body read from
+// stdin.
+func (rc *RequestConfig) templateBody() []string {
+	if rc.BodyFile != "" {
+		return []string{bodyFilePrefix + rc.BodyFile}
+	}
+	if rc.BodyFromStdin {
+		return []string{bodyStdinMarker}
+	}
+	lines := make([]string, 0, len(rc.Body))
+	for i, line := range rc.Body {
+		switch {
+		case i == 0 && strings.HasPrefix(line, bodyFilePrefix):
+			line = bodyFilePrefix + line
+		case needsBodyEscape(line, i > 0):
+			indent := len(line) - len(strings.TrimLeft(line, " \t"))
+			line = line[:indent] + bodyEscape + line[indent:]
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// keyValueLines returns the `key = value` lines of the settings whose value is not empty, sorted by
+// key.
+func keyValueLines(settings map[string]string) []string {
+	var lines []string
+	for key, value := range settings {
+		if value != "" {
+			lines = append(lines, key+" = "+value)
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// quoteTokens joins the tokens into a line that TokenizeLine splits back into them, quoting the
+// tokens holding whitespace, quotes or a `#` that would start a comment. A backslash only escapes
+// a quote, so it is kept as is.
+func quoteTokens(tokens []string) string {
+	quoted := make([]string, len(tokens))
+	for i, token := range tokens {
+		if token != "" && !strings.ContainsAny(token, " \t\"'`#") {
+			quoted[i] = token
+			continue
+		}
+		quoted[i] = `"` + strings.ReplaceAll(token, `"`, `\"`) + `"`
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,103 @@
+package data
+
+import (
+	"bytes"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteTemplateRoundTrip(t *testing.T) {
+	delim := ";"
+	cfg := Config{QueryDelim: &delim}
+	tmpl := strings.Join([]string{
+		"@confirm",
+		"[Host]",
+		"https://example.com/users/{id}",
+		"[Method]",
+		"post",
+		"[Headers]",
+		`X-Tag: a \# b`,
+		"X-Color: #fff",
+		"[Query]",
+		"page=2;tag=a b",
+		"[Params]",
+		"id = 42",
+		"[Timeout]",
+		"15",
+		"[Backend]",
+		"curl",
+		`--header "X-Note: c \# d"`,
+		"[Expect]",
+		"status = 201",
+		"[Body]",
+		"@@handle",
+		`\[Host]`,
+		"# not a comment",
+	}, "\n")
+	want, err := ParseTemplate("", tmpl, cfg)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if got := want.Headers[0]; got != "X-Tag: a # b" {
+		t.Fatalf("header = %q, want the escaped # kept", got)
+	}
+	if want.Timeout != 15 || want.PathParams["id"] != "42" {
+		t.Fatalf("Timeout = %d, PathParams = %v, want 15 and id = 42", want.Timeout, want.PathParams)
+	}
+	var out bytes.Buffer
+	if err := want.WriteTemplate(&out, cfg); err != nil {
+		t.Fatalf("WriteTemplate() error = %v", err)
+	}
+	got, err := ParseTemplate("", out.String(), cfg)
+	if err != nil {
+		t.Fatalf("ParseTemplate() of the written template error = %v\n%s", err, out.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v\n%s", got, want, out.String())
+	}
+}
+
+func TestStripTrailingComment(t *testing.T) {
+	tests := map[string]string{
+		"X-A: b # comment": "X-A: b",
+		"X-A: b #":         "X-A: b",
+		"X-A: #fff":        "X-A: #fff",
+		`X-A: a \# b`:      "X-A: a # b",
+		"https://h/#frag":  "https://h/#frag",
+	}
+	for line, want := range tests {
+		if got := stripTrailingComment(line); got != want {
+			t.Errorf("stripTrailingComment(%q) = %q, want %q", line, got, want)
+		}
+		if got := stripTrailingComment(escapeTrailingComment(want)); got != want {
+			t.Errorf("escaped %q reads back as %q", want, got)
+		}
+	}
+}
+
+func TestWriteTemplateEscapesBodyTerminator(t *testing.T) {
+	rc := &RequestConfig{Host: &url.URL{Scheme: "https", Host: "example.com"}, Body: []string{"a", "[/Body]", "[/body]", "b"}}
+	var out bytes.Buffer
+	if err := rc.WriteTemplate(&out, Config{}); err != nil {
+		t.Fatalf("WriteTemplate() error = %v", err)
+	}
+	got, err := ParseTemplate("", out.String(), Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Body, rc.Body) {
+		t.Errorf("Body = %q, want %q\n%s", got.Body, rc.Body, out.String())
+	}
+}
+
+func TestParseTemplateBodyTerminatorIgnoresCase(t *testing.T) {
+	rc, err := ParseTemplate("", "[Host]\nhttps://example.com\n[Body]\n[Host]\n[/BODY]\n[Method]\nPUT\n", Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if want := []string{"[Host]"}; !reflect.DeepEqual(rc.Body, want) || rc.Method != "PUT" {
+		t.Errorf("Body = %q, Method = %q, want %q and PUT", rc.Body, rc.Method, want)
+	}
+}
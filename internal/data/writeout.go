@@ -0,0 +1,92 @@
+package data
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// writeOutEscapes maps the letter following a backslash in a FormatWriteOut format to the
+// character it stands for.
+var writeOutEscapes = map[byte]byte{'n': '\n', 'r': '\r', 't': '\t'}
+
+// The method FormatWriteOut expands a format in the style of the curl `-w` option with the details
+// of the result, so the same format can be used whatever the backend. The supported variables are:
+//   - `%{http_code}`: the status code, as three digits.
+//   - `%{size_download}`: the size of the captured response body, in bytes.
+//   - `%{url_effective}`: the final URL.
+//   - `%{remote_ip}`: the IP address the request was sent to, when the backend reports it.
+//   - `%{time_namelookup}`, `%{time_connect}`, `%{time_starttransfer}` and `%{time_total}`: the
+//     time, in seconds, from the start of the request until the end of the name resolution, of the
+//     connection, until the first response byte and until the end of the transfer, as in curl.
+//
+// As in curl, `%%` stands for a literal `%`, and `\n`, `\r` and `\t` for a newline, a carriage
+// return and a tab. Unknown variables expand to nothing, as in curl.
+//
+// Parameters:
+//   - format: The format to expand. An empty format expands to an empty string.
+//
+// Returns:
+//   - The expanded format.
+func (rr RequestResult) FormatWriteOut(format string) string {
+	if format == "" {
+		return ""
+	}
+	var out strings.Builder
+	for i := 0; i < len(format); i++ {
+		switch c := format[i]; {
+		case c == '%' && strings.HasPrefix(format[i:], "%%"):
+			out.WriteByte('%')
+			i++
+		case c == '%' && strings.HasPrefix(format[i:], "%{"):
+			end := strings.IndexByte(format[i:], '}')
+			if end < 0 {
+				out.WriteString(format[i:])
+				return out.String()
+			}
+			name := format[i+2 : i+end]
+			out.WriteString(rr.writeOutVariable(name))
+			i += end
+		case c == '\\' && i+1 < len(format) && writeOutEscapes[format[i+1]] != 0:
+			out.WriteByte(writeOutEscapes[format[i+1]])
+			i++
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
+// writeOutVariable returns the value of a variable of FormatWriteOut, or an empty string when the
+// variable is unknown.
+func (rr RequestResult) writeOutVariable(name string) string {
+	total := rr.Timings.Total
+	if total == 0 {
+		total = rr.Duration
+	}
+	switch name {
+	case "http_code":
+		return fmt.Sprintf("%03d", rr.StatusCode)
+	case "size_download":
+		return strconv.Itoa(len(rr.Stdout))
+	case "url_effective":
+		return rr.FinalURL
+	case "remote_ip":
+		return rr.RemoteIP
+	case "time_namelookup":
+		return writeOutSeconds(rr.Timings.DNS)
+	case "time_connect":
+		return writeOutSeconds(rr.Timings.DNS + rr.Timings.Connect)
+	case "time_starttransfer":
+		return writeOutSeconds(rr.Timings.TTFB)
+	case "time_total":
+		return writeOutSeconds(total)
+	}
+	return ""
+}
+
+// writeOutSeconds formats a duration as seconds with six decimals, as curl does.
+func writeOutSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 6, 64)
+}
@@ -0,0 +1,63 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatWriteOut(t *testing.T) {
+	result := RequestResult{
+		StatusCode: 204,
+		Stdout:     "héllo",
+		FinalURL:   "https://example.com/users",
+		RemoteIP:   "203.0.113.7",
+		Timings:    Timings{DNS: 2 * time.Millisecond, Connect: 3 * time.Millisecond, TTFB: 10 * time.Millisecond, Total: 1500 * time.Millisecond},
+	}
+	tests := map[string]struct {
+		format, want string
+	}{
+		"empty":        {"", ""},
+		"variables":    {"%{http_code} %{size_download} %{url_effective} %{remote_ip}", "204 6 https://example.com/users 203.0.113.7"},
+		"times":        {"%{time_namelookup} %{time_connect} %{time_starttransfer} %{time_total}", "0.002000 0.005000 0.010000 1.500000"},
+		"escapes":      {`\n%{http_code}\t100%%\r\x`, "\n204\t100%\r\\x"},
+		"unknown":      {"[%{size_upload}]", "[]"},
+		"unterminated": {"code %{http_code", "code %{http_code"},
+	}
+	for name, tt := range tests {
+		if got := result.FormatWriteOut(tt.format); got != tt.want {
+			t.Errorf("%s: FormatWriteOut(%q) = %q, want %q", name, tt.format, got, tt.want)
+		}
+	}
+
+	padded := RequestResult{StatusCode: 0, Duration: 250 * time.Millisecond}
+	if got, want := padded.FormatWriteOut("%{http_code} %{time_total}"), "000 0.250000"; got != want {
+		t.Errorf("FormatWriteOut() without timings = %q, want %q", got, want)
+	}
+}
+
+func TestFormatWriteOutMatchesCurl(t *testing.T) {
+	// The output of curl run with the format below, after the body
+	const format = "%{http_code} %{time_total} %{size_download} %{url_effective}"
+	const curlPrinted = "200 0.123456 2 https://example.com/final"
+	stdout := "ok" + curlWriteOutMarker +
+		"http_code=200\nremote_ip=203.0.113.7\nurl_effective=https://example.com/final\n" +
+		"time_namelookup=0.001000\ntime_connect=0.002000\ntime_appconnect=0.000000\n" +
+		"time_starttransfer=0.100000\ntime_total=0.123456\n"
+	body, meta := ParseCurlWriteOut(stdout)
+	fromCurl := RequestResult{Stdout: body}
+	fromCurl.ApplyCurlWriteOut(meta)
+	if got := fromCurl.FormatWriteOut(format); got != curlPrinted {
+		t.Errorf("FormatWriteOut() of a curl result = %q, want %q as printed by curl", got, curlPrinted)
+	}
+
+	// The same response captured by the native backend
+	native := RequestResult{
+		StatusCode: 200,
+		Stdout:     "ok",
+		FinalURL:   "https://example.com/final",
+		Timings:    Timings{Total: 123456 * time.Microsecond},
+	}
+	if got := native.FormatWriteOut(format); got != curlPrinted {
+		t.Errorf("FormatWriteOut() of a native result = %q, want %q as printed by curl", got, curlPrinted)
+	}
+}
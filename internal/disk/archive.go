@@ -0,0 +1,240 @@
+package disk
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/pkg/errors"
+)
+
+// ArchiveTemplate is a template read from an archive, kept in memory.
+type ArchiveTemplate struct {
+	// Name is the path of the archive joined with the path of the template inside it, as in
+	// `suite.tar.gz/users/get.ini`, which ReadRawTemplateString reads back from the archive.
+	Name string
+
+	// Contents is the raw contents of the template, without its byte order mark.
+	Contents string
+}
+
+// MaxArchiveEntryBytes limits the size of each template read from an archive, and MaxArchiveBytes
+// the size of all of them, so a zip bomb cannot exhaust the memory.
+var (
+	MaxArchiveEntryBytes int64 = 10 << 20
+	MaxArchiveBytes      int64 = 100 << 20
+)
+
+// archiveExtensions lists the extensions of the archives LoadTemplatesFromArchive reads, mapped to
+// whether the archive is a gzipped tarball, a zip archive otherwise.
+var archiveExtensions = map[string]bool{".tar.gz": true, ".tgz": true, ".zip": false}
+
+// The function IsTemplateArchive reports whether a template filename argument names an archive of
+// templates, a `.tar.gz`, `.tgz` or `.zip` file, to be read with LoadTemplatesFromArchive instead of
+// being parsed as a template.
+//
+// Parameters:
+//   - name: The template filename argument.
+//
+// Returns:
+//   - True if the filename has the extension of a supported archive, false otherwise.
+func IsTemplateArchive(name string) bool {
+	_, _, ok := archiveKind(name)
+	return ok
+}
+
+// archiveKind returns the archive extension of the filename and whether it is a gzipped tarball,
+// or false when the filename is not an archive.
+func archiveKind(name string) (string, bool, bool) {
+	lower := strings.ToLower(name)
+	for ext, tarball := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return ext, tarball, true
+		}
+	}
+	return "", false, false
+}
+
+// The function LoadTemplatesFromArchive reads the templates bundled in a `.tar.gz`, `.tgz` or
+// `.zip` archive, without extracting them to disk. The entries with one of the extensions of the
+// discovery options are returned in the order of the archive, or sorted by name when the options
+// sort the templates, while the other entries are ignored. Templates included by a template of
+// the archive, and the files its body is read from, are resolved on disk, not in the archive.
+//
+// Parameters:
+//   - archivePath: The path of the archive.
+//   - opts: The options providing the template extensions and whether the templates are sorted.
+//
+// Returns:
+//   - The templates of the archive.
+//   - An error if the archive cannot be read, if one of its entries is an absolute path or escapes
+//     the archive with `..`, even if it is not a template, or if it holds a template larger than
+//     MaxArchiveEntryBytes or templates larger than MaxArchiveBytes in total.
+func LoadTemplatesFromArchive(archivePath string, opts DiscoveryOptions) ([]ArchiveTemplate, error) {
+	_, tarball, ok := archiveKind(archivePath)
+	if !ok {
+		return nil, errors.Errorf("Unsupported template archive, expected a .tar.gz, .tgz or .zip file: %s", archivePath)
+	}
+	reader := &archiveReader{archivePath: archivePath, extensions: opts.Extensions}
+	var err error
+	if tarball {
+		err = reader.readTar()
+	} else {
+		err = reader.readZip()
+	}
+	if err != nil {
+		return nil, err
+	}
+	templates := reader.templates
+	if opts.Sort {
+		sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	}
+	return templates, nil
+}
+
+// readArchiveTemplate returns the contents of a template named after the archive holding it, as
+// listed by LoadTemplatesFromArchive, or false when no parent of the filename is a template archive.
+// The archive is read again on every call, so nothing is kept between them.
+func readArchiveTemplate(filename string) (string, bool, error) {
+	archivePath, ok := templateArchiveOf(filename)
+	if !ok {
+		return "", false, nil
+	}
+	templates, err := LoadTemplatesFromArchive(archivePath, DiscoveryOptions{Extensions: []string{filepath.Ext(filename)}})
+	if err != nil {
+		return "", true, err
+	}
+	for _, tmpl := range templates {
+		if tmpl.Name == filepath.Clean(filename) {
+			return tmpl.Contents, true, nil
+		}
+	}
+	return "", true, errors.Errorf("No template %s in the template archive: %s", filename, archivePath)
+}
+
+// templateArchiveOf returns the template archive a filename listed by LoadTemplatesFromArchive
+// belongs to, its closest parent that is a regular file named like an archive, or false when none is.
+func templateArchiveOf(filename string) (string, bool) {
+	archivePath := filepath.Dir(filename)
+	for ; !IsTemplateArchive(archivePath); archivePath = filepath.Dir(archivePath) {
+		if parent := filepath.Dir(archivePath); parent == archivePath {
+			return "", false
+		}
+	}
+	if fi, err := os.Stat(archivePath); err != nil || !fi.Mode().IsRegular() {
+		return "", false
+	}
+	return archivePath, true
+}
+
+// archiveReader reads the templates of an archive into memory, collecting them along with the
+// size read so far.
+type archiveReader struct {
+	archivePath string
+	extensions  []string
+	size        int64
+	templates   []ArchiveTemplate
+}
+
+// readTar reads the templates of a gzipped tarball.
+func (r *archiveReader) readTar() error {
+	file, err := os.Open(r.archivePath)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to open the template archive: %s", r.archivePath)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to decompress the template archive: %s", r.archivePath)
+	}
+	defer gz.Close()
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "Failed to read the template archive: %s", r.archivePath)
+		}
+		name, err := archiveEntryName(r.archivePath, header.Name)
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg || !hasTemplateExtension(name, r.extensions) {
+			continue
+		}
+		if err := r.read(name, reader); err != nil {
+			return err
+		}
+	}
+}
+
+// readZip reads the templates of a zip archive.
+func (r *archiveReader) readZip() error {
+	reader, err := zip.OpenReader(r.archivePath)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to open the template archive: %s", r.archivePath)
+	}
+	defer reader.Close()
+	for _, entry := range reader.File {
+		name, err := archiveEntryName(r.archivePath, entry.Name)
+		if err != nil {
+			return err
+		}
+		if !entry.Mode().IsRegular() || !hasTemplateExtension(name, r.extensions) {
+			continue
+		}
+		if err := r.readZipEntry(name, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readZipEntry reads a template of a zip archive.
+func (r *archiveReader) readZipEntry(name string, entry *zip.File) error {
+	rc, err := entry.Open()
+	if err != nil {
+		return errors.Wrapf(err, "Failed to read %s from the template archive: %s", name, r.archivePath)
+	}
+	defer rc.Close()
+	return r.read(name, rc)
+}
+
+// read collects the contents of a template of the archive, reading no more than the size limits
+// allow.
+func (r *archiveReader) read(name string, src io.Reader) error {
+	limit := min(MaxArchiveEntryBytes, MaxArchiveBytes-r.size)
+	contents, err := io.ReadAll(io.LimitReader(src, limit+1))
+	if err != nil {
+		return errors.Wrapf(err, "Failed to read %s from the template archive: %s", name, r.archivePath)
+	}
+	if int64(len(contents)) > limit {
+		return errors.Errorf("Refusing the entry %q of the template archive %s, it exceeds the size limit", name, r.archivePath)
+	}
+	r.size += int64(len(contents))
+	r.templates = append(r.templates, ArchiveTemplate{
+		Name:     filepath.Join(r.archivePath, filepath.FromSlash(name)),
+		Contents: data.TrimBOM(string(contents)),
+	})
+	return nil
+}
+
+// archiveEntryName returns the cleaned slash-separated name of an archive entry, rejecting the
+// absolute names and those escaping the archive, which would be written outside of the extraction
+// directory by a naive extractor.
+func archiveEntryName(archivePath, name string) (string, error) {
+	cleaned := path.Clean(strings.ReplaceAll(name, `\`, "/"))
+	if path.IsAbs(cleaned) || filepath.VolumeName(cleaned) != "" || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", errors.Errorf("Refusing the unsafe entry %q of the template archive: %s", name, archivePath)
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,145 @@
+package disk
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+// writeTarGz writes the files, by entry name, to a gzipped tarball in a temporary directory.
+func writeTarGz(t *testing.T, files map[string]string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, contents := range files {
+		header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(contents)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "suite.tar.gz")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeZip writes the files, by entry name, to a zip archive in a temporary directory.
+func writeZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, contents := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "suite.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExpandTemplateDirectoriesReadsArchives(t *testing.T) {
+	files := map[string]string{
+		"users/get.ini":   "[Host]\nhttps://example.com/users\n",
+		"users/README.md": "not a template",
+		"orders/list.ini": "[Host]\nhttps://example.com/orders\n",
+	}
+	for _, archive := range []string{writeTarGz(t, files), writeZip(t, files)} {
+		filenames, err := expandTemplateDirectories([]string{archive}, DefaultDiscoveryOptions())
+		if err != nil {
+			t.Fatalf("%s: expandTemplateDirectories() error = %v", archive, err)
+		}
+		sort.Strings(filenames)
+		want := []string{filepath.Join(archive, "orders", "list.ini"), filepath.Join(archive, "users", "get.ini")}
+		if !reflect.DeepEqual(filenames, want) {
+			t.Fatalf("%s: templates = %q, want %q", archive, filenames, want)
+		}
+		contents, err := ReadRawTemplateString(want[1])
+		if err != nil {
+			t.Fatalf("ReadRawTemplateString() error = %v", err)
+		}
+		rc, err := data.ParseTemplate(want[1], contents, data.Config{})
+		if err != nil {
+			t.Fatalf("ParseTemplate() error = %v", err)
+		}
+		if rc.Host.String() != "https://example.com/users" {
+			t.Errorf("host = %s, want the host of the template in the archive", rc.Host)
+		}
+		if _, err := ReadRawTemplateString(archive); err == nil {
+			t.Errorf("ReadRawTemplateString(%s) succeeded, want the archive refused", archive)
+		}
+		if _, err := ReadRawTemplateString(filepath.Join(archive, "missing.ini")); err == nil {
+			t.Errorf("ReadRawTemplateString() of a template missing from %s succeeded, want an error", archive)
+		}
+		if _, err := os.Stat(filepath.Join(filepath.Dir(archive), "users")); !os.IsNotExist(err) {
+			t.Errorf("the templates of %s are written to disk", archive)
+		}
+	}
+}
+
+func TestLoadTemplatesFromArchiveLimits(t *testing.T) {
+	defer func(entry, total int64) { MaxArchiveEntryBytes, MaxArchiveBytes = entry, total }(MaxArchiveEntryBytes, MaxArchiveBytes)
+	MaxArchiveEntryBytes, MaxArchiveBytes = 100, 150
+	tests := map[string]map[string]string{
+		"large entry":  {"big.ini": strings.Repeat("#", 101)},
+		"large total":  {"a.ini": strings.Repeat("#", 80), "b.ini": strings.Repeat("#", 80)},
+		"unsafe entry": {"../escape.ini": "[Host]\nhttps://example.com\n"},
+	}
+	for name, files := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := LoadTemplatesFromArchive(writeZip(t, files), DefaultDiscoveryOptions()); err == nil {
+				t.Error("LoadTemplatesFromArchive() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestGetTemplateFilenamesFromArchive(t *testing.T) {
+	t.Setenv(EnvironmentVariable, "")
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	files := map[string]string{"a.ini": "[Host]\nhttps://example.com/a\n", "b.ini": "[Host]\nhttps://example.com/b\n"}
+	for _, archive := range []string{writeTarGz(t, files), writeZip(t, files)} {
+		opts := DefaultDiscoveryOptions()
+		opts.Sort = true
+		got, err := GetTemplateFilenamesFrom([]string{archive}, stdin, opts)
+		if err != nil {
+			t.Fatalf("GetTemplateFilenamesFrom(%s) error = %v", archive, err)
+		}
+		if want := []string{filepath.Join(archive, "a.ini"), filepath.Join(archive, "b.ini")}; !reflect.DeepEqual(got, want) {
+			t.Errorf("GetTemplateFilenamesFrom(%s) = %q, want %q", archive, got, want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package disk
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/pkg/errors"
+)
+
+// backendExecutables maps the backends whose executable name differs from the backend name.
+var backendExecutables = map[string]string{
+	"httpie": "http",
+}
+
+// The function BackendExecutable returns the name of the executable that implements the given
+// backend, which is usually the backend name itself (httpie is installed as `http`).
+func BackendExecutable(backend string) string {
+	if executable, ok := backendExecutables[backend]; ok {
+		return executable
+	}
+	return backend
+}
+
+// lookupBackend returns the path of the executable of the backend found in the PATH, or an empty
+// path for a custom backend run by its executor, which needs no executable.
+func lookupBackend(backend string) (string, error) {
+	if _, executor, ok := CustomBackend(backend); ok && executor != nil {
+		return "", nil
+	}
+	return exec.LookPath(BackendExecutable(backend))
+}
+
+// The function DetectBackend returns the first backend, following backendPriorityOrder, whose
+// executable can be found in the PATH.
+//
+// Returns:
+//   - The name of the detected backend.
+//   - An error if none of the supported backends is installed.
+func DetectBackend() (string, error) {
+	for _, backend := range backendPriorityOrder {
+		if _, err := lookupBackend(backend); err == nil {
+			return backend, nil
+		}
+	}
+	return "", errors.Errorf("None of the supported backends %v could be found in the PATH", backendPriorityOrder)
+}
+
+// BackendInfo describes a backend and whether it can run on this system, as listed by
+// BackendStatus.
+type BackendInfo struct {
+	// Name is the name of the backend, as written in the [Backend] section.
+	Name string
+
+	// Available tells whether the executable of the backend was found in the PATH. The native
+	// backend is always available.
+	Available bool
+
+	// Path is the path of the executable of the backend, empty when it is not available, for the
+	// native backend and for the custom backends run by their executor.
+	Path string
+
+	// Priority is the rank of the backend when none is requested, starting at 1, the first available
+	// backend being picked by DetectBackend.
+	Priority int
+}
+
+// The function BackendStatus probes the PATH for the executable of each backend, following
+// backendPriorityOrder, to explain which backend a request without a [Backend] section runs with.
+// The native backend, which ResolveBackend falls back to when no external tool is installed, comes
+// last.
+//
+// Returns:
+//   - The BackendInfo of each backend, ordered by priority.
+func BackendStatus() []BackendInfo {
+	infos := make([]BackendInfo, 0, len(backendPriorityOrder)+1)
+	for i, backend := range backendPriorityOrder {
+		info := BackendInfo{Name: backend, Priority: i + 1}
+		if path, err := lookupBackend(backend); err == nil {
+			info.Available, info.Path = true, path
+		}
+		infos = append(infos, info)
+	}
+	return append(infos, BackendInfo{Name: data.NativeBackend, Available: true, Priority: len(backendPriorityOrder) + 1})
+}
+
+// BackendVariable is the environment variable selecting the backend of every request, over the
+// [Backend] section of the templates.
+const BackendVariable = "VORTEX_BACKEND"
+
+// The function ValidateBackend checks that the name is one of the supported backends, including
+// the native one.
+//
+// Parameters:
+//   - name: The backend name to check.
+//
+// Returns:
+//   - An error listing the valid backends if the name is unknown.
+func ValidateBackend(name string) error {
+	if name == data.NativeBackend {
+		return nil
+	}
+	for _, backend := range backendPriorityOrder {
+		if name == backend {
+			return nil
+		}
+	}
+	valid := append(append([]string(nil), backendPriorityOrder...), data.NativeBackend)
+	return errors.Errorf("Unknown backend %q, expected one of %s", name, strings.Join(valid, ", "))
+}
+
+// The function BackendOverride returns the backend selected by the VORTEX_BACKEND environment
+// variable, or an empty string when it is unset or empty.
+//
+// Returns:
+//   - The name of the backend.
+//   - An error if the variable names an unknown backend.
+func BackendOverride() (string, error) {
+	backend := strings.TrimSpace(os.Getenv(BackendVariable))
+	if backend == "" {
+		return "", nil
+	}
+	if err := ValidateBackend(backend); err != nil {
+		return "", errors.Wrapf(err, "Invalid %s", BackendVariable)
+	}
+	return backend, nil
+}
+
+// The function ResolveBackend returns the backend a request actually runs with. A requested
+// backend is validated with ValidateBackend and used when its executable is in the PATH, the native
+// backend being always available. Without a requested backend, the first installed one is picked
+// with DetectBackend, falling back to the native backend when none of the external tools is
+// installed.
+//
+// Parameters:
+//   - requested: The name of the requested backend, or an empty string to pick one.
+//
+// Returns:
+//   - The name of the backend to use.
+//   - An error if the requested backend is unknown, or a BackendNotFoundError if its executable
+//     cannot be found in the PATH.
+func ResolveBackend(requested string) (string, error) {
+	if requested == "" {
+		if backend, err := DetectBackend(); err == nil {
+			return backend, nil
+		}
+		return data.NativeBackend, nil
+	}
+	if err := ValidateBackend(requested); err != nil {
+		return "", err
+	}
+	if requested == data.NativeBackend {
+		return requested, nil
+	}
+	if _, err := lookupBackend(requested); err != nil {
+		return "", &BackendNotFoundError{Backend: requested, Err: err}
+	}
+	return requested, nil
+}
+
+// BackendNotFoundError is the error returned by ResolveBackend when the executable of the requested
+// backend cannot be found in the PATH.
+type BackendNotFoundError struct {
+	// Backend is the name of the requested backend.
+	Backend string
+
+	// Err is the error of the lookup of the executable.
+	Err error
+}
+
+// The method Error names the backend and its missing executable.
+func (e *BackendNotFoundError) Error() string {
+	return fmt.Sprintf("The %s backend was requested but %s cannot be found in the PATH: %v", e.Backend, BackendExecutable(e.Backend), e.Err)
+}
+
+// The method Unwrap returns the error of the lookup, so errors.Is and errors.As can inspect it.
+func (e *BackendNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// The method ExitCode returns ExitNoBackend.
+func (e *BackendNotFoundError) ExitCode() int {
+	return data.ExitNoBackend
+}
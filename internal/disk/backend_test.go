@@ -0,0 +1,92 @@
+package disk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+// fakePath makes a directory holding an executable for each name the only directory of the PATH.
+func fakePath(t *testing.T, names ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+	return dir
+}
+
+func TestBackendStatus(t *testing.T) {
+	dir := fakePath(t, "wget")
+	want := []BackendInfo{
+		{Name: "curl", Priority: 1},
+		{Name: "httpie", Priority: 2},
+		{Name: "wget", Available: true, Path: filepath.Join(dir, "wget"), Priority: 3},
+		{Name: data.NativeBackend, Available: true, Priority: 4},
+	}
+	if got := BackendStatus(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BackendStatus() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDetectBackend(t *testing.T) {
+	fakePath(t, "http", "wget")
+	if got, err := DetectBackend(); err != nil || got != "httpie" {
+		t.Errorf("DetectBackend() = %q, %v, want httpie", got, err)
+	}
+	fakePath(t)
+	if _, err := DetectBackend(); err == nil {
+		t.Error("DetectBackend() with an empty PATH succeeded, want an error")
+	}
+}
+
+func TestBackendOverride(t *testing.T) {
+	tests := map[string]struct {
+		value, want string
+		wantErr     bool
+	}{
+		"unset":   {"", "", false},
+		"httpie":  {"httpie", "httpie", false},
+		"spaces":  {" native ", data.NativeBackend, false},
+		"unknown": {"postman", "", true},
+	}
+	for name, tt := range tests {
+		t.Setenv(BackendVariable, tt.value)
+		got, err := BackendOverride()
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "expected one of curl, httpie, wget, native") {
+				t.Errorf("%s: BackendOverride() error = %v, want the valid backends listed", name, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: BackendOverride() = %q, %v, want %q", name, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolveBackend(t *testing.T) {
+	fakePath(t, "curl", "wget")
+	if got, err := ResolveBackend("wget"); err != nil || got != "wget" {
+		t.Errorf("ResolveBackend(wget) = %q, %v, want wget", got, err)
+	}
+	var notFound *BackendNotFoundError
+	if _, err := ResolveBackend("httpie"); !errors.As(err, &notFound) || notFound.Backend != "httpie" {
+		t.Errorf("ResolveBackend(httpie) error = %v, want a BackendNotFoundError", err)
+	}
+	if got, err := ResolveBackend(""); err != nil || got != "curl" {
+		t.Errorf("ResolveBackend() = %q, %v, want the detected curl", got, err)
+	}
+	fakePath(t)
+	if got, err := ResolveBackend(""); err != nil || got != data.NativeBackend {
+		t.Errorf("ResolveBackend() with an empty PATH = %q, %v, want the native fallback", got, err)
+	}
+}
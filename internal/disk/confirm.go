@@ -0,0 +1,64 @@
+package disk
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/pkg/errors"
+)
+
+// Prompt is the writer the confirmation questions are printed on. It is stderr so the questions
+// never mix with the response printed on stdout, and can be replaced like Stdin.
+var Prompt io.Writer = os.Stderr
+
+// ErrUserDeclined is returned, wrapped, by ConfirmRequest when the user does not confirm sending a
+// destructive request.
+var ErrUserDeclined = errors.New("The request was declined")
+
+// The function ConfirmRequest asks whether to send a RequestConfig setting Confirm whose method is
+// destructive, as told by the IsDestructive method of the configuration. The question is printed
+// on the prompt writer, usually Prompt, and the answer read from stdin, usually Stdin: only `y`
+// and `yes`, in any case, confirm the request. The question is skipped when the configuration sets
+// AssumeYes, which is required in non-interactive mode. Other requests are confirmed without
+// asking.
+//
+// Parameters:
+//   - rc: The request configuration about to be sent.
+//   - cfg: The configuration listing the destructive methods and telling whether to assume yes.
+//   - stdin: The reader the answer is typed on.
+//   - prompt: The writer the question is printed on.
+//
+// Returns:
+//   - An error wrapping ErrUserDeclined if the user does not confirm the request, or an error if
+//     the mode is not interactive and the configuration does not assume yes.
+func ConfirmRequest(rc *data.RequestConfig, cfg data.Config, stdin io.Reader, prompt io.Writer) error {
+	method := strings.ToUpper(rc.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	if !rc.Confirm || cfg.AssumeYes || !cfg.IsDestructive(method) {
+		return nil
+	}
+	target := ""
+	if rc.Host != nil {
+		target = rc.Host.Redacted()
+	}
+	if !Interactive {
+		return errors.Errorf("The %s request to %s must be confirmed, pass --yes to send it in non-interactive mode", method, target)
+	}
+	fmt.Fprintf(prompt, "About to %s %s. Proceed? [y/N] ", method, target)
+	answer, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return errors.Wrap(err, "Failed to read the confirmation")
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return errors.Wrapf(ErrUserDeclined, "%s %s", method, target)
+}
@@ -1,16 +1,22 @@
 package disk
 
 import (
+	"bufio"
+	"bytes"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/hashicorp/go-envparse"
+	"github.com/larayavrs/vortex/internal/data"
 	"github.com/pkg/errors"
 )
 
 // The function ReadEnviromentFile reads and processes the environment configuration from a file specified by the given path.
 // This function attempts to open and read the file at the provided path. The file should contain environment variables
 // in a format suitable for processing (e.g., key=value pairs). The function will parse the file and apply the environment
-// variables accordingly.
+// variables accordingly. Variables already set in the process environment are left untouched. ReadEnvironmentFileInto
+// reads the file without modifying the process environment.
 //
 // Parameters:
 //   - path: The file path to the environment configuration file.
@@ -21,28 +27,105 @@ import (
 //   - An error if the file cannot be read, if there are issues with parsing, or if the file is missing and
 //     `errorMissingFile` is true. Otherwise, it returns nil indicating success.
 func ReadEnviromentFile(path string, errorMissingFile bool) error {
+	return ReadEnvironmentFileInto(path, errorMissingFile, func(varkey, varvalue string) error {
+		if _, exists := os.LookupEnv(varkey); exists {
+			return nil
+		}
+		return os.Setenv(varkey, varvalue)
+	})
+}
+
+// The function ReadEnvironmentFileInto reads the environment configuration from the file at the given path, like
+// ReadEnviromentFile, but hands each variable to the given setter instead of the process environment. This allows
+// collecting the variables into a scoped environment, such as the `Env` of a single command, without leaking them
+// into the process.
+//
+// The `${VAR}` references in the values are expanded with data.ExpandVariables, to the other variables of the file
+// first and to the process environment otherwise, and reference cycles are rejected. Single-quoted values are kept
+// literally, as in the shell, and so are the values holding no reference, whose `$$` sequences are not unescaped.
+//
+// Parameters:
+//   - path: The file path to the environment configuration file.
+//   - errorMissingFile: If true, the function will return an error if the file is not found. If false, a missing file
+//     is silently ignored.
+//   - set: The function called with the name and value of every variable of the file. Returning an error stops the
+//     reading.
+//
+// Returns:
+//   - An error if the file cannot be read, parsed or expanded, as with a reference cycle, if the setter fails, or if
+//     the file is missing and `errorMissingFile` is true, in which case it wraps os.ErrNotExist. Otherwise, it returns
+//     nil indicating success.
+func ReadEnvironmentFileInto(path string, errorMissingFile bool, set func(k, v string) error) error {
 	file, err := os.Open(path)
-	if os.IsNotExist(err) {
+	if errors.Is(err, os.ErrNotExist) {
 		if errorMissingFile {
-			return errors.New("Environment file not found" + path)
+			// Wrap the original error so callers can still check it with errors.Is
+			return errors.Wrapf(err, "Environment file not found: %s", path)
 		}
 		return nil
 	}
 	if err != nil {
 		return errors.Wrap(err, "Failed to load environment file")
 	}
-	if file != nil {
-		res, err := envparse.Parse(file)
-		if err != nil {
-			return errors.Wrap(err, "Failed to parse environment file")
+	defer file.Close()
+	contents, err := io.ReadAll(file)
+	if err != nil {
+		return errors.Wrap(err, "Failed to load environment file")
+	}
+	res, err := envparse.Parse(bytes.NewReader(contents))
+	if err != nil {
+		return errors.Wrap(err, "Failed to parse environment file")
+	}
+	if err := expandEnvironmentValues(res, contents); err != nil {
+		return errors.Wrapf(err, "Failed to expand environment file %s", path)
+	}
+	for varkey, varvalue := range res {
+		if err := set(varkey, varvalue); err != nil {
+			return errors.Wrap(err, "Failed to set environment variable")
 		}
-		for varkey, varvalue := range res {
-			if _, exists := os.LookupEnv(varkey); !exists {
-				if err := os.Setenv(varkey, varvalue); err != nil {
-					return errors.Wrap(err, "Failed to set environment variable")
-				}
-			}
+	}
+	return nil
+}
+
+// expandEnvironmentValues expands, in place, the variable references of the values of an environment file, as
+// described by ReadEnvironmentFileInto. The contents of the file tell which values are single-quoted.
+func expandEnvironmentValues(vars map[string]string, contents []byte) error {
+	openDelim, _ := data.Config{}.InterpolationDelims()
+	literal := singleQuotedKeys(contents)
+	expandable := make(map[string]string)
+	for name, value := range vars {
+		if !literal[name] && strings.Contains(value, openDelim) {
+			expandable[name] = value
+		}
+	}
+	if len(expandable) == 0 {
+		return nil
+	}
+	expanded, err := data.ExpandVariables(expandable, data.Config{}, func(name string) string {
+		if value, ok := vars[name]; ok {
+			return value
 		}
+		return os.Getenv(name)
+	})
+	if err != nil {
+		return err
+	}
+	for name, value := range expanded {
+		vars[name] = value
 	}
 	return nil
 }
+
+// singleQuotedKeys returns the names of the variables of an environment file whose value is single-quoted.
+func singleQuotedKeys(contents []byte) map[string]bool {
+	keys := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimPrefix(strings.TrimSpace(scanner.Text()), "export ")
+		name, value, found := strings.Cut(line, "=")
+		if found && strings.HasPrefix(strings.TrimSpace(value), "'") {
+			keys[strings.TrimSpace(name)] = true
+		}
+	}
+	return keys
+}
@@ -0,0 +1,136 @@
+package disk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readEnvironment writes the contents to an environment file and returns the variables read from it.
+func readEnvironment(t *testing.T, contents string) (map[string]string, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	vars := make(map[string]string)
+	err := ReadEnvironmentFileInto(path, true, func(k, v string) error {
+		vars[k] = v
+		return nil
+	})
+	return vars, err
+}
+
+func TestReadEnvironmentFileIntoExpandsReferences(t *testing.T) {
+	t.Setenv("VORTEX_TEST_HOST", "example.com")
+	vars, err := readEnvironment(t, "URL=https://${HOST}/${VERSION}\nHOST=${VORTEX_TEST_HOST}\nVERSION=v2\n")
+	if err != nil {
+		t.Fatalf("ReadEnvironmentFileInto() error = %v", err)
+	}
+	if got, want := vars["URL"], "https://example.com/v2"; got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+}
+
+func TestReadEnvironmentFileIntoRejectsCycles(t *testing.T) {
+	tests := map[string]struct {
+		contents string
+		cycle    string
+	}{
+		"two variables":  {"A=${B}\nB=${A}\n", "A -> B -> A"},
+		"self reference": {"A=${A}\n", "A -> A"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := readEnvironment(t, tt.contents)
+			if err == nil || !strings.Contains(err.Error(), tt.cycle) {
+				t.Errorf("ReadEnvironmentFileInto() error = %v, want the cycle %q", err, tt.cycle)
+			}
+		})
+	}
+}
+
+func TestReadEnvironmentFileIntoKeepsLiteralValues(t *testing.T) {
+	t.Setenv("HOME", "/home/vortex")
+	vars, err := readEnvironment(t, "PASSWORD=pa$$word\nQUOTED='${HOME}'\nOPEN='${'\nEXPORTED=${HOME}\n")
+	if err != nil {
+		t.Fatalf("ReadEnvironmentFileInto() error = %v", err)
+	}
+	want := map[string]string{
+		"PASSWORD": "pa$$word",
+		"QUOTED":   "${HOME}",
+		"OPEN":     "${",
+		"EXPORTED": "/home/vortex",
+	}
+	for name, value := range want {
+		if vars[name] != value {
+			t.Errorf("%s = %q, want %q", name, vars[name], value)
+		}
+	}
+}
+
+func TestReadEnvironmentFileIntoScopedSetter(t *testing.T) {
+	// t.Setenv restores the environment once the variable is unset
+	t.Setenv("VORTEX_TEST_SCOPED", "")
+	os.Unsetenv("VORTEX_TEST_SCOPED")
+	vars, err := readEnvironment(t, "VORTEX_TEST_SCOPED=value\nexport OTHER=2\n")
+	if err != nil {
+		t.Fatalf("ReadEnvironmentFileInto() error = %v", err)
+	}
+	if want := map[string]string{"VORTEX_TEST_SCOPED": "value", "OTHER": "2"}; !reflect.DeepEqual(vars, want) {
+		t.Errorf("ReadEnvironmentFileInto() set %q, want %q", vars, want)
+	}
+	if value, ok := os.LookupEnv("VORTEX_TEST_SCOPED"); ok {
+		t.Errorf("ReadEnvironmentFileInto() set VORTEX_TEST_SCOPED=%q in the process environment", value)
+	}
+
+	failure := errors.New("read-only environment")
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err = ReadEnvironmentFileInto(path, true, func(string, string) error { return failure })
+	if !errors.Is(err, failure) {
+		t.Errorf("ReadEnvironmentFileInto() error = %v, want the setter error", err)
+	}
+}
+
+func TestReadEnviromentFileKeepsProcessVariables(t *testing.T) {
+	t.Setenv("VORTEX_TEST_SET", "process")
+	t.Setenv("VORTEX_TEST_UNSET", "")
+	os.Unsetenv("VORTEX_TEST_UNSET")
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("VORTEX_TEST_SET=file\nVORTEX_TEST_UNSET=file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReadEnviromentFile(path, true); err != nil {
+		t.Fatalf("ReadEnviromentFile() error = %v", err)
+	}
+	if got := os.Getenv("VORTEX_TEST_SET"); got != "process" {
+		t.Errorf("VORTEX_TEST_SET = %q, want the process value kept", got)
+	}
+	if got := os.Getenv("VORTEX_TEST_UNSET"); got != "file" {
+		t.Errorf("VORTEX_TEST_UNSET = %q, want the file value", got)
+	}
+}
+
+func TestReadEnviromentFileMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", ".env")
+	err := ReadEnviromentFile(path, true)
+	if want := "Environment file not found: " + path; err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("ReadEnviromentFile() error = %v, want it to start with %q", err, want)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadEnviromentFile() error = %v, want it to wrap os.ErrNotExist", err)
+	}
+	var pathErr *os.PathError
+	if !errors.As(err, &pathErr) || pathErr.Path != path {
+		t.Errorf("ReadEnviromentFile() error = %v, want the os.PathError of %s", err, path)
+	}
+	if err := ReadEnviromentFile(path, false); err != nil {
+		t.Errorf("ReadEnviromentFile() of an optional missing file error = %v, want nil", err)
+	}
+}
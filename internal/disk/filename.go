@@ -1,30 +1,289 @@
 package disk
 
 import (
+	"bufio"
 	"flag"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/larayavrs/vortex/internal/data"
 	"github.com/larayavrs/vortex/pkg"
 	"github.com/pkg/errors"
 )
 
-// localTemplateFilenames stores the filenames of locally available templates.
-// This variable holds a slice of strings where each string represents the name of a template
-// file found within a specific directory or source. It is typically used to cache or reference
-// the templates that have been retrieved, allowing for easier access and manipulation of these
-// filenames throughout the program.
-var localTemplateFilenames []string
+// DiscoveryOptions controls how a directory given in place of a template filename is expanded
+// into the template files it contains. Directories are walked with `filepath.WalkDir`, so the
+// discovered filenames are returned in lexical order.
+type DiscoveryOptions struct {
+	// Extensions lists the file extensions, including the leading dot, that identify template
+	// files. Matching is case-insensitive. Files with any other extension are ignored.
+	Extensions []string
+
+	// MaxDepth limits how many directory levels below the given directory are walked. A value of
+	// zero only inspects the directory itself, while a negative value walks without any limit.
+	MaxDepth int
+
+	// IncludeHidden, if true, also walks directories whose name starts with a dot. Hidden
+	// directories are skipped by default.
+	IncludeHidden bool
+
+	// Sort, if true, sorts the final list of template filenames so the output is deterministic
+	// regardless of the order in which arguments, globs and directories were provided.
+	Sort bool
+
+	// Environment, if set, is the active template environment used by GetTemplateFilenames, such
+	// as `dev` or `prod`, usually chosen on the command line. It takes precedence over
+	// EnvironmentVariable, see ActiveEnvironment.
+	Environment string
+}
+
+// EnvironmentVariable names the environment variable selecting the active template environment,
+// such as `dev` or `prod`, when none is given explicitly.
+const EnvironmentVariable = "VORTEX_ENV"
+
+// The function ActiveEnvironment returns the active template environment: the given environment,
+// or else the value of the VORTEX_ENV environment variable.
+//
+// Parameters:
+//   - env: The environment chosen explicitly, such as the Environment of the DiscoveryOptions. It
+//     may be empty.
+//
+// Returns:
+//   - The active environment, empty when no environment is active.
+func ActiveEnvironment(env string) string {
+	if env != "" {
+		return env
+	}
+	return os.Getenv(EnvironmentVariable)
+}
+
+// The function ResolveEnvironmentTemplate returns the environment-specific variant of a template
+// when it exists: with the environment `prod`, `api.ini` resolves to `api.prod.ini`. The template
+// itself is returned when no environment is given, the variant does not exist or the template is
+// remote. The edit suffix of the filename is kept.
+//
+// Parameters:
+//   - filename: The filename of the template.
+//   - env: The active environment, as returned by ActiveEnvironment.
+//
+// Returns:
+//   - The filename of the template to use.
+func ResolveEnvironmentTemplate(filename, env string) string {
+	if env == "" || data.IsRemoteTemplate(filename) {
+		return filename
+	}
+	path, suffix := filename, ""
+	if strings.HasSuffix(path, editFileSuffix) {
+		path, suffix = strings.TrimSuffix(path, editFileSuffix), editFileSuffix
+	}
+	ext := filepath.Ext(path)
+	variant := strings.TrimSuffix(path, ext) + "." + env + ext
+	if fi, err := os.Stat(variant); err == nil && !fi.IsDir() {
+		return variant + suffix
+	}
+	return filename
+}
+
+// TemplateDiscovery holds the options used by GetTemplateFilenames when one of the provided
+// filenames is a directory. It can be adjusted before template discovery takes place.
+var TemplateDiscovery = DefaultDiscoveryOptions()
+
+// The function DefaultDiscoveryOptions returns the default template discovery options: only
+// `*.ini` files are considered templates, directories are walked recursively without a depth
+// limit and hidden directories are skipped.
+func DefaultDiscoveryOptions() DiscoveryOptions {
+	return DiscoveryOptions{
+		Extensions: []string{".ini"},
+		MaxDepth:   -1,
+	}
+}
+
+// The function DiscoverTemplates walks the given directory and collects every template file
+// found in it according to the provided options. The returned paths are joined with the
+// directory, so they can be read directly, and are sorted lexically by the walk.
+//
+// Parameters:
+//   - dir: The directory to search for template files.
+//   - opts: The options controlling the recursion depth, extensions and hidden directories.
+//
+// Returns:
+//   - A slice of strings containing the paths of all templates found.
+//   - An error if the directory cannot be walked.
+func DiscoverTemplates(dir string, opts DiscoveryOptions) ([]string, error) {
+	var found []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path == dir {
+				return nil
+			}
+			if !opts.IncludeHidden && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			depth := len(strings.Split(rel, string(filepath.Separator)))
+			if opts.MaxDepth >= 0 && depth > opts.MaxDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if hasTemplateExtension(entry.Name(), opts.Extensions) {
+			found = append(found, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to discover templates in directory: %s", dir)
+	}
+	return found, nil
+}
+
+// hasTemplateExtension reports whether the filename ends with one of the template extensions.
+func hasTemplateExtension(name string, extensions []string) bool {
+	ext := filepath.Ext(name)
+	for _, candidate := range extensions {
+		if strings.EqualFold(ext, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// listFilePrefix marks a filename argument as a list file, whose lines are template filenames.
+const listFilePrefix = "@"
+
+// expandListFiles replaces every `@path` argument with the template filenames listed in that
+// file, one or more per line. Blank lines and lines starting with `#` are ignored, and each line
+// is tokenized so quoted paths containing spaces are supported. List files cannot reference other
+// list files.
+func expandListFiles(filenames []string) ([]string, error) {
+	expanded := make([]string, 0, len(filenames))
+	for _, name := range filenames {
+		if !strings.HasPrefix(name, listFilePrefix) {
+			expanded = append(expanded, name)
+			continue
+		}
+		listFile := strings.TrimPrefix(name, listFilePrefix)
+		contents, err := os.ReadFile(listFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read the template list file: %s", listFile)
+		}
+		for i, line := range strings.Split(string(contents), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			tokens, err := pkg.TokenizeLine(line)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to tokenize line %d of %s", i+1, listFile)
+			}
+			for _, token := range tokens {
+				if strings.HasPrefix(token, listFilePrefix) {
+					return nil, errors.Errorf("Nested list file %s referenced on line %d of %s", token, i+1, listFile)
+				}
+			}
+			expanded = append(expanded, tokens...)
+		}
+	}
+	return expanded, nil
+}
+
+// globMetaChars are the characters that turn a filename argument into a glob pattern.
+const globMetaChars = "*?["
+
+// expandTemplateDirectories replaces every directory in the list of filenames with the
+// templates discovered inside it, every template archive with the templates it holds and every
+// glob pattern with the files it matches, leaving regular filenames untouched. Duplicate
+// filenames are removed, keeping the first occurrence.
+func expandTemplateDirectories(filenames []string, opts DiscoveryOptions) ([]string, error) {
+	filenames, err := expandListFiles(filenames)
+	if err != nil {
+		return nil, err
+	}
+	expanded := make([]string, 0, len(filenames))
+	for _, name := range filenames {
+		if data.IsRemoteTemplate(name) {
+			expanded = append(expanded, name)
+			continue
+		}
+		if IsTemplateArchive(name) {
+			templates, err := LoadTemplatesFromArchive(name, opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, tmpl := range templates {
+				expanded = append(expanded, tmpl.Name)
+			}
+			continue
+		}
+		fi, err := os.Stat(name)
+		if err != nil && strings.ContainsAny(name, globMetaChars) {
+			matches, err := filepath.Glob(name)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Invalid glob pattern: %s", name)
+			}
+			expanded = append(expanded, matches...)
+			continue
+		}
+		if err != nil || !fi.IsDir() {
+			expanded = append(expanded, name)
+			continue
+		}
+		discovered, err := DiscoverTemplates(name, opts)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, discovered...)
+	}
+	expanded = dedupeFilenames(expanded)
+	if opts.Sort {
+		sort.Strings(expanded)
+	}
+	return expanded, nil
+}
+
+// dedupeFilenames removes repeated filenames while preserving the first-seen order.
+func dedupeFilenames(filenames []string) []string {
+	seen := make(map[string]struct{}, len(filenames))
+	unique := filenames[:0]
+	for _, name := range filenames {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		unique = append(unique, name)
+	}
+	return unique
+}
 
 // GetTemplateFilenames retrieves a list of template filenames from a predefined directory or source.
 // This function searches for template files within a specific directory, gathers their filenames,
-// and returns them as a slice of strings. The function also handles any errors that may occur
-// during the process, such as issues with accessing the directory or reading the filenames.
+// and returns them as a slice of strings. Any filename that refers to a directory is replaced by
+// the templates discovered inside it using the TemplateDiscovery options. The function also handles
+// any errors that may occur during the process, such as issues with accessing the directory or
+// reading the filenames. A "-" argument is replaced by the filenames piped through stdin, an
+// "@path" argument by the filenames listed in that file, glob patterns are expanded and repeated
+// filenames are only returned once. When an environment is active, the Environment of the
+// TemplateDiscovery options or VORTEX_ENV as told by ActiveEnvironment, each template is replaced
+// by its environment-specific variant if it exists. Template archives, as told by
+// IsTemplateArchive, are replaced by the templates LoadTemplatesFromArchive reads from them, named
+// after the archive so ReadRawTemplateString reads them back. The URLs of remote templates are
+// returned as they are, to be fetched by ReadRawTemplateString.
 //
 // Returns:
 //   - A slice of strings containing the filenames of all templates found.
 //   - An error if there is an issue accessing the directory or reading the files. If no error occurs,
-//     the returned error will be nil.
+//     the returned error will be nil. ErrNoTemplates is returned when there are no arguments and
+//     stdin is a terminal.
 //
 // Example usage:
 //
@@ -34,26 +293,167 @@ var localTemplateFilenames []string
 //	}
 //	fmt.Println("Template filenames:", filenames)
 func GetTemplateFilenames() ([]string, error) {
-	if len(flag.Args()) >= 1 {
-		localTemplateFilenames = flag.Args()
+	return GetTemplateFilenamesFrom(flag.Args(), os.Stdin, TemplateDiscovery)
+}
+
+// The function GetTemplateFilenamesFrom retrieves the template filenames like GetTemplateFilenames,
+// from the given arguments and stdin instead of those of the process, and with the given discovery
+// options instead of the TemplateDiscovery ones, for programs parsing their command line with their
+// own flag set. Nothing is shared between its calls, so they can run concurrently.
+//
+// Parameters:
+//   - args: The arguments naming the templates, directories, archives and URLs.
+//   - stdin: The file the template filenames may be piped through.
+//   - opts: The options discovering the templates of directories and archives, and the active
+//     environment.
+//
+// Returns:
+//   - The filenames of all templates found.
+//   - An error as described by GetTemplateFilenames.
+func GetTemplateFilenamesFrom(args []string, stdin *os.File, opts DiscoveryOptions) ([]string, error) {
+	filenames, err := collectTemplateFilenames(args, stdin, opts)
+	if err != nil {
+		return nil, err
 	}
-	fi, err := os.Stdin.Stat()
+	if env := ActiveEnvironment(opts.Environment); env != "" {
+		for i, name := range filenames {
+			filenames[i] = ResolveEnvironmentTemplate(name, env)
+		}
+		filenames = dedupeFilenames(filenames)
+	}
+	if err := ValidateTemplateFilenames(filenames); err != nil {
+		return nil, err
+	}
+	return filenames, nil
+}
+
+// The function ValidateTemplateFilenames checks up front that every template filename refers to
+// an existing, readable file, so all typos are reported at once instead of failing one template
+// at a time while reading them. Filenames carrying the edit suffix are checked without it, the
+// URLs of remote templates are only checked once fetched, and the templates of an archive, listed
+// from it by LoadTemplatesFromArchive, are only checked once read.
+//
+// Parameters:
+//   - filenames: The template filenames to validate.
+//
+// Returns:
+//   - An error listing every missing or unreadable template, or nil if all of them are usable.
+func ValidateTemplateFilenames(filenames []string) error {
+	var problems []string
+	for _, name := range filenames {
+		path := strings.TrimSuffix(name, editFileSuffix)
+		if _, archived := templateArchiveOf(path); archived || data.IsRemoteTemplate(name) {
+			continue
+		}
+		fi, err := os.Stat(path)
+		if err == nil && fi.IsDir() {
+			err = errors.New("is a directory")
+		}
+		if err == nil {
+			var file *os.File
+			if file, err = os.Open(path); err == nil {
+				_ = file.Close()
+			}
+		}
+		if err != nil {
+			if os.IsNotExist(err) {
+				problems = append(problems, path+": file does not exist")
+			} else if os.IsPermission(err) {
+				problems = append(problems, path+": permission denied")
+			} else {
+				problems = append(problems, path+": "+err.Error())
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("Invalid template files:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// ErrNoTemplates is returned by GetTemplateFilenames when no template is given, neither as an
+// argument nor through a piped stdin, so the caller can print its usage instead of doing nothing.
+var ErrNoTemplates = errors.New("No template files provided, pass them as arguments or through stdin")
+
+// stdinMarker is the filename argument that stands for the template filenames piped through
+// stdin, allowing them to be spliced at a known position among the other arguments.
+const stdinMarker = "-"
+
+// collectTemplateFilenames gathers the template filenames from the given arguments and, when it
+// is not a terminal, from the given stdin. Providing filenames through both sources is an error,
+// unless the arguments contain the stdin marker, which is replaced by the piped filenames, and so is
+// providing none with stdin being a terminal, reported as ErrNoTemplates.
+func collectTemplateFilenames(args []string, stdin *os.File, opts DiscoveryOptions) ([]string, error) {
+	markerIndex := -1
+	for i, arg := range args {
+		if arg != stdinMarker {
+			continue
+		}
+		if markerIndex >= 0 {
+			return nil, errors.Errorf("The stdin marker %q can only be provided once", stdinMarker)
+		}
+		markerIndex = i
+	}
+	if markerIndex >= 0 {
+		filenamesViaPipe, err := readTemplateFilenames(stdin)
+		if err != nil {
+			return nil, err
+		}
+		filenames := make([]string, 0, len(args)-1+len(filenamesViaPipe))
+		filenames = append(filenames, args[:markerIndex]...)
+		filenames = append(filenames, filenamesViaPipe...)
+		filenames = append(filenames, args[markerIndex+1:]...)
+		return expandTemplateDirectories(filenames, opts)
+	}
+	filenames := make([]string, 0, len(args))
+	filenames = append(filenames, args...)
+	fi, err := stdin.Stat()
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to get file info")
 	}
+	if (fi.Mode()&os.ModeCharDevice) != 0 && len(args) == 0 {
+		return nil, ErrNoTemplates
+	}
 	if (fi.Mode() & os.ModeCharDevice) == 0 {
-		fileNameBytes, err := io.ReadAll(os.Stdin)
+		filenamesViaPipe, err := readTemplateFilenames(stdin)
 		if err != nil {
-			return nil, errors.Wrap(err, "Failed to read from stdin")
+			return nil, err
 		}
-		localTemplateFilenamesViaPipe, err := pkg.TokenizeLine(string(fileNameBytes))
-		if err != nil {
-			return nil, errors.Wrap(err, "Failed to tokenize line")
+		if len(filenamesViaPipe) > 0 {
+			if len(args) > 0 {
+				return nil, errors.New("Template filenames are provided via stdin and as arguments")
+			}
+			filenames = append(filenames, filenamesViaPipe...)
 		}
-		if len(localTemplateFilenamesViaPipe) > 0 {
-			return nil, errors.Wrap(err, "Template filenames are provided via stdin and as arguments")
+	}
+	return expandTemplateDirectories(filenames, opts)
+}
+
+// readTemplateFilenames reads the lines of stdin and tokenizes them into template filenames with
+// TokenizeLines, so the large lists piped by other tools are tokenized concurrently. A quoted
+// filename cannot span several lines.
+func readTemplateFilenames(stdin io.Reader) ([]string, error) {
+	var lines []string
+	reader := bufio.NewReader(stdin)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			lines = append(lines, line)
 		}
-		localTemplateFilenames = append(localTemplateFilenames, localTemplateFilenamesViaPipe...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to read the template filenames from stdin")
+		}
+	}
+	tokens, err := pkg.TokenizeLines(lines)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to tokenize stdin")
+	}
+	var filenames []string
+	for _, lineTokens := range tokens {
+		filenames = append(filenames, lineTokens...)
 	}
-	return localTemplateFilenames, nil
+	return filenames, nil
 }
@@ -0,0 +1,316 @@
+package disk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestActiveEnvironment(t *testing.T) {
+	t.Setenv(EnvironmentVariable, "dev")
+	if got := ActiveEnvironment(""); got != "dev" {
+		t.Errorf("ActiveEnvironment(\"\") = %q, want the variable dev", got)
+	}
+	if got := ActiveEnvironment("prod"); got != "prod" {
+		t.Errorf("ActiveEnvironment(\"prod\") = %q, want prod", got)
+	}
+}
+
+func TestResolveEnvironmentTemplate(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "api.ini")
+	for _, name := range []string{"api.ini", "api.prod.ini"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := map[string]struct {
+		filename, env, want string
+	}{
+		"variant":         {base, "prod", filepath.Join(dir, "api.prod.ini")},
+		"missing variant": {base, "dev", base},
+		"no environment":  {base, "", base},
+		"remote":          {"https://example.com/api.ini", "prod", "https://example.com/api.ini"},
+	}
+	for name, tt := range tests {
+		if got := ResolveEnvironmentTemplate(tt.filename, tt.env); got != tt.want {
+			t.Errorf("%s: ResolveEnvironmentTemplate() = %q, want %q", name, got, tt.want)
+		}
+	}
+}
+
+func TestGetTemplateFilenamesFromOptions(t *testing.T) {
+	t.Setenv(EnvironmentVariable, "")
+	dir := t.TempDir()
+	for _, name := range []string{"b.ini", "a.ini", "a.prod.ini"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	args := []string{filepath.Join(dir, "b.ini"), filepath.Join(dir, "a.ini")}
+	opts := DefaultDiscoveryOptions()
+	opts.Environment = "prod"
+	opts.Sort = true
+	got, err := GetTemplateFilenamesFrom(args, stdin, opts)
+	if err != nil {
+		t.Fatalf("GetTemplateFilenamesFrom() error = %v", err)
+	}
+	if want := []string{filepath.Join(dir, "a.prod.ini"), filepath.Join(dir, "b.ini")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTemplateFilenamesFrom() = %q, want %q", got, want)
+	}
+	if TemplateDiscovery.Environment != "" || TemplateDiscovery.Sort {
+		t.Errorf("TemplateDiscovery = %+v, want the options of the call left out of it", TemplateDiscovery)
+	}
+	got, err = GetTemplateFilenamesFrom(args, stdin, DefaultDiscoveryOptions())
+	if err != nil {
+		t.Fatalf("GetTemplateFilenamesFrom() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, args) {
+		t.Errorf("GetTemplateFilenamesFrom() with the default options = %q, want %q", got, args)
+	}
+}
+
+// writeFiles creates the empty files, by slash-separated path, and their directories under dir.
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDiscoverTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "b.ini", "a.INI", "notes.txt", "users/get.ini", "users/admin/delete.ini", ".git/hooks.ini", "users/.cache/old.ini")
+	join := func(names ...string) []string {
+		var paths []string
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, filepath.FromSlash(name)))
+		}
+		return paths
+	}
+	tests := map[string]struct {
+		opts func(*DiscoveryOptions)
+		want []string
+	}{
+		"default":    {func(*DiscoveryOptions) {}, join("a.INI", "b.ini", "users/admin/delete.ini", "users/get.ini")},
+		"top level":  {func(o *DiscoveryOptions) { o.MaxDepth = 0 }, join("a.INI", "b.ini")},
+		"one level":  {func(o *DiscoveryOptions) { o.MaxDepth = 1 }, join("a.INI", "b.ini", "users/get.ini")},
+		"hidden":     {func(o *DiscoveryOptions) { o.IncludeHidden = true }, join(".git/hooks.ini", "a.INI", "b.ini", "users/.cache/old.ini", "users/admin/delete.ini", "users/get.ini")},
+		"extensions": {func(o *DiscoveryOptions) { o.Extensions = []string{".txt"} }, join("notes.txt")},
+	}
+	for name, tt := range tests {
+		opts := DefaultDiscoveryOptions()
+		tt.opts(&opts)
+		got, err := DiscoverTemplates(dir, opts)
+		if err != nil {
+			t.Errorf("%s: DiscoverTemplates() error = %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: DiscoverTemplates() = %q, want %q", name, got, tt.want)
+		}
+	}
+	if _, err := DiscoverTemplates(filepath.Join(dir, "missing"), DefaultDiscoveryOptions()); err == nil {
+		t.Error("DiscoverTemplates() of a missing directory succeeded, want an error")
+	}
+	stdin := devNull(t)
+	got, err := GetTemplateFilenamesFrom([]string{filepath.Join(dir, "users")}, stdin, DefaultDiscoveryOptions())
+	if err != nil {
+		t.Fatalf("GetTemplateFilenamesFrom() error = %v", err)
+	}
+	if want := join("users/admin/delete.ini", "users/get.ini"); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTemplateFilenamesFrom() of a directory = %q, want %q", got, want)
+	}
+}
+
+// devNull opens the null device, to stand for an empty piped stdin.
+func devNull(t *testing.T) *os.File {
+	t.Helper()
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { stdin.Close() })
+	return stdin
+}
+
+// pipedStdin returns a file holding the contents, standing for a stdin piped by another program.
+func pipedStdin(t *testing.T, contents string) *os.File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stdin, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { stdin.Close() })
+	return stdin
+}
+
+func TestGetTemplateFilenamesStdinAndArgs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.ini", "b.ini")
+	a, b := filepath.Join(dir, "a.ini"), filepath.Join(dir, "b.ini")
+
+	got, err := GetTemplateFilenamesFrom(nil, pipedStdin(t, a+"\n"+b+"\n"), DefaultDiscoveryOptions())
+	if err != nil {
+		t.Fatalf("GetTemplateFilenamesFrom() with stdin error = %v", err)
+	}
+	if want := []string{a, b}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTemplateFilenamesFrom() with stdin = %q, want %q", got, want)
+	}
+
+	got, err = GetTemplateFilenamesFrom([]string{b, a}, pipedStdin(t, ""), DefaultDiscoveryOptions())
+	if err != nil {
+		t.Fatalf("GetTemplateFilenamesFrom() with arguments error = %v", err)
+	}
+	if want := []string{b, a}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTemplateFilenamesFrom() with arguments = %q, want %q", got, want)
+	}
+
+	if _, err := GetTemplateFilenamesFrom([]string{a}, pipedStdin(t, b+"\n"), DefaultDiscoveryOptions()); err == nil {
+		t.Error("GetTemplateFilenamesFrom() with stdin and arguments succeeded, want an error")
+	}
+}
+
+func TestGetTemplateFilenamesStdinMarker(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.ini", "b.ini", "piped one.ini", "piped2.ini")
+	a, b := filepath.Join(dir, "a.ini"), filepath.Join(dir, "b.ini")
+	first, second := filepath.Join(dir, "piped one.ini"), filepath.Join(dir, "piped2.ini")
+	stdin := pipedStdin(t, `"`+first+`" `+second+"\n")
+
+	got, err := GetTemplateFilenamesFrom([]string{a, "-", b}, stdin, DefaultDiscoveryOptions())
+	if err != nil {
+		t.Fatalf("GetTemplateFilenamesFrom() error = %v", err)
+	}
+	if want := []string{a, first, second, b}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTemplateFilenamesFrom() = %q, want %q", got, want)
+	}
+
+	_, err = GetTemplateFilenamesFrom([]string{a, "-", b, "-"}, pipedStdin(t, first+"\n"), DefaultDiscoveryOptions())
+	if err == nil || !strings.Contains(err.Error(), "only be provided once") {
+		t.Errorf("GetTemplateFilenamesFrom() with two markers error = %v, want the marker rejected", err)
+	}
+}
+
+func TestGetTemplateFilenamesDedupeAndSort(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "c.ini", "a.ini", "b.ini")
+	a, b, c := filepath.Join(dir, "a.ini"), filepath.Join(dir, "b.ini"), filepath.Join(dir, "c.ini")
+	stdin := devNull(t)
+
+	got, err := GetTemplateFilenamesFrom([]string{c, a, c, a}, stdin, DefaultDiscoveryOptions())
+	if err != nil {
+		t.Fatalf("GetTemplateFilenamesFrom() error = %v", err)
+	}
+	if want := []string{c, a}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTemplateFilenamesFrom() of duplicates = %q, want %q", got, want)
+	}
+
+	opts := DefaultDiscoveryOptions()
+	opts.Sort = true
+	for i := 0; i < 2; i++ {
+		got, err = GetTemplateFilenamesFrom([]string{c, filepath.Join(dir, "*.ini")}, stdin, opts)
+		if err != nil {
+			t.Fatalf("GetTemplateFilenamesFrom() error = %v", err)
+		}
+		if want := []string{a, b, c}; !reflect.DeepEqual(got, want) {
+			t.Errorf("GetTemplateFilenamesFrom() of a glob = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestValidateTemplateFilenames(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "ok.ini", "edit.ini")
+	ok, edit := filepath.Join(dir, "ok.ini"), filepath.Join(dir, "edit.ini")
+	missing, typo := filepath.Join(dir, "missing.ini"), filepath.Join(dir, "typo.ini")
+
+	if err := ValidateTemplateFilenames([]string{ok, edit + editFileSuffix, "https://example.com/api.ini"}); err != nil {
+		t.Errorf("ValidateTemplateFilenames() of usable templates error = %v", err)
+	}
+	err := ValidateTemplateFilenames([]string{ok, missing, dir, typo + editFileSuffix})
+	if err == nil {
+		t.Fatal("ValidateTemplateFilenames() of missing templates succeeded, want an error")
+	}
+	for _, problem := range []string{missing + ": file does not exist", dir + ": is a directory", typo + ": file does not exist"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("ValidateTemplateFilenames() error = %v, want it to list %q", err, problem)
+		}
+	}
+	if strings.Contains(err.Error(), ok) {
+		t.Errorf("ValidateTemplateFilenames() error = %v, want the existing template left out", err)
+	}
+}
+
+func TestGetTemplateFilenamesListFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.ini", "with space.ini", "b.ini")
+	a, spaced, b := filepath.Join(dir, "a.ini"), filepath.Join(dir, "with space.ini"), filepath.Join(dir, "b.ini")
+	list := filepath.Join(dir, "paths.txt")
+	contents := "# the user templates\n" + a + "\n\n   \n\"" + spaced + "\"\n  # disabled.ini\n"
+	if err := os.WriteFile(list, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := GetTemplateFilenamesFrom([]string{"@" + list, b}, devNull(t), DefaultDiscoveryOptions())
+	if err != nil {
+		t.Fatalf("GetTemplateFilenamesFrom() error = %v", err)
+	}
+	if want := []string{a, spaced, b}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTemplateFilenamesFrom() = %q, want %q", got, want)
+	}
+
+	nested := filepath.Join(dir, "nested.txt")
+	if err := os.WriteFile(nested, []byte(a+"\n@"+list+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = GetTemplateFilenamesFrom([]string{"@" + nested}, devNull(t), DefaultDiscoveryOptions())
+	if err == nil || !strings.Contains(err.Error(), "Nested list file") {
+		t.Errorf("GetTemplateFilenamesFrom() of a nested list file error = %v, want it rejected", err)
+	}
+}
+
+func TestGetTemplateFilenamesNoTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.ini")
+	a := filepath.Join(dir, "a.ini")
+	// The null device is a character device, as a terminal is
+	tests := map[string]struct {
+		args    []string
+		stdin   *os.File
+		want    []string
+		wantErr error
+	}{
+		"no args, terminal":   {nil, devNull(t), nil, ErrNoTemplates},
+		"no args, piped":      {nil, pipedStdin(t, a+"\n"), []string{a}, nil},
+		"no args, empty pipe": {nil, pipedStdin(t, ""), []string{}, nil},
+		"args, terminal":      {[]string{a}, devNull(t), []string{a}, nil},
+	}
+	for name, tt := range tests {
+		got, err := GetTemplateFilenamesFrom(tt.args, tt.stdin, DefaultDiscoveryOptions())
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: GetTemplateFilenamesFrom() error = %v, want %v", name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && len(got)+len(tt.want) > 0 && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: GetTemplateFilenamesFrom() = %q, want %q", name, got, tt.want)
+		}
+	}
+}
@@ -1,5 +1,14 @@
 package disk
 
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/pkg/errors"
+)
+
 // Defines the order of preference for backend tools used to execute HTTP requests.
 // This slice of strings specifies the priority in which different backend tools should be selected
 // when performing HTTP operations. The tools are listed in order of their priority, with the first
@@ -12,13 +21,14 @@ var backendPriorityOrder = []string{
 
 // Defines the template used to generate a starter configuration file for the program.
 // This template is used to create a new configuration file with default values and placeholders.
-// The template includes sections for the host, headers, query parameters, request body, and backend options.
+// The template includes sections for the host, headers, query parameters, request body, authentication
+// and backend options.
 // The user can fill in the specific values for each section to customize the configuration file.
 var starterTemplate = `[Host]
 http://localhost:${PORT}
 
 [Headers]
-Content-Type: application/json
+{{ Headers }}
 
 # [Query]
 # key1=value1&key2=value2
@@ -28,6 +38,114 @@ Content-Type: application/json
 #   "key": "value"
 # }
 
+# [Auth]
+# type = basic
+# user = ${USER}
+# password = ${PASSWORD}
+#
+# [Auth]
+# type = bearer
+# token = ${TOKEN}
+#
+# [Auth]
+# type = awsv4
+# region = us-east-1
+# service = execute-api
+#
+# [Auth]
+# type = oauth2
+# token_url = https://auth.example.com/oauth/token
+# client_id = ${CLIENT_ID}
+# client_secret = ${CLIENT_SECRET}
+
 [Backend]
 {{ Backends }}
 `
+
+const (
+	// backendsPlaceholder is replaced by the backend section contents when generating a template.
+	backendsPlaceholder = "{{ Backends }}"
+
+	// headersPlaceholder is replaced by the pre-seeded headers when generating a template.
+	headersPlaceholder = "{{ Headers }}"
+)
+
+// defaultTemplateHeaders are the headers pre-seeded in generated templates when no headers are
+// given in the TemplateOptions.
+var defaultTemplateHeaders = []string{
+	"Content-Type: application/json",
+}
+
+// TemplateOptions customizes the contents of a generated starter template.
+type TemplateOptions struct {
+	// Headers lists the header lines pre-seeded in the [Headers] section, such as
+	// `Accept: application/json` or a `User-Agent`. If nil, only `Content-Type: application/json`
+	// is added.
+	Headers []string
+
+	// NoDetectBackend, if true, fills the [Backend] section with the commented list of supported
+	// backends instead of the backend detected on this machine.
+	NoDetectBackend bool
+}
+
+// The function GenerateTemplate writes a starter template to the given writer. When customPath is
+// empty the built-in starter template is used, otherwise the template is read from customPath so
+// teams can share their own house-style template. In both cases the `{{ Headers }}` placeholder is
+// replaced by the pre-seeded headers and the `{{ Backends }}` placeholder by the backend detected
+// on this machine with DetectBackend. When no backend is installed, or the options set
+// NoDetectBackend, the supported backends are listed instead, in order of preference, commented
+// out so that the backend is detected automatically until one of them is uncommented. Without
+// options the generated template only seeds a JSON content type. The generated template starts
+// with the `# vortex-template vN` format version comment unless the custom template already
+// declares a version.
+//
+// Parameters:
+//   - w: The writer receiving the generated template.
+//   - customPath: The path of a custom starter template, or an empty string for the built-in one.
+//   - opts: Optional settings controlling the pre-seeded headers and backend. Only the first
+//     value is used.
+//
+// Returns:
+//   - An error if the custom template cannot be read or the template cannot be written.
+func GenerateTemplate(w io.Writer, customPath string, opts ...TemplateOptions) error {
+	var options TemplateOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	tmpl := starterTemplate
+	if customPath != "" {
+		contents, err := os.ReadFile(customPath)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to read the custom starter template: %s", customPath)
+		}
+		tmpl = string(contents)
+	}
+	if !strings.HasPrefix(tmpl, data.TemplateVersionPrefix) {
+		tmpl = data.TemplateVersionHeader() + "\n" + tmpl
+	}
+	headers := options.Headers
+	if headers == nil {
+		headers = defaultTemplateHeaders
+	}
+	tmpl = strings.ReplaceAll(tmpl, headersPlaceholder, strings.Join(headers, "\n"))
+	tmpl = strings.ReplaceAll(tmpl, backendsPlaceholder, renderBackends(!options.NoDetectBackend))
+	if _, err := io.WriteString(w, tmpl); err != nil {
+		return errors.Wrap(err, "Failed to write the starter template")
+	}
+	return nil
+}
+
+// renderBackends returns the contents of the [Backend] section of a generated template: the
+// detected backend when requested and available, otherwise the commented list of backends.
+func renderBackends(detect bool) string {
+	if detect {
+		if backend, err := DetectBackend(); err == nil {
+			return backend
+		}
+	}
+	backends := make([]string, 0, len(backendPriorityOrder))
+	for _, backend := range backendPriorityOrder {
+		backends = append(backends, "# "+backend)
+	}
+	return strings.Join(backends, "\n")
+}
@@ -0,0 +1,101 @@
+package disk
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+func TestGenerateTemplateDefaults(t *testing.T) {
+	fakePath(t, "wget")
+	var buf bytes.Buffer
+	if err := GenerateTemplate(&buf, ""); err != nil {
+		t.Fatalf("GenerateTemplate() error = %v", err)
+	}
+	got := buf.String()
+	if !strings.HasPrefix(got, data.TemplateVersionHeader()+"\n") {
+		t.Errorf("the template does not start with the format version:\n%s", got)
+	}
+	if !strings.Contains(got, "[Headers]\nContent-Type: application/json\n") {
+		t.Errorf("the template does not seed a JSON content type:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "[Backend]\nwget\n") {
+		t.Errorf("the template does not name the detected backend:\n%s", got)
+	}
+	if _, err := data.ParseTemplate("", strings.ReplaceAll(got, "${PORT}", "8080"), data.Config{}); err != nil {
+		t.Errorf("the generated template does not parse: %v", err)
+	}
+}
+
+func TestGenerateTemplateOptions(t *testing.T) {
+	fakePath(t, "curl")
+	var buf bytes.Buffer
+	opts := TemplateOptions{
+		Headers:         []string{"Accept: application/json", "User-Agent: vortex"},
+		NoDetectBackend: true,
+	}
+	if err := GenerateTemplate(&buf, "", opts); err != nil {
+		t.Fatalf("GenerateTemplate() error = %v", err)
+	}
+	got := buf.String()
+	if !strings.Contains(got, "[Headers]\nAccept: application/json\nUser-Agent: vortex\n") {
+		t.Errorf("the template does not seed the given headers:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "[Backend]\n# curl\n# httpie\n# wget\n") {
+		t.Errorf("the template does not list the supported backends:\n%s", got)
+	}
+
+	// Without any installed backend, the supported backends are listed
+	fakePath(t)
+	buf.Reset()
+	if err := GenerateTemplate(&buf, ""); err != nil {
+		t.Fatalf("GenerateTemplate() error = %v", err)
+	}
+	if !strings.HasSuffix(buf.String(), "[Backend]\n# curl\n# httpie\n# wget\n") {
+		t.Errorf("the template does not list the supported backends:\n%s", buf.String())
+	}
+}
+
+func TestGenerateTemplateCustomPath(t *testing.T) {
+	fakePath(t, "wget")
+	custom := filepath.Join(t.TempDir(), "house.ini")
+	if err := os.WriteFile(custom, []byte("[Host]\nhttps://api.example.com\n\n[Backend]\n{{ Backends }}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := GenerateTemplate(&buf, custom); err != nil {
+		t.Fatalf("GenerateTemplate() error = %v", err)
+	}
+	want := data.TemplateVersionHeader() + "\n[Host]\nhttps://api.example.com\n\n[Backend]\nwget\n"
+	if got := buf.String(); got != want {
+		t.Errorf("GenerateTemplate() = %q, want %q", got, want)
+	}
+	if err := GenerateTemplate(&buf, filepath.Join(t.TempDir(), "missing.ini")); err == nil {
+		t.Error("GenerateTemplate() of a missing custom template succeeded, want an error")
+	}
+}
+
+func TestGenerateTemplateCommentsAuth(t *testing.T) {
+	fakePath(t)
+	var buf bytes.Buffer
+	if err := GenerateTemplate(&buf, ""); err != nil {
+		t.Fatalf("GenerateTemplate() error = %v", err)
+	}
+	got := buf.String()
+	for _, example := range []string{"# [Auth]\n# type = basic\n", "# [Auth]\n# type = bearer\n# token = ${TOKEN}\n"} {
+		if !strings.Contains(got, example) {
+			t.Errorf("the template does not show the auth example %q:\n%s", example, got)
+		}
+	}
+	rc, err := data.ParseTemplate("", strings.ReplaceAll(got, "${PORT}", "8080"), data.Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if rc.Auth != nil {
+		t.Errorf("the generated template sets Auth = %+v, want the examples commented", rc.Auth)
+	}
+}
@@ -0,0 +1,49 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/pkg/errors"
+)
+
+// HeadersFileVariable is the environment variable overriding the path of the default headers file.
+const HeadersFileVariable = "VORTEX_HEADERS_FILE"
+
+// The function DefaultHeadersPath returns the path of the machine-wide default headers file: the
+// value of the VORTEX_HEADERS_FILE environment variable when it is set, otherwise
+// `vortex/headers.ini` in the user configuration directory, such as `~/.config/vortex/headers.ini`.
+//
+// Returns:
+//   - The path of the default headers file, which may not exist.
+//   - An error if the user configuration directory cannot be determined.
+func DefaultHeadersPath() (string, error) {
+	if path := os.Getenv(HeadersFileVariable); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to locate the default headers file")
+	}
+	return filepath.Join(dir, "vortex", "headers.ini"), nil
+}
+
+// The function LoadDefaultHeaders reads the `Name: value` header lines of the default headers file
+// located by DefaultHeadersPath, which are applied to every request with ApplyDefaultHeaders. A
+// missing file holds no default headers, and so does a missing user configuration directory, such
+// as when HOME is unset in a container.
+//
+// Returns:
+//   - The default header lines, or nil when there is no default headers file.
+//   - An error if the file exists but cannot be read or holds a malformed header line.
+func LoadDefaultHeaders() ([]string, error) {
+	path, err := DefaultHeadersPath()
+	if err != nil {
+		return nil, nil
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data.ReadHeadersFile(path)
+}
@@ -0,0 +1,36 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadDefaultHeaders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "headers.ini")
+	if err := os.WriteFile(path, []byte("# shared\nX-Team: api\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(HeadersFileVariable, path)
+	headers, err := LoadDefaultHeaders()
+	if err != nil {
+		t.Fatalf("LoadDefaultHeaders() error = %v", err)
+	}
+	if want := []string{"X-Team: api"}; !reflect.DeepEqual(headers, want) {
+		t.Errorf("LoadDefaultHeaders() = %q, want %q", headers, want)
+	}
+}
+
+func TestLoadDefaultHeadersWithoutConfigDir(t *testing.T) {
+	t.Setenv(HeadersFileVariable, "")
+	t.Setenv("HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if _, err := os.UserConfigDir(); err == nil {
+		t.Skip("the user configuration directory does not depend on HOME on this platform")
+	}
+	headers, err := LoadDefaultHeaders()
+	if err != nil || headers != nil {
+		t.Errorf("LoadDefaultHeaders() = %q, %v, want no default headers", headers, err)
+	}
+}
@@ -0,0 +1,21 @@
+package disk
+
+import (
+	"strings"
+)
+
+// OverrideList is a flag.Value collecting the `key=value` overrides of a repeated flag, such as
+// `-set`, in order, to be applied to the parsed templates with ApplyOverrides. The program
+// defining the flag registers it on its own flag set.
+type OverrideList []string
+
+// The method String returns the collected overrides, separated by commas.
+func (ol *OverrideList) String() string {
+	return strings.Join(*ol, ",")
+}
+
+// The method Set adds an override given to the flag.
+func (ol *OverrideList) Set(value string) error {
+	*ol = append(*ol, value)
+	return nil
+}
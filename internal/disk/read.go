@@ -5,7 +5,9 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/larayavrs/vortex/internal/data"
 	"github.com/larayavrs/vortex/pkg"
 	"github.com/pkg/errors"
 )
@@ -25,10 +27,63 @@ const (
 	fallbackEditor = "code"
 )
 
+// editorVariables are the environment variables naming the editor, in the order they are read.
+var editorVariables = []string{"VORTEX_EDITOR", "VISUAL", "EDITOR", "GIT_EDITOR"}
+
+// fallbackEditors are the editors looked up in the PATH when no editor variable is set, in order.
+// vi comes last as POSIX systems are expected to provide it.
+var fallbackEditors = []string{fallbackEditor, "vi"}
+
+// Interactive tells whether a user is present to edit the templates. When false, such as in
+// continuous integration, the `editFileSuffix` of a filename is ignored and the template is read
+// as is, instead of waiting forever for an editor to be closed. It defaults to whether stdin is a
+// terminal, so programs embedding vortex without a terminal never block on an editor or a prompt.
+var Interactive = stdinIsTerminal()
+
+// stdinIsTerminal reports whether the process standard input is a terminal.
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && (fi.Mode()&os.ModeCharDevice) != 0
+}
+
+// EditorConfig names the editor opening the templates, for programs embedding vortex that choose
+// the editor themselves instead of through the environment.
+type EditorConfig struct {
+	// Command is the editor executable. When empty, the editor is read from the environment, as
+	// described by CaptureEditorOutputWith.
+	Command string
+
+	// Args are the arguments passed to Command before the name of the edited file. They are ignored
+	// when Command is empty.
+	Args []string
+
+	// Verbose reports the chosen editor, and the variable it was read from, through the Logger.
+	Verbose bool
+
+	// Logger, if set, receives the verbose messages instead of the default Logger.
+	Logger data.Logger
+
+	// SaveEdits writes the edited content back to the template file, so the edits outlive the
+	// request. The template file is left untouched when the editor saved it unchanged.
+	SaveEdits bool
+}
+
+// EditedTemplate is the content of a template file once edited by EditTemplate.
+type EditedTemplate struct {
+	// Contents is the content of the template file after editing.
+	Contents string
+
+	// Changed tells whether the editor saved a content differing from the original one. Quitting the
+	// editor without changes, or saving the file as is, which only updates its modification time,
+	// leaves it false.
+	Changed bool
+}
+
 // Function CaptureEditorOutput captures and returns the content of a temporary file after it has been edited.
 // This function is used to open a temporary file in an external editor, allow the user to make edits,
 // and then read the updated content of the file once the editor is closed. The function reads the entire
 // content of the file and returns it as a string, along with any errors encountered during the process.
+// The editor is read from the environment, as described by CaptureEditorOutputWith.
 //
 // Parameters:
 //   - tempfile: A pointer to the `os.File` representing the temporary file that will be edited. This file
@@ -36,34 +91,45 @@ const (
 //
 // Returns:
 //   - A string containing the updated content of the temporary file after editing.
-//   - An error if there is an issue with opening the file, reading its content, or interacting with the editor.
+//   - An error if there is an issue with opening the file, reading its content, or interacting with the editor,
+//     or if the editor saved content that is not valid UTF-8, naming the file and the offset of the invalid byte.
 func CaptureEditorOutput(tempfile *os.File) (string, error) {
-	editorEnviromentVar := "VISUAL"
-	editorEnvStr := os.Getenv(editorEnviromentVar)
-	if editorEnvStr == "" {
-		editorEnviromentVar = "EDITOR"
-		editorEnvStr = os.Getenv(editorEnviromentVar)
-	}
-	if editorEnvStr == "" {
-		_, err := exec.LookPath(fallbackEditor)
-		if err != nil {
-			return "", errors.New("Could not find a suitable editor to open the file. Please set the VISUAL or EDITOR environment variable to specify an editor.")
-		}
-		editorEnviromentVar = fallbackEditor
-		editorEnvStr = fallbackEditor
-	}
-	editorCmdArgs, err := pkg.TokenizeLine(editorEnvStr)
+	return CaptureEditorOutputWith(EditorConfig{}, tempfile)
+}
+
+// Function CaptureEditorOutputWith captures and returns the content of a temporary file after it has been
+// edited with the editor of the EditorConfig. When the EditorConfig sets no Command, the editor command line
+// is read from the first non-empty variable among VORTEX_EDITOR, VISUAL, EDITOR and GIT_EDITOR, and when
+// none is set, the first of the fallback editors, `code` then `vi`, found in the PATH is used. The
+// environment is never consulted, nor modified, when the Command is set.
+//
+// Parameters:
+//   - cfg: The editor to run. A zero EditorConfig reads the editor from the environment.
+//   - tempfile: A pointer to the `os.File` representing the temporary file that will be edited. This file
+//     should already be created and accessible by the external editor.
+//
+// Returns:
+//   - A string containing the updated content of the temporary file after editing.
+//   - An error if no editor is available, if the editor fails, if the file cannot be read back, or if the
+//     editor saved content that is not valid UTF-8.
+func CaptureEditorOutputWith(cfg EditorConfig, tempfile *os.File) (string, error) {
+	editorSource, editorCmdArgs, err := resolveEditor(cfg)
 	if err != nil {
-		return "", errors.Wrapf(err, "Failed to parse editor command")
+		return "", err
 	}
+	return runEditor(editorSource, editorCmdArgs, tempfile)
+}
+
+// runEditor opens the tempfile with the editor command line and returns its content once edited.
+func runEditor(editorSource string, editorCmdArgs []string, tempfile *os.File) (string, error) {
 	editorArgs := append(editorCmdArgs[1:], tempfile.Name())
 	cmd := exec.Command(editorCmdArgs[0], editorArgs...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	err = cmd.Run()
+	err := cmd.Run()
 	if err != nil {
-		return "", errors.Wrapf(err, "Failed to run editor command %s %s", editorEnviromentVar, cmd.String())
+		return "", errors.Wrapf(err, "Failed to run editor command %s %s", editorSource, cmd.String())
 	}
 	_, err = tempfile.Seek(0, 0)
 	if err != nil {
@@ -73,13 +139,86 @@ func CaptureEditorOutput(tempfile *os.File) (string, error) {
 	if err != nil {
 		return "", errors.Wrap(err, "Failed to read the content of the file")
 	}
+	if err := checkUTF8(tempfile.Name(), tempfileContents); err != nil {
+		return "", err
+	}
 	return string(tempfileContents), nil
 }
 
+// resolveEditor returns where the editor comes from and its command line, the executable first.
+func resolveEditor(cfg EditorConfig) (string, []string, error) {
+	source, cmdline, err := lookupEditor(cfg)
+	if err != nil {
+		return "", nil, err
+	}
+	if cfg.Verbose {
+		data.Config{Logger: cfg.Logger}.Log().Log("Using editor", "source", source, "command", strings.Join(cmdline, " "))
+	}
+	return source, cmdline, nil
+}
+
+// findEditor resolves the editor and checks that its executable exists.
+func findEditor(cfg EditorConfig) (string, []string, error) {
+	editorSource, editorCmdArgs, err := resolveEditor(cfg)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := exec.LookPath(editorCmdArgs[0]); err != nil {
+		return "", nil, errors.Wrapf(err, "Could not find the editor %s set by %s", editorCmdArgs[0], editorSource)
+	}
+	return editorSource, editorCmdArgs, nil
+}
+
+// lookupEditor returns the editor of the EditorConfig, the environment or the fallback editors.
+func lookupEditor(cfg EditorConfig) (string, []string, error) {
+	if cfg.Command != "" {
+		return "EditorConfig", append([]string{cfg.Command}, cfg.Args...), nil
+	}
+	for _, editorEnviromentVar := range editorVariables {
+		editorEnvStr := os.Getenv(editorEnviromentVar)
+		if editorEnvStr == "" {
+			continue
+		}
+		editorCmdArgs, err := pkg.TokenizeLine(editorEnvStr)
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "Failed to parse editor command")
+		}
+		if len(editorCmdArgs) == 0 {
+			return "", nil, errors.Errorf("The %s environment variable holds no editor command", editorEnviromentVar)
+		}
+		return editorEnviromentVar, editorCmdArgs, nil
+	}
+	for _, editor := range fallbackEditors {
+		if _, err := exec.LookPath(editor); err == nil {
+			return editor, []string{editor}, nil
+		}
+	}
+	return "", nil, errors.Errorf("Could not find a suitable editor to open the file. Please set one of the %s environment variables to specify an editor.", strings.Join(editorVariables, ", "))
+}
+
+// checkUTF8 returns an error naming the file and the byte offset of the first invalid sequence when
+// the contents are not valid UTF-8, such as a file saved by an editor in a legacy encoding.
+func checkUTF8(name string, contents []byte) error {
+	if utf8.Valid(contents) {
+		return nil
+	}
+	offset := 0
+	for offset < len(contents) {
+		r, size := utf8.DecodeRune(contents[offset:])
+		if r == utf8.RuneError && size <= 1 {
+			break
+		}
+		offset += size
+	}
+	return errors.Errorf("The edited file %s is not valid UTF-8 (invalid byte 0x%02x at offset %d), please save it with the UTF-8 encoding", name, contents[offset], offset)
+}
+
 // Function LoadEditedTemplateContent reads and returns the content of a template file after it has been edited.
 // This function is designed to load a template file, open it in an external editor for the user to make
 // changes, and then read the modified content once the editing process is complete. The function handles
 // the entire lifecycle, from opening the file in the editor to capturing and returning the updated content.
+// The editor is resolved and looked up in the PATH before anything else, so no temporary file is created
+// when it is missing.
 //
 // Parameters:
 //   - srcTemp: The name of the template file that will be opened and edited. This should be
@@ -87,20 +226,61 @@ func CaptureEditorOutput(tempfile *os.File) (string, error) {
 //
 // Returns:
 //   - A string containing the edited content of the template file.
-//   - An error if there is an issue with opening the file, launching the editor, or reading the updated content.
+//   - An error if no editor is found, or if there is an issue with opening the file, launching the editor, or
+//     reading the updated content.
 func LoadEditedTemplateContent(srcTemp string) (string, error) {
-	rawTemplate, err := os.Open(srcTemp)
+	return LoadEditedTemplateContentWith(EditorConfig{}, srcTemp)
+}
+
+// Function LoadEditedTemplateContentWith reads and returns the content of a template file after it has been
+// edited with the editor of the EditorConfig, as described by LoadEditedTemplateContent.
+//
+// Parameters:
+//   - cfg: The editor to run. A zero EditorConfig reads the editor from the environment.
+//   - srcTemp: The name of the template file that will be opened and edited.
+//
+// Returns:
+//   - A string containing the edited content of the template file.
+//   - An error if no editor is found, or if there is an issue with opening the file, launching the editor, or
+//     reading the updated content.
+func LoadEditedTemplateContentWith(cfg EditorConfig, srcTemp string) (string, error) {
+	edited, err := EditTemplate(cfg, srcTemp)
+	return edited.Contents, err
+}
+
+// The function EditTemplate opens a copy of a template file with the editor of the EditorConfig,
+// as described by LoadEditedTemplateContent, and compares the saved content with the original one.
+// When the content changed and the EditorConfig sets SaveEdits, it is written back to the template
+// file. A content byte-identical to the original is never written back.
+//
+// Parameters:
+//   - cfg: The editor to run. A zero EditorConfig reads the editor from the environment.
+//   - srcTemp: The name of the template file that will be opened and edited.
+//
+// Returns:
+//   - The EditedTemplate holding the edited content and whether it changed.
+//   - An error if no editor is found, or if there is an issue with reading the file, launching the editor,
+//     reading the updated content, or saving it back.
+func EditTemplate(cfg EditorConfig, srcTemp string) (edited EditedTemplate, err error) {
+	// The editor is checked first, so nothing is copied when it cannot be run
+	editorSource, editorCmdArgs, err := findEditor(cfg)
 	if err != nil {
-		return "", errors.Wrapf(err, "Cannot open the template file: %s", srcTemp)
+		return EditedTemplate{}, err
+	}
+
+	original, err := os.ReadFile(srcTemp)
+	if err != nil {
+		return EditedTemplate{}, errors.Wrapf(err, "Cannot open the template file: %s", srcTemp)
 	}
 
 	// Ini format is not supported by the editor, so we need to convert it to a supported format before editing
 	tempFile, err := os.CreateTemp("", "vtx*.ini")
 	if err != nil {
-		return "", errors.Wrap(err, "Failed to create a temporary file")
+		return EditedTemplate{}, errors.Wrap(err, "Failed to create a temporary file")
 	}
 
 	defer func() {
+		tempFile.Close()
 		if rmvErr := os.Remove(tempFile.Name()); rmvErr != nil {
 			wrappedRmvErr := errors.Wrapf(rmvErr, "Failed to remove the temporary file: %s", tempFile.Name())
 			if err == nil {
@@ -111,19 +291,45 @@ func LoadEditedTemplateContent(srcTemp string) (string, error) {
 		}
 	}()
 
-	_, err = io.Copy(tempFile, rawTemplate)
+	if _, err = tempFile.Write(original); err != nil {
+		return EditedTemplate{}, errors.Wrap(err, "Failed to copy the template file to the temporary file")
+	}
+
+	contents, err := runEditor(editorSource, editorCmdArgs, tempFile)
 	if err != nil {
-		return "", errors.Wrap(err, "Failed to copy the template file to the temporary file")
+		return EditedTemplate{}, err
+	}
+	edited = EditedTemplate{Contents: contents, Changed: contents != string(original)}
+	if edited.Changed && cfg.SaveEdits {
+		if err = saveEditedTemplate(srcTemp, contents); err != nil {
+			return EditedTemplate{}, err
+		}
 	}
+	return edited, nil
+}
 
-	return CaptureEditorOutput(tempFile)
+// saveEditedTemplate writes the edited content back to the template file, keeping its permissions.
+func saveEditedTemplate(srcTemp, contents string) error {
+	info, err := os.Stat(srcTemp)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to save the edits to the template file: %s", srcTemp)
+	}
+	if err := os.WriteFile(srcTemp, []byte(contents), info.Mode().Perm()); err != nil {
+		return errors.Wrapf(err, "Failed to save the edits to the template file: %s", srcTemp)
+	}
+	return nil
 }
 
 // The function ReadRawTemplateString reads the raw content of a template file and optionally allows for its editing.
 // This function checks if the provided filename has a specific suffix indicating that the file should
 // be opened in an editor for editing. If the suffix is present, it trims the suffix and opens the file
 // in the editor, returning the modified content. If the suffix is not present, it reads the content of
-// the file directly from the filesystem.
+// the file directly from the filesystem. A leading UTF-8 byte order mark is removed in both cases.
+// When Interactive is false, the suffix is trimmed and the file is read without opening the editor,
+// which is logged through the default Logger. An `http://` or `https://` filename is downloaded with
+// FetchRemoteTemplate, and a temporary copy of it is opened in the editor when it carries the suffix.
+// A template archive is refused, since GetTemplateFilenames replaces it by its templates, whose
+// contents are read back from the archive in memory without opening the editor.
 //
 // Parameters:
 //   - tmpFilename: The name of the template file to read. If the filename ends with `editFileSuffix`,
@@ -133,8 +339,37 @@ func LoadEditedTemplateContent(srcTemp string) (string, error) {
 //   - A string containing the raw or edited content of the template file.
 //   - An error if there is an issue reading the file or loading the edited content.
 func ReadRawTemplateString(tmpFilename string) (string, error) {
+	return ReadRawTemplateStringWith(EditorConfig{}, tmpFilename)
+}
+
+// The function ReadRawTemplateStringWith reads the raw content of a template file like
+// ReadRawTemplateString, opening the files carrying the edit suffix with the editor of the
+// EditorConfig.
+//
+// Parameters:
+//   - cfg: The editor to run. A zero EditorConfig reads the editor from the environment.
+//   - tmpFilename: The name of the template file to read.
+//
+// Returns:
+//   - A string containing the raw or edited content of the template file.
+//   - An error if there is an issue reading the file or loading the edited content.
+func ReadRawTemplateStringWith(cfg EditorConfig, tmpFilename string) (string, error) {
+	if data.IsRemoteTemplate(tmpFilename) {
+		return readRemoteTemplate(cfg, tmpFilename)
+	}
+	if IsTemplateArchive(strings.TrimSuffix(tmpFilename, editFileSuffix)) {
+		return "", errors.Errorf("%s is a template archive, its templates are listed by GetTemplateFilenames", tmpFilename)
+	}
+	if contents, ok, err := readArchiveTemplate(strings.TrimSuffix(tmpFilename, editFileSuffix)); ok {
+		return contents, err
+	}
 	if strings.HasSuffix(tmpFilename, editFileSuffix) {
-		return LoadEditedTemplateContent(strings.TrimSuffix(tmpFilename, editFileSuffix))
+		tmpFilename = strings.TrimSuffix(tmpFilename, editFileSuffix)
+		if Interactive {
+			contents, err := LoadEditedTemplateContentWith(cfg, tmpFilename)
+			return data.TrimBOM(contents), err
+		}
+		data.Config{}.Log().Log("Skipping the editor in non-interactive mode", "file", tmpFilename)
 	}
 
 	fcontents, err := os.ReadFile(tmpFilename)
@@ -142,5 +377,5 @@ func ReadRawTemplateString(tmpFilename string) (string, error) {
 		return "", errors.Wrapf(err, "Failed to read the file: %s", tmpFilename)
 	}
 
-	return string(fcontents), nil
+	return data.TrimBOM(string(fcontents)), nil
 }
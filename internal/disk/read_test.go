@@ -0,0 +1,247 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+func TestReadRawTemplateStringEditSuffix(t *testing.T) {
+	interactive := Interactive
+	t.Cleanup(func() { Interactive = interactive })
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "get.ini")
+	if err := os.WriteFile(filename, []byte("[Host]\nhttps://example.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// The editor replaces the template with a request to another host
+	editor := filepath.Join(dir, "editor.sh")
+	script := "#!/bin/sh\nprintf '[Host]\\nhttps://edited.example.com\\n' > \"$1\"\n"
+	if err := os.WriteFile(editor, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := EditorConfig{Command: editor}
+
+	Interactive = true
+	got, err := ReadRawTemplateStringWith(cfg, filename+editFileSuffix)
+	if err != nil {
+		t.Fatalf("ReadRawTemplateStringWith() error = %v", err)
+	}
+	if want := "[Host]\nhttps://edited.example.com\n"; got != want {
+		t.Errorf("ReadRawTemplateStringWith() in interactive mode = %q, want the edited %q", got, want)
+	}
+
+	Interactive = false
+	got, err = ReadRawTemplateStringWith(EditorConfig{Command: "false"}, filename+editFileSuffix)
+	if err != nil {
+		t.Fatalf("ReadRawTemplateStringWith() error = %v", err)
+	}
+	if want := "[Host]\nhttps://example.com\n"; got != want {
+		t.Errorf("ReadRawTemplateStringWith() in non-interactive mode = %q, want the file %q", got, want)
+	}
+}
+
+func TestLookupEditorVariables(t *testing.T) {
+	for _, variable := range editorVariables {
+		t.Run(variable, func(t *testing.T) {
+			for _, other := range editorVariables {
+				t.Setenv(other, "")
+			}
+			t.Setenv(variable, "my-editor --wait")
+			source, cmdline, err := lookupEditor(EditorConfig{})
+			if err != nil {
+				t.Fatalf("lookupEditor() error = %v", err)
+			}
+			if want := []string{"my-editor", "--wait"}; source != variable || !reflect.DeepEqual(cmdline, want) {
+				t.Errorf("lookupEditor() = %s, %q, want %s, %q", source, cmdline, variable, want)
+			}
+		})
+	}
+	t.Setenv("VISUAL", "visual")
+	t.Setenv("GIT_EDITOR", "git-editor")
+	if source, _, _ := lookupEditor(EditorConfig{}); source != "VISUAL" {
+		t.Errorf("lookupEditor() = %s, want the first set variable VISUAL", source)
+	}
+	if source, cmdline, _ := lookupEditor(EditorConfig{Command: "nano", Args: []string{"-w"}}); source != "EditorConfig" || !reflect.DeepEqual(cmdline, []string{"nano", "-w"}) {
+		t.Errorf("lookupEditor() = %s, %q, want the command of the EditorConfig", source, cmdline)
+	}
+}
+
+func TestReadRawTemplateStringStripsBOM(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "windows.ini")
+	if err := os.WriteFile(filename, []byte("\ufeff[Host]\r\nhttps://example.com\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadRawTemplateString(filename)
+	if err != nil {
+		t.Fatalf("ReadRawTemplateString() error = %v", err)
+	}
+	if want := "[Host]\r\nhttps://example.com\r\n"; got != want {
+		t.Errorf("ReadRawTemplateString() = %q, want %q", got, want)
+	}
+	rc, err := data.ParseTemplate(filename, got, data.Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if rc.Host.String() != "https://example.com" {
+		t.Errorf("Host = %v, want https://example.com", rc.Host)
+	}
+}
+
+// writeEditor writes an editor running the shell script, which receives the edited file as $1, and
+// returns its path.
+func writeEditor(t *testing.T, script string) string {
+	t.Helper()
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return editor
+}
+
+func TestCaptureEditorOutputInvalidUTF8(t *testing.T) {
+	tests := map[string]struct {
+		script, want string
+	}{
+		"latin-1": {`printf 'caf\351' > "$1"`, "invalid byte 0xe9 at offset 3"},
+		"utf-8":   {`printf 'caf\303\251' > "$1"`, ""},
+	}
+	for name, tt := range tests {
+		tempfile, err := os.CreateTemp(t.TempDir(), "vtx*.ini")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := CaptureEditorOutputWith(EditorConfig{Command: writeEditor(t, tt.script)}, tempfile)
+		tempfile.Close()
+		if tt.want == "" {
+			if err != nil || got != "café" {
+				t.Errorf("%s: CaptureEditorOutputWith() = %q, %v, want %q", name, got, err, "café")
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), tempfile.Name()) {
+			t.Errorf("%s: CaptureEditorOutputWith() error = %v, want the file name and %q", name, err, tt.want)
+		}
+	}
+}
+
+func TestCaptureEditorOutputWith(t *testing.T) {
+	for _, variable := range editorVariables {
+		t.Setenv(variable, "")
+	}
+	// The editor writes the two arguments preceding the edited file to it
+	explicit := writeEditor(t, `printf 'explicit %s %s' "$1" "$2" > "$3"`)
+	env := writeEditor(t, `printf 'environment' > "$1"`)
+	t.Setenv("VISUAL", env)
+	tests := map[string]struct {
+		cfg  EditorConfig
+		want string
+	}{
+		"explicit editor": {EditorConfig{Command: explicit, Args: []string{"--wait", "-n"}}, "explicit --wait -n"},
+		"env fallback":    {EditorConfig{}, "environment"},
+		"args only":       {EditorConfig{Args: []string{"--wait"}}, "environment"},
+	}
+	for name, tt := range tests {
+		tempfile, err := os.CreateTemp(t.TempDir(), "vtx*.ini")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := CaptureEditorOutputWith(tt.cfg, tempfile)
+		tempfile.Close()
+		if err != nil {
+			t.Errorf("%s: CaptureEditorOutputWith() error = %v", name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: CaptureEditorOutputWith() = %q, want %q", name, got, tt.want)
+		}
+	}
+	if os.Getenv("VISUAL") != env {
+		t.Errorf("CaptureEditorOutputWith() modified VISUAL to %q", os.Getenv("VISUAL"))
+	}
+}
+
+func TestEditTemplateWithoutEditor(t *testing.T) {
+	// Neither the template nor the temporary directory exist, so an error about the editor shows it
+	// is checked before the template is read and the temporary file created
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", filepath.Join(dir, "tmp"))
+	t.Setenv("PATH", dir)
+	for _, variable := range editorVariables {
+		t.Setenv(variable, "")
+	}
+	tests := map[string]struct {
+		cfg  EditorConfig
+		want string
+	}{
+		"none configured": {EditorConfig{}, "Could not find a suitable editor"},
+		"missing command": {EditorConfig{Command: "no-such-editor"}, "Could not find the editor no-such-editor"},
+	}
+	for name, tt := range tests {
+		_, err := LoadEditedTemplateContentWith(tt.cfg, filepath.Join(dir, "get.ini"))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: LoadEditedTemplateContentWith() error = %v, want %q", name, err, tt.want)
+		}
+	}
+}
+
+func TestEditTemplateChanged(t *testing.T) {
+	const original = "[Host]\nhttps://example.com\n"
+	tests := map[string]struct {
+		script, contents string
+		changed          bool
+	}{
+		"quit":          {"exit 0\n", original, false},
+		"mtime only":    {`touch "$1"` + "\n", original, false},
+		"saved as is":   {`printf '[Host]\nhttps://example.com\n' > "$1"` + "\n", original, false},
+		"edited":        {`printf '[Host]\nhttps://edited.example.com\n' > "$1"` + "\n", "[Host]\nhttps://edited.example.com\n", true},
+		"newline added": {`printf '\n' >> "$1"` + "\n", original + "\n", true},
+	}
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for name, tt := range tests {
+		for _, save := range []bool{false, true} {
+			template := filepath.Join(t.TempDir(), "get.ini")
+			if err := os.WriteFile(template, []byte(original), 0o640); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(template, past, past); err != nil {
+				t.Fatal(err)
+			}
+			edited, err := EditTemplate(EditorConfig{Command: writeEditor(t, tt.script), SaveEdits: save}, template)
+			if err != nil {
+				t.Errorf("%s, save %v: EditTemplate() error = %v", name, save, err)
+				continue
+			}
+			if edited.Contents != tt.contents || edited.Changed != tt.changed {
+				t.Errorf("%s, save %v: EditTemplate() = %q, changed %v, want %q, changed %v", name, save, edited.Contents, edited.Changed, tt.contents, tt.changed)
+			}
+			written := tt.changed && save
+			contents, err := os.ReadFile(template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := original
+			if written {
+				want = tt.contents
+			}
+			if string(contents) != want {
+				t.Errorf("%s, save %v: the template holds %q, want %q", name, save, contents, want)
+			}
+			info, err := os.Stat(template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rewritten := !info.ModTime().Equal(past); rewritten != written {
+				t.Errorf("%s, save %v: the template was written: %v, want %v", name, save, rewritten, written)
+			}
+			if info.Mode().Perm() != 0o640 {
+				t.Errorf("%s, save %v: the template mode = %v, want it kept", name, save, info.Mode().Perm())
+			}
+		}
+	}
+}
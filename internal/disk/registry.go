@@ -0,0 +1,99 @@
+package disk
+
+import (
+	"context"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/pkg/errors"
+)
+
+// BackendArgsBuilder builds the command line arguments of a backend for a request, like the
+// BuildCurlArgs function of the curl backend.
+type BackendArgsBuilder func(*data.RequestConfig, data.Config) ([]string, error)
+
+// BackendExecutor runs a request with the arguments built by the BackendArgsBuilder of a custom
+// backend, instead of running its executable, for tools driven in-process.
+type BackendExecutor func(ctx context.Context, args []string, rc *data.RequestConfig) (data.RequestResult, error)
+
+// customBackend is a backend registered with RegisterBackend.
+type customBackend struct {
+	builder  BackendArgsBuilder
+	executor BackendExecutor
+}
+
+// customBackends holds the backends registered with RegisterBackend, by name.
+var customBackends = map[string]*customBackend{}
+
+// The function RegisterBackend registers a custom backend, such as an in-house HTTP tool, so it can
+// be named in the [Backend] section and VORTEX_BACKEND like the built-in ones. The backend is
+// appended to backendPriorityOrder, after the built-in backends, so DetectBackend picks it when
+// none of them is installed. The request is executed by running the executable of the same name
+// with the arguments built by the builder, its standard output being the response body, unless an
+// executor is registered with RegisterBackendExecutor. Backends must be registered before any
+// request is executed, usually from an init function, as the registry is not safe for concurrent
+// use.
+//
+// Parameters:
+//   - name: The name of the backend, made of letters, digits, `-` and `_`.
+//   - builder: The function building the arguments of the executable for a request.
+//
+// Returns:
+//   - An error if the name is invalid or already taken by another backend, or the builder is nil.
+func RegisterBackend(name string, builder BackendArgsBuilder) error {
+	if name == "" {
+		return errors.New("A custom backend must have a name")
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return errors.Errorf("Invalid backend name %q, expected letters, digits, - and _", name)
+		}
+	}
+	if ValidateBackend(name) == nil {
+		return errors.Errorf("The backend %q is already registered", name)
+	}
+	if builder == nil {
+		return errors.Errorf("The custom backend %q has no argument builder", name)
+	}
+	customBackends[name] = &customBackend{builder: builder}
+	backendPriorityOrder = append(backendPriorityOrder, name)
+	return nil
+}
+
+// The function RegisterBackendExecutor sets the executor of a custom backend registered with
+// RegisterBackend. The executor receives the arguments built for the request and returns its
+// result, so the backend runs without an executable in the PATH.
+//
+// Parameters:
+//   - name: The name of the custom backend.
+//   - executor: The function executing the requests of the backend.
+//
+// Returns:
+//   - An error if the backend is not a registered custom backend, or the executor is nil.
+func RegisterBackendExecutor(name string, executor BackendExecutor) error {
+	custom, ok := customBackends[name]
+	if !ok {
+		return errors.Errorf("The backend %q is not a registered custom backend", name)
+	}
+	if executor == nil {
+		return errors.Errorf("The custom backend %q has no executor", name)
+	}
+	custom.executor = executor
+	return nil
+}
+
+// The function CustomBackend returns the argument builder and the executor, which may be nil, of
+// a backend registered with RegisterBackend.
+//
+// Parameters:
+//   - name: The name of the backend.
+//
+// Returns:
+//   - The argument builder and the executor of the backend.
+//   - True if the backend is a registered custom backend, false otherwise.
+func CustomBackend(name string) (BackendArgsBuilder, BackendExecutor, bool) {
+	custom, ok := customBackends[name]
+	if !ok {
+		return nil, nil, false
+	}
+	return custom.builder, custom.executor, true
+}
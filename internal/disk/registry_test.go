@@ -0,0 +1,105 @@
+package disk
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+// isolateRegistry restores the registered backends and their priority once the test ends.
+func isolateRegistry(t *testing.T) {
+	t.Helper()
+	order := append([]string(nil), backendPriorityOrder...)
+	custom := make(map[string]*customBackend, len(customBackends))
+	for name, backend := range customBackends {
+		custom[name] = backend
+	}
+	t.Cleanup(func() {
+		backendPriorityOrder = order
+		customBackends = custom
+	})
+}
+
+// fakeArgs builds the arguments of the fake backend: the method and the URL of the request.
+func fakeArgs(rc *data.RequestConfig, _ data.Config) ([]string, error) {
+	return []string{rc.Method, rc.Host.String()}, nil
+}
+
+func TestRegisterBackendValidation(t *testing.T) {
+	isolateRegistry(t)
+	if err := RegisterBackend("fake-tool_2", fakeArgs); err != nil {
+		t.Fatalf("RegisterBackend() error = %v", err)
+	}
+	tests := map[string]struct {
+		name    string
+		builder BackendArgsBuilder
+	}{
+		"empty name":   {"", fakeArgs},
+		"invalid name": {"fake tool", fakeArgs},
+		"built-in":     {"curl", fakeArgs},
+		"native":       {data.NativeBackend, fakeArgs},
+		"duplicate":    {"fake-tool_2", fakeArgs},
+		"nil builder":  {"other-tool", nil},
+	}
+	for name, tt := range tests {
+		if err := RegisterBackend(tt.name, tt.builder); err == nil {
+			t.Errorf("%s: RegisterBackend(%q) succeeded, want an error", name, tt.name)
+		}
+	}
+	if err := RegisterBackendExecutor("unknown-tool", func(context.Context, []string, *data.RequestConfig) (data.RequestResult, error) {
+		return data.RequestResult{}, nil
+	}); err == nil {
+		t.Error("RegisterBackendExecutor() of an unregistered backend succeeded, want an error")
+	}
+	if err := RegisterBackendExecutor("fake-tool_2", nil); err == nil {
+		t.Error("RegisterBackendExecutor() with a nil executor succeeded, want an error")
+	}
+}
+
+func TestRegisterBackendDetectionAndArgs(t *testing.T) {
+	isolateRegistry(t)
+	if err := RegisterBackend("fake-tool", fakeArgs); err != nil {
+		t.Fatalf("RegisterBackend() error = %v", err)
+	}
+	if err := ValidateBackend("fake-tool"); err != nil {
+		t.Errorf("ValidateBackend() error = %v", err)
+	}
+
+	// The custom backend comes after the built-in ones, and is detected by its executable
+	fakePath(t, "fake-tool")
+	if got, err := DetectBackend(); err != nil || got != "fake-tool" {
+		t.Errorf("DetectBackend() = %q, %v, want fake-tool", got, err)
+	}
+	fakePath(t, "fake-tool", "wget")
+	if got, err := DetectBackend(); err != nil || got != "wget" {
+		t.Errorf("DetectBackend() = %q, %v, want wget first", got, err)
+	}
+
+	// An executor makes the backend available without an executable
+	fakePath(t)
+	if _, err := DetectBackend(); err == nil {
+		t.Error("DetectBackend() without executables succeeded, want an error")
+	}
+	executor := func(context.Context, []string, *data.RequestConfig) (data.RequestResult, error) {
+		return data.RequestResult{}, nil
+	}
+	if err := RegisterBackendExecutor("fake-tool", executor); err != nil {
+		t.Fatalf("RegisterBackendExecutor() error = %v", err)
+	}
+	if got, err := DetectBackend(); err != nil || got != "fake-tool" {
+		t.Errorf("DetectBackend() = %q, %v, want fake-tool run by its executor", got, err)
+	}
+
+	builder, gotExecutor, ok := CustomBackend("fake-tool")
+	if !ok || builder == nil || gotExecutor == nil {
+		t.Fatalf("CustomBackend() = %v, %v, %v, want the builder and the executor", builder != nil, gotExecutor != nil, ok)
+	}
+	rc := &data.RequestConfig{Method: "GET", Host: &url.URL{Scheme: "https", Host: "example.com", Path: "/users"}}
+	args, err := builder(rc, data.Config{})
+	if want := []string{"GET", "https://example.com/users"}; err != nil || !reflect.DeepEqual(args, want) {
+		t.Errorf("builder() = %q, %v, want %q", args, err, want)
+	}
+}
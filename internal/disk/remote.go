@@ -0,0 +1,83 @@
+package disk
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/pkg/errors"
+)
+
+// RemoteTemplateTimeout limits the time spent fetching a remote template, see FetchRemoteTemplate.
+// A zero value fetches without a timeout.
+var RemoteTemplateTimeout = 30 * time.Second
+
+// MaxRemoteTemplateBytes limits the size of a remote template, see FetchRemoteTemplate.
+var MaxRemoteTemplateBytes int64 = 1 << 20
+
+// The function FetchRemoteTemplate downloads the template served at the URL with the Go HTTP
+// client, such as a template shared on a Git web host. The proxy is read from the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment variables and the download is limited by
+// RemoteTemplateTimeout and MaxRemoteTemplateBytes. The template is parsed under its URL, so its
+// references to local files are refused, as described by data.IsRemoteTemplate.
+//
+// Parameters:
+//   - rawURL: The URL of the template.
+//
+// Returns:
+//   - The contents of the template, without its byte order mark.
+//   - An error if the template cannot be downloaded, is larger than MaxRemoteTemplateBytes, or the
+//     server responds with a status other than 200 OK.
+func FetchRemoteTemplate(rawURL string) (string, error) {
+	client := &http.Client{
+		Transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
+		Timeout:   RemoteTemplateTimeout,
+	}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to fetch the remote template: %s", rawURL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("Failed to fetch the remote template %s, the server returned HTTP status %d", rawURL, resp.StatusCode)
+	}
+	contents, err := io.ReadAll(io.LimitReader(resp.Body, MaxRemoteTemplateBytes+1))
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to read the remote template: %s", rawURL)
+	}
+	if int64(len(contents)) > MaxRemoteTemplateBytes {
+		return "", errors.Errorf("The remote template %s is larger than %d bytes", rawURL, MaxRemoteTemplateBytes)
+	}
+	return data.TrimBOM(string(contents)), nil
+}
+
+// readRemoteTemplate fetches a remote template and, when the argument carries the edit suffix in
+// interactive mode, opens a temporary copy of it with the editor of the EditorConfig.
+func readRemoteTemplate(cfg EditorConfig, name string) (string, error) {
+	rawURL := strings.TrimSuffix(name, editFileSuffix)
+	contents, err := FetchRemoteTemplate(rawURL)
+	if err != nil || rawURL == name {
+		return contents, err
+	}
+	if !Interactive {
+		data.Config{}.Log().Log("Skipping the editor in non-interactive mode", "file", rawURL)
+		return contents, nil
+	}
+	tempFile, err := os.CreateTemp("", "vtx-remote*.ini")
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to create a temporary file")
+	}
+	defer os.Remove(tempFile.Name())
+	_, err = tempFile.WriteString(contents)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to copy the remote template to the temporary file")
+	}
+	edited, err := LoadEditedTemplateContentWith(cfg, tempFile.Name())
+	return data.TrimBOM(edited), err
+}
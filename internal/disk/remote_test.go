@@ -0,0 +1,37 @@
+package disk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchRemoteTemplate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/get.ini":
+			w.Write([]byte("\xef\xbb\xbf[Host]\nhttps://example.com\n"))
+		case "/large.ini":
+			w.Write([]byte(strings.Repeat("#", int(MaxRemoteTemplateBytes)+1)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	contents, err := FetchRemoteTemplate(server.URL + "/get.ini")
+	if err != nil {
+		t.Fatalf("FetchRemoteTemplate() error = %v", err)
+	}
+	if want := "[Host]\nhttps://example.com\n"; contents != want {
+		t.Errorf("FetchRemoteTemplate() = %q, want %q", contents, want)
+	}
+
+	if _, err := FetchRemoteTemplate(server.URL + "/missing.ini"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("FetchRemoteTemplate() of a missing template error = %v, want the 404 status", err)
+	}
+	if _, err := FetchRemoteTemplate(server.URL + "/large.ini"); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("FetchRemoteTemplate() of a large template error = %v, want the size limit", err)
+	}
+}
@@ -0,0 +1,46 @@
+package disk
+
+import (
+	"os"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/pkg/errors"
+)
+
+// The function EditLastResponse opens the last response, as recorded by SaveLastResponse when the
+// configuration sets KeepLastResponse, in the editor of the EditorConfig for inspection. The body
+// is formatted and written to a temporary file whose extension matches its content type, as
+// described by EditableStdout, so the editor highlights it. The temporary file is removed once the
+// editor is closed, discarding the edits.
+//
+// Parameters:
+//   - cfg: The editor to run. A zero EditorConfig reads the editor from the environment.
+//
+// Returns:
+//   - An error if no response was recorded, or the temporary file or the editor fails.
+func EditLastResponse(cfg EditorConfig) error {
+	result, err := data.LoadLastResponse()
+	if err != nil {
+		return err
+	}
+	body, ext, err := result.EditableStdout()
+	if err != nil {
+		return err
+	}
+	// The editor is checked first, so no temporary file is left behind when it cannot be run
+	editorSource, editorCmdArgs, err := findEditor(cfg)
+	if err != nil {
+		return err
+	}
+	tempFile, err := os.CreateTemp("", "vtx-response*"+ext)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create a temporary file")
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+	if _, err := tempFile.WriteString(body); err != nil {
+		return errors.Wrapf(err, "Failed to write the response to %s", tempFile.Name())
+	}
+	_, err = runEditor(editorSource, editorCmdArgs, tempFile)
+	return err
+}
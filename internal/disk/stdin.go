@@ -0,0 +1,52 @@
+package disk
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/larayavrs/vortex/internal/data"
+	"github.com/pkg/errors"
+)
+
+// Stdin is the reader the request bodies declared with a `-` [Body] line are typed on. It can be
+// replaced by programs embedding vortex and by tests.
+var Stdin io.Reader = os.Stdin
+
+// The function ReadStdinBody reads the body of a RequestConfig whose template declares it with a
+// single `-` [Body] line, from the given reader until EOF, usually Stdin. The body is only read in
+// interactive mode and when stdin is a terminal, so it never competes with template filenames
+// piped through stdin. The RequestConfig no longer reads from stdin afterwards, so executing it
+// again sends the same body. Nothing is done for other requests.
+//
+// Parameters:
+//   - rc: The request configuration whose body is read.
+//   - stdin: The reader the body is typed on.
+//
+// Returns:
+//   - An error if the mode is not interactive, stdin is not a terminal, or reading it fails.
+func ReadStdinBody(rc *data.RequestConfig, stdin io.Reader) error {
+	if !rc.BodyFromStdin {
+		return nil
+	}
+	if !Interactive {
+		return errors.New("The request body is read from stdin, which requires the interactive mode")
+	}
+	if file, ok := stdin.(*os.File); ok {
+		if fi, err := file.Stat(); err == nil && (fi.Mode()&os.ModeCharDevice) == 0 {
+			return errors.New("The request body is read from stdin, which must be a terminal")
+		}
+	}
+	data.Config{}.Log().Log("Type the request body, then press Ctrl-D to send it")
+	contents, err := io.ReadAll(stdin)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read the request body from stdin")
+	}
+	body := strings.TrimSuffix(string(contents), "\n")
+	rc.Body = nil
+	if body != "" {
+		rc.Body = strings.Split(body, "\n")
+	}
+	rc.BodyFromStdin = false
+	return nil
+}
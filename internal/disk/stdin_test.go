@@ -0,0 +1,34 @@
+package disk
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/larayavrs/vortex/internal/data"
+)
+
+func TestReadStdinBody(t *testing.T) {
+	interactive := Interactive
+	t.Cleanup(func() { Interactive = interactive })
+	rc, err := data.ParseTemplate("", "[Host]\nhttps://example.com\n[Body]\n-\n", data.Config{})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if !rc.BodyFromStdin || rc.Body != nil {
+		t.Fatalf("ParseTemplate() BodyFromStdin = %v, Body = %q, want a body read from stdin", rc.BodyFromStdin, rc.Body)
+	}
+
+	Interactive = false
+	if err := ReadStdinBody(rc, strings.NewReader("{}\n")); err == nil {
+		t.Error("ReadStdinBody() outside of the interactive mode succeeded, want an error")
+	}
+
+	Interactive = true
+	if err := ReadStdinBody(rc, strings.NewReader("{\n  \"name\": \"vortex\"\n}\n")); err != nil {
+		t.Fatalf("ReadStdinBody() error = %v", err)
+	}
+	if want := []string{"{", `  "name": "vortex"`, "}"}; !reflect.DeepEqual(rc.Body, want) || rc.BodyFromStdin {
+		t.Errorf("Body = %q, BodyFromStdin = %v, want %q read once", rc.Body, rc.BodyFromStdin, want)
+	}
+}
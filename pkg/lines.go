@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// minLinesPerWorker is the number of lines below which TokenizeLines does not start another
+// worker, since tokenizing a few lines is cheaper than synchronizing goroutines.
+const minLinesPerWorker = 256
+
+// Function TokenizeLines splits each of the given lines into tokens like TokenizeLine, tokenizing
+// large inputs concurrently on up to GOMAXPROCS goroutines, each reusing the pooled buffers of
+// TokenizeLine. A quoted string cannot span several lines.
+//
+// Parameters:
+//   - lines: The lines to tokenize.
+//
+// Returns:
+//   - The tokens of each line, in the order of the lines. A blank line has no tokens.
+//   - An error naming the first line, in input order, that could not be tokenized, such as a line
+//     holding an unterminated quote.
+func TokenizeLines(lines []string) ([][]string, error) {
+	tokens := make([][]string, len(lines))
+	errs := make([]error, len(lines))
+	workers := min(runtime.GOMAXPROCS(0), (len(lines)+minLinesPerWorker-1)/minLinesPerWorker)
+	if workers <= 1 {
+		for i, line := range lines {
+			if tokens[i], errs[i] = TokenizeLine(line); errs[i] != nil {
+				break
+			}
+		}
+	} else {
+		// Each worker tokenizes a contiguous chunk, so the results are written without locking
+		chunk := (len(lines) + workers - 1) / workers
+		var wg sync.WaitGroup
+		for start := 0; start < len(lines); start += chunk {
+			end := min(start+chunk, len(lines))
+			wg.Add(1)
+			go func(start, end int) {
+				defer wg.Done()
+				for i := start; i < end; i++ {
+					if tokens[i], errs[i] = TokenizeLine(lines[i]); errs[i] != nil {
+						return
+					}
+				}
+			}(start, end)
+		}
+		wg.Wait()
+	}
+	for i, err := range errs {
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to tokenize line %d", i+1)
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,74 @@
+package pkg
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// manyLines returns n lines holding quoted paths, each naming its index.
+func manyLines(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf(`"dir %d/template.ini" plain-%d`, i, i)
+	}
+	return lines
+}
+
+func TestTokenizeLinesPreservesOrder(t *testing.T) {
+	// Enough lines to be split among several workers, run with -race to check they share nothing
+	lines := manyLines(20 * minLinesPerWorker)
+	tokens, err := TokenizeLines(lines)
+	if err != nil {
+		t.Fatalf("TokenizeLines() error = %v", err)
+	}
+	if len(tokens) != len(lines) {
+		t.Fatalf("TokenizeLines() returned %d lines, want %d", len(tokens), len(lines))
+	}
+	for i, got := range tokens {
+		want := []string{fmt.Sprintf("dir %d/template.ini", i), fmt.Sprintf("plain-%d", i)}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("line %d: got %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestTokenizeLinesBlankLine(t *testing.T) {
+	tokens, err := TokenizeLines([]string{"a b", "", "c"})
+	if err != nil {
+		t.Fatalf("TokenizeLines() error = %v", err)
+	}
+	want := [][]string{{"a", "b"}, nil, {"c"}}
+	if !reflect.DeepEqual(tokens, want) {
+		t.Errorf("TokenizeLines() = %q, want %q", tokens, want)
+	}
+}
+
+func TestTokenizeLinesFirstErrorLine(t *testing.T) {
+	for _, n := range []int{10, 20 * minLinesPerWorker} {
+		lines := manyLines(n)
+		// Two broken lines, in different chunks for the concurrent case: the first one is reported
+		lines[n/3] = `"unterminated`
+		lines[n-1] = `'also unterminated`
+		_, err := TokenizeLines(lines)
+		if err == nil {
+			t.Fatalf("%d lines: TokenizeLines() succeeded, want an error", n)
+		}
+		want := fmt.Sprintf("Failed to tokenize line %d", n/3+1)
+		if !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("%d lines: TokenizeLines() error = %q, want it to start with %q", n, err, want)
+		}
+	}
+}
+
+func BenchmarkTokenizeLines(b *testing.B) {
+	lines := manyLines(10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := TokenizeLines(lines); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
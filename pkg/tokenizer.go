@@ -0,0 +1,293 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// errUnterminated is returned by finish when the input ends inside a quoted string.
+var errUnterminated = errors.New("unterminated quote")
+
+// tokenizer splits a stream of runes into tokens, following the quoting rules of TokenizeLine.
+// The runes are fed one at a time, so the input does not need to be held in memory.
+type tokenizer struct {
+	// tokens holds the tokens extracted so far.
+	tokens []string
+
+	// builder accumulates the runes of the token being read.
+	builder *bytes.Buffer
+
+	// quoteRune is the quote that opened the quoted string being read, or 0 when the tokenizer is
+	// not inside a quoted string.
+	quoteRune rune
+
+	// quotePos is the position, in runes, of quoteRune, used to report unterminated quotes.
+	quotePos int
+
+	// pos is the position, in runes, of the next rune.
+	pos int
+
+	// escaped is true when the previous rune was an escape that may apply to the next one.
+	escaped bool
+
+	// opts holds the optional rules enabled by TokenizeLineOpts.
+	opts TokenizeOptions
+
+	// quoted is true when the token being read holds a quoted string.
+	quoted bool
+
+	// quoteOffset is the length, in bytes, of the token being read before its first quoted string.
+	// It is only meaningful when quoted is true.
+	quoteOffset int
+
+	// heredocs lists the heredocs opened on the current line, or being read, in order.
+	heredocs []heredoc
+
+	// inHeredoc is true while the lines of the first heredoc are read.
+	inHeredoc bool
+
+	// heredocLine accumulates the line of the heredoc being read.
+	heredocLine []rune
+
+	// heredocContent holds the lines of the heredoc read so far.
+	heredocContent []string
+
+	// lineEnded is true once a newline delimiter ended a line, after the heredocs it opened, where
+	// TokenizeLinePartialOpts stops.
+	lineEnded bool
+}
+
+// heredocPrefix starts a token opening a heredoc.
+const heredocPrefix = "<<"
+
+// heredoc is a heredoc opened by a `<<DELIM` token.
+type heredoc struct {
+	// delim is the line ending the heredoc.
+	delim string
+
+	// quoted is true when the delimiter was quoted, which suppresses the expansion.
+	quoted bool
+
+	// index is the position of the token replaced by the heredoc content.
+	index int
+}
+
+// newTokenizer returns a tokenizer using a buffer of tokenBufferPool.
+func newTokenizer() *tokenizer {
+	builder := tokenBufferPool.Get().(*bytes.Buffer)
+	builder.Reset()
+	return &tokenizer{builder: builder}
+}
+
+// release returns the buffer of the tokenizer to tokenBufferPool, unless it grew too large.
+func (t *tokenizer) release() {
+	if t.builder.Cap() <= maxPooledBufferSize {
+		t.builder.Reset()
+		tokenBufferPool.Put(t.builder)
+	}
+	t.builder = nil
+}
+
+// isQuote reports whether the rune opens a quoted string.
+func isQuote(r rune) bool {
+	for _, qr := range quoteRunes {
+		if r == qr {
+			return true
+		}
+	}
+	return false
+}
+
+// feed processes the next rune of the input.
+func (t *tokenizer) feed(r rune) {
+	defer func() { t.pos++ }()
+	if t.inHeredoc {
+		t.feedHeredoc(r)
+		return
+	}
+	if t.escaped {
+		t.escaped = false
+		// An escape only applies to the quote closing the string, or to any quote outside of one
+		if (t.quoteRune > 0 && r == t.quoteRune) || (t.quoteRune == 0 && isQuote(r)) {
+			t.builder.WriteRune(r)
+			return
+		}
+		t.builder.WriteRune(quoteEscapeRune)
+	}
+	if t.quoteRune > 0 {
+		t.quoted = true
+		switch r {
+		case quoteEscapeRune:
+			t.escaped = true
+		case t.quoteRune:
+			t.quoteRune = 0
+		default:
+			t.builder.WriteRune(r)
+		}
+		return
+	}
+	switch {
+	case t.isDelimiter(r):
+		// A delimiter ends the token being read, and is skipped between tokens
+		t.endToken()
+		if r == '\n' {
+			t.inHeredoc = len(t.heredocs) > 0
+			t.lineEnded = !t.inHeredoc
+		}
+	case t.opts.RawQuotes:
+		// Quotes and escapes are ordinary characters in raw mode
+		t.builder.WriteRune(r)
+	case r == quoteEscapeRune:
+		t.escaped = true
+	case isQuote(r):
+		if !t.quoted {
+			t.quoteOffset = t.builder.Len()
+		}
+		t.quoteRune, t.quotePos = r, t.pos
+	default:
+		t.builder.WriteRune(r)
+	}
+}
+
+// finish ends the input, adding the last token. It returns errUnterminated when the input ends
+// inside a quoted string.
+func (t *tokenizer) finish() error {
+	if t.escaped {
+		t.escaped = false
+		t.builder.WriteRune(quoteEscapeRune)
+	}
+	if t.quoteRune > 0 {
+		return errUnterminated
+	}
+	t.endToken()
+	if t.inHeredoc && strings.TrimSuffix(string(t.heredocLine), "\r") == t.heredocs[0].delim {
+		t.endHeredoc()
+	}
+	if len(t.heredocs) > 0 {
+		return errors.Errorf("Unterminated heredoc, missing the %q delimiter line", t.heredocs[0].delim)
+	}
+	return nil
+}
+
+// isDelimiter reports whether the rune separates tokens: one of the custom delimiters when the
+// options set some, otherwise a whitespace.
+func (t *tokenizer) isDelimiter(r rune) bool {
+	if t.opts.Delimiters != "" {
+		return strings.ContainsRune(t.opts.Delimiters, r)
+	}
+	return unicode.IsSpace(r)
+}
+
+// endToken adds the token being read, if any, and registers it as a heredoc when it opens one. Only
+// an unquoted `<<` opens a heredoc, so `'<<EOF'` is a literal token while `<<'EOF'` is a heredoc
+// with a quoted delimiter.
+func (t *tokenizer) endToken() {
+	if t.builder.Len() == 0 {
+		t.quoted = false
+		return
+	}
+	token := t.builder.String()
+	t.builder.Reset()
+	opener := !t.quoted || t.quoteOffset >= len(heredocPrefix)
+	if delim, ok := strings.CutPrefix(token, heredocPrefix); ok && opener && t.opts.Heredoc && delim != "" {
+		t.heredocs = append(t.heredocs, heredoc{delim: delim, quoted: t.quoted, index: len(t.tokens)})
+		token = ""
+	}
+	t.quoted = false
+	t.tokens = append(t.tokens, token)
+}
+
+// feedHeredoc processes the next rune of a heredoc, ending it on its delimiter line.
+func (t *tokenizer) feedHeredoc(r rune) {
+	if r != '\n' {
+		t.heredocLine = append(t.heredocLine, r)
+		return
+	}
+	line := strings.TrimSuffix(string(t.heredocLine), "\r")
+	t.heredocLine = t.heredocLine[:0]
+	if line != t.heredocs[0].delim {
+		t.heredocContent = append(t.heredocContent, line)
+		return
+	}
+	t.endHeredoc()
+}
+
+// endHeredoc replaces the token of the heredoc being read by its content, and starts reading the
+// next heredoc opened on the same line, if any.
+func (t *tokenizer) endHeredoc() {
+	doc := t.heredocs[0]
+	content := strings.Join(t.heredocContent, "\n")
+	if !doc.quoted && t.opts.Expand != nil {
+		content = t.opts.Expand(content)
+	}
+	t.tokens[doc.index] = content
+	t.heredocs = t.heredocs[1:]
+	t.heredocContent = nil
+	t.heredocLine = t.heredocLine[:0]
+	t.inHeredoc = len(t.heredocs) > 0
+	t.lineEnded = !t.inHeredoc
+}
+
+// Function TokenizeReader splits the text read from the reader into tokens, following the same
+// quoting rules as TokenizeLine. The text is tokenized incrementally as it is read, so large piped
+// inputs are never held in memory as a whole, and a token or a quoted string may span several
+// reads.
+//
+// Parameters:
+//   - r: The reader providing the text to tokenize.
+//
+// Returns:
+//   - A slice of strings holding the tokens, in order.
+//   - An error if the reader fails, or if a quote is never closed, reporting its position in runes
+//     and the surrounding text like TokenizeLine.
+func TokenizeReader(r io.Reader) ([]string, error) {
+	t := newTokenizer()
+	defer t.release()
+	reader := bufio.NewReader(r)
+	// recent holds the last runes read, and context the runes around the last opened quote, to
+	// report an unterminated quote without keeping the whole input
+	var recent, context []rune
+	contextStart := 0
+	for {
+		ch, _, err := reader.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to read the input to tokenize")
+		}
+		openQuote := t.quoteRune == 0 && !t.escaped && isQuote(ch)
+		t.feed(ch)
+		if openQuote {
+			contextStart = max(0, t.quotePos-unterminatedContextBefore)
+			context = append(context[:0], recent[len(recent)-(t.quotePos-contextStart):]...)
+		}
+		if t.quoteRune > 0 && len(context) < t.quotePos-contextStart+unterminatedContextAfter {
+			context = append(context, ch)
+		}
+		recent = append(recent, ch)
+		if len(recent) > unterminatedContextBefore {
+			recent = recent[1:]
+		}
+	}
+	if err := t.finish(); err != nil {
+		if err != errUnterminated {
+			return nil, err
+		}
+		// The context holds enough runes around the quote for Ellipsize to behave as on the whole input
+		return nil, unterminatedQuoteError(t.quotePos, t.quotePos-contextStart, context)
+	}
+	return t.tokens, nil
+}
+
+// Number of runes kept before and after an opening quote to report it when it is unterminated:
+// enough for Ellipsize to show errUnterminatedQuote runes on each side and decide on the ellipses.
+const (
+	unterminatedContextBefore = 2*errUnterminatedQuote + 1
+	unterminatedContextAfter  = 2*errUnterminatedQuote + 2
+)
@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// chunkReader returns its chunks, one per read.
+type chunkReader struct {
+	chunks []string
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks[0] = r.chunks[0][n:]
+	if r.chunks[0] == "" {
+		r.chunks = r.chunks[1:]
+	}
+	return n, nil
+}
+
+func TestTokenizeReader(t *testing.T) {
+	input := "a.tmpl \"dir/with space.tmpl\"\n'it\\'s.tmpl' plain\\name.tmpl é.tmpl"
+	want, err := TokenizeLine(input)
+	if err != nil {
+		t.Fatalf("TokenizeLine() error = %v", err)
+	}
+	tests := map[string]io.Reader{
+		"bytes reader": bytes.NewReader([]byte(input)),
+		"one byte":     iotest.OneByteReader(strings.NewReader(input)),
+		"split quoted": &chunkReader{chunks: []string{`a.tmpl "dir/wi`, `th space.tmpl"`, "\n'it\\", `'s.tmpl' plain\name.tmpl `, "\xc3", "\xa9.tmpl"}},
+		"empty reads":  &chunkReader{chunks: []string{"", input}},
+	}
+	for name, r := range tests {
+		got, err := TokenizeReader(r)
+		if err != nil {
+			t.Errorf("%s: TokenizeReader() error = %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: TokenizeReader() = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestTokenizeReaderUnterminatedQuote(t *testing.T) {
+	tests := map[string]string{
+		"short":     `a "b`,
+		"start":     `"abcdefghijklmnop`,
+		"long":      strings.Repeat("x", 100) + ` "unterminated ` + strings.Repeat("y", 100),
+		"multibyte": "ééééééééé 'abc",
+		"reopened":  `"closed" abcdefghij 'open`,
+	}
+	for name, input := range tests {
+		_, want := TokenizeLine(input)
+		_, err := TokenizeReader(iotest.OneByteReader(strings.NewReader(input)))
+		if err == nil || want == nil || err.Error() != want.Error() {
+			t.Errorf("%s: TokenizeReader() error = %v, want %v", name, err, want)
+		}
+	}
+}
+
+func TestTokenizeReaderReadError(t *testing.T) {
+	failure := errors.New("broken pipe")
+	_, err := TokenizeReader(io.MultiReader(strings.NewReader("a b"), iotest.ErrReader(failure)))
+	if !errors.Is(err, failure) {
+		t.Errorf("TokenizeReader() error = %v, want the read error", err)
+	}
+}
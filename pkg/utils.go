@@ -1,7 +1,9 @@
 package pkg
 
 import (
+	"bytes"
 	"strings"
+	"sync"
 	"unicode"
 
 	"github.com/pkg/errors"
@@ -22,67 +24,29 @@ const (
 	threeChars = 3
 )
 
-var (
-	// This is a list of runes that are considered valid quote characters.
-	quoteRunes = [...]rune{'"', '\''}
-
-	// Holds a slice of strings where each string represents a token extracted
-	// from a command line input. This variable is used to store the results of tokenization
-	// operations, allowing further processing or analysis of individual tokens.
-	//
-	// Example usage:
-	//    tokenizedLines = TokenizeLine(cmdline)
-	//    // tokenizedLines now contains the tokens from cmdline.
-	tokenizedLines []string
-
-	// lastQuoteRune represents the last rune (character) used to denote a quote in the
-	// command line input. This variable is used to keep track of the type of quote (e.g., single
-	// or double quote) that was last encountered during parsing, helping to correctly handle
-	// nested or unclosed quotes.
-	//
-	// Example usage:
-	//    lastQuoteRune = '"' // Indicates that the last encountered quote was a double quote.
-	lastQuoteRune rune
-
-	// lastQuotePos stores the position (index) of the last quote character encountered
-	// in the command line input. This variable helps to track the location of the last quote,
-	// which is useful for detecting unterminated quotes or managing quoted strings during
-	// tokenization and parsing.
-	//
-	// Example usage:
-	//    lastQuotePos = 42 // Indicates the position of the last quote character in the input string.
-	lastQuotePos int
-
-	// builder is an instance of strings.Builder used for efficiently building and
-	// concatenating strings. The strings.Builder type provides a mutable buffer
-	// for string concatenation, which is more efficient than using string concatenation
-	// operators (+) in a loop, as it avoids creating multiple intermediate string objects.
-	//
-	// Example usage:
-	//    builder.WriteString("Hello, ")
-	//    builder.WriteString("world!")
-	//    result := builder.String() // result will be "Hello, world!"
-	builder strings.Builder
-
-	// preContext and postContext are string variables used to hold portions of the original
-	// string before and after the ellipsis, respectively. These variables are typically used
-	// within the `Ellipsize` function to store the preserved segments of the string before
-	// and after the truncation process.
-	//
-	// - preContext stores the substring from the start of the string up to the `from` index.
-	// - postContext stores the substring from the `to` index onward to the end of the string.
-	//
-	// These variables help in constructing the final ellipsized string that combines
-	// the preserved parts with an ellipsis in the middle.
-	preContext, postContext string
-)
+// This is a list of runes that are considered valid quote characters.
+var quoteRunes = [...]rune{'"', '\''}
+
+// maxPooledBufferSize is the capacity above which a token buffer is dropped instead of being
+// returned to tokenBufferPool, so one huge line does not pin its memory forever.
+const maxPooledBufferSize = 64 << 10
+
+// tokenBufferPool holds the buffers used by TokenizeLine to accumulate tokens. A bytes.Buffer is
+// pooled rather than a strings.Builder because the latter discards its memory on Reset, since the
+// strings it returned share it, while a bytes.Buffer copies each token out and keeps its capacity.
+var tokenBufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
 
 // Function TokenizeLine splits the given command line string into individual tokens.
 // This function processes the input string, which may contain multiple words and
 // delimiters, and returns a slice of strings where each string is a separate token
 // extracted from the command line. The function handles common tokenization rules such
 // as whitespace separation and quoted strings. If an error occurs during tokenization,
-// it will return an error detailing the issue.
+// it will return an error detailing the issue. The function is safe for concurrent use and reuses
+// its working buffers across calls.
 //
 // Parameters:
 //   - cmdline: The input command line string to be tokenized. This string may contain
@@ -94,65 +58,154 @@ var (
 //   - An error if there is an issue with tokenization, such as invalid syntax or unclosed
 //     quotes. If no error occurs, the error will be nil.
 func TokenizeLine(cmdline string) ([]string, error) {
-	cmdlineRune := []rune(cmdline)
-	builder.Grow(len(cmdlineRune))
-NextRune:
-	for i := 0; i < len(cmdlineRune); i++ {
-		head := cmdlineRune[i]
-		if lastQuoteRune == 0 && unicode.IsSpace(head) && builder.Len() == 0 {
-			continue
-		}
-		if lastQuoteRune > 0 {
-			if head == quoteEscapeRune && i < len(cmdlineRune)-1 && cmdlineRune[i+1] == lastQuoteRune {
-				builder.WriteRune(lastQuoteRune)
-				i = i + 1
-				continue
-			}
-			// If the current rune is the same as the last quote rune, we have reached the end of the quoted string.
-			if head == lastQuoteRune {
-				lastQuoteRune = 0
-				continue
-			}
-			builder.WriteRune(head)
-			continue
-		}
-		if head == quoteEscapeRune && i < len(cmdlineRune)-1 {
-			for _, qr := range quoteRunes {
-				if cmdlineRune[i+1] == qr {
-					builder.WriteRune(qr)
-					i = i + 1
-					continue NextRune
-				}
-			}
+	return TokenizeLineOpts(cmdline, TokenizeOptions{})
+}
+
+// TokenizeOptions enables optional tokenization rules of TokenizeLineOpts.
+type TokenizeOptions struct {
+	// Delimiters, if set, lists the characters separating tokens, such as `,` or a tab, in place
+	// of the whitespace. Quotes and escapes still take precedence, so a quoted token can hold a
+	// delimiter. Consecutive delimiters do not produce empty tokens.
+	Delimiters string
+
+	// RawQuotes, if true, treats quotes and escapes as ordinary characters, so the input is only
+	// split on the delimiters. A lone quote is then kept in its token instead of being reported as
+	// unterminated.
+	RawQuotes bool
+
+	// Heredoc, if true, recognizes heredocs: a `<<DELIM` token is replaced by the lines following
+	// the current line, up to a line equal to `DELIM`, kept literally as a single token. Several
+	// heredocs opened on the same line are read one after the other. A heredoc starts after a
+	// newline delimiter, so custom Delimiters must include `\n` for heredocs to be recognized.
+	Heredoc bool
+
+	// Expand, if set, is applied to the content of the heredocs whose delimiter is unquoted, such
+	// as `<<EOF`. A quoted delimiter, as in `<<'EOF'`, suppresses the expansion.
+	Expand func(string) string
+}
+
+// Function TokenizeLineOpts splits the given command line string into tokens like TokenizeLine,
+// with the optional rules enabled in the options.
+//
+// Parameters:
+//   - cmdline: The input command line string to be tokenized.
+//   - opts: The optional tokenization rules.
+//
+// Returns:
+//   - A slice of strings where each string is a token extracted from the input command line.
+//   - An error if a quote is never closed, or a heredoc never reaches its delimiter line.
+func TokenizeLineOpts(cmdline string, opts TokenizeOptions) ([]string, error) {
+	t := newTokenizer()
+	t.opts = opts
+	defer t.release()
+	for _, r := range cmdline {
+		t.feed(r)
+	}
+	if err := t.finish(); err != nil {
+		if err != errUnterminated {
+			return nil, err
 		}
-		// If the current rune is a quote rune, we need to start a quoted string.
-		for _, qr := range quoteRunes {
-			if head == qr {
-				lastQuoteRune = qr
-				lastQuotePos = i
-				continue NextRune
-			}
+		return nil, unterminatedQuoteError(t.quotePos, t.quotePos, []rune(cmdline))
+	}
+	return t.tokens, nil
+}
+
+// Function TokenizeLinePartial splits the first line of the given text into tokens like
+// TokenizeLine, and reports how far it read, so an incremental parser can resume after the line.
+//
+// Parameters:
+//   - cmdline: The text whose first line is tokenized.
+//
+// Returns:
+//   - A slice of strings holding the tokens of the first line.
+//   - The number of runes consumed, see TokenizeLinePartialOpts.
+//   - An error if a quote is never closed.
+func TokenizeLinePartial(cmdline string) ([]string, int, error) {
+	return TokenizeLinePartialOpts(cmdline, TokenizeOptions{})
+}
+
+// Function TokenizeLinePartialOpts splits the first line of the given text into tokens like
+// TokenizeLineOpts, stopping after the newline delimiter ending it. A newline inside a quoted
+// string does not end the line, and when heredocs are enabled, the heredocs opened on the line are
+// read up to their delimiter lines, included. The text is read to its end when it holds no such
+// newline, for example when the custom Delimiters exclude `\n`.
+//
+// Parameters:
+//   - cmdline: The text whose first line is tokenized.
+//   - opts: The optional tokenization rules.
+//
+// Returns:
+//   - A slice of strings holding the tokens of the first line.
+//   - The number of runes consumed, which is the index, in runes, past the newline ending the line
+//     or the last heredoc delimiter line, or the length of the text when it was read to its end.
+//   - An error if a quote is never closed, or a heredoc never reaches its delimiter line.
+func TokenizeLinePartialOpts(cmdline string, opts TokenizeOptions) ([]string, int, error) {
+	t := newTokenizer()
+	t.opts = opts
+	defer t.release()
+	for _, r := range cmdline {
+		t.feed(r)
+		if t.lineEnded {
+			break
 		}
-		// If the current rune is a space, we have reached the end of a token.
-		if unicode.IsSpace(head) && lastQuoteRune == 0 {
-			tokenizedLines = append(tokenizedLines, builder.String())
-			builder.Reset()
-			continue
+	}
+	if err := t.finish(); err != nil {
+		if err != errUnterminated {
+			return nil, t.pos, err
 		}
-		builder.WriteRune(head)
+		return nil, t.pos, unterminatedQuoteError(t.quotePos, t.quotePos, []rune(cmdline))
+	}
+	return t.tokens, t.pos, nil
+}
+
+// Function TokenizeState splits the given command line string into tokens like TokenizeLine, but
+// reports a quote left open at the end of the line instead of failing, for shell completions that
+// need to know whether the cursor is inside a quoted string. The token being typed, the one under
+// a cursor placed at the end of the line, is the last of the tokens: when a quote is open, it holds
+// the text typed after the quote, possibly empty.
+//
+// Parameters:
+//   - cmdline: The input command line string to be tokenized, usually up to the cursor.
+//
+// Returns:
+//   - A slice of strings holding the tokens parsed so far.
+//   - The quote left open at the end of the line, `"` or `'`, or 0 when every quote is closed.
+//   - The position, in runes, of the open quote in the line, or -1 when every quote is closed.
+func TokenizeState(cmdline string) (tokens []string, openQuote rune, openPos int) {
+	t := newTokenizer()
+	defer t.release()
+	for _, r := range cmdline {
+		t.feed(r)
 	}
-	if lastQuoteRune > 0 {
-		context := Ellipsize(
-			lastQuotePos-errUnterminatedQuote,
-			lastQuotePos+errUnterminatedQuote+1,
-			cmdline,
-		)
-		return nil, errors.Errorf("Unterminated quote at position %d: %s", lastQuotePos, context)
+	if t.escaped {
+		t.builder.WriteRune(quoteEscapeRune)
 	}
-	if builder.Len() > 0 {
-		tokenizedLines = append(tokenizedLines, builder.String())
+	if t.quoteRune == 0 {
+		t.endToken()
+		return t.tokens, 0, -1
 	}
-	return tokenizedLines, nil
+	return append(t.tokens, t.builder.String()), t.quoteRune, t.quotePos
+}
+
+// unterminatedQuoteError reports a quote that is never closed, at the position pos, in runes, of the
+// input. The text holds the input, or a part of it where the quote is at the index quoteIndex. The
+// error shows the text around the quote, ellipsized, on a second line, and a caret under the quote
+// on a third one.
+func unterminatedQuoteError(pos, quoteIndex int, text []rune) error {
+	from := quoteIndex - errUnterminatedQuote
+	context := Ellipsize(from, quoteIndex+errUnterminatedQuote+1, string(text))
+	column := quoteIndex
+	if from > threeChars {
+		column = threeChars + errUnterminatedQuote
+	}
+	// Whitespaces such as tabs and newlines are shown as spaces, so the caret stays aligned
+	context = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return ' '
+		}
+		return r
+	}, context)
+	return errors.Errorf("Unterminated quote at rune position %d:\n%s\n%s^", pos, context, strings.Repeat(" ", column))
 }
 
 // Ellipsize shortens a string by replacing the middle part with an ellipsis ("...").
@@ -160,6 +213,7 @@ NextRune:
 // is preserved up to the `from` index, and the end of the string is preserved from the `to` index.
 // The middle portion of the string between `from` and `to` is replaced with an ellipsis.
 // If the `from` and `to` indices do not leave enough room for the ellipsis, the original string may be returned.
+// The indices count runes, not bytes, so multibyte characters are never split.
 //
 // Parameters:
 //   - from: The index at which to start preserving the string from the beginning.
@@ -170,6 +224,9 @@ NextRune:
 //   - A new string where the middle portion between `from` and `to` is replaced with an ellipsis,
 //     or the original string if the indices do not allow for proper truncation.
 func Ellipsize(from, to int, val string) string {
+	runes := []rune(val)
+	// preContext and postContext hold the ellipsis placed before and after the preserved part.
+	var preContext, postContext string
 	preContextIndex := from
 	if preContextIndex <= threeChars {
 		preContextIndex = 0
@@ -178,11 +235,14 @@ func Ellipsize(from, to int, val string) string {
 		preContext = "..."
 	}
 	postContextIndex := to
-	if postContextIndex >= (len(val) - threeChars) {
-		postContextIndex = len(val)
+	if postContextIndex >= (len(runes) - threeChars) {
+		postContextIndex = len(runes)
 		postContext = ""
 	} else {
 		postContext = "..."
 	}
-	return preContext + val[preContextIndex:postContextIndex] + postContext
+	if preContextIndex > postContextIndex {
+		preContextIndex = postContextIndex
+	}
+	return preContext + string(runes[preContextIndex:postContextIndex]) + postContext
 }
@@ -0,0 +1,277 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// benchmarkLine is a template line with quoted and plain tokens, long enough for the token buffer
+// to grow.
+var benchmarkLine = `curl --header "Authorization: Bearer ` + strings.Repeat("x", 200) + `" 'https://example.com/a b' -v`
+
+// tokenizeNaive tokenizes the line like TokenizeLine, with a new buffer instead of a pooled one.
+func tokenizeNaive(cmdline string) ([]string, error) {
+	t := &tokenizer{builder: new(bytes.Buffer)}
+	for _, r := range cmdline {
+		t.feed(r)
+	}
+	if err := t.finish(); err != nil {
+		return nil, err
+	}
+	return t.tokens, nil
+}
+
+func TestTokenizeLineResetsPooledBuffer(t *testing.T) {
+	dirty := new(bytes.Buffer)
+	dirty.WriteString("leftover")
+	tokenBufferPool.Put(dirty)
+	got, err := TokenizeLine(`a "b c"`)
+	if err != nil {
+		t.Fatalf("TokenizeLine() error = %v", err)
+	}
+	if want := []string{"a", "b c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TokenizeLine() = %q, want %q", got, want)
+	}
+}
+
+func TestTokenizeLineAllocatesLessThanNaive(t *testing.T) {
+	pooled := testing.AllocsPerRun(200, func() {
+		_, _ = TokenizeLine(benchmarkLine)
+	})
+	naive := testing.AllocsPerRun(200, func() {
+		_, _ = tokenizeNaive(benchmarkLine)
+	})
+	if pooled >= naive {
+		t.Errorf("TokenizeLine allocates %.1f times per call, want fewer than the %.1f of a new buffer", pooled, naive)
+	}
+}
+
+func BenchmarkTokenizeLine(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := TokenizeLine(benchmarkLine); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTokenizeLineNaive(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := tokenizeNaive(benchmarkLine); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestTokenizeLineOptsHeredoc(t *testing.T) {
+	opts := TokenizeOptions{Heredoc: true, Expand: strings.ToUpper}
+	tests := map[string]struct {
+		line string
+		want []string
+	}{
+		"basic":            {"post <<EOF\n{\"a\": 1}\nline two\nEOF", []string{"post", "{\"A\": 1}\nLINE TWO"}},
+		"quoted delim":     {"post <<'EOF'\n{\"a\": 1}\nEOF", []string{"post", `{"a": 1}`}},
+		"quoted opener":    {"echo '<<EOF' done", []string{"echo", "<<EOF", "done"}},
+		"partially quoted": {`echo "<"<EOF done`, []string{"echo", "<<EOF", "done"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := TokenizeLineOpts(tt.line, opts)
+			if err != nil {
+				t.Fatalf("TokenizeLineOpts() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TokenizeLineOpts() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeLineOptsUnterminatedHeredoc(t *testing.T) {
+	if _, err := TokenizeLineOpts("post <<EOF\n{}\n", TokenizeOptions{Heredoc: true}); err == nil {
+		t.Error("TokenizeLineOpts() error = nil, want an unterminated heredoc error")
+	}
+}
+
+func TestTokenizeLineKeepsNoState(t *testing.T) {
+	if _, err := TokenizeLine(`first "second third"`); err != nil {
+		t.Fatalf("TokenizeLine() error = %v", err)
+	}
+	got, err := TokenizeLine("fourth")
+	if err != nil {
+		t.Fatalf("TokenizeLine() error = %v", err)
+	}
+	if want := []string{"fourth"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TokenizeLine() after another call = %q, want %q", got, want)
+	}
+}
+
+func TestTokenizeLineOptsDelimiters(t *testing.T) {
+	tests := map[string]struct {
+		line, delimiters string
+		want             []string
+	}{
+		"comma":            {"a,b,,c", ",", []string{"a", "b", "c"}},
+		"tab":              {"a b\tc\t\td", "\t", []string{"a b", "c", "d"}},
+		"several":          {"a,b;c", ",;", []string{"a", "b", "c"}},
+		"quoted delimiter": {`a,"b,c",'d,e'`, ",", []string{"a", "b,c", "d,e"}},
+		"escaped quote":    {`a,"b\",c"`, ",", []string{"a", `b",c`}},
+		"default":          {"a, b\tc", "", []string{"a,", "b", "c"}},
+	}
+	for name, tt := range tests {
+		got, err := TokenizeLineOpts(tt.line, TokenizeOptions{Delimiters: tt.delimiters})
+		if err != nil {
+			t.Errorf("%s: TokenizeLineOpts() error = %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: TokenizeLineOpts() = %q, want %q", name, got, tt.want)
+		}
+	}
+}
+
+func TestTokenizeLineUnterminatedQuoteCaret(t *testing.T) {
+	tests := map[string]struct {
+		line, context string
+		pos           int
+	}{
+		"short":            {`ab "cd`, `ab "cd`, 3},
+		"multibyte prefix": {`héllo wörld "ünterminated`, `...ld "ünt...`, 12},
+		"multibyte start":  {`日本 "語`, `日本 "語`, 3},
+		"tab":              {"a\t'b", "a 'b", 2},
+	}
+	for name, tt := range tests {
+		_, err := TokenizeLine(tt.line)
+		if err == nil {
+			t.Errorf("%s: TokenizeLine() succeeded, want an unterminated quote error", name)
+			continue
+		}
+		lines := strings.Split(err.Error(), "\n")
+		if len(lines) != 3 {
+			t.Errorf("%s: TokenizeLine() error = %q, want the message, the context and the caret", name, err)
+			continue
+		}
+		if want := fmt.Sprintf("Unterminated quote at rune position %d:", tt.pos); lines[0] != want {
+			t.Errorf("%s: message = %q, want %q", name, lines[0], want)
+		}
+		if lines[1] != tt.context {
+			t.Errorf("%s: context = %q, want %q", name, lines[1], tt.context)
+		}
+		caret := utf8.RuneCountInString(lines[2]) - 1
+		if !strings.HasSuffix(lines[2], "^") || strings.TrimSpace(lines[2]) != "^" || !isQuote([]rune(lines[1])[caret]) {
+			t.Errorf("%s: caret line %q is not under the quote of %q", name, lines[2], lines[1])
+		}
+	}
+}
+
+func TestTokenizeLineOptsRawQuotes(t *testing.T) {
+	tests := map[string]struct {
+		line string
+		opts TokenizeOptions
+		want []string
+	}{
+		"unmatched quote": {`it's "quoted text`, TokenizeOptions{RawQuotes: true}, []string{"it's", `"quoted`, "text"}},
+		"escapes":         {`a\ b c\"`, TokenizeOptions{RawQuotes: true}, []string{`a\`, "b", `c\"`}},
+		"delimiters":      {`"a,b",'c`, TokenizeOptions{RawQuotes: true, Delimiters: ","}, []string{`"a`, `b"`, "'c"}},
+		"default":         {`"a b" c`, TokenizeOptions{}, []string{"a b", "c"}},
+	}
+	for name, tt := range tests {
+		got, err := TokenizeLineOpts(tt.line, tt.opts)
+		if err != nil {
+			t.Errorf("%s: TokenizeLineOpts() error = %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: TokenizeLineOpts() = %q, want %q", name, got, tt.want)
+		}
+	}
+	if _, err := TokenizeLine(`it's`); err == nil {
+		t.Error("TokenizeLine() of an unmatched quote succeeded, want an error without raw quotes")
+	}
+}
+
+func TestTokenizeLinePartial(t *testing.T) {
+	heredoc := TokenizeOptions{Heredoc: true}
+	tests := map[string]struct {
+		text     string
+		opts     TokenizeOptions
+		want     []string
+		consumed int
+	}{
+		"whole line":     {`a "b c" d`, TokenizeOptions{}, []string{"a", "b c", "d"}, 9},
+		"multibyte":      {"héllo wörld", TokenizeOptions{}, []string{"héllo", "wörld"}, 11},
+		"first line":     {"a b\nc d", TokenizeOptions{}, []string{"a", "b"}, 4},
+		"quoted newline": {"a 'b\nc'\nd", TokenizeOptions{}, []string{"a", "b\nc"}, 8},
+		"heredoc":        {"post <<EOF\n{}\nEOF\nnext line", heredoc, []string{"post", "{}"}, 18},
+		"heredoc at end": {"post <<EOF\n{}\nEOF", heredoc, []string{"post", "{}"}, 17},
+		"no newline":     {"a,b\nc", TokenizeOptions{Delimiters: ","}, []string{"a", "b\nc"}, 5},
+		"empty first":    {"\nnext", TokenizeOptions{}, nil, 1},
+	}
+	for name, tt := range tests {
+		got, consumed, err := TokenizeLinePartialOpts(tt.text, tt.opts)
+		if err != nil {
+			t.Errorf("%s: TokenizeLinePartialOpts() error = %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) || consumed != tt.consumed {
+			t.Errorf("%s: TokenizeLinePartialOpts() = %q, %d, want %q, %d", name, got, consumed, tt.want, tt.consumed)
+		}
+	}
+
+	// The consumed runes tell where to resume
+	text := "first line\nsecond 'line'\n"
+	first, consumed, err := TokenizeLinePartial(text)
+	if err != nil {
+		t.Fatalf("TokenizeLinePartial() error = %v", err)
+	}
+	second, _, err := TokenizeLinePartial(string([]rune(text)[consumed:]))
+	if err != nil {
+		t.Fatalf("TokenizeLinePartial() error = %v", err)
+	}
+	if !reflect.DeepEqual(first, []string{"first", "line"}) || !reflect.DeepEqual(second, []string{"second", "line"}) {
+		t.Errorf("TokenizeLinePartial() lines = %q, %q", first, second)
+	}
+	if _, _, err := TokenizeLinePartial("a 'b\nc"); err == nil {
+		t.Error("TokenizeLinePartial() of an unterminated quote succeeded, want an error")
+	}
+}
+
+func TestTokenizeState(t *testing.T) {
+	tests := map[string]struct {
+		cmdline   string
+		tokens    []string
+		openQuote rune
+		openPos   int
+	}{
+		"no open quote":       {`curl -H 'Accept: */*' url`, []string{"curl", "-H", "Accept: */*", "url"}, 0, -1},
+		"trailing space":      {"vortex get.ini ", []string{"vortex", "get.ini"}, 0, -1},
+		"empty":               {"", nil, 0, -1},
+		"open double quote":   {`vortex -H "X-Name: vör`, []string{"vortex", "-H", "X-Name: vör"}, '"', 10},
+		"open single quote":   {`echo 'it''s`, []string{"echo", "its"}, '\'', 9},
+		"just opened":         {`echo "`, []string{"echo", ""}, '"', 5},
+		"quote inside token":  {`--header="Accept`, []string{"--header=Accept"}, '"', 9},
+		"other quote nested":  {`say "it's`, []string{"say", "it's"}, '"', 4},
+		"closed then escaped": {`a "b" c\`, []string{"a", "b", `c\`}, 0, -1},
+	}
+	for name, tt := range tests {
+		tokens, openQuote, openPos := TokenizeState(tt.cmdline)
+		if !reflect.DeepEqual(tokens, tt.tokens) || openQuote != tt.openQuote || openPos != tt.openPos {
+			t.Errorf("%s: TokenizeState(%q) = %q, %q, %d, want %q, %q, %d", name, tt.cmdline, tokens, openQuote, openPos, tt.tokens, tt.openQuote, tt.openPos)
+		}
+	}
+
+	// The closed lines are tokenized like TokenizeLine
+	line := `post "a b" 'c' d\ e`
+	want, err := TokenizeLine(line)
+	if err != nil {
+		t.Fatalf("TokenizeLine() error = %v", err)
+	}
+	if got, _, _ := TokenizeState(line); !reflect.DeepEqual(got, want) {
+		t.Errorf("TokenizeState(%q) = %q, want %q as TokenizeLine", line, got, want)
+	}
+}